@@ -36,6 +36,46 @@ var (
 		Name:  "service",
 		Usage: "service name(committer, witness)",
 	}
+	FromHeightFlag = &cli.Int64Flag{
+		Name:  "from",
+		Usage: "the first block height of the range",
+	}
+	ToHeightFlag = &cli.Int64Flag{
+		Name:  "to",
+		Usage: "the last block height of the range",
+	}
+	OutputDirFlag = &cli.StringFlag{
+		Name:  "out",
+		Usage: "the output directory",
+	}
+	WitnessDirFlag = &cli.StringFlag{
+		Name:  "witness-dir",
+		Usage: "the directory of the exported block witness files",
+	}
+	ProofDirFlag = &cli.StringFlag{
+		Name:  "proof-dir",
+		Usage: "the directory of the block proof files",
+	}
+	LevelDBFileFlag = &cli.StringFlag{
+		Name:  "leveldb",
+		Usage: "the directory of the leveldb tree database",
+	}
+	PebbleFileFlag = &cli.StringFlag{
+		Name:  "pebble",
+		Usage: "the directory of the pebble tree database",
+	}
+	DryRunFlag = &cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "report the changes without applying them",
+	}
+	RepairFlag = &cli.BoolFlag{
+		Name:  "repair",
+		Usage: "repair the mismatched leaves in place",
+	}
+	SnapshotFileFlag = &cli.StringFlag{
+		Name:  "file",
+		Usage: "the state snapshot archive",
+	}
 	BatchSizeFlag = &cli.IntFlag{
 		Name:  "batch",
 		Value: 1000,
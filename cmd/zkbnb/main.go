@@ -19,8 +19,15 @@ import (
 	"github.com/bnb-chain/zkbnb/service/prover"
 	"github.com/bnb-chain/zkbnb/service/sender"
 	"github.com/bnb-chain/zkbnb/service/witness"
+	"github.com/bnb-chain/zkbnb/tools/audit"
 	"github.com/bnb-chain/zkbnb/tools/dbinitializer"
+	"github.com/bnb-chain/zkbnb/tools/offlineproving"
 	"github.com/bnb-chain/zkbnb/tools/recovery"
+	"github.com/bnb-chain/zkbnb/tools/snapshot"
+	"github.com/bnb-chain/zkbnb/tools/treemigration"
+	"github.com/bnb-chain/zkbnb/tools/treeprune"
+	"github.com/bnb-chain/zkbnb/tools/treeverify"
+	"github.com/bnb-chain/zkbnb/tools/witnessmigration"
 
 	"net/http"
 )
@@ -54,6 +61,8 @@ func main() {
 				Usage: "Run prover service",
 				Flags: []cli.Flag{
 					flags.ConfigFlag,
+					flags.WitnessDirFlag,
+					flags.ProofDirFlag,
 					flags.MetricsEnabledFlag,
 					flags.MetricsHTTPFlag,
 					flags.MetricsPortFlag,
@@ -66,6 +75,16 @@ func main() {
 						return cli.ShowSubcommandHelp(cCtx)
 					}
 					startMetricsServer(cCtx)
+					if cCtx.IsSet(flags.WitnessDirFlag.Name) {
+						if !cCtx.IsSet(flags.ProofDirFlag.Name) {
+							return cli.ShowSubcommandHelp(cCtx)
+						}
+						return prover.RunOffline(
+							cCtx.String(flags.ConfigFlag.Name),
+							cCtx.String(flags.WitnessDirFlag.Name),
+							cCtx.String(flags.ProofDirFlag.Name),
+						)
+					}
 					return prover.Run(cCtx.String(flags.ConfigFlag.Name))
 				},
 			},
@@ -88,6 +107,33 @@ func main() {
 					startMetricsServer(cCtx)
 					return witness.Run(cCtx.String(flags.ConfigFlag.Name))
 				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "export",
+						Usage: "Export block witnesses into files for offline proving",
+						Flags: []cli.Flag{
+							flags.DSNFlag,
+							flags.FromHeightFlag,
+							flags.ToHeightFlag,
+							flags.OutputDirFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.DSNFlag.Name) ||
+								!cCtx.IsSet(flags.FromHeightFlag.Name) ||
+								!cCtx.IsSet(flags.ToHeightFlag.Name) ||
+								!cCtx.IsSet(flags.OutputDirFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+
+							return offlineproving.ExportWitness(
+								cCtx.String(flags.DSNFlag.Name),
+								cCtx.Int64(flags.FromHeightFlag.Name),
+								cCtx.Int64(flags.ToHeightFlag.Name),
+								cCtx.String(flags.OutputDirFlag.Name),
+							)
+						},
+					},
+				},
 			},
 			{
 				Name:  "monitor",
@@ -133,15 +179,41 @@ func main() {
 				Name: "fullnode",
 				Flags: []cli.Flag{
 					flags.ConfigFlag,
+					flags.MetricsEnabledFlag,
+					flags.MetricsHTTPFlag,
+					flags.MetricsPortFlag,
+					flags.PProfEnabledFlag,
+					flags.PProfAddrFlag,
+					flags.PProfPortFlag,
 				},
 				Usage: "Run fullnode service",
 				Action: func(cCtx *cli.Context) error {
 					if !cCtx.IsSet(flags.ConfigFlag.Name) {
 						return cli.ShowSubcommandHelp(cCtx)
 					}
-
+					startMetricsServer(cCtx)
 					return fullnode.Run(cCtx.String(flags.ConfigFlag.Name))
 				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "resync",
+						Usage: "Roll back the database and the trees of the fullnode to a height to sync again",
+						Flags: []cli.Flag{
+							flags.ConfigFlag,
+							flags.FromHeightFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.ConfigFlag.Name) ||
+								!cCtx.IsSet(flags.FromHeightFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+							return fullnode.Resync(
+								cCtx.String(flags.ConfigFlag.Name),
+								cCtx.Int64(flags.FromHeightFlag.Name),
+							)
+						},
+					},
+				},
 			},
 			{
 				Name:  "sender",
@@ -211,6 +283,49 @@ func main() {
 							)
 						},
 					},
+					{
+						Name:  "migrate-witness",
+						Usage: "Convert block witnesses from json into the binary format",
+						Flags: []cli.Flag{
+							flags.DSNFlag,
+							flags.BatchSizeFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.DSNFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+
+							return witnessmigration.MigrateBlockWitness(
+								cCtx.String(flags.DSNFlag.Name),
+								cCtx.Int(flags.BatchSizeFlag.Name),
+							)
+						},
+					},
+				},
+			},
+			{
+				Name:  "proof",
+				Usage: "Proof tools",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "import",
+						Usage: "Verify the offline generated proofs and import them into the database",
+						Flags: []cli.Flag{
+							flags.ConfigFlag,
+							flags.ProofDirFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.ConfigFlag.Name) ||
+								!cCtx.IsSet(flags.ProofDirFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+
+							return offlineproving.ImportProof(
+								cCtx.String(flags.ConfigFlag.Name),
+								cCtx.String(flags.ProofDirFlag.Name),
+							)
+						},
+					},
 				},
 			},
 			{
@@ -241,6 +356,161 @@ func main() {
 							return nil
 						},
 					},
+					{
+						Name:  "prune",
+						Usage: "Prune the historical versions of the treedb, the service must be stopped",
+						Flags: []cli.Flag{
+							flags.ConfigFlag,
+							flags.ServiceNameFlag,
+							flags.DryRunFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.ConfigFlag.Name) ||
+								!cCtx.IsSet(flags.ServiceNameFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+							return treeprune.PruneTreeDB(
+								cCtx.String(flags.ConfigFlag.Name),
+								cCtx.String(flags.ServiceNameFlag.Name),
+								cCtx.Bool(flags.DryRunFlag.Name),
+							)
+						},
+					},
+					{
+						Name:  "verify",
+						Usage: "Verify the treedb against the database at the height, the service must be stopped to repair",
+						Flags: []cli.Flag{
+							flags.ConfigFlag,
+							flags.BlockHeightFlag,
+							flags.ServiceNameFlag,
+							flags.BatchSizeFlag,
+							flags.RepairFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.ConfigFlag.Name) ||
+								!cCtx.IsSet(flags.BlockHeightFlag.Name) ||
+								!cCtx.IsSet(flags.ServiceNameFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+							repair := cCtx.Bool(flags.RepairFlag.Name)
+							report, err := treeverify.VerifyTree(
+								cCtx.String(flags.ConfigFlag.Name),
+								cCtx.String(flags.ServiceNameFlag.Name),
+								cCtx.Int64(flags.BlockHeightFlag.Name),
+								cCtx.Int(flags.BatchSizeFlag.Name),
+								repair,
+							)
+							if err != nil {
+								return err
+							}
+							if report.UnknownLeaves || (!repair && !report.Consistent()) {
+								return fmt.Errorf("treedb is inconsistent with the database, accounts: %v, assets: %v, nfts: %v",
+									report.Accounts, report.Assets, report.Nfts)
+							}
+							return nil
+						},
+					},
+					{
+						Name:  "migrate-pebble",
+						Usage: "Copy the leveldb treedb into a new pebble treedb",
+						Flags: []cli.Flag{
+							flags.LevelDBFileFlag,
+							flags.PebbleFileFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.LevelDBFileFlag.Name) ||
+								!cCtx.IsSet(flags.PebbleFileFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+							return treemigration.MigrateLevelDBToPebble(
+								cCtx.String(flags.LevelDBFileFlag.Name),
+								cCtx.String(flags.PebbleFileFlag.Name),
+							)
+						},
+					},
+				},
+			},
+			{
+				Name:  "snapshot",
+				Usage: "State snapshot tools",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "export",
+						Usage: "Export the state at the block height into a snapshot archive",
+						Flags: []cli.Flag{
+							flags.DSNFlag,
+							flags.BlockHeightFlag,
+							flags.SnapshotFileFlag,
+							flags.BatchSizeFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.DSNFlag.Name) ||
+								!cCtx.IsSet(flags.BlockHeightFlag.Name) ||
+								!cCtx.IsSet(flags.SnapshotFileFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+							return snapshot.ExportSnapshot(
+								cCtx.String(flags.DSNFlag.Name),
+								cCtx.Int64(flags.BlockHeightFlag.Name),
+								cCtx.String(flags.SnapshotFileFlag.Name),
+								cCtx.Int(flags.BatchSizeFlag.Name),
+							)
+						},
+					},
+					{
+						Name:  "import",
+						Usage: "Rebuild the treedb of the service from a snapshot archive",
+						Flags: []cli.Flag{
+							flags.ConfigFlag,
+							flags.ServiceNameFlag,
+							flags.SnapshotFileFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.ConfigFlag.Name) ||
+								!cCtx.IsSet(flags.ServiceNameFlag.Name) ||
+								!cCtx.IsSet(flags.SnapshotFileFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+							return snapshot.ImportSnapshot(
+								cCtx.String(flags.ConfigFlag.Name),
+								cCtx.String(flags.ServiceNameFlag.Name),
+								cCtx.String(flags.SnapshotFileFlag.Name),
+							)
+						},
+					},
+				},
+			},
+			{
+				Name:  "audit",
+				Usage: "Audit tools",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "state",
+						Usage: "Replay blocks from the tx table and compare the states with the stored blocks",
+						Flags: []cli.Flag{
+							flags.DSNFlag,
+							flags.FromHeightFlag,
+							flags.ToHeightFlag,
+						},
+						Action: func(cCtx *cli.Context) error {
+							if !cCtx.IsSet(flags.DSNFlag.Name) ||
+								!cCtx.IsSet(flags.FromHeightFlag.Name) ||
+								!cCtx.IsSet(flags.ToHeightFlag.Name) {
+								return cli.ShowSubcommandHelp(cCtx)
+							}
+
+							err := audit.AuditState(
+								cCtx.String(flags.DSNFlag.Name),
+								cCtx.Int64(flags.FromHeightFlag.Name),
+								cCtx.Int64(flags.ToHeightFlag.Name),
+							)
+							if err != nil {
+								// exit with failure so that CI catches the divergence
+								return cli.Exit(err.Error(), 1)
+							}
+							return nil
+						},
+					},
 				},
 			},
 		},
@@ -19,10 +19,13 @@ package chain
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
 	"github.com/ethereum/go-ethereum/common"
 
+	cryptoTypes "github.com/bnb-chain/zkbnb-crypto/circuit/types"
 	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
 	common2 "github.com/bnb-chain/zkbnb/common"
 	"github.com/bnb-chain/zkbnb/types"
@@ -171,3 +174,425 @@ func ParseFullExitNftPubData(pubData []byte) (tx *txtypes.FullExitNftTxInfo, err
 	}
 	return tx, nil
 }
+
+// TxPubDataSize is the size of the pub data of a tx in a block, the pub data of every tx
+// takes the same number of chunks.
+const TxPubDataSize = chunkBytesSize * cryptoTypes.PubDataSizePerTx
+
+const chunkBytesSize = 32
+
+// ParseBlockPubData splits the pub data of a block, as committed to L1, into the infos of
+// its txs. The empty txs padding the block are dropped. The infos only have the fields
+// in the pub data: the signatures, nonces and expiry of the layer2 txs are not set, and
+// neither are the fields the executors set from the states.
+func ParseBlockPubData(pubData []byte) ([]txtypes.TxInfo, error) {
+	if len(pubData)%TxPubDataSize != 0 {
+		return nil, errors.New("[ParseBlockPubData] invalid size")
+	}
+	txInfos := make([]txtypes.TxInfo, 0, len(pubData)/TxPubDataSize)
+	for offset := 0; offset < len(pubData); offset += TxPubDataSize {
+		txPubData := pubData[offset : offset+TxPubDataSize]
+		if txPubData[0] == types.TxTypeEmpty {
+			continue
+		}
+		txInfo, err := ParseTxPubData(txPubData)
+		if err != nil {
+			return nil, err
+		}
+		txInfos = append(txInfos, txInfo)
+	}
+	return txInfos, nil
+}
+
+// ParseTxPubData parses the pub data of a tx in a block.
+func ParseTxPubData(pubData []byte) (txtypes.TxInfo, error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseTxPubData] invalid size")
+	}
+	switch int64(pubData[0]) {
+	case types.TxTypeRegisterZns:
+		return parseRegisterZnsBlockPubData(pubData)
+	case types.TxTypeDeposit:
+		return parseDepositBlockPubData(pubData)
+	case types.TxTypeDepositNft:
+		return parseDepositNftBlockPubData(pubData)
+	case types.TxTypeTransfer:
+		return ParseTransferPubData(pubData)
+	case types.TxTypeWithdraw:
+		return ParseWithdrawPubData(pubData)
+	case types.TxTypeCreateCollection:
+		return ParseCreateCollectionPubData(pubData)
+	case types.TxTypeMintNft:
+		return ParseMintNftPubData(pubData)
+	case types.TxTypeTransferNft:
+		return ParseTransferNftPubData(pubData)
+	case types.TxTypeAtomicMatch:
+		return ParseAtomicMatchPubData(pubData)
+	case types.TxTypeCancelOffer:
+		return ParseCancelOfferPubData(pubData)
+	case types.TxTypeWithdrawNft:
+		return ParseWithdrawNftPubData(pubData)
+	case types.TxTypeFullExit:
+		return parseFullExitBlockPubData(pubData)
+	case types.TxTypeFullExitNft:
+		return parseFullExitNftBlockPubData(pubData)
+	}
+	return nil, fmt.Errorf("[ParseTxPubData] unsupported tx type %d", pubData[0])
+}
+
+// The priority operations are laid out differently in the block than in the requests
+// emitted by L1, the parsers below read the layout of the block.
+
+func parseRegisterZnsBlockPubData(pubData []byte) (*txtypes.RegisterZnsTxInfo, error) {
+	offset := 0
+	offset, txType := common2.ReadUint8(pubData, offset)
+	_, accountIndex := common2.ReadUint32(pubData, offset)
+	offset = chunkBytesSize
+	offset, accountName := common2.ReadBytes32(pubData, offset)
+	offset, accountNameHash := common2.ReadBytes32(pubData, offset)
+	offset, pubKeyX := common2.ReadBytes32(pubData, offset)
+	_, pubKeyY := common2.ReadBytes32(pubData, offset)
+	pk := new(eddsa.PublicKey)
+	pk.A.X.SetBytes(pubKeyX)
+	pk.A.Y.SetBytes(pubKeyY)
+	return &txtypes.RegisterZnsTxInfo{
+		TxType:          txType,
+		AccountIndex:    int64(accountIndex),
+		AccountName:     common2.CleanAccountName(common2.SerializeAccountName(accountName)),
+		AccountNameHash: accountNameHash,
+		PubKey:          common.Bytes2Hex(pk.Bytes()),
+	}, nil
+}
+
+func parseDepositBlockPubData(pubData []byte) (*txtypes.DepositTxInfo, error) {
+	offset := 0
+	offset, txType := common2.ReadUint8(pubData, offset)
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, assetId := common2.ReadUint16(pubData, offset)
+	_, amount := common2.ReadUint128(pubData, offset)
+	_, accountNameHash := common2.ReadBytes32(pubData, chunkBytesSize)
+	return &txtypes.DepositTxInfo{
+		TxType:          txType,
+		AccountIndex:    int64(accountIndex),
+		AccountNameHash: accountNameHash,
+		AssetId:         int64(assetId),
+		AssetAmount:     amount,
+	}, nil
+}
+
+func parseDepositNftBlockPubData(pubData []byte) (*txtypes.DepositNftTxInfo, error) {
+	offset := 0
+	offset, txType := common2.ReadUint8(pubData, offset)
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, nftL1Address := common2.ReadAddress(pubData, offset)
+	// creator account index, creator treasury rate and collection id are prefix padded
+	offset = 2*chunkBytesSize - 8
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	offset, collectionId := common2.ReadUint16(pubData, offset)
+	offset, nftContentHash := common2.ReadBytes32(pubData, offset)
+	offset, nftL1TokenId := common2.ReadUint256(pubData, offset)
+	_, accountNameHash := common2.ReadBytes32(pubData, offset)
+	return &txtypes.DepositNftTxInfo{
+		TxType:              txType,
+		AccountIndex:        int64(accountIndex),
+		NftIndex:            nftIndex,
+		NftL1Address:        nftL1Address,
+		CreatorAccountIndex: int64(creatorAccountIndex),
+		CreatorTreasuryRate: int64(creatorTreasuryRate),
+		NftContentHash:      nftContentHash,
+		NftL1TokenId:        nftL1TokenId,
+		AccountNameHash:     accountNameHash,
+		CollectionId:        int64(collectionId),
+	}, nil
+}
+
+func parseFullExitBlockPubData(pubData []byte) (*txtypes.FullExitTxInfo, error) {
+	offset := 0
+	offset, txType := common2.ReadUint8(pubData, offset)
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, assetId := common2.ReadUint16(pubData, offset)
+	_, assetAmount := common2.ReadUint128(pubData, offset)
+	_, accountNameHash := common2.ReadBytes32(pubData, chunkBytesSize)
+	return &txtypes.FullExitTxInfo{
+		TxType:          txType,
+		AccountIndex:    int64(accountIndex),
+		AccountNameHash: accountNameHash,
+		AssetId:         int64(assetId),
+		AssetAmount:     assetAmount,
+	}, nil
+}
+
+func parseFullExitNftBlockPubData(pubData []byte) (*txtypes.FullExitNftTxInfo, error) {
+	offset := 0
+	offset, txType := common2.ReadUint8(pubData, offset)
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, collectionId := common2.ReadUint16(pubData, offset)
+	// the nft l1 address is prefix padded
+	offset = 2*chunkBytesSize - types.AddressBytesSize
+	offset, nftL1Address := common2.ReadAddress(pubData, offset)
+	offset, accountNameHash := common2.ReadBytes32(pubData, offset)
+	offset, creatorAccountNameHash := common2.ReadBytes32(pubData, offset)
+	offset, nftContentHash := common2.ReadBytes32(pubData, offset)
+	_, nftL1TokenId := common2.ReadUint256(pubData, offset)
+	return &txtypes.FullExitNftTxInfo{
+		TxType:                 txType,
+		AccountIndex:           int64(accountIndex),
+		CreatorAccountIndex:    int64(creatorAccountIndex),
+		CreatorTreasuryRate:    int64(creatorTreasuryRate),
+		NftIndex:               nftIndex,
+		CollectionId:           int64(collectionId),
+		NftL1Address:           nftL1Address,
+		AccountNameHash:        accountNameHash,
+		CreatorAccountNameHash: creatorAccountNameHash,
+		NftContentHash:         nftContentHash,
+		NftL1TokenId:           nftL1TokenId,
+	}, nil
+}
+
+func ParseTransferPubData(pubData []byte) (tx *txtypes.TransferTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseTransferPubData] invalid size")
+	}
+	offset := 1
+	offset, fromAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, toAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, assetId := common2.ReadUint16(pubData, offset)
+	offset, packedAmount := common2.ReadUint40(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, packedFee := common2.ReadUint16(pubData, offset)
+	_, callDataHash := common2.ReadBytes32(pubData, chunkBytesSize)
+	tx = &txtypes.TransferTxInfo{
+		FromAccountIndex:  int64(fromAccountIndex),
+		ToAccountIndex:    int64(toAccountIndex),
+		AssetId:           int64(assetId),
+		AssetAmount:       common2.FromPackedAmount(packedAmount),
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: common2.FromPackedFee(int64(packedFee)),
+		CallDataHash:      callDataHash,
+	}
+	return tx, nil
+}
+
+func ParseWithdrawPubData(pubData []byte) (tx *txtypes.WithdrawTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseWithdrawPubData] invalid size")
+	}
+	offset := 1
+	offset, fromAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, toAddress := common2.ReadAddress(pubData, offset)
+	_, assetId := common2.ReadUint16(pubData, offset)
+	// the second chunk is prefix padded
+	offset = 2*chunkBytesSize - 24
+	offset, assetAmount := common2.ReadUint128(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, packedFee := common2.ReadUint16(pubData, offset)
+	tx = &txtypes.WithdrawTxInfo{
+		FromAccountIndex:  int64(fromAccountIndex),
+		AssetId:           int64(assetId),
+		AssetAmount:       assetAmount,
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: common2.FromPackedFee(int64(packedFee)),
+		ToAddress:         toAddress,
+	}
+	return tx, nil
+}
+
+func ParseCreateCollectionPubData(pubData []byte) (tx *txtypes.CreateCollectionTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseCreateCollectionPubData] invalid size")
+	}
+	offset := 1
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, collectionId := common2.ReadUint16(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, packedFee := common2.ReadUint16(pubData, offset)
+	tx = &txtypes.CreateCollectionTxInfo{
+		AccountIndex:      int64(accountIndex),
+		CollectionId:      int64(collectionId),
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: common2.FromPackedFee(int64(packedFee)),
+	}
+	return tx, nil
+}
+
+func ParseMintNftPubData(pubData []byte) (tx *txtypes.MintNftTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseMintNftPubData] invalid size")
+	}
+	offset := 1
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, toAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	offset, packedFee := common2.ReadUint16(pubData, offset)
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	_, collectionId := common2.ReadUint16(pubData, offset)
+	_, nftContentHash := common2.ReadBytes32(pubData, chunkBytesSize)
+	tx = &txtypes.MintNftTxInfo{
+		CreatorAccountIndex: int64(creatorAccountIndex),
+		ToAccountIndex:      int64(toAccountIndex),
+		NftIndex:            nftIndex,
+		NftContentHash:      common.Bytes2Hex(nftContentHash),
+		NftCollectionId:     int64(collectionId),
+		CreatorTreasuryRate: int64(creatorTreasuryRate),
+		GasAccountIndex:     int64(gasAccountIndex),
+		GasFeeAssetId:       int64(gasFeeAssetId),
+		GasFeeAssetAmount:   common2.FromPackedFee(int64(packedFee)),
+	}
+	return tx, nil
+}
+
+func ParseTransferNftPubData(pubData []byte) (tx *txtypes.TransferNftTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseTransferNftPubData] invalid size")
+	}
+	offset := 1
+	offset, fromAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, toAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, packedFee := common2.ReadUint16(pubData, offset)
+	_, callDataHash := common2.ReadBytes32(pubData, chunkBytesSize)
+	tx = &txtypes.TransferNftTxInfo{
+		FromAccountIndex:  int64(fromAccountIndex),
+		ToAccountIndex:    int64(toAccountIndex),
+		NftIndex:          nftIndex,
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: common2.FromPackedFee(int64(packedFee)),
+		CallDataHash:      callDataHash,
+	}
+	return tx, nil
+}
+
+// ParseAtomicMatchPubData parses the pub data of an atomic match. The treasury rate of the
+// offers isn't in the pub data, it is set to the lowest rate giving the treasury amount.
+func ParseAtomicMatchPubData(pubData []byte) (tx *txtypes.AtomicMatchTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseAtomicMatchPubData] invalid size")
+	}
+	offset := 1
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, buyAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, buyOfferId := common2.ReadUint24(pubData, offset)
+	offset, sellAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, sellOfferId := common2.ReadUint24(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, assetId := common2.ReadUint16(pubData, offset)
+	// the second chunk is prefix padded
+	offset = 2*chunkBytesSize - 23
+	offset, packedAmount := common2.ReadUint40(pubData, offset)
+	offset, packedCreatorAmount := common2.ReadUint40(pubData, offset)
+	offset, packedTreasuryAmount := common2.ReadUint40(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, packedFee := common2.ReadUint16(pubData, offset)
+
+	assetAmount := common2.FromPackedAmount(packedAmount)
+	treasuryAmount := common2.FromPackedAmount(packedTreasuryAmount)
+	treasuryRate := int64(0)
+	if assetAmount.Sign() > 0 {
+		// treasury amount = asset amount * treasury rate / 10000, rounded down
+		rate := new(big.Int).Mul(treasuryAmount, big.NewInt(10000))
+		rate.Add(rate, new(big.Int).Sub(assetAmount, big.NewInt(1)))
+		treasuryRate = rate.Div(rate, assetAmount).Int64()
+	}
+	tx = &txtypes.AtomicMatchTxInfo{
+		AccountIndex: int64(accountIndex),
+		BuyOffer: &txtypes.OfferTxInfo{
+			Type:         types.BuyOfferType,
+			OfferId:      buyOfferId,
+			AccountIndex: int64(buyAccountIndex),
+			NftIndex:     nftIndex,
+			AssetId:      int64(assetId),
+			AssetAmount:  assetAmount,
+			TreasuryRate: treasuryRate,
+		},
+		SellOffer: &txtypes.OfferTxInfo{
+			Type:         types.SellOfferType,
+			OfferId:      sellOfferId,
+			AccountIndex: int64(sellAccountIndex),
+			NftIndex:     nftIndex,
+			AssetId:      int64(assetId),
+			AssetAmount:  assetAmount,
+			TreasuryRate: treasuryRate,
+		},
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: common2.FromPackedFee(int64(packedFee)),
+		CreatorAmount:     common2.FromPackedAmount(packedCreatorAmount),
+		TreasuryAmount:    treasuryAmount,
+	}
+	return tx, nil
+}
+
+func ParseCancelOfferPubData(pubData []byte) (tx *txtypes.CancelOfferTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseCancelOfferPubData] invalid size")
+	}
+	offset := 1
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, offerId := common2.ReadUint24(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	_, packedFee := common2.ReadUint16(pubData, offset)
+	tx = &txtypes.CancelOfferTxInfo{
+		AccountIndex:      int64(accountIndex),
+		OfferId:           offerId,
+		GasAccountIndex:   int64(gasAccountIndex),
+		GasFeeAssetId:     int64(gasFeeAssetId),
+		GasFeeAssetAmount: common2.FromPackedFee(int64(packedFee)),
+	}
+	return tx, nil
+}
+
+func ParseWithdrawNftPubData(pubData []byte) (tx *txtypes.WithdrawNftTxInfo, err error) {
+	if len(pubData) != TxPubDataSize {
+		return nil, errors.New("[ParseWithdrawNftPubData] invalid size")
+	}
+	offset := 1
+	offset, accountIndex := common2.ReadUint32(pubData, offset)
+	offset, creatorAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, creatorTreasuryRate := common2.ReadUint16(pubData, offset)
+	offset, nftIndex := common2.ReadUint40(pubData, offset)
+	_, collectionId := common2.ReadUint16(pubData, offset)
+	// the second and third chunks are prefix padded
+	_, nftL1Address := common2.ReadAddress(pubData, 2*chunkBytesSize-types.AddressBytesSize)
+	offset = 3*chunkBytesSize - 28
+	offset, toAddress := common2.ReadAddress(pubData, offset)
+	offset, gasAccountIndex := common2.ReadUint32(pubData, offset)
+	offset, gasFeeAssetId := common2.ReadUint16(pubData, offset)
+	offset, packedFee := common2.ReadUint16(pubData, offset)
+	offset, nftContentHash := common2.ReadBytes32(pubData, offset)
+	offset, nftL1TokenId := common2.ReadUint256(pubData, offset)
+	_, creatorAccountNameHash := common2.ReadBytes32(pubData, offset)
+	tx = &txtypes.WithdrawNftTxInfo{
+		AccountIndex:           int64(accountIndex),
+		CreatorAccountIndex:    int64(creatorAccountIndex),
+		CreatorAccountNameHash: creatorAccountNameHash,
+		CreatorTreasuryRate:    int64(creatorTreasuryRate),
+		NftIndex:               nftIndex,
+		NftContentHash:         nftContentHash,
+		NftL1Address:           nftL1Address,
+		NftL1TokenId:           nftL1TokenId,
+		CollectionId:           int64(collectionId),
+		ToAddress:              toAddress,
+		GasAccountIndex:        int64(gasAccountIndex),
+		GasFeeAssetId:          int64(gasFeeAssetId),
+		GasFeeAssetAmount:      common2.FromPackedFee(int64(packedFee)),
+	}
+	return tx, nil
+}
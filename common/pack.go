@@ -15,3 +15,20 @@ func ToPackedAmount(amount *big.Int) (res int64, err error) {
 func ToPackedFee(amount *big.Int) (res int64, err error) {
 	return util.ToPackedFee(amount)
 }
+
+// FromPackedAmount : convert the 40 bit packed amount back to big int
+func FromPackedAmount(packedAmount int64) *big.Int {
+	return unpack(packedAmount)
+}
+
+// FromPackedFee : convert the 16 bit packed fee back to big int
+func FromPackedFee(packedFee int64) *big.Int {
+	return unpack(packedFee)
+}
+
+// unpack computes mantissa * 10^exponent, the exponent is stored in the lowest 5 bits.
+func unpack(packed int64) *big.Int {
+	mantissa := big.NewInt(packed >> 5)
+	exponent := big.NewInt(packed & 0x1f)
+	return mantissa.Mul(mantissa, new(big.Int).Exp(big.NewInt(10), exponent, nil))
+}
@@ -37,3 +37,17 @@ func TestToPackedFee(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, fee, int64(32011))
 }
+
+func TestFromPackedAmount(t *testing.T) {
+	a, _ := new(big.Int).SetString("123456789000000000000", 10)
+	packed, err := ToPackedAmount(a)
+	assert.NoError(t, err)
+	assert.Equal(t, a.String(), FromPackedAmount(packed).String())
+}
+
+func TestFromPackedFee(t *testing.T) {
+	amount, _ := new(big.Int).SetString("100000000000000", 10)
+	packed, err := ToPackedFee(amount)
+	assert.NoError(t, err)
+	assert.Equal(t, amount.String(), FromPackedFee(packed).String())
+}
@@ -52,23 +52,15 @@ func GenerateProof(
 	if err != nil {
 		return proof, err
 	}
-	var verifyWitness circuit.BlockConstraints
-	verifyWitness.OldStateRoot = cBlock.OldStateRoot
-	verifyWitness.NewStateRoot = cBlock.NewStateRoot
-	verifyWitness.BlockCommitment = cBlock.BlockCommitment
 	witness, err := frontend.NewWitness(&blockWitness, ecc.BN254)
 	if err != nil {
 		return proof, err
 	}
-	vWitness, err := frontend.NewWitness(&verifyWitness, ecc.BN254, frontend.PublicOnly())
-	if err != nil {
-		return proof, err
-	}
 	proof, err = groth16.Prove(r1cs, provingKey, witness, backend.WithHints(types.Keccak256))
 	if err != nil {
 		return proof, err
 	}
-	err = groth16.Verify(proof, verifyingKey, vWitness)
+	err = VerifyProof(proof, verifyingKey, cBlock.OldStateRoot, cBlock.NewStateRoot, cBlock.BlockCommitment)
 	if err != nil {
 		return proof, err
 	}
@@ -76,6 +68,23 @@ func GenerateProof(
 	return proof, nil
 }
 
+// VerifyProof verifies the block proof against its public inputs.
+func VerifyProof(
+	proof groth16.Proof,
+	verifyingKey groth16.VerifyingKey,
+	oldRoot, newRoot, commitment []byte,
+) error {
+	var verifyWitness circuit.BlockConstraints
+	verifyWitness.OldStateRoot = oldRoot
+	verifyWitness.NewStateRoot = newRoot
+	verifyWitness.BlockCommitment = commitment
+	vWitness, err := frontend.NewWitness(&verifyWitness, ecc.BN254, frontend.PublicOnly())
+	if err != nil {
+		return err
+	}
+	return groth16.Verify(proof, verifyingKey, vWitness)
+}
+
 type FormattedProof struct {
 	A      [2]*big.Int
 	B      [2][2]*big.Int
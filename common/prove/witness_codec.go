@@ -0,0 +1,438 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package prove
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/bnb-chain/zkbnb-crypto/circuit"
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+)
+
+const (
+	// binaryWitnessVersion is bumped whenever the binary layout changes, the witnesses
+	// encoded by the previous versions are to be migrated.
+	binaryWitnessVersion = 1
+	binaryWitnessMagic   = "ZKBW"
+
+	binaryWitnessHeaderSize = len(binaryWitnessMagic) + 1 + layoutFingerprintSize
+	layoutFingerprintSize   = 8
+)
+
+var (
+	ErrInvalidWitnessData    = errors.New("invalid witness data")
+	ErrUnsupportedWitnessFmt = errors.New("unsupported witness format")
+	ErrWitnessLayoutChanged  = errors.New("witness layout mismatches the circuit block, the witness format version should be bumped")
+
+	bigIntPtrType = reflect.TypeOf(&big.Int{})
+
+	// circuitBlockFingerprint identifies the layout of circuit.Block the binary witnesses
+	// are encoded with, as the fields are encoded in declaration order without tags.
+	circuitBlockFingerprint = layoutFingerprint(reflect.TypeOf(circuit.Block{}))
+
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// EncodeBlockWitness encodes the crypto block into the witness row with the given format.
+func EncodeBlockWitness(cryptoBlock *circuit.Block, witness *blockwitness.BlockWitness, format int64) error {
+	switch format {
+	case blockwitness.FormatJSON:
+		bz, err := json.Marshal(cryptoBlock)
+		if err != nil {
+			return err
+		}
+		witness.WitnessData = string(bz)
+		witness.WitnessBytes = nil
+	case blockwitness.FormatBinaryV1:
+		bz, err := MarshalBinaryBlock(cryptoBlock)
+		if err != nil {
+			return err
+		}
+		witness.WitnessData = ""
+		witness.WitnessBytes = bz
+	default:
+		return ErrUnsupportedWitnessFmt
+	}
+	witness.Format = format
+	return nil
+}
+
+// DecodeBlockWitness decodes the crypto block from the witness row, both the
+// legacy json format and the binary formats are supported.
+func DecodeBlockWitness(witness *blockwitness.BlockWitness) (*circuit.Block, error) {
+	switch witness.Format {
+	case blockwitness.FormatJSON:
+		var cryptoBlock *circuit.Block
+		err := json.Unmarshal([]byte(witness.WitnessData), &cryptoBlock)
+		if err != nil {
+			return nil, err
+		}
+		return cryptoBlock, nil
+	case blockwitness.FormatBinaryV1:
+		return UnmarshalBinaryBlock(witness.WitnessBytes)
+	}
+	return nil, ErrUnsupportedWitnessFmt
+}
+
+// MarshalBinaryBlock encodes the crypto block into a zstd compressed binary layout.
+// The payload starts with a header of the magic, the format version and the layout
+// fingerprint of circuit.Block. Every field is written in declaration order: integers
+// are varints, byte slices (field elements) and strings are length prefixed, pointers
+// carry a presence byte.
+func MarshalBinaryBlock(cryptoBlock *circuit.Block) ([]byte, error) {
+	if err := initZstd(); err != nil {
+		return nil, err
+	}
+	raw, err := marshalRawBlock(cryptoBlock)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, binaryWitnessHeaderSize)
+	header = append(header, binaryWitnessMagic...)
+	header = append(header, binaryWitnessVersion)
+	header = append(header, circuitBlockFingerprint...)
+	return zstdEncoder.EncodeAll(raw, header), nil
+}
+
+func marshalRawBlock(cryptoBlock *circuit.Block) ([]byte, error) {
+	e := &witnessEncoder{}
+	err := e.encode(reflect.ValueOf(cryptoBlock))
+	if err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// UnmarshalBinaryBlock decodes the crypto block encoded by MarshalBinaryBlock.
+func UnmarshalBinaryBlock(data []byte) (*circuit.Block, error) {
+	if err := initZstd(); err != nil {
+		return nil, err
+	}
+	if len(data) < binaryWitnessHeaderSize || string(data[:len(binaryWitnessMagic)]) != binaryWitnessMagic {
+		return nil, ErrInvalidWitnessData
+	}
+	version := data[len(binaryWitnessMagic)]
+	if version != binaryWitnessVersion {
+		return nil, fmt.Errorf("%w: binary witness version %d", ErrUnsupportedWitnessFmt, version)
+	}
+	if !bytes.Equal(data[len(binaryWitnessMagic)+1:binaryWitnessHeaderSize], circuitBlockFingerprint) {
+		return nil, ErrWitnessLayoutChanged
+	}
+	raw, err := zstdDecoder.DecodeAll(data[binaryWitnessHeaderSize:], nil)
+	if err != nil {
+		return nil, err
+	}
+	var cryptoBlock *circuit.Block
+	d := &witnessDecoder{r: bytes.NewReader(raw)}
+	err = d.decode(reflect.ValueOf(&cryptoBlock).Elem())
+	if err != nil {
+		return nil, err
+	}
+	if d.r.Len() != 0 {
+		return nil, ErrInvalidWitnessData
+	}
+	return cryptoBlock, nil
+}
+
+// layoutFingerprint hashes the field names and kinds of the type recursively, any change
+// to the layout encoded by the witnessEncoder changes the fingerprint.
+func layoutFingerprint(t reflect.Type) []byte {
+	var buf bytes.Buffer
+	writeLayout(&buf, t, make(map[reflect.Type]bool))
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:layoutFingerprintSize]
+}
+
+func writeLayout(buf *bytes.Buffer, t reflect.Type, visiting map[reflect.Type]bool) {
+	if t == bigIntPtrType {
+		buf.WriteString("bigint")
+		return
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		buf.WriteString("[]")
+		writeLayout(buf, t.Elem(), visiting)
+	case reflect.Array:
+		fmt.Fprintf(buf, "[%d]", t.Len())
+		writeLayout(buf, t.Elem(), visiting)
+	case reflect.Ptr:
+		buf.WriteString("*")
+		writeLayout(buf, t.Elem(), visiting)
+	case reflect.Struct:
+		if visiting[t] {
+			buf.WriteString(t.Name())
+			return
+		}
+		visiting[t] = true
+		buf.WriteString(t.Name() + "{")
+		for i := 0; i < t.NumField(); i++ {
+			buf.WriteString(t.Field(i).Name + ":")
+			writeLayout(buf, t.Field(i).Type, visiting)
+			buf.WriteString(";")
+		}
+		buf.WriteString("}")
+		delete(visiting, t)
+	default:
+		buf.WriteString(t.Kind().String())
+	}
+}
+
+func initZstd() error {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdErr
+}
+
+type witnessEncoder struct {
+	buf     bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *witnessEncoder) putUvarint(x uint64) {
+	n := binary.PutUvarint(e.scratch[:], x)
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *witnessEncoder) putVarint(x int64) {
+	n := binary.PutVarint(e.scratch[:], x)
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *witnessEncoder) encode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf.WriteByte(1)
+		} else {
+			e.buf.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.putVarint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.putUvarint(v.Uint())
+	case reflect.String:
+		e.putUvarint(uint64(v.Len()))
+		e.buf.WriteString(v.String())
+	case reflect.Slice:
+		// Zero length is reserved for nil slices.
+		if v.IsNil() {
+			e.putUvarint(0)
+			return nil
+		}
+		e.putUvarint(uint64(v.Len()) + 1)
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.buf.Write(v.Bytes())
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			bz := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(bz), v)
+			e.buf.Write(bz)
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.buf.WriteByte(0)
+			return nil
+		}
+		e.buf.WriteByte(1)
+		if v.Type() == bigIntPtrType {
+			x := v.Interface().(*big.Int)
+			e.buf.WriteByte(byte(x.Sign() + 1))
+			bz := x.Bytes()
+			e.putUvarint(uint64(len(bz)))
+			e.buf.Write(bz)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				return fmt.Errorf("unable to encode unexported field %s.%s", t.Name(), t.Field(i).Name)
+			}
+			if err := e.encode(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unable to encode kind %s", v.Kind())
+	}
+	return nil
+}
+
+type witnessDecoder struct {
+	r *bytes.Reader
+}
+
+func (d *witnessDecoder) length() (int, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, ErrInvalidWitnessData
+	}
+	// Every element takes at least one byte, reject lengths exceeding the input.
+	if n > uint64(d.r.Len()) {
+		return 0, ErrInvalidWitnessData
+	}
+	return int(n), nil
+}
+
+func (d *witnessDecoder) decode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return ErrInvalidWitnessData
+		}
+		v.SetBool(b != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := binary.ReadVarint(d.r)
+		if err != nil || v.OverflowInt(x) {
+			return ErrInvalidWitnessData
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := binary.ReadUvarint(d.r)
+		if err != nil || v.OverflowUint(x) {
+			return ErrInvalidWitnessData
+		}
+		v.SetUint(x)
+	case reflect.String:
+		n, err := d.length()
+		if err != nil {
+			return err
+		}
+		bz := make([]byte, n)
+		if _, err := io.ReadFull(d.r, bz); err != nil {
+			return ErrInvalidWitnessData
+		}
+		v.SetString(string(bz))
+	case reflect.Slice:
+		n, err := d.length()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		n--
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			bz := make([]byte, n)
+			if _, err := io.ReadFull(d.r, bz); err != nil {
+				return ErrInvalidWitnessData
+			}
+			v.SetBytes(bz)
+			return nil
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			bz := make([]byte, v.Len())
+			if _, err := io.ReadFull(d.r, bz); err != nil {
+				return ErrInvalidWitnessData
+			}
+			reflect.Copy(v, reflect.ValueOf(bz))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		present, err := d.r.ReadByte()
+		if err != nil {
+			return ErrInvalidWitnessData
+		}
+		if present == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.Type() == bigIntPtrType {
+			sign, err := d.r.ReadByte()
+			if err != nil || sign > 2 {
+				return ErrInvalidWitnessData
+			}
+			n, err := d.length()
+			if err != nil {
+				return err
+			}
+			bz := make([]byte, n)
+			if _, err := io.ReadFull(d.r, bz); err != nil {
+				return ErrInvalidWitnessData
+			}
+			x := new(big.Int).SetBytes(bz)
+			if sign == 0 {
+				x.Neg(x)
+			}
+			v.Set(reflect.ValueOf(x))
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		return d.decode(v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				return fmt.Errorf("unable to decode unexported field %s.%s", t.Name(), t.Field(i).Name)
+			}
+			if err := d.decode(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unable to decode kind %s", v.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package prove
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb-crypto/circuit"
+	cryptoTypes "github.com/bnb-chain/zkbnb-crypto/circuit/types"
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+)
+
+func testCryptoBlock() *circuit.Block {
+	stateRoot := make([]byte, 32)
+	for i := range stateRoot {
+		stateRoot[i] = byte(i)
+	}
+	transferTx := circuit.EmptyTx(stateRoot)
+	transferTx.TxType = cryptoTypes.TxTypeTransfer
+	transferTx.Nonce = 7
+	transferTx.ExpiredAt = 1665000000000
+	transferTx.TransferTxInfo = &cryptoTypes.TransferTx{
+		FromAccountIndex:  2,
+		ToAccountIndex:    3,
+		ToAccountNameHash: stateRoot,
+		AssetId:           1,
+		AssetAmount:       1000,
+		GasAccountIndex:   1,
+		GasFeeAssetId:     0,
+		GasFeeAssetAmount: 10,
+		CallDataHash:      []byte{},
+	}
+	transferTx.AccountsInfoBefore[0].AssetsInfo[0].Balance = new(big.Int).Lsh(big.NewInt(1), 100)
+	transferTx.AccountsInfoBefore[1].AssetsInfo[1].Balance = big.NewInt(-5)
+	return &circuit.Block{
+		BlockNumber:     12,
+		CreatedAt:       1665000000000,
+		OldStateRoot:    stateRoot,
+		NewStateRoot:    stateRoot,
+		BlockCommitment: stateRoot,
+		Txs:             []*circuit.Tx{transferTx, circuit.EmptyTx(stateRoot)},
+		Gas: &circuit.Gas{
+			GasAssetCount: 2,
+			AccountInfoBefore: &cryptoTypes.GasAccount{
+				AccountIndex: 1,
+				AssetsInfo: []*cryptoTypes.AccountAsset{
+					cryptoTypes.EmptyAccountAsset(0),
+					cryptoTypes.EmptyAccountAsset(1),
+				},
+			},
+			MerkleProofsAccountAssetsBefore: make([][AssetMerkleLevels][]byte, 2),
+		},
+	}
+}
+
+func TestBlockWitnessCodec(t *testing.T) {
+	cryptoBlock := testCryptoBlock()
+	expected, err := json.Marshal(cryptoBlock)
+	assert.NoError(t, err)
+
+	for _, format := range []int64{blockwitness.FormatJSON, blockwitness.FormatBinaryV1} {
+		witness := &blockwitness.BlockWitness{Height: cryptoBlock.BlockNumber}
+		err = EncodeBlockWitness(cryptoBlock, witness, format)
+		assert.NoError(t, err)
+		assert.Equal(t, format, witness.Format)
+
+		decoded, err := DecodeBlockWitness(witness)
+		assert.NoError(t, err)
+		actual, err := json.Marshal(decoded)
+		assert.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual))
+	}
+}
+
+func TestBinaryBlockCompression(t *testing.T) {
+	cryptoBlock := testCryptoBlock()
+	jsonBz, err := json.Marshal(cryptoBlock)
+	assert.NoError(t, err)
+	binaryBz, err := MarshalBinaryBlock(cryptoBlock)
+	assert.NoError(t, err)
+	assert.Less(t, len(binaryBz), len(jsonBz))
+
+	decoded, err := UnmarshalBinaryBlock(binaryBz)
+	assert.NoError(t, err)
+	assert.Equal(t, cryptoBlock, decoded)
+
+	_, err = UnmarshalBinaryBlock(jsonBz)
+	assert.Error(t, err)
+}
+
+// TestBinaryBlockGolden pins the binary witness layout, the witnesses stored by the
+// current format version must keep decoding. A failure means the circuit block or the
+// encoding changed, the format version should be bumped along with a migration.
+func TestBinaryBlockGolden(t *testing.T) {
+	golden, err := os.ReadFile("testdata/witness_binary_v1.golden")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "5a4b425701230f1cc8c187749d", hex.EncodeToString(golden[:binaryWitnessHeaderSize]))
+	raw, err := marshalRawBlock(testCryptoBlock())
+	assert.NoError(t, err)
+	sum := sha256.Sum256(raw)
+	assert.Equal(t, "d2ee952d2a709a0c801db17359987759592b4c53a27b44ea4f4eb911f18fc260", hex.EncodeToString(sum[:]))
+
+	decoded, err := UnmarshalBinaryBlock(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, testCryptoBlock(), decoded)
+
+	bz, err := MarshalBinaryBlock(testCryptoBlock())
+	assert.NoError(t, err)
+	assert.Equal(t, golden[:binaryWitnessHeaderSize], bz[:binaryWitnessHeaderSize])
+}
+
+func TestBinaryBlockHeader(t *testing.T) {
+	bz, err := MarshalBinaryBlock(testCryptoBlock())
+	assert.NoError(t, err)
+
+	invalidVersion := append([]byte{}, bz...)
+	invalidVersion[len(binaryWitnessMagic)] = binaryWitnessVersion + 1
+	_, err = UnmarshalBinaryBlock(invalidVersion)
+	assert.ErrorIs(t, err, ErrUnsupportedWitnessFmt)
+
+	changedLayout := append([]byte{}, bz...)
+	changedLayout[len(binaryWitnessMagic)+1] ^= 0xff
+	_, err = UnmarshalBinaryBlock(changedLayout)
+	assert.ErrorIs(t, err, ErrWitnessLayoutChanged)
+
+	_, err = UnmarshalBinaryBlock(bz[binaryWitnessHeaderSize:])
+	assert.ErrorIs(t, err, ErrInvalidWitnessData)
+}
+
+func TestWitnessFiles(t *testing.T) {
+	dir := t.TempDir()
+	cryptoBlock := testCryptoBlock()
+	for _, height := range []int64{12, 3, 100} {
+		cryptoBlock.BlockNumber = height
+		assert.NoError(t, WriteWitnessFile(dir, cryptoBlock))
+	}
+
+	heights, paths, err := ListWitnessFiles(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 12, 100}, heights)
+	decoded, err := ReadWitnessFile(paths[2])
+	assert.NoError(t, err)
+	assert.Equal(t, cryptoBlock, decoded)
+
+	heights, _, err = ListProofFiles(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, heights)
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package prove
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/bnb-chain/zkbnb-crypto/circuit"
+)
+
+const (
+	witnessFilePattern = "block_%d.witness"
+	proofFilePattern   = "block_%d.proof"
+)
+
+// ProofFile is the proof generated from a witness file, it keeps the raw
+// proof so that it can be verified again before being imported.
+type ProofFile struct {
+	BlockHeight int64
+	BlockSize   int
+	Proof       []byte
+}
+
+func WitnessFilePath(dir string, height int64) string {
+	return filepath.Join(dir, fmt.Sprintf(witnessFilePattern, height))
+}
+
+func ProofFilePath(dir string, height int64) string {
+	return filepath.Join(dir, fmt.Sprintf(proofFilePattern, height))
+}
+
+// WriteWitnessFile writes the crypto block into dir in the binary witness format.
+func WriteWitnessFile(dir string, cryptoBlock *circuit.Block) error {
+	bz, err := MarshalBinaryBlock(cryptoBlock)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(WitnessFilePath(dir, cryptoBlock.BlockNumber), bz)
+}
+
+func ReadWitnessFile(path string) (*circuit.Block, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalBinaryBlock(bz)
+}
+
+// WriteProofFile writes the raw proof of the block into dir.
+func WriteProofFile(dir string, height int64, blockSize int, proof groth16.Proof) error {
+	var buf bytes.Buffer
+	_, err := proof.WriteRawTo(&buf)
+	if err != nil {
+		return err
+	}
+	bz, err := json.Marshal(&ProofFile{
+		BlockHeight: height,
+		BlockSize:   blockSize,
+		Proof:       buf.Bytes(),
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ProofFilePath(dir, height), bz)
+}
+
+func ReadProofFile(path string) (*ProofFile, groth16.Proof, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var proofFile ProofFile
+	err = json.Unmarshal(bz, &proofFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof := groth16.NewProof(ecc.BN254)
+	_, err = proof.ReadFrom(bytes.NewReader(proofFile.Proof))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid proof of block %d: %v", proofFile.BlockHeight, err)
+	}
+	return &proofFile, proof, nil
+}
+
+// ListWitnessFiles returns the witness files in dir ordered by block height.
+func ListWitnessFiles(dir string) (heights []int64, paths []string, err error) {
+	return listFiles(dir, witnessFilePattern)
+}
+
+// ListProofFiles returns the proof files in dir ordered by block height.
+func ListProofFiles(dir string) (heights []int64, paths []string, err error) {
+	return listFiles(dir, proofFilePattern)
+}
+
+func listFiles(dir string, pattern string) ([]int64, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := make(map[int64]string)
+	heights := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		var height int64
+		if entry.IsDir() {
+			continue
+		}
+		_, err := fmt.Sscanf(entry.Name(), pattern, &height)
+		if err != nil || fmt.Sprintf(pattern, height) != entry.Name() {
+			continue
+		}
+		files[height] = filepath.Join(dir, entry.Name())
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	paths := make([]string, 0, len(heights))
+	for _, height := range heights {
+		paths = append(paths, files[height])
+	}
+	return heights, paths, nil
+}
+
+// writeFileAtomic writes into a temporary file first, so that a partially
+// written file is never picked up by the readers.
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	err := os.WriteFile(tmpPath, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package prove
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const witnessFixtureGolden = "testdata/witness_fixture.golden"
+
+type fixtureAccountModel struct {
+	account.AccountModel
+	accounts map[int64]*account.Account
+}
+
+func (m *fixtureAccountModel) GetConfirmedAccountByIndex(accountIndex int64) (*account.Account, error) {
+	acc, ok := m.accounts[accountIndex]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	copied := *acc
+	return &copied, nil
+}
+
+// witnessFixture builds a small chain of txs with their tx details, tracking the
+// balances and nonces the executor would have written.
+type witnessFixture struct {
+	accountModel *fixtureAccountModel
+	keys         map[int64]*eddsa.PrivateKey
+	balances     map[int64]map[int64]int64
+	nonces       map[int64]int64
+}
+
+func newWitnessFixture() *witnessFixture {
+	return &witnessFixture{
+		accountModel: &fixtureAccountModel{accounts: make(map[int64]*account.Account)},
+		keys:         make(map[int64]*eddsa.PrivateKey),
+		balances:     make(map[int64]map[int64]int64),
+		nonces:       make(map[int64]int64),
+	}
+}
+
+func fixtureNameHash(name string) []byte {
+	hash := sha256.Sum256([]byte(name))
+	// keep the hash in the scalar field
+	hash[0] = 0
+	return hash[:]
+}
+
+func (f *witnessFixture) registerZns(t *testing.T, accountIndex int64) *tx.Tx {
+	seed := bytes.Repeat([]byte{byte(accountIndex + 1)}, 32)
+	key, err := eddsa.GenerateKey(bytes.NewReader(seed))
+	assert.NoError(t, err)
+	f.keys[accountIndex] = key
+	f.balances[accountIndex] = make(map[int64]int64)
+
+	name := fmt.Sprintf("fixture%d.legend", accountIndex)
+	pk := common.Bytes2Hex(key.PublicKey.Bytes())
+	f.accountModel.accounts[accountIndex] = &account.Account{
+		AccountIndex:    accountIndex,
+		AccountName:     name,
+		PublicKey:       pk,
+		AccountNameHash: common.Bytes2Hex(fixtureNameHash(name)),
+		AssetInfo:       types.EmptyAccountAssetInfo,
+		AssetRoot:       common.Bytes2Hex(tree.NilAccountAssetRoot),
+	}
+	return f.newTx(t, types.TxTypeRegisterZns, accountIndex, &txtypes.RegisterZnsTxInfo{
+		TxType:          types.TxTypeRegisterZns,
+		AccountIndex:    accountIndex,
+		AccountName:     name,
+		AccountNameHash: fixtureNameHash(name),
+		PubKey:          pk,
+	})
+}
+
+func (f *witnessFixture) deposit(t *testing.T, accountIndex, assetId, amount int64) *tx.Tx {
+	oTx := f.newTx(t, types.TxTypeDeposit, accountIndex, &txtypes.DepositTxInfo{
+		TxType:          types.TxTypeDeposit,
+		AccountNameHash: common.FromHex(f.accountModel.accounts[accountIndex].AccountNameHash),
+		AssetId:         assetId,
+		AssetAmount:     big.NewInt(amount),
+		AccountIndex:    accountIndex,
+	})
+	oTx.TxDetails = []*tx.TxDetail{f.assetDetail(accountIndex, 0, assetId, amount, false)}
+	return oTx
+}
+
+func (f *witnessFixture) transfer(t *testing.T, from, to, assetId, amount, gasAssetId, gasFee int64) *tx.Tx {
+	txInfo := &txtypes.TransferTxInfo{
+		FromAccountIndex:  from,
+		ToAccountIndex:    to,
+		ToAccountNameHash: f.accountModel.accounts[to].AccountNameHash,
+		AssetId:           assetId,
+		AssetAmount:       big.NewInt(amount),
+		GasAccountIndex:   types.GasAccount,
+		GasFeeAssetId:     gasAssetId,
+		GasFeeAssetAmount: big.NewInt(gasFee),
+		CallDataHash:      []byte{},
+		ExpiredAt:         1665000000000,
+		Nonce:             f.nonces[from],
+		Sig:               f.sign(t, from, "transfer"),
+	}
+	oTx := f.newTx(t, types.TxTypeTransfer, from, txInfo)
+	oTx.ExpiredAt = txInfo.ExpiredAt
+	oTx.TxDetails = []*tx.TxDetail{
+		f.assetDetail(from, 0, assetId, -amount, false),
+		f.assetDetail(from, 0, gasAssetId, -gasFee, false),
+		f.assetDetail(to, 1, assetId, amount, false),
+		f.assetDetail(types.GasAccount, 2, gasAssetId, gasFee, true),
+	}
+	f.nonces[from]++
+	return oTx
+}
+
+func (f *witnessFixture) mintNft(t *testing.T, creator, to, nftIndex, gasAssetId, gasFee int64) *tx.Tx {
+	txInfo := &txtypes.MintNftTxInfo{
+		CreatorAccountIndex: creator,
+		ToAccountIndex:      to,
+		ToAccountNameHash:   f.accountModel.accounts[to].AccountNameHash,
+		NftIndex:            nftIndex,
+		NftContentHash:      common.Bytes2Hex(fixtureNameHash("content")),
+		CreatorTreasuryRate: 30,
+		GasAccountIndex:     types.GasAccount,
+		GasFeeAssetId:       gasAssetId,
+		GasFeeAssetAmount:   big.NewInt(gasFee),
+		ExpiredAt:           1665000000000,
+		Nonce:               f.nonces[creator],
+		Sig:                 f.sign(t, creator, "mintNft"),
+	}
+	oTx := f.newTx(t, types.TxTypeMintNft, creator, txInfo)
+	oTx.ExpiredAt = txInfo.ExpiredAt
+	nftInfo := types.ConstructNftInfo(nftIndex, creator, to, txInfo.NftContentHash,
+		types.EmptyL1TokenId, types.EmptyL1Address, txInfo.CreatorTreasuryRate, 0)
+	oTx.TxDetails = []*tx.TxDetail{
+		f.assetDetail(creator, 0, gasAssetId, -gasFee, false),
+		f.assetDetail(to, 1, gasAssetId, 0, false),
+		{
+			AssetId:      nftIndex,
+			AssetType:    types.NftAssetType,
+			AccountIndex: to,
+			Balance:      types.EmptyNftInfo(nftIndex).String(),
+			BalanceDelta: nftInfo.String(),
+			AccountOrder: types.NilAccountOrder,
+			Nonce:        f.nonces[to],
+		},
+		f.assetDetail(types.GasAccount, 2, gasAssetId, gasFee, true),
+	}
+	f.nonces[creator]++
+	return oTx
+}
+
+func (f *witnessFixture) newTx(t *testing.T, txType, accountIndex int64, txInfo interface{}) *tx.Tx {
+	txInfoBytes, err := json.Marshal(txInfo)
+	assert.NoError(t, err)
+	return &tx.Tx{
+		TxType:       txType,
+		TxInfo:       string(txInfoBytes),
+		AccountIndex: accountIndex,
+		Nonce:        f.nonces[accountIndex],
+	}
+}
+
+func (f *witnessFixture) assetDetail(accountIndex, accountOrder, assetId, delta int64, isGas bool) *tx.TxDetail {
+	balance := f.balances[accountIndex][assetId]
+	f.balances[accountIndex][assetId] = balance + delta
+	return &tx.TxDetail{
+		AssetId:      assetId,
+		AssetType:    types.FungibleAssetType,
+		AccountIndex: accountIndex,
+		Balance:      types.ConstructAccountAsset(assetId, big.NewInt(balance), types.ZeroBigInt).String(),
+		BalanceDelta: types.ConstructAccountAsset(assetId, big.NewInt(delta), types.ZeroBigInt).String(),
+		AccountOrder: accountOrder,
+		Nonce:        f.nonces[accountIndex],
+		IsGas:        isGas,
+	}
+}
+
+func (f *witnessFixture) sign(t *testing.T, accountIndex int64, msg string) []byte {
+	sig, err := f.keys[accountIndex].Sign(fixtureNameHash(msg), mimc.NewMiMC())
+	assert.NoError(t, err)
+	return sig
+}
+
+// blocks returns the fixture chain. It covers new accounts, single account txs, txs
+// over distinct accounts, a tx which touches the same account twice and an nft tx.
+func (f *witnessFixture) blocks(t *testing.T) []*block.Block {
+	var registers []*tx.Tx
+	for i := int64(0); i < 4; i++ {
+		registers = append(registers, f.registerZns(t, i))
+	}
+	return []*block.Block{
+		{
+			BlockHeight: 1,
+			Txs: append(registers,
+				f.deposit(t, 2, 0, 1000),
+				f.deposit(t, 2, 1, 1000),
+				f.deposit(t, 3, 0, 500),
+			),
+		},
+		{
+			BlockHeight: 2,
+			Txs: []*tx.Tx{
+				f.transfer(t, 2, 3, 0, 100, 1, 10),
+				f.transfer(t, 3, 3, 0, 50, 0, 5),
+				f.mintNft(t, 2, 3, 0, 1, 10),
+				f.transfer(t, 3, 2, 0, 20, 0, 5),
+			},
+		},
+	}
+}
+
+// TestWitnessFixture checks the witnesses against the ones generated by the
+// serial witness helper for the same fixture chain, without the need of a database.
+func TestWitnessFixture(t *testing.T) {
+	fixture := newWitnessFixture()
+	ctx, err := tree.NewContext("witness", tree.MemoryDB, false, 128, nil, nil, nil)
+	assert.NoError(t, err)
+	accountTree, err := tree.NewAccountTree(ctx, 0)
+	assert.NoError(t, err)
+	nftTree, err := tree.NewNftTree(ctx, 0)
+	assert.NoError(t, err)
+	assetTrees := tree.NewLazyTreeCache(assetTreeCacheSize, -1, 0, func(index, block int64) bsmt.SparseMerkleTree {
+		assetTree, err := tree.NewAccountAssetTree(ctx, index, block)
+		assert.NoError(t, err)
+		return assetTree
+	})
+	witnessHelper := NewWitnessHelper(ctx, accountTree, nftTree, assetTrees, fixture.accountModel, nil)
+
+	var actual []string
+	for _, b := range fixture.blocks(t) {
+		for idx, oTx := range b.Txs {
+			txWitness, err := witnessHelper.ConstructTxWitness(oTx, uint64(b.BlockHeight))
+			assert.NoError(t, err, fmt.Sprintf("block %d, tx %d", b.BlockHeight, idx))
+			bz, err := json.Marshal(txWitness)
+			assert.NoError(t, err)
+			actual = append(actual, string(bz))
+		}
+		gasWitness, err := witnessHelper.ConstructGasWitness(b)
+		assert.NoError(t, err, fmt.Sprintf("block %d, gas", b.BlockHeight))
+		bz, err := json.Marshal(gasWitness)
+		assert.NoError(t, err)
+		actual = append(actual, string(bz))
+	}
+
+	golden, err := os.Open(witnessFixtureGolden)
+	assert.NoError(t, err)
+	defer golden.Close()
+	var expected []string
+	scanner := bufio.NewScanner(golden)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		expected = append(expected, scanner.Text())
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Equal(t, len(expected), len(actual))
+	for i := range expected {
+		if i < len(actual) {
+			assert.Equal(t, expected[i], actual[i], fmt.Sprintf("witness %d differs", i))
+		}
+	}
+}
@@ -129,17 +129,31 @@ func (w *WitnessHelper) constructWitnessInfo(
 	if err != nil {
 		return nil, err
 	}
+	// construct nft witness, the nft tree is independent of the account trees
+	var (
+		nftRootBefore         []byte
+		nftBefore             *cryptoTypes.Nft
+		merkleProofsNftBefore [NftMerkleLevels][]byte
+		nftErrChan            = make(chan error, 1)
+	)
+	defer close(nftErrChan)
+	err = w.treeCtx.RoutinePool().Submit(func() {
+		var nftErr error
+		nftRootBefore, nftBefore, merkleProofsNftBefore, nftErr = w.constructNftWitness(proverNftInfo)
+		nftErrChan <- nftErr
+	})
+	if err != nil {
+		return nil, err
+	}
 	// construct account witness
 	accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err :=
 		w.constructAccountWitness(oTx, finalityBlockNr, accountKeys, proverAccounts)
+	nftErr := <-nftErrChan
 	if err != nil {
 		return nil, err
 	}
-	// construct nft witness
-	nftRootBefore, nftBefore, merkleProofsNftBefore, err :=
-		w.constructNftWitness(proverNftInfo)
-	if err != nil {
-		return nil, err
+	if nftErr != nil {
+		return nil, nftErr
 	}
 	stateRootBefore := tree.ComputeStateRootHash(accountRootBefore, nftRootBefore)
 	stateRootAfter := tree.ComputeStateRootHash(w.accountTree.Root(), w.nftTree.Root())
@@ -175,12 +189,19 @@ func (w *WitnessHelper) constructAccountWitness(
 	accountRootBefore = w.accountTree.Root()
 	var (
 		accountCount = 0
+		// asset witnesses are built ahead of the account tree updates
+		assetWitnesses []*accountAssetWitness
 	)
+	if proverAccounts != nil {
+		assetWitnesses, err = w.constructAccountAssetWitnesses(accountKeys, proverAccounts)
+		if err != nil {
+			return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
+		}
+	}
 	for _, accountKey := range accountKeys {
 		var (
 			cryptoAccount = new(cryptoTypes.Account)
-			// get account asset before
-			assetCount = 0
+			assetWitness  *accountAssetWitness
 		)
 		// get account before
 		accountMerkleProofs, err := w.accountTree.GetProof(uint64(accountKey))
@@ -228,8 +249,14 @@ func (w *WitnessHelper) constructAccountWitness(
 					AssetInfo:       make(map[int64]*types.AccountAsset, 0),
 				}
 			}
+			// the asset tree is only padded
+			assetWitness, err = w.constructAccountAssetWitness(accountKey, nil)
+			if err != nil {
+				return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
+			}
 		} else {
 			proverAccountInfo := proverAccounts[accountCount]
+			assetWitness = assetWitnesses[accountCount]
 			pk, err := common2.ParsePubKey(proverAccountInfo.AccountInfo.PublicKey)
 			if err != nil {
 				return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
@@ -240,51 +267,11 @@ func (w *WitnessHelper) constructAccountWitness(
 				AccountPk:       pk,
 				Nonce:           proverAccountInfo.AccountInfo.Nonce,
 				CollectionNonce: proverAccountInfo.AccountInfo.CollectionNonce,
-				AssetRoot:       w.assetTrees.Get(accountKey).Root(),
+				AssetRoot:       assetWitness.assetRootBefore,
 			}
-			for i, accountAsset := range proverAccountInfo.AccountAssets {
-				assetMerkleProof, err := w.assetTrees.Get(accountKey).GetProof(uint64(accountAsset.AssetId))
-				if err != nil {
-					return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-				}
-				// set crypto account asset
-				cryptoAccount.AssetsInfo[assetCount] = &cryptoTypes.AccountAsset{
-					AssetId:                  accountAsset.AssetId,
-					Balance:                  accountAsset.Balance,
-					OfferCanceledOrFinalized: accountAsset.OfferCanceledOrFinalized,
-				}
-
-				// set merkle proof
-				merkleProofsAccountAssetsBefore[accountCount][assetCount], err = SetFixedAccountAssetArray(assetMerkleProof)
-				if err != nil {
-					return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-				}
-				// update asset merkle tree
-				nBalance, err := chain.ComputeNewBalance(
-					proverAccountInfo.AssetsRelatedTxDetails[i].AssetType,
-					proverAccountInfo.AssetsRelatedTxDetails[i].Balance,
-					proverAccountInfo.AssetsRelatedTxDetails[i].BalanceDelta,
-				)
-				if err != nil {
-					return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-				}
-				nAsset, err := types.ParseAccountAsset(nBalance)
-				if err != nil {
-					return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-				}
-				nAssetHash, err := tree.ComputeAccountAssetLeafHash(nAsset.Balance.String(), nAsset.OfferCanceledOrFinalized.String())
-				if err != nil {
-					return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-				}
-				err = w.assetTrees.Get(accountKey).Set(uint64(accountAsset.AssetId), nAssetHash)
-				if err != nil {
-					return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-				}
-
-				assetCount++
-
-				// cache gas account's assets
-				if accountKey == types.GasAccount {
+			// cache gas account's assets
+			if accountKey == types.GasAccount {
+				for _, nAsset := range assetWitness.newAssets {
 					w.gasAccountInfo.AssetInfo[nAsset.AssetId] = &types.AccountAsset{
 						AssetId:                  nAsset.AssetId,
 						Balance:                  nAsset.Balance,
@@ -292,23 +279,9 @@ func (w *WitnessHelper) constructAccountWitness(
 					}
 				}
 			}
-			if err != nil {
-				return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-			}
-		}
-		// padding empty account asset
-		for assetCount < NbAccountAssetsPerAccount {
-			cryptoAccount.AssetsInfo[assetCount] = cryptoTypes.EmptyAccountAsset(LastAccountAssetId)
-			assetMerkleProof, err := w.assetTrees.Get(accountKey).GetProof(LastAccountAssetId)
-			if err != nil {
-				return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-			}
-			merkleProofsAccountAssetsBefore[accountCount][assetCount], err = SetFixedAccountAssetArray(assetMerkleProof)
-			if err != nil {
-				return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
-			}
-			assetCount++
 		}
+		cryptoAccount.AssetsInfo = assetWitness.assetsInfo
+		merkleProofsAccountAssetsBefore[accountCount] = assetWitness.merkleProofs
 		// set account merkle proof
 		merkleProofsAccountBefore[accountCount], err = SetFixedAccountArray(accountMerkleProofs)
 		if err != nil {
@@ -328,7 +301,7 @@ func (w *WitnessHelper) constructAccountWitness(
 			proverAccounts[accountCount].AccountInfo.PublicKey,
 			nonce,
 			collectionNonce,
-			w.assetTrees.Get(accountKey).Root(),
+			assetWitness.assetRootAfter,
 		)
 		if err != nil {
 			return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, err
@@ -342,7 +315,7 @@ func (w *WitnessHelper) constructAccountWitness(
 		if accountKey == types.GasAccount {
 			w.gasAccountInfo.Nonce = nonce
 			w.gasAccountInfo.CollectionNonce = collectionNonce
-			w.gasAccountInfo.AssetRoot = common.Bytes2Hex(w.assetTrees.Get(accountKey).Root())
+			w.gasAccountInfo.AssetRoot = common.Bytes2Hex(assetWitness.assetRootAfter)
 		}
 
 		// set account info before
@@ -386,6 +359,136 @@ func (w *WitnessHelper) constructAccountWitness(
 	return accountRootBefore, accountsInfoBefore, merkleProofsAccountAssetsBefore, merkleProofsAccountBefore, nil
 }
 
+// accountAssetWitness is the asset tree part of an account witness.
+type accountAssetWitness struct {
+	assetRootBefore []byte
+	assetsInfo      [NbAccountAssetsPerAccount]*cryptoTypes.AccountAsset
+	merkleProofs    [NbAccountAssetsPerAccount][AssetMerkleLevels][]byte
+	newAssets       []*types.AccountAsset
+	assetRootAfter  []byte
+}
+
+// constructAccountAssetWitnesses builds the asset witnesses of the accounts in order.
+// The asset trees of distinct accounts are independent, so they are built on the
+// tree routine pool unless an account shows up more than once in the tx.
+func (w *WitnessHelper) constructAccountAssetWitnesses(
+	accountKeys []int64,
+	proverAccounts []*AccountWitnessInfo,
+) ([]*accountAssetWitness, error) {
+	assetWitnesses := make([]*accountAssetWitness, len(accountKeys))
+	distinct := make(map[int64]bool, len(accountKeys))
+	for _, accountKey := range accountKeys {
+		distinct[accountKey] = true
+	}
+	if len(distinct) != len(accountKeys) || len(accountKeys) == 1 {
+		for i, accountKey := range accountKeys {
+			assetWitness, err := w.constructAccountAssetWitness(accountKey, proverAccounts[i])
+			if err != nil {
+				return nil, err
+			}
+			assetWitnesses[i] = assetWitness
+		}
+		return assetWitnesses, nil
+	}
+
+	errChan := make(chan error, len(accountKeys))
+	defer close(errChan)
+	submitted := 0
+	var err error
+	for i, accountKey := range accountKeys {
+		i, accountKey := i, accountKey
+		err = w.treeCtx.RoutinePool().Submit(func() {
+			assetWitness, err := w.constructAccountAssetWitness(accountKey, proverAccounts[i])
+			assetWitnesses[i] = assetWitness
+			errChan <- err
+		})
+		if err != nil {
+			break
+		}
+		submitted++
+	}
+	for ; submitted > 0; submitted-- {
+		taskErr := <-errChan
+		if taskErr != nil && err == nil {
+			err = taskErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return assetWitnesses, nil
+}
+
+// constructAccountAssetWitness gets the asset proofs of the account and updates its asset
+// tree, the remaining slots are padded with the empty asset.
+func (w *WitnessHelper) constructAccountAssetWitness(
+	accountKey int64,
+	proverAccountInfo *AccountWitnessInfo,
+) (*accountAssetWitness, error) {
+	var (
+		assetTree    = w.assetTrees.Get(accountKey)
+		assetWitness = &accountAssetWitness{assetRootBefore: assetTree.Root()}
+		assetCount   = 0
+	)
+	if proverAccountInfo != nil {
+		for i, accountAsset := range proverAccountInfo.AccountAssets {
+			assetMerkleProof, err := assetTree.GetProof(uint64(accountAsset.AssetId))
+			if err != nil {
+				return nil, err
+			}
+			// set crypto account asset
+			assetWitness.assetsInfo[assetCount] = &cryptoTypes.AccountAsset{
+				AssetId:                  accountAsset.AssetId,
+				Balance:                  accountAsset.Balance,
+				OfferCanceledOrFinalized: accountAsset.OfferCanceledOrFinalized,
+			}
+			// set merkle proof
+			assetWitness.merkleProofs[assetCount], err = SetFixedAccountAssetArray(assetMerkleProof)
+			if err != nil {
+				return nil, err
+			}
+			// update asset merkle tree
+			nBalance, err := chain.ComputeNewBalance(
+				proverAccountInfo.AssetsRelatedTxDetails[i].AssetType,
+				proverAccountInfo.AssetsRelatedTxDetails[i].Balance,
+				proverAccountInfo.AssetsRelatedTxDetails[i].BalanceDelta,
+			)
+			if err != nil {
+				return nil, err
+			}
+			nAsset, err := types.ParseAccountAsset(nBalance)
+			if err != nil {
+				return nil, err
+			}
+			nAssetHash, err := tree.ComputeAccountAssetLeafHash(nAsset.Balance.String(), nAsset.OfferCanceledOrFinalized.String())
+			if err != nil {
+				return nil, err
+			}
+			err = assetTree.Set(uint64(accountAsset.AssetId), nAssetHash)
+			if err != nil {
+				return nil, err
+			}
+			assetWitness.newAssets = append(assetWitness.newAssets, nAsset)
+			assetCount++
+		}
+	}
+	// padding empty account asset
+	for assetCount < NbAccountAssetsPerAccount {
+		assetWitness.assetsInfo[assetCount] = cryptoTypes.EmptyAccountAsset(LastAccountAssetId)
+		assetMerkleProof, err := assetTree.GetProof(LastAccountAssetId)
+		if err != nil {
+			return nil, err
+		}
+		assetWitness.merkleProofs[assetCount], err = SetFixedAccountAssetArray(assetMerkleProof)
+		if err != nil {
+			return nil, err
+		}
+		assetCount++
+	}
+	assetWitness.assetRootAfter = assetTree.Root()
+	return assetWitness, nil
+}
+
 func (w *WitnessHelper) constructNftWitness(
 	proverNftInfo *NftWitnessInfo,
 ) (
@@ -28,7 +28,6 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
-	"github.com/bnb-chain/zkbnb-crypto/circuit"
 	"github.com/bnb-chain/zkbnb/dao/account"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/blockwitness"
@@ -57,8 +56,7 @@ func TestConstructWitness(t *testing.T) {
 		assert.NoError(t, err)
 		w, err := witnessModel.GetBlockWitnessByHeight(h)
 		assert.NoError(t, err)
-		var cBlock circuit.Block
-		err = json.Unmarshal([]byte(w.WitnessData), &cBlock)
+		cBlock, err := DecodeBlockWitness(w)
 		assert.NoError(t, err)
 		err = witnessHelper.ResetCache(h)
 		assert.NoError(t, err)
@@ -78,7 +76,7 @@ func TestConstructWitness(t *testing.T) {
 }
 
 func getWitnessHelper(blockHeight int64) (*WitnessHelper, error) {
-	ctx, err := tree.NewContext("witness", tree.MemoryDB, false, 128, nil, nil)
+	ctx, err := tree.NewContext("witness", tree.MemoryDB, false, 128, nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
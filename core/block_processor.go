@@ -66,6 +66,55 @@ func (p *CommitProcessor) Process(tx *tx.Tx) error {
 	return nil
 }
 
+// PubDataProcessor executes the txs rebuilt from the pub data committed to L1. The
+// signatures, nonces and expiry of the layer2 txs aren't in the pub data, so the inputs
+// aren't verified, the state root of the block is checked against L1 instead.
+type PubDataProcessor struct {
+	bc *BlockChain
+}
+
+func NewPubDataProcessor(bc *BlockChain) Processor {
+	return &PubDataProcessor{
+		bc: bc,
+	}
+}
+
+func (p *PubDataProcessor) Process(tx *tx.Tx) error {
+	p.bc.setCurrentBlockTimeStamp()
+	defer p.bc.resetCurrentBlockTimeStamp()
+
+	executor, err := executor.NewTxExecutor(p.bc, tx)
+	if err != nil {
+		return fmt.Errorf("new tx executor failed")
+	}
+
+	err = executor.Prepare()
+	if err != nil {
+		return err
+	}
+	txDetails, err := executor.GenerateTxDetails()
+	if err != nil {
+		return err
+	}
+	tx.TxDetails = txDetails
+	err = executor.ApplyTransaction()
+	if err != nil {
+		panic(err)
+	}
+	err = executor.GeneratePubData()
+	if err != nil {
+		panic(err)
+	}
+	tx, err = executor.GetExecutedTx()
+	if err != nil {
+		panic(err)
+	}
+
+	p.bc.Statedb.Txs = append(p.bc.Statedb.Txs, tx)
+
+	return nil
+}
+
 type APIProcessor struct {
 	bc *BlockChain
 }
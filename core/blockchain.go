@@ -57,10 +57,18 @@ type ChainConfig struct {
 		//nolint:staticcheck
 		LevelDBOption tree.LevelDBOption `json:",optional"`
 		//nolint:staticcheck
+		PebbleDBOption tree.PebbleDBOption `json:",optional"`
+		//nolint:staticcheck
 		RedisDBOption tree.RedisDBOption `json:",optional"`
 		//nolint:staticcheck
+		Retention tree.RetentionOption `json:",optional"`
+		//nolint:staticcheck
 		RoutinePoolSize    int `json:",optional"`
 		AssetTreeCacheSize int
+		// Number of recent blocks whose most active accounts have their asset trees
+		// preloaded at startup, 0 disables the warm-up.
+		//nolint:staticcheck
+		AssetTreeWarmUpBlocks int64 `json:",optional"`
 	}
 }
 
@@ -100,16 +108,21 @@ func NewBlockChain(config *ChainConfig, moduleName string) (*BlockChain, error)
 		curHeight--
 	}
 	redisCache := dbcache.NewRedisCache(config.CacheRedis[0].Host, config.CacheRedis[0].Pass, 15*time.Minute)
-	treeCtx, err := tree.NewContext(moduleName, config.TreeDB.Driver, false, config.TreeDB.RoutinePoolSize, &config.TreeDB.LevelDBOption, &config.TreeDB.RedisDBOption)
+	treeCtx, err := tree.NewContext(moduleName, config.TreeDB.Driver, false, config.TreeDB.RoutinePoolSize, &config.TreeDB.LevelDBOption, &config.TreeDB.PebbleDBOption, &config.TreeDB.RedisDBOption)
 	if err != nil {
 		return nil, err
 	}
 
 	treeCtx.SetOptions(bsmt.BatchSizeLimit(3 * 1024 * 1024))
+	treeCtx.SetRetention(config.TreeDB.Retention)
 	bc.Statedb, err = sdb.NewStateDB(treeCtx, bc.ChainDB, redisCache, &config.CacheConfig, config.TreeDB.AssetTreeCacheSize, bc.currentBlock.StateRoot, curHeight)
 	if err != nil {
 		return nil, err
 	}
+	treeCtx.StartPruner(bc.Statedb.AccountAssetTrees.GetNextAccountIndex)
+	if config.TreeDB.AssetTreeWarmUpBlocks > 0 {
+		bc.warmUpAssetTrees(curHeight, config.TreeDB.AssetTreeWarmUpBlocks, config.TreeDB.AssetTreeCacheSize)
+	}
 	bc.processor = NewCommitProcessor(bc)
 
 	// register metrics
@@ -123,6 +136,25 @@ func NewBlockChain(config *ChainConfig, moduleName string) (*BlockChain, error)
 	return bc, nil
 }
 
+// NewBlockChainForReplay creates the blockchain on top of the given chain db and tree context,
+// the chain starts from curBlock, it is used by the tools replaying the stored blocks.
+func NewBlockChainForReplay(chainDb *sdb.ChainDB, treeCtx *tree.Context, redisCache dbcache.Cache,
+	assetTreeCacheSize int, curBlock *block.Block) (*BlockChain, error) {
+	bc := &BlockChain{
+		ChainDB:      chainDb,
+		currentBlock: curBlock,
+	}
+	cacheConfig := statedb.DefaultCacheConfig
+	var err error
+	bc.Statedb, err = sdb.NewStateDB(treeCtx, chainDb, redisCache, &cacheConfig, assetTreeCacheSize,
+		curBlock.StateRoot, curBlock.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	bc.processor = NewCommitProcessor(bc)
+	return bc, nil
+}
+
 // NewBlockChainForDryRun - for dry run mode, we can reuse existing models for quick creation
 // , e.g., for sending tx, we can create blockchain for each request quickly
 func NewBlockChainForDryRun(accountModel account.AccountModel,
@@ -152,6 +184,12 @@ func (bc *BlockChain) ApplyTransaction(tx *tx.Tx) error {
 	return bc.processor.Process(tx)
 }
 
+// ApplyTransactionFromPubData applies a tx rebuilt from the pub data of a block committed
+// to L1, see PubDataProcessor.
+func (bc *BlockChain) ApplyTransactionFromPubData(tx *tx.Tx) error {
+	return NewPubDataProcessor(bc).Process(tx)
+}
+
 func (bc *BlockChain) InitNewBlock() (*block.Block, error) {
 	newBlock := &block.Block{
 		Model: gorm.Model{
@@ -174,15 +212,31 @@ func (bc *BlockChain) CurrentBlock() *block.Block {
 }
 
 func (bc *BlockChain) CommitNewBlock(blockSize int, createdAt int64) (*block.BlockStates, error) {
+	return bc.CommitCheckedBlock(blockSize, createdAt, nil)
+}
+
+// CommitCheckedBlock commits the new block as CommitNewBlock does, the packed block is
+// checked before the trees are committed, nothing is committed if the check fails.
+func (bc *BlockChain) CommitCheckedBlock(blockSize int, createdAt int64, check func(newBlock *block.Block) error) (*block.BlockStates, error) {
 	newBlock, compressedBlock, err := bc.commitNewBlock(blockSize, createdAt)
 	if err != nil {
 		return nil, err
 	}
+	if check != nil {
+		err = check(newBlock)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	currentHeight := bc.currentBlock.BlockHeight
 
 	start := time.Now()
-	err = tree.CommitTrees(uint64(currentHeight), bc.Statedb.AccountTree, bc.Statedb.AccountAssetTrees, bc.Statedb.NftTree)
+	prunedVersion, err := bc.Statedb.TreeCtx.PrunedVersion(currentHeight, bc.BlockModel.GetLatestVerifiedHeight, currentHeight)
+	if err != nil {
+		return nil, err
+	}
+	err = tree.CommitTrees(bc.Statedb.TreeCtx, prunedVersion, bc.Statedb.AccountTree, bc.Statedb.AccountAssetTrees, bc.Statedb.NftTree)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,173 @@
+package executor
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type pubDataChain struct {
+	statedb *sdb.StateDB
+}
+
+func (c *pubDataChain) VerifyExpiredAt(int64) error                       { return nil }
+func (c *pubDataChain) VerifyNonce(int64, int64) error                    { return nil }
+func (c *pubDataChain) VerifyGas(int64, int64, int, *big.Int, bool) error { return nil }
+func (c *pubDataChain) StateDB() *sdb.StateDB                             { return c.statedb }
+func (c *pubDataChain) DB() *sdb.ChainDB                                  { return nil }
+func (c *pubDataChain) CurrentBlock() *block.Block                        { return &block.Block{} }
+
+func generatePubData(t *testing.T, txType int64, txInfo interface{}) []byte {
+	txInfoBytes, err := json.Marshal(txInfo)
+	require.NoError(t, err)
+	bc := &pubDataChain{statedb: &sdb.StateDB{StateCache: sdb.NewStateCache("")}}
+	executor, err := NewTxExecutor(bc, &tx.Tx{TxType: txType, TxInfo: string(txInfoBytes)})
+	require.NoError(t, err)
+	require.NoError(t, executor.GeneratePubData())
+	require.Len(t, bc.statedb.PubData, chain.TxPubDataSize)
+	return bc.statedb.PubData
+}
+
+func TestParseTxPubData(t *testing.T) {
+	amount, _ := new(big.Int).SetString("123456789000000000000", 10)
+	fee := big.NewInt(1000000000000000)
+	callDataHash := common.FromHex("0x0f3b5d6c9b1e2f4a7c8d9e0f1a2b3c4d5e6f708192a3b4c5d6e7f80112233445")
+
+	transfer := &txtypes.TransferTxInfo{
+		FromAccountIndex:  3,
+		ToAccountIndex:    5,
+		AssetId:           1,
+		AssetAmount:       amount,
+		GasAccountIndex:   1,
+		GasFeeAssetId:     2,
+		GasFeeAssetAmount: fee,
+		CallDataHash:      callDataHash,
+	}
+	parsed, err := chain.ParseTxPubData(generatePubData(t, types.TxTypeTransfer, transfer))
+	require.NoError(t, err)
+	assert.Equal(t, transfer, parsed)
+
+	withdraw := &txtypes.WithdrawTxInfo{
+		FromAccountIndex:  3,
+		AssetId:           2,
+		AssetAmount:       big.NewInt(987654321),
+		GasAccountIndex:   1,
+		GasFeeAssetId:     0,
+		GasFeeAssetAmount: fee,
+		ToAddress:         "0x299c9B8DA3D1c8Bd53a5afc5be8f7D3a74e1aa01",
+	}
+	parsed, err = chain.ParseTxPubData(generatePubData(t, types.TxTypeWithdraw, withdraw))
+	require.NoError(t, err)
+	assert.Equal(t, withdraw, parsed)
+
+	mintNft := &txtypes.MintNftTxInfo{
+		CreatorAccountIndex: 3,
+		ToAccountIndex:      4,
+		NftIndex:            17,
+		NftContentHash:      common.Bytes2Hex(callDataHash),
+		NftCollectionId:     2,
+		CreatorTreasuryRate: 30,
+		GasAccountIndex:     1,
+		GasFeeAssetId:       0,
+		GasFeeAssetAmount:   fee,
+	}
+	parsed, err = chain.ParseTxPubData(generatePubData(t, types.TxTypeMintNft, mintNft))
+	require.NoError(t, err)
+	assert.Equal(t, mintNft, parsed)
+
+	atomicMatch := &txtypes.AtomicMatchTxInfo{
+		AccountIndex: 3,
+		BuyOffer: &txtypes.OfferTxInfo{
+			Type:         types.BuyOfferType,
+			OfferId:      130,
+			AccountIndex: 4,
+			NftIndex:     17,
+			AssetId:      0,
+			AssetAmount:  amount,
+			TreasuryRate: 200,
+		},
+		SellOffer: &txtypes.OfferTxInfo{
+			Type:         types.SellOfferType,
+			OfferId:      7,
+			AccountIndex: 5,
+			NftIndex:     17,
+			AssetId:      0,
+			AssetAmount:  amount,
+			TreasuryRate: 200,
+		},
+		GasAccountIndex:   1,
+		GasFeeAssetId:     0,
+		GasFeeAssetAmount: fee,
+		CreatorAmount:     new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(30)), big.NewInt(TenThousand)),
+		TreasuryAmount:    new(big.Int).Div(new(big.Int).Mul(amount, big.NewInt(200)), big.NewInt(TenThousand)),
+	}
+	parsed, err = chain.ParseTxPubData(generatePubData(t, types.TxTypeAtomicMatch, atomicMatch))
+	require.NoError(t, err)
+	assert.Equal(t, atomicMatch, parsed)
+
+	withdrawNft := &txtypes.WithdrawNftTxInfo{
+		AccountIndex:           4,
+		CreatorAccountIndex:    3,
+		CreatorAccountNameHash: callDataHash,
+		CreatorTreasuryRate:    30,
+		NftIndex:               17,
+		NftContentHash:         callDataHash,
+		NftL1Address:           "0x299c9B8DA3D1c8Bd53a5afc5be8f7D3a74e1aa01",
+		NftL1TokenId:           big.NewInt(42),
+		CollectionId:           2,
+		ToAddress:              "0x8b2C5A5744F42AA9269BaabDd05933a96D8EF911",
+		GasAccountIndex:        1,
+		GasFeeAssetId:          0,
+		GasFeeAssetAmount:      fee,
+	}
+	parsed, err = chain.ParseTxPubData(generatePubData(t, types.TxTypeWithdrawNft, withdrawNft))
+	require.NoError(t, err)
+	assert.Equal(t, withdrawNft, parsed)
+
+	depositNft := &txtypes.DepositNftTxInfo{
+		TxType:              types.TxTypeDepositNft,
+		AccountIndex:        4,
+		NftIndex:            17,
+		NftL1Address:        "0x299c9B8DA3D1c8Bd53a5afc5be8f7D3a74e1aa01",
+		CreatorAccountIndex: 3,
+		CreatorTreasuryRate: 30,
+		NftContentHash:      callDataHash,
+		NftL1TokenId:        big.NewInt(42),
+		AccountNameHash:     callDataHash,
+		CollectionId:        2,
+	}
+	parsed, err = chain.ParseTxPubData(generatePubData(t, types.TxTypeDepositNft, depositNft))
+	require.NoError(t, err)
+	assert.Equal(t, depositNft, parsed)
+}
+
+func TestParseBlockPubData(t *testing.T) {
+	cancelOffer := &txtypes.CancelOfferTxInfo{
+		AccountIndex:      3,
+		OfferId:           129,
+		GasAccountIndex:   1,
+		GasFeeAssetId:     0,
+		GasFeeAssetAmount: big.NewInt(1000000000000000),
+	}
+	pubData := generatePubData(t, types.TxTypeCancelOffer, cancelOffer)
+	// the block is padded with an empty tx
+	pubData = append(pubData, make([]byte, chain.TxPubDataSize)...)
+
+	txInfos, err := chain.ParseBlockPubData(pubData)
+	require.NoError(t, err)
+	assert.Equal(t, []txtypes.TxInfo{cancelOffer}, txInfos)
+
+	_, err = chain.ParseBlockPubData(pubData[1:])
+	assert.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/common/chain"
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+// DetachedBlock is an executed block whose state root is computed by FinishBlock, while
+// the txs of the next block are executed.
+type DetachedBlock struct {
+	// the block finished in the background, a copy of the current block of the chain
+	block    *block.Block
+	current  *block.Block
+	snapshot *sdb.StateSnapshot
+
+	prevStateRoot string
+	pubData       []byte
+	pubDataOffset []uint32
+}
+
+// Height returns the height of the detached block.
+func (d *DetachedBlock) Height() int64 {
+	return d.block.BlockHeight
+}
+
+// DetachBlock packs the executed txs into a new block without computing its state root,
+// and detaches the states of the block from the StateDB, so that the txs of the next
+// block can be executed on top of them. Blocks are detached one at a time: the detached
+// block must be finished, persisted and released before the next one is detached.
+func (bc *BlockChain) DetachBlock(blockSize int, createdAt int64) (*DetachedBlock, error) {
+	s := bc.Statedb
+	if blockSize < len(s.Txs) {
+		return nil, errors.New("block size too small")
+	}
+
+	newBlock := bc.currentBlock
+	if newBlock.BlockStatus != block.StatusProposing {
+		newBlock = &block.Block{
+			Model: gorm.Model{
+				CreatedAt: time.UnixMilli(createdAt),
+			},
+			BlockHeight: bc.currentBlock.BlockHeight + 1,
+			StateRoot:   bc.currentBlock.StateRoot,
+			BlockStatus: block.StatusProposing,
+		}
+	}
+
+	// Align pub data.
+	s.AlignPubData(blockSize)
+
+	newBlock.BlockSize = uint16(blockSize)
+	newBlock.PriorityOperations = s.PriorityOperations
+	newBlock.PendingOnChainOperationsHash = common.Bytes2Hex(s.PendingOnChainOperationsHash)
+	newBlock.Txs = s.Txs
+	for _, executedTx := range newBlock.Txs {
+		executedTx.TxStatus = tx.StatusPacked
+	}
+	newBlock.BlockStatus = block.StatusPending
+	if len(s.PendingOnChainOperationsPubData) > 0 {
+		onChainOperationsPubDataBytes, err := json.Marshal(s.PendingOnChainOperationsPubData)
+		if err != nil {
+			return nil, fmt.Errorf("marshal pending onChain operation pubData failed: %v", err)
+		}
+		newBlock.PendingOnChainOperationsPubData = string(onChainOperationsPubDataBytes)
+	}
+
+	detached := &DetachedBlock{
+		prevStateRoot: newBlock.StateRoot,
+		pubData:       s.PubData,
+		pubDataOffset: s.PubDataOffset,
+	}
+	snapshot, err := s.Detach()
+	if err != nil {
+		return nil, err
+	}
+	// The state root is unknown until the block is finished. Until ReleaseBlock sets it,
+	// the block kept by the chain and the next block initialized on top of it hold an
+	// empty root, InitNewBlock purges the cache with it as well. Nothing reads the root in
+	// between: the next block is only detached once this one is released.
+	newBlock.StateRoot = ""
+	finishing := *newBlock
+	detached.block = &finishing
+	detached.current = newBlock
+	detached.snapshot = snapshot
+	bc.currentBlock = newBlock
+	return detached, nil
+}
+
+// FinishBlock computes the state root of the detached block, commits the trees, and
+// returns the states of the block to persist. It runs in the background, the detached
+// blocks must be finished in order.
+func (bc *BlockChain) FinishBlock(d *DetachedBlock) (*block.BlockStates, error) {
+	s := bc.Statedb
+	newBlock := d.block
+	if d.prevStateRoot == "" {
+		return nil, fmt.Errorf("state root of block %d is not released", newBlock.BlockHeight-1)
+	}
+
+	start := time.Now()
+	stateRoot, err := s.SnapshotRoot(d.snapshot)
+	if err != nil {
+		return nil, err
+	}
+	updateTreeMetics.Set(float64(time.Since(start).Milliseconds()))
+
+	newBlock.StateRoot = stateRoot
+	newBlock.BlockCommitment = chain.CreateBlockCommitment(newBlock.BlockHeight, newBlock.CreatedAt.UnixMilli(),
+		common.FromHex(d.prevStateRoot), common.FromHex(stateRoot),
+		d.pubData, int64(len(d.pubDataOffset)))
+
+	offsetBytes, err := json.Marshal(d.pubDataOffset)
+	if err != nil {
+		return nil, fmt.Errorf("marshal pubData offset failed: %v", err)
+	}
+	compressedBlock := &compressedblock.CompressedBlock{
+		BlockSize:         newBlock.BlockSize,
+		BlockHeight:       newBlock.BlockHeight,
+		StateRoot:         newBlock.StateRoot,
+		PublicData:        common.Bytes2Hex(d.pubData),
+		Timestamp:         newBlock.CreatedAt.UnixMilli(),
+		PublicDataOffsets: string(offsetBytes),
+	}
+
+	start = time.Now()
+	prunedVersion, err := s.TreeCtx.PrunedVersion(newBlock.BlockHeight, bc.BlockModel.GetLatestVerifiedHeight, newBlock.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	err = tree.CommitTrees(s.TreeCtx, prunedVersion, s.AccountTree, s.AccountAssetTrees, s.NftTree)
+	if err != nil {
+		return nil, err
+	}
+	commitTreeMetics.Set(float64(time.Since(start).Milliseconds()))
+
+	pendingAccount, pendingAccountHistory, pendingNft, pendingNftHistory, err := s.SnapshotPendingStates(d.snapshot, newBlock.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	return &block.BlockStates{
+		Block:                 newBlock,
+		CompressedBlock:       compressedBlock,
+		PendingAccount:        pendingAccount,
+		PendingAccountHistory: pendingAccountHistory,
+		PendingNft:            pendingNft,
+		PendingNftHistory:     pendingNftHistory,
+	}, nil
+}
+
+// ReleaseBlock drops the detached states once the finished block is persisted, and sets
+// the state root of the block to the blocks kept by the chain. The current block of the
+// chain is either the detached block or the next block proposed on top of it, it must
+// hold the finished root once the block is released.
+func (bc *BlockChain) ReleaseBlock(d *DetachedBlock) error {
+	if d.block.StateRoot == "" {
+		return fmt.Errorf("block %d is released before it is finished", d.Height())
+	}
+	d.current.StateRoot = d.block.StateRoot
+	d.current.BlockCommitment = d.block.BlockCommitment
+	if bc.currentBlock != d.current && bc.currentBlock.BlockHeight == d.current.BlockHeight+1 {
+		// the next block is proposed on top of the detached one
+		bc.currentBlock.StateRoot = d.block.StateRoot
+	}
+	if bc.currentBlock.StateRoot != d.block.StateRoot {
+		return fmt.Errorf("state root of block %d is not restored, current block: %d", d.Height(), bc.currentBlock.BlockHeight)
+	}
+	bc.Statedb.StateRoot = d.block.StateRoot
+	bc.Statedb.ReleaseSnapshot(d.snapshot)
+	return nil
+}
@@ -0,0 +1,312 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const testBlocks = 4
+
+type mockBlockModel struct {
+	block.BlockModel
+}
+
+func (m *mockBlockModel) GetLatestVerifiedHeight() (int64, error) {
+	return 0, nil
+}
+
+type mockAccountModel struct {
+	account.AccountModel
+}
+
+func (m *mockAccountModel) GetAccountByIndex(_ int64) (*account.Account, error) {
+	return nil, types.DbErrNotFound
+}
+
+type mockAccountHistoryModel struct {
+	account.AccountHistoryModel
+}
+
+func (m *mockAccountHistoryModel) GetValidAccountCount(_ int64) (int64, error) {
+	return 0, nil
+}
+
+type mockNftModel struct {
+	nft.L2NftModel
+}
+
+func (m *mockNftModel) GetNft(_ int64) (*nft.L2Nft, error) {
+	return nil, types.DbErrNotFound
+}
+
+type mockNftHistoryModel struct {
+	nft.L2NftHistoryModel
+}
+
+func (m *mockNftHistoryModel) GetLatestNftsCountByBlockHeight(_ int64) (int64, error) {
+	return 0, nil
+}
+
+// newTestChain creates an empty chain whose states are only kept by the StateDB and the trees.
+func newTestChain(t *testing.T) *BlockChain {
+	treeCtx, err := tree.NewContext("committer", tree.LevelDB, true, 0,
+		&tree.LevelDBOption{File: t.TempDir(), Cache: 16, Handles: 16}, nil, nil)
+	require.NoError(t, err)
+	chainDb := &sdb.ChainDB{
+		BlockModel:          &mockBlockModel{},
+		AccountModel:        &mockAccountModel{},
+		AccountHistoryModel: &mockAccountHistoryModel{},
+		L2NftModel:          &mockNftModel{},
+		L2NftHistoryModel:   &mockNftHistoryModel{},
+	}
+	bc, err := NewBlockChainForReplay(chainDb, treeCtx, dbcache.NewMemoryCache(), 16,
+		&block.Block{BlockStatus: block.StatusVerifiedAndExecuted})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = treeCtx.TreeDB.Close()
+	})
+
+	stateRoot := common.Bytes2Hex(tree.ComputeStateRootHash(bc.Statedb.AccountTree.Root(), bc.Statedb.NftTree.Root()))
+	bc.currentBlock.StateRoot = stateRoot
+	bc.Statedb.StateRoot = stateRoot
+	return bc
+}
+
+func newTestAccount(accountIndex int64) *types.AccountInfo {
+	return &types.AccountInfo{
+		AccountIndex:    accountIndex,
+		PublicKey:       fmt.Sprintf("%064x", accountIndex+1),
+		AccountNameHash: fmt.Sprintf("%064x", accountIndex+100),
+		AssetInfo: map[int64]*types.AccountAsset{
+			0: {AssetId: 0, Balance: big.NewInt(0), OfferCanceledOrFinalized: big.NewInt(0)},
+		},
+	}
+}
+
+// executeTestBlock applies the changes of the block at the height the way the executors do:
+// the gas account collects the gas of the block, account 2 is updated by every block, a new
+// account is created, and the nfts 0 and 1 are minted and transferred in turn.
+func executeTestBlock(t *testing.T, bc *BlockChain, height int64) {
+	s := bc.Statedb
+	bc.currentBlock.CreatedAt = time.UnixMilli(1660000000000 + height*1000)
+
+	if height == 1 {
+		s.SetPendingAccount(types.GasAccount, newTestAccount(types.GasAccount))
+	}
+	s.SetPendingGas(0, big.NewInt(height))
+	s.MarkAccountAssetsDirty(types.GasAccount, []int64{0})
+
+	for _, accountIndex := range []int64{2, height + 2} {
+		accountInfo, err := s.GetFormatAccount(accountIndex)
+		if err == types.AppErrAccountNotFound {
+			accountInfo = newTestAccount(accountIndex)
+		} else {
+			require.NoError(t, err)
+		}
+		accountInfo.AssetInfo[0].Balance = new(big.Int).Add(accountInfo.AssetInfo[0].Balance, big.NewInt(height))
+		accountInfo.Nonce++
+		s.SetPendingAccount(accountIndex, accountInfo)
+		s.MarkAccountAssetsDirty(accountIndex, []int64{0})
+	}
+
+	nftIndex := height % 2
+	nftInfo, err := s.GetNft(nftIndex)
+	if err == types.AppErrNftNotFound {
+		nftInfo = &nft.L2Nft{
+			NftIndex:            nftIndex,
+			CreatorAccountIndex: 2,
+			NftContentHash:      fmt.Sprintf("%064x", nftIndex+1),
+			NftL1Address:        common.Address{}.Hex(),
+			NftL1TokenId:        "0",
+			CreatorTreasuryRate: 10,
+		}
+	} else {
+		require.NoError(t, err)
+	}
+	nftInfo.OwnerAccountIndex = height + 2
+	s.SetPendingNft(nftIndex, nftInfo)
+	s.MarkNftDirty(nftIndex)
+
+	require.NoError(t, s.SyncStateCacheToRedis())
+}
+
+// commitSerial commits the blocks one after another, as the committer does without the pipeline.
+func commitSerial(t *testing.T, bc *BlockChain) []*block.BlockStates {
+	states := make([]*block.BlockStates, 0, testBlocks)
+	for height := int64(1); height <= testBlocks; height++ {
+		_, err := bc.InitNewBlock()
+		require.NoError(t, err)
+		executeTestBlock(t, bc, height)
+		blockStates, err := bc.CommitNewBlock(1, bc.currentBlock.CreatedAt.UnixMilli())
+		require.NoError(t, err)
+		// the pending nfts are the cached ones updated in place by the next blocks, the
+		// committer persists them before executing the next block
+		for i, pendingNft := range blockStates.PendingNft {
+			copied := *pendingNft
+			blockStates.PendingNft[i] = &copied
+		}
+		states = append(states, blockStates)
+	}
+	return states
+}
+
+type finishResult struct {
+	states *block.BlockStates
+	err    error
+}
+
+// commitPipelined executes each block while the previous one is finished, as the committer
+// does with the pipeline. If finishLate is set, a block is only finished once the next block
+// is executed, otherwise it is finished in the background as soon as it is detached.
+func commitPipelined(t *testing.T, bc *BlockChain, finishLate bool) []*block.BlockStates {
+	states := make([]*block.BlockStates, 0, testBlocks)
+	var (
+		inflight *DetachedBlock
+		done     chan finishResult
+	)
+	wait := func() {
+		var result finishResult
+		if finishLate {
+			result.states, result.err = bc.FinishBlock(inflight)
+		} else {
+			result = <-done
+		}
+		require.NoError(t, result.err)
+		states = append(states, result.states)
+		require.NoError(t, bc.ReleaseBlock(inflight))
+		inflight = nil
+	}
+
+	_, err := bc.InitNewBlock()
+	require.NoError(t, err)
+	for height := int64(1); height <= testBlocks; height++ {
+		executeTestBlock(t, bc, height)
+		if inflight != nil {
+			wait()
+		}
+		inflight, err = bc.DetachBlock(1, bc.currentBlock.CreatedAt.UnixMilli())
+		require.NoError(t, err)
+		if !finishLate {
+			done = make(chan finishResult, 1)
+			go func(d *DetachedBlock) {
+				blockStates, err := bc.FinishBlock(d)
+				done <- finishResult{states: blockStates, err: err}
+			}(inflight)
+		}
+		_, err = bc.InitNewBlock()
+		require.NoError(t, err)
+	}
+	wait()
+	return states
+}
+
+func sortStates(states *block.BlockStates) {
+	sort.Slice(states.PendingAccount, func(i, j int) bool {
+		return states.PendingAccount[i].AccountIndex < states.PendingAccount[j].AccountIndex
+	})
+	sort.Slice(states.PendingAccountHistory, func(i, j int) bool {
+		return states.PendingAccountHistory[i].AccountIndex < states.PendingAccountHistory[j].AccountIndex
+	})
+	sort.Slice(states.PendingNft, func(i, j int) bool {
+		return states.PendingNft[i].NftIndex < states.PendingNft[j].NftIndex
+	})
+	sort.Slice(states.PendingNftHistory, func(i, j int) bool {
+		return states.PendingNftHistory[i].NftIndex < states.PendingNftHistory[j].NftIndex
+	})
+}
+
+func assertSameBlocks(t *testing.T, expected, actual []*block.BlockStates) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		sortStates(expected[i])
+		sortStates(actual[i])
+		assert.Equal(t, expected[i].Block.BlockHeight, actual[i].Block.BlockHeight)
+		assert.Equal(t, expected[i].Block.StateRoot, actual[i].Block.StateRoot)
+		assert.Equal(t, expected[i].Block.BlockCommitment, actual[i].Block.BlockCommitment)
+		assert.Equal(t, expected[i].CompressedBlock, actual[i].CompressedBlock)
+		assert.Equal(t, expected[i].PendingAccount, actual[i].PendingAccount)
+		assert.Equal(t, expected[i].PendingAccountHistory, actual[i].PendingAccountHistory)
+		assert.Equal(t, expected[i].PendingNft, actual[i].PendingNft)
+		assert.Equal(t, expected[i].PendingNftHistory, actual[i].PendingNftHistory)
+	}
+}
+
+func TestPipelinedBlocksEqualSerial(t *testing.T) {
+	serial := commitSerial(t, newTestChain(t))
+	for i := 1; i < len(serial); i++ {
+		assert.NotEqual(t, serial[i-1].Block.StateRoot, serial[i].Block.StateRoot)
+	}
+
+	pipelined := newTestChain(t)
+	assertSameBlocks(t, serial, commitPipelined(t, pipelined, false))
+	assert.Equal(t, serial[len(serial)-1].Block.StateRoot, pipelined.Statedb.StateRoot)
+}
+
+func TestPipelinedBlocksFinishedLate(t *testing.T) {
+	serial := commitSerial(t, newTestChain(t))
+
+	// the next block is executed on top of the detached states before they are finished
+	assertSameBlocks(t, serial, commitPipelined(t, newTestChain(t), true))
+}
+
+func TestDetachBlockOneAtATime(t *testing.T) {
+	bc := newTestChain(t)
+	_, err := bc.InitNewBlock()
+	require.NoError(t, err)
+	executeTestBlock(t, bc, 1)
+	detached, err := bc.DetachBlock(1, bc.currentBlock.CreatedAt.UnixMilli())
+	require.NoError(t, err)
+	_, err = bc.InitNewBlock()
+	require.NoError(t, err)
+	executeTestBlock(t, bc, 2)
+	// the root of the next block is only known once the detached block is finished
+	assert.Empty(t, bc.CurrentBlock().StateRoot)
+
+	_, err = bc.Statedb.Detach()
+	assert.EqualError(t, err, "a snapshot is already detached")
+	assert.EqualError(t, bc.ReleaseBlock(detached), "block 1 is released before it is finished")
+
+	blockStates, err := bc.FinishBlock(detached)
+	require.NoError(t, err)
+	require.NoError(t, bc.ReleaseBlock(detached))
+	assert.Equal(t, blockStates.Block.StateRoot, bc.CurrentBlock().StateRoot)
+	assert.Equal(t, int64(2), bc.CurrentBlock().BlockHeight)
+	_, err = bc.Statedb.Detach()
+	assert.NoError(t, err)
+}
+
+func TestCommitCheckedBlock(t *testing.T) {
+	bc := newTestChain(t)
+	_, err := bc.InitNewBlock()
+	require.NoError(t, err)
+	executeTestBlock(t, bc, 1)
+	accountVersion, nftVersion := bc.Statedb.AccountTree.LatestVersion(), bc.Statedb.NftTree.LatestVersion()
+
+	// the trees aren't committed if the packed block is rejected
+	var checked *block.Block
+	_, err = bc.CommitCheckedBlock(1, bc.currentBlock.CreatedAt.UnixMilli(), func(newBlock *block.Block) error {
+		checked = newBlock
+		return errors.New("block not matched")
+	})
+	assert.EqualError(t, err, "block not matched")
+	require.NotNil(t, checked)
+	assert.Equal(t, int64(1), checked.BlockHeight)
+	assert.Equal(t, accountVersion, bc.Statedb.AccountTree.LatestVersion())
+	assert.Equal(t, nftVersion, bc.Statedb.NftTree.LatestVersion())
+}
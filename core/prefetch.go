@@ -0,0 +1,70 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/tx"
+)
+
+// warmUpAssetTrees preloads the asset trees of the accounts sending the most txs in the
+// recent blocks, so that the first blocks after a restart don't open them one by one.
+func (bc *BlockChain) warmUpAssetTrees(curHeight int64, blocks int64, limit int) {
+	accountIndexes, err := bc.TxModel.GetMostActiveAccounts(curHeight-blocks, int64(limit))
+	if err != nil {
+		logx.Errorf("get most active accounts failed: %v", err)
+		return
+	}
+	prefetched := bc.Statedb.AccountAssetTrees.Prefetch(accountIndexes)
+	logx.Infof("warmed up %d asset trees from the txs of the last %d blocks", prefetched, blocks)
+}
+
+// PrefetchAssetTrees preloads the asset trees of the accounts referenced by the txs, and
+// returns the number of trees loaded.
+func (bc *BlockChain) PrefetchAssetTrees(txs []*tx.Tx) int {
+	seen := make(map[int64]bool)
+	accountIndexes := make([]int64, 0, len(txs))
+	for _, poolTx := range txs {
+		indexes := append([]int64{poolTx.AccountIndex}, TxAccountIndexes(poolTx.TxInfo)...)
+		for _, index := range indexes {
+			if index >= 0 && !seen[index] {
+				seen[index] = true
+				accountIndexes = append(accountIndexes, index)
+			}
+		}
+	}
+	return bc.Statedb.AccountAssetTrees.Prefetch(accountIndexes)
+}
+
+// TxAccountIndexes returns the values of all the account index fields of a tx info, nil if
+// the tx info can't be decoded.
+func TxAccountIndexes(txInfo string) []int64 {
+	var info interface{}
+	if err := json.Unmarshal([]byte(txInfo), &info); err != nil {
+		return nil
+	}
+	return txInfoAccountIndexes(info)
+}
+
+// txInfoAccountIndexes collects the values of all the account index fields of a tx info,
+// such as FromAccountIndex, ToAccountIndex and GasAccountIndex, including the nested offers.
+func txInfoAccountIndexes(txInfo interface{}) []int64 {
+	var indexes []int64
+	switch v := txInfo.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if index, ok := value.(float64); ok && strings.HasSuffix(key, "AccountIndex") {
+				indexes = append(indexes, int64(index))
+				continue
+			}
+			indexes = append(indexes, txInfoAccountIndexes(value)...)
+		}
+	case []interface{}:
+		for _, value := range v {
+			indexes = append(indexes, txInfoAccountIndexes(value)...)
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,164 @@
+package statedb
+
+import (
+	"errors"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// committingLayer keeps the states of the block committed in the background, so that they
+// are read by the next block until they are persisted. It is only accessed by the
+// goroutine executing the txs.
+type committingLayer struct {
+	accounts map[int64]*types.AccountInfo
+	nfts     map[int64]*nft.L2Nft
+}
+
+// StateSnapshot is the states of an executed block detached from the StateDB. The states
+// are copies, so that the block can be finished while the next block is executed.
+type StateSnapshot struct {
+	cache *StateCache
+	// copies of the dirty and pending accounts and nfts
+	accounts map[int64]*types.AccountInfo
+	nfts     map[int64]*nft.L2Nft
+}
+
+func (s *StateDB) getCommittingAccount(accountIndex int64) (*types.AccountInfo, bool) {
+	if s.committing == nil {
+		return nil, false
+	}
+	account, exist := s.committing.accounts[accountIndex]
+	return account, exist
+}
+
+func (s *StateDB) getCommittingNft(nftIndex int64) (*nft.L2Nft, bool) {
+	if s.committing == nil {
+		return nil, false
+	}
+	nft, exist := s.committing.nfts[nftIndex]
+	return nft, exist
+}
+
+// Detach detaches the states of the executed block from the StateDB and returns a
+// snapshot of them. The pending states are kept as a layer read by the next block until
+// ReleaseSnapshot is called. Only one snapshot can be detached at a time.
+func (s *StateDB) Detach() (*StateSnapshot, error) {
+	if s.committing != nil {
+		return nil, errors.New("a snapshot is already detached")
+	}
+	cache := s.StateCache
+	snapshot := &StateSnapshot{
+		cache:    cache,
+		accounts: make(map[int64]*types.AccountInfo, len(cache.dirtyAccountsAndAssetsMap)),
+		nfts:     make(map[int64]*nft.L2Nft, len(cache.dirtyNftMap)),
+	}
+
+	// The txs of the next block update the cached states in place, so the snapshot
+	// takes copies of all the states read when the block is finished.
+	for accountIndex := range cache.dirtyAccountsAndAssetsMap {
+		account, err := s.GetFormatAccount(accountIndex)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.accounts[accountIndex] = account.DeepCopy()
+	}
+	for nftIndex := range cache.dirtyNftMap {
+		nft, err := s.GetNft(nftIndex)
+		if err != nil {
+			return nil, err
+		}
+		copied := *nft
+		snapshot.nfts[nftIndex] = &copied
+	}
+	pendingAccounts := make(map[int64]*types.AccountInfo, len(cache.PendingAccountMap))
+	for accountIndex, account := range cache.PendingAccountMap {
+		copied, exist := snapshot.accounts[accountIndex]
+		if !exist {
+			copied = account.DeepCopy()
+			snapshot.accounts[accountIndex] = copied
+		}
+		pendingAccounts[accountIndex] = copied
+	}
+	pendingNfts := make(map[int64]*nft.L2Nft, len(cache.PendingNftMap))
+	for nftIndex, pending := range cache.PendingNftMap {
+		copied, exist := snapshot.nfts[nftIndex]
+		if !exist {
+			n := *pending
+			copied = &n
+			snapshot.nfts[nftIndex] = copied
+		}
+		pendingNfts[nftIndex] = copied
+	}
+
+	// The gas of the block is applied to the gas account read by the next block,
+	// the snapshot applies it to its own copy when the block is finished.
+	if gasAccount, exist := cache.PendingAccountMap[types.GasAccount]; exist {
+		applyGasUpdate(gasAccount, cache.PendingGasMap)
+	}
+
+	s.committing = &committingLayer{
+		accounts: cache.PendingAccountMap,
+		nfts:     cache.PendingNftMap,
+	}
+	cache.PendingAccountMap = pendingAccounts
+	cache.PendingNftMap = pendingNfts
+	s.StateCache = NewStateCache(cache.StateRoot)
+	return snapshot, nil
+}
+
+// SnapshotRoot updates the trees with the states of the snapshot and returns the new
+// state root. Snapshots must be applied to the trees in the order they are detached.
+func (s *StateDB) SnapshotRoot(snapshot *StateSnapshot) (string, error) {
+	stateRoot, err := s.intermediateRoot(snapshot.cache, snapshot.getAccount, snapshot.getNft)
+	if err != nil {
+		return "", err
+	}
+	snapshot.cache.StateRoot = stateRoot
+	return stateRoot, nil
+}
+
+// SnapshotPendingStates returns the accounts and nfts of the snapshot to persist.
+func (s *StateDB) SnapshotPendingStates(snapshot *StateSnapshot, blockHeight int64) (
+	[]*account.Account, []*account.AccountHistory, []*nft.L2Nft, []*nft.L2NftHistory, error) {
+	pendingAccount, pendingAccountHistory, err := getPendingAccount(snapshot.cache, blockHeight)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	pendingNft, pendingNftHistory, err := getPendingNft(snapshot.cache, blockHeight)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return pendingAccount, pendingAccountHistory, pendingNft, pendingNftHistory, nil
+}
+
+// ReleaseSnapshot drops the layer of the snapshot once its states are persisted, and
+// updates the cached accounts with the asset roots computed for the snapshot.
+func (s *StateDB) ReleaseSnapshot(snapshot *StateSnapshot) {
+	for accountIndex, copied := range snapshot.accounts {
+		if cached, exist := s.AccountCache.Peek(accountIndex); exist {
+			cached.(*types.AccountInfo).AssetRoot = copied.AssetRoot
+		}
+		if pending, exist := s.PendingAccountMap[accountIndex]; exist {
+			pending.AssetRoot = copied.AssetRoot
+		}
+	}
+	s.committing = nil
+}
+
+func (s *StateSnapshot) getAccount(accountIndex int64) (*types.AccountInfo, error) {
+	account, exist := s.accounts[accountIndex]
+	if !exist {
+		return nil, types.AppErrAccountNotFound
+	}
+	return account, nil
+}
+
+func (s *StateSnapshot) getNft(nftIndex int64) (*nft.L2Nft, error) {
+	nft, exist := s.nfts[nftIndex]
+	if !exist {
+		return nil, types.AppErrNftNotFound
+	}
+	return nft, nil
+}
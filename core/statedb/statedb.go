@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
@@ -63,6 +64,9 @@ type StateDB struct {
 	NftTree           bsmt.SparseMerkleTree
 	AccountAssetTrees *tree.AssetTreeCache
 	TreeCtx           *tree.Context
+
+	// States of the block committed in the background, until they are persisted.
+	committing *committingLayer
 }
 
 func NewStateDB(treeCtx *tree.Context, chainDb *ChainDB,
@@ -152,6 +156,12 @@ func (s *StateDB) GetFormatAccount(accountIndex int64) (*types.AccountInfo, erro
 		return cached.(*types.AccountInfo), nil
 	}
 
+	committing, exist := s.getCommittingAccount(accountIndex)
+	if exist {
+		s.AccountCache.Add(accountIndex, committing)
+		return committing, nil
+	}
+
 	account, err := s.chainDb.AccountModel.GetAccountByIndex(accountIndex)
 	if err == types.DbErrNotFound {
 		return nil, types.AppErrAccountNotFound
@@ -185,6 +195,12 @@ func (s *StateDB) GetAccount(accountIndex int64) (*account.Account, error) {
 		}
 	}
 
+	committing, exist := s.getCommittingAccount(accountIndex)
+	if exist {
+		s.AccountCache.Add(accountIndex, committing)
+		return chain.FromFormatAccountInfo(committing)
+	}
+
 	account, err := s.chainDb.AccountModel.GetAccountByIndex(accountIndex)
 	if err != nil {
 		return nil, err
@@ -212,6 +228,13 @@ func (s *StateDB) GetAccountByName(accountName string) (*account.Account, error)
 			return account, nil
 		}
 	}
+	if s.committing != nil {
+		for _, accountInfo := range s.committing.accounts {
+			if accountInfo.AccountName == accountName {
+				return chain.FromFormatAccountInfo(accountInfo)
+			}
+		}
+	}
 
 	account, err := s.chainDb.AccountModel.GetAccountByName(accountName)
 	if err != nil {
@@ -236,6 +259,13 @@ func (s *StateDB) GetAccountByNameHash(accountNameHash string) (*account.Account
 			return account, nil
 		}
 	}
+	if s.committing != nil {
+		for _, accountInfo := range s.committing.accounts {
+			if accountInfo.AccountNameHash == accountNameHash {
+				return chain.FromFormatAccountInfo(accountInfo)
+			}
+		}
+	}
 
 	account, err := s.chainDb.AccountModel.GetAccountByNameHash(accountNameHash)
 	if err != nil {
@@ -254,6 +284,11 @@ func (s *StateDB) GetNft(nftIndex int64) (*nft.L2Nft, error) {
 	if exist {
 		return cached.(*nft.L2Nft), nil
 	}
+	committing, exist := s.getCommittingNft(nftIndex)
+	if exist {
+		s.NftCache.Add(nftIndex, committing)
+		return committing, nil
+	}
 	nft, err := s.chainDb.L2NftModel.GetNft(nftIndex)
 	if err == types.DbErrNotFound {
 		return nil, types.AppErrNftNotFound
@@ -338,12 +373,16 @@ func (s *StateDB) PurgeCache(stateRoot string) {
 }
 
 func (s *StateDB) GetPendingAccount(blockHeight int64) ([]*account.Account, []*account.AccountHistory, error) {
+	return getPendingAccount(s.StateCache, blockHeight)
+}
+
+func getPendingAccount(cache *StateCache, blockHeight int64) ([]*account.Account, []*account.AccountHistory, error) {
 	pendingAccount := make([]*account.Account, 0)
 	pendingAccountHistory := make([]*account.AccountHistory, 0)
 
-	for _, formatAccount := range s.PendingAccountMap {
+	for _, formatAccount := range cache.PendingAccountMap {
 		if formatAccount.AccountIndex == types.GasAccount {
-			s.applyGasUpdate(formatAccount)
+			applyGasUpdate(formatAccount, cache.PendingGasMap)
 		}
 
 		newAccount, err := chain.FromFormatAccountInfo(formatAccount)
@@ -365,8 +404,8 @@ func (s *StateDB) GetPendingAccount(blockHeight int64) ([]*account.Account, []*a
 	return pendingAccount, pendingAccountHistory, nil
 }
 
-func (s *StateDB) applyGasUpdate(formatAccount *types.AccountInfo) {
-	for assetId, delta := range s.StateCache.PendingGasMap {
+func applyGasUpdate(formatAccount *types.AccountInfo, pendingGas map[int64]*big.Int) {
+	for assetId, delta := range pendingGas {
 		if asset, ok := formatAccount.AssetInfo[assetId]; ok {
 			formatAccount.AssetInfo[assetId].Balance = ffmath.Add(asset.Balance, delta)
 		} else {
@@ -379,10 +418,14 @@ func (s *StateDB) applyGasUpdate(formatAccount *types.AccountInfo) {
 }
 
 func (s *StateDB) GetPendingNft(blockHeight int64) ([]*nft.L2Nft, []*nft.L2NftHistory, error) {
+	return getPendingNft(s.StateCache, blockHeight)
+}
+
+func getPendingNft(cache *StateCache, blockHeight int64) ([]*nft.L2Nft, []*nft.L2NftHistory, error) {
 	pendingNft := make([]*nft.L2Nft, 0)
 	pendingNftHistory := make([]*nft.L2NftHistory, 0)
 
-	for _, newNft := range s.PendingNftMap {
+	for _, newNft := range cache.PendingNftMap {
 		pendingNft = append(pendingNft, newNft)
 		pendingNftHistory = append(pendingNftHistory, &nft.L2NftHistory{
 			NftIndex:            newNft.NftIndex,
@@ -480,11 +523,27 @@ type treeUpdateResp struct {
 }
 
 func (s *StateDB) IntermediateRoot(cleanDirty bool) error {
+	stateRoot, err := s.intermediateRoot(s.StateCache, s.GetFormatAccount, s.GetNft)
+	if cleanDirty {
+		s.dirtyAccountsAndAssetsMap = make(map[int64]map[int64]bool, 0)
+		s.dirtyNftMap = make(map[int64]bool, 0)
+	}
+	if err != nil {
+		return err
+	}
+	s.StateRoot = stateRoot
+	return nil
+}
+
+// intermediateRoot updates the trees with the dirty states of the cache, the states are
+// read by the given functions, and returns the new state root.
+func (s *StateDB) intermediateRoot(cache *StateCache,
+	getAccount func(int64) (*types.AccountInfo, error), getNft func(int64) (*nft.L2Nft, error)) (string, error) {
 	taskNum := 0
 	resultChan := make(chan *treeUpdateResp, 1)
 	defer close(resultChan)
 
-	for accountIndex, assetsMap := range s.dirtyAccountsAndAssetsMap {
+	for accountIndex, assetsMap := range cache.dirtyAccountsAndAssetsMap {
 		assets := make([]int64, 0, len(assetsMap))
 		for assetIndex, isDirty := range assetsMap {
 			if !isDirty {
@@ -495,7 +554,7 @@ func (s *StateDB) IntermediateRoot(cleanDirty bool) error {
 		taskNum++
 		err := func(accountIndex int64, assets []int64) error {
 			return gopool.Submit(func() {
-				index, leaf, err := s.updateAccountTree(accountIndex, assets)
+				index, leaf, err := s.updateAccountTree(cache, getAccount, accountIndex, assets)
 				resultChan <- &treeUpdateResp{
 					role:  accountTreeRole,
 					index: index,
@@ -505,18 +564,18 @@ func (s *StateDB) IntermediateRoot(cleanDirty bool) error {
 			})
 		}(accountIndex, assets)
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	for nftIndex, isDirty := range s.dirtyNftMap {
+	for nftIndex, isDirty := range cache.dirtyNftMap {
 		if !isDirty {
 			continue
 		}
 		taskNum++
 		err := func(nftIndex int64) error {
 			return gopool.Submit(func() {
-				index, leaf, err := s.updateNftTree(nftIndex)
+				index, leaf, err := s.updateNftTree(getNft, nftIndex)
 				resultChan <- &treeUpdateResp{
 					role:  nftTreeRole,
 					index: index,
@@ -526,21 +585,16 @@ func (s *StateDB) IntermediateRoot(cleanDirty bool) error {
 			})
 		}(nftIndex)
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	if cleanDirty {
-		s.dirtyAccountsAndAssetsMap = make(map[int64]map[int64]bool, 0)
-		s.dirtyNftMap = make(map[int64]bool, 0)
-	}
-
-	pendingAccountItem := make([]bsmt.Item, 0, len(s.dirtyAccountsAndAssetsMap))
-	pendingNftItem := make([]bsmt.Item, 0, len(s.dirtyNftMap))
+	pendingAccountItem := make([]bsmt.Item, 0, len(cache.dirtyAccountsAndAssetsMap))
+	pendingNftItem := make([]bsmt.Item, 0, len(cache.dirtyNftMap))
 	for i := 0; i < taskNum; i++ {
 		result := <-resultChan
 		if result.err != nil {
-			return result.err
+			return "", result.err
 		}
 
 		switch result.role {
@@ -557,7 +611,7 @@ func (s *StateDB) IntermediateRoot(cleanDirty bool) error {
 		}
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
 	err = gopool.Submit(func() {
 		resultChan <- &treeUpdateResp{
@@ -566,24 +620,24 @@ func (s *StateDB) IntermediateRoot(cleanDirty bool) error {
 		}
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
 	for i := 0; i < 2; i++ {
 		result := <-resultChan
 		if result.err != nil {
-			return fmt.Errorf("update %s tree failed, %v", result.role, result.err)
+			return "", fmt.Errorf("update %s tree failed, %v", result.role, result.err)
 		}
 	}
 
 	hFunc := mimc.NewMiMC()
 	hFunc.Write(s.AccountTree.Root())
 	hFunc.Write(s.NftTree.Root())
-	s.StateRoot = common.Bytes2Hex(hFunc.Sum(nil))
-	return nil
+	return common.Bytes2Hex(hFunc.Sum(nil)), nil
 }
 
-func (s *StateDB) updateAccountTree(accountIndex int64, assets []int64) (int64, []byte, error) {
-	account, err := s.GetFormatAccount(accountIndex)
+func (s *StateDB) updateAccountTree(cache *StateCache, getAccount func(int64) (*types.AccountInfo, error),
+	accountIndex int64, assets []int64) (int64, []byte, error) {
+	account, err := getAccount(accountIndex)
 	if err != nil {
 		return accountIndex, nil, err
 	}
@@ -601,7 +655,7 @@ func (s *StateDB) updateAccountTree(accountIndex int64, assets []int64) (int64,
 		}
 		balance := account.AssetInfo[assetId].Balance
 		if isGasAsset {
-			balance = ffmath.Add(balance, s.GetPendingGas(assetId))
+			balance = ffmath.Add(balance, cache.GetPendingGas(assetId))
 		}
 		assetLeaf, err := tree.ComputeAccountAssetLeafHash(
 			balance.String(),
@@ -633,8 +687,8 @@ func (s *StateDB) updateAccountTree(accountIndex int64, assets []int64) (int64,
 	return accountIndex, nAccountLeafHash, nil
 }
 
-func (s *StateDB) updateNftTree(nftIndex int64) (int64, []byte, error) {
-	nft, err := s.GetNft(nftIndex)
+func (s *StateDB) updateNftTree(getNft func(int64) (*nft.L2Nft, error), nftIndex int64) (int64, []byte, error) {
+	nft, err := getNft(nftIndex)
 	if err != nil {
 		return nftIndex, nil, err
 	}
@@ -694,6 +748,13 @@ func (s *StateDB) GetNextNftIndex() int64 {
 			maxNftIndex = index
 		}
 	}
+	if s.committing != nil {
+		for index := range s.committing.nfts {
+			if index > maxNftIndex {
+				maxNftIndex = index
+			}
+		}
+	}
 	return maxNftIndex + 1
 }
 
@@ -758,6 +819,7 @@ func (s *StateDB) Close() {
 		logx.Errorf("close redis error: %s", err.Error())
 	}
 
+	s.TreeCtx.StopPruner()
 	err = s.TreeCtx.TreeDB.Close()
 	if err != nil {
 		logx.Errorf("close treedb error: %s", err.Error())
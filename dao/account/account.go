@@ -44,6 +44,7 @@ type (
 		GetAccounts(limit int, offset int64) (accounts []*Account, err error)
 		GetAccountsTotalCount() (count int64, err error)
 		UpdateAccountsInTransact(tx *gorm.DB, accounts []*Account) error
+		DeleteAccountsInTransact(tx *gorm.DB, accountIndexes []int64) error
 	}
 
 	defaultAccountModel struct {
@@ -178,3 +179,14 @@ func (m *defaultAccountModel) UpdateAccountsInTransact(tx *gorm.DB, accounts []*
 	}
 	return nil
 }
+
+func (m *defaultAccountModel) DeleteAccountsInTransact(tx *gorm.DB, accountIndexes []int64) error {
+	if len(accountIndexes) == 0 {
+		return nil
+	}
+	dbTx := tx.Table(m.table).Unscoped().Where("account_index IN ?", accountIndexes).Delete(&Account{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
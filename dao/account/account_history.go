@@ -34,6 +34,8 @@ type (
 		GetValidAccountCount(height int64) (accounts int64, err error)
 		CreateAccountHistoriesInTransact(tx *gorm.DB, histories []*AccountHistory) error
 		GetLatestAccountHistory(accountIndex, height int64) (accountHistory *AccountHistory, err error)
+		GetAccountIndexesAboveHeight(height int64) (accountIndexes []int64, err error)
+		DeleteAccountHistoriesAboveHeightInTransact(tx *gorm.DB, height int64) error
 	}
 
 	defaultAccountHistoryModel struct {
@@ -131,3 +133,21 @@ func (m *defaultAccountHistoryModel) GetLatestAccountHistory(accountIndex, heigh
 	}
 	return accountHistory, nil
 }
+
+// GetAccountIndexesAboveHeight returns the accounts updated above the height.
+func (m *defaultAccountHistoryModel) GetAccountIndexesAboveHeight(height int64) (accountIndexes []int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("l2_block_height > ?", height).
+		Distinct("account_index").Pluck("account_index", &accountIndexes)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return accountIndexes, nil
+}
+
+func (m *defaultAccountHistoryModel) DeleteAccountHistoriesAboveHeightInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("l2_block_height > ?", height).Delete(&AccountHistory{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
@@ -52,6 +52,7 @@ type (
 		GetCommittedBlocksCount() (count int64, err error)
 		GetVerifiedBlocksCount() (count int64, err error)
 		GetLatestVerifiedHeight() (height int64, err error)
+		GetLatestHeightByStatus(status int) (height int64, err error)
 		GetBlockByCommitment(blockCommitment string) (block *Block, err error)
 		GetCommittedBlocksBetween(start, end int64) (blocks []*Block, err error)
 		GetBlocksTotalCount() (count int64, err error)
@@ -60,6 +61,7 @@ type (
 		CreateBlockInTransact(tx *gorm.DB, oBlock *Block) error
 		UpdateBlocksWithoutTxsInTransact(tx *gorm.DB, blocks []*Block) (err error)
 		UpdateBlockInTransact(tx *gorm.DB, block *Block) (err error)
+		DeleteBlocksAboveHeightInTransact(tx *gorm.DB, height int64) error
 	}
 
 	defaultBlockModel struct {
@@ -188,6 +190,18 @@ func (m *defaultBlockModel) GetBlocksBetween(start int64, end int64) (blocks []*
 	return blocks, nil
 }
 
+// GetLatestHeightByStatus returns the height of the last block reaching the status.
+func (m *defaultBlockModel) GetLatestHeightByStatus(status int) (height int64, err error) {
+	dbTx := m.DB.Table(m.table).Select("block_height").Where("block_status >= ?", status).
+		Order("block_height desc").Limit(1).Find(&height)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return 0, types.DbErrNotFound
+	}
+	return height, nil
+}
+
 func (m *defaultBlockModel) GetBlockByCommitment(blockCommitment string) (block *Block, err error) {
 	var (
 		txForeignKeyColumn = `Txs`
@@ -371,3 +385,11 @@ func (m *defaultBlockModel) UpdateBlockInTransact(tx *gorm.DB, block *Block) (er
 	}
 	return nil
 }
+
+func (m *defaultBlockModel) DeleteBlocksAboveHeightInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("block_height > ?", height).Delete(&Block{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
@@ -31,6 +31,14 @@ const (
 	StatusReceived
 )
 
+const (
+	// FormatJSON is the legacy format, the witness is kept as json in WitnessData.
+	FormatJSON = iota
+	// FormatBinaryV1 keeps the zstd compressed binary witness in WitnessBytes, prefixed
+	// with the format version and the layout fingerprint of the circuit block.
+	FormatBinaryV1
+)
+
 const (
 	TableName = `block_witness`
 )
@@ -42,8 +50,12 @@ type (
 		GetLatestBlockWitnessHeight() (height int64, err error)
 		GetBlockWitnessByHeight(height int64) (witness *BlockWitness, err error)
 		UpdateBlockWitnessStatus(witness *BlockWitness, status int64) error
+		UpdateBlockWitnessStatusByHeightInTransact(tx *gorm.DB, height int64, status int64) error
 		GetLatestBlockWitness() (witness *BlockWitness, err error)
+		GetBlockWitnessesByStatus(status int64, fromHeight int64, limit int) (witnesses []*BlockWitness, err error)
+		GetBlockWitnessesByFormat(format int64, limit int) (witnesses []*BlockWitness, err error)
 		CreateBlockWitness(witness *BlockWitness) error
+		UpdateBlockWitnessData(witness *BlockWitness) error
 	}
 
 	defaultBlockWitnessModel struct {
@@ -53,9 +65,11 @@ type (
 
 	BlockWitness struct {
 		gorm.Model
-		Height      int64 `gorm:"index:idx_height,unique"`
-		WitnessData string
-		Status      int64
+		Height       int64 `gorm:"index:idx_height,unique"`
+		WitnessData  string
+		WitnessBytes []byte
+		Format       int64 `gorm:"default:0"`
+		Status       int64
 	}
 )
 
@@ -99,6 +113,27 @@ func (m *defaultBlockWitnessModel) GetLatestBlockWitness() (witness *BlockWitnes
 	return witness, nil
 }
 
+func (m *defaultBlockWitnessModel) GetBlockWitnessesByStatus(status int64, fromHeight int64, limit int) (witnesses []*BlockWitness, err error) {
+	dbTx := m.DB.Table(m.table).Where("status = ? AND height >= ?", status, fromHeight).
+		Order("height asc").Limit(limit).Find(&witnesses)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return witnesses, nil
+}
+
+func (m *defaultBlockWitnessModel) GetBlockWitnessesByFormat(format int64, limit int) (witnesses []*BlockWitness, err error) {
+	dbTx := m.DB.Table(m.table).Where("format = ?", format).Order("height asc").Limit(limit).Find(&witnesses)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return witnesses, nil
+}
+
 func (m *defaultBlockWitnessModel) GetBlockWitnessByHeight(height int64) (witness *BlockWitness, err error) {
 	dbTx := m.DB.Table(m.table).Where("height = ?", height).Limit(1).Find(&witness)
 	if dbTx.Error != nil {
@@ -133,3 +168,29 @@ func (m *defaultBlockWitnessModel) UpdateBlockWitnessStatus(witness *BlockWitnes
 	}
 	return nil
 }
+
+// UpdateBlockWitnessStatusByHeightInTransact updates the status of the witness at the height,
+// if it's stored.
+func (m *defaultBlockWitnessModel) UpdateBlockWitnessStatusByHeightInTransact(tx *gorm.DB, height int64, status int64) error {
+	dbTx := tx.Table(m.table).Where("height = ?", height).
+		Updates(map[string]interface{}{"status": status, "updated_at": time.Now()})
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	return nil
+}
+
+func (m *defaultBlockWitnessModel) UpdateBlockWitnessData(witness *BlockWitness) error {
+	// Only the witness columns are updated, the status may be changed by provers at the same time.
+	dbTx := m.DB.Table(m.table).Where("id = ?", witness.ID).Updates(map[string]interface{}{
+		"witness_data":  witness.WitnessData,
+		"witness_bytes": witness.WitnessBytes,
+		"format":        witness.Format,
+	})
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return types.DbErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package dbcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+var (
+	memoryKeyNotExist = errors.New("memory cache: nil")
+)
+
+// MemoryCache keeps the values in process, it is used by the tools that
+// replay the chain and must not touch the caches shared with services.
+type MemoryCache struct {
+	lock   sync.RWMutex
+	values map[string][]byte
+}
+
+func NewMemoryCache() Cache {
+	return &MemoryCache{
+		values: make(map[string][]byte),
+	}
+}
+
+func (c *MemoryCache) GetWithSet(ctx context.Context, key string, valueStruct interface{}, query QueryFunc) (interface{}, error) {
+	value, err := c.Get(ctx, key, valueStruct)
+	if err == nil {
+		return value, nil
+	}
+	if err == memoryKeyNotExist {
+		value, err = query()
+		if err != nil {
+			return nil, err
+		}
+		return value, c.Set(ctx, key, value)
+	}
+	return nil, err
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string, value interface{}) (interface{}, error) {
+	c.lock.RLock()
+	bz, ok := c.values[key]
+	c.lock.RUnlock()
+	if !ok {
+		return nil, memoryKeyNotExist
+	}
+	err := json.Unmarshal(bz, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *MemoryCache) Set(_ context.Context, key string, value interface{}) error {
+	bz, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.values[key] = bz
+	c.lock.Unlock()
+	return nil
+}
+
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.lock.Lock()
+	delete(c.values, key)
+	c.lock.Unlock()
+	return nil
+}
+
+func (c *MemoryCache) Close() error {
+	return nil
+}
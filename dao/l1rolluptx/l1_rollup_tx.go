@@ -44,6 +44,7 @@ type (
 		GetLatestPendingTx(txType int64) (tx *L1RollupTx, err error)
 		GetL1RollupTxsByStatus(txStatus int) (txs []*L1RollupTx, err error)
 		GetL1RollupTxsByHash(hash string) (txs []*L1RollupTx, err error)
+		GetL1RollupTxByL2BlockHeight(txType int64, blockHeight int64) (tx *L1RollupTx, err error)
 		DeleteL1RollupTx(tx *L1RollupTx) error
 		UpdateL1RollupTxsInTransact(tx *gorm.DB, txs []*L1RollupTx) error
 	}
@@ -115,6 +116,20 @@ func (m *defaultL1RollupTxModel) GetL1RollupTxsByHash(hash string) (txs []*L1Rol
 	return txs, nil
 }
 
+// GetL1RollupTxByL2BlockHeight returns the handled tx rolling up the block, the blocks are rolled
+// up in batches and the tx is recorded with the height of the last block in the batch. The pending
+// txs are skipped, they may be replaced or never mined.
+func (m *defaultL1RollupTxModel) GetL1RollupTxByL2BlockHeight(txType int64, blockHeight int64) (tx *L1RollupTx, err error) {
+	dbTx := m.DB.Table(m.table).Where("tx_type = ? AND l2_block_height >= ? AND tx_status = ?", txType, blockHeight, StatusHandled).
+		Order("l2_block_height, id desc").Limit(1).Find(&tx)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return tx, nil
+}
+
 func (m *defaultL1RollupTxModel) DeleteL1RollupTx(rollupTx *L1RollupTx) error {
 	return m.DB.Transaction(func(tx *gorm.DB) error {
 		dbTx := tx.Table(m.table).Where("id = ?", rollupTx.ID).Delete(&rollupTx)
@@ -0,0 +1,50 @@
+package l1rolluptx
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+func TestGetL1RollupTxByL2BlockHeight(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	require.NoError(t, err)
+	model := NewL1RollupTxModel(db)
+
+	// the blocks 1 to 3 are committed by a handled tx, a pending tx commits the blocks 3 to 5
+	rows := []*L1RollupTx{
+		{L1TxHash: "0x01", TxStatus: StatusHandled, TxType: TxTypeCommit, L2BlockHeight: 3},
+		{L1TxHash: "0x02", TxStatus: StatusPending, TxType: TxTypeCommit, L2BlockHeight: 5},
+	}
+	query := regexp.QuoteMeta(`SELECT * FROM "l1_rollup_tx" WHERE (tx_type = $1 AND l2_block_height >= $2 AND tx_status = $3)`)
+	expect := func(blockHeight int64) {
+		result := sqlmock.NewRows([]string{"id", "l1_tx_hash", "tx_status", "tx_type", "l2_block_height"})
+		for i, row := range rows {
+			if row.TxStatus == StatusHandled && row.L2BlockHeight >= blockHeight {
+				result.AddRow(i+1, row.L1TxHash, row.TxStatus, row.TxType, row.L2BlockHeight)
+			}
+		}
+		mock.ExpectQuery(query).WithArgs(TxTypeCommit, blockHeight, StatusHandled).WillReturnRows(result)
+	}
+
+	expect(3)
+	tx, err := model.GetL1RollupTxByL2BlockHeight(TxTypeCommit, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "0x01", tx.L1TxHash)
+
+	// the block is only committed by the pending tx
+	expect(4)
+	_, err = model.GetL1RollupTxByL2BlockHeight(TxTypeCommit, 4)
+	assert.Equal(t, types.DbErrNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
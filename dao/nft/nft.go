@@ -35,7 +35,10 @@ type (
 		GetLatestNftIndex() (nftIndex int64, err error)
 		GetNftsByAccountIndex(accountIndex, limit, offset int64) (nfts []*L2Nft, err error)
 		GetNftsCountByAccountIndex(accountIndex int64) (int64, error)
+		GetNftsByCollection(creatorAccountIndex, collectionId, limit, offset int64) (nfts []*L2Nft, err error)
+		GetNftsCountByCollection(creatorAccountIndex, collectionId int64) (int64, error)
 		UpdateNftsInTransact(tx *gorm.DB, nfts []*L2Nft) error
+		DeleteNftsInTransact(tx *gorm.DB, nftIndexes []int64) error
 	}
 	defaultL2NftModel struct {
 		table string
@@ -117,6 +120,30 @@ func (m *defaultL2NftModel) GetNftsCountByAccountIndex(accountIndex int64) (int6
 	return count, nil
 }
 
+// GetNftsByCollection returns the nfts minted in the collection of the creator.
+func (m *defaultL2NftModel) GetNftsByCollection(creatorAccountIndex, collectionId, limit, offset int64) (nftList []*L2Nft, err error) {
+	dbTx := m.DB.Table(m.table).
+		Where("creator_account_index = ? and collection_id = ? and deleted_at is NULL", creatorAccountIndex, collectionId).
+		Limit(int(limit)).Offset(int(offset)).Order("nft_index desc").Find(&nftList)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return nftList, nil
+}
+
+func (m *defaultL2NftModel) GetNftsCountByCollection(creatorAccountIndex, collectionId int64) (int64, error) {
+	var count int64
+	dbTx := m.DB.Table(m.table).
+		Where("creator_account_index = ? and collection_id = ? and deleted_at is NULL", creatorAccountIndex, collectionId).
+		Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
 func (m *defaultL2NftModel) UpdateNftsInTransact(tx *gorm.DB, nfts []*L2Nft) error {
 	for _, pendingNft := range nfts {
 		dbTx := tx.Table(m.table).Where("nft_index = ?", pendingNft.NftIndex).
@@ -134,3 +161,14 @@ func (m *defaultL2NftModel) UpdateNftsInTransact(tx *gorm.DB, nfts []*L2Nft) err
 	}
 	return nil
 }
+
+func (m *defaultL2NftModel) DeleteNftsInTransact(tx *gorm.DB, nftIndexes []int64) error {
+	if len(nftIndexes) == 0 {
+		return nil
+	}
+	dbTx := tx.Table(m.table).Unscoped().Where("nft_index IN ?", nftIndexes).Delete(&L2Nft{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
@@ -36,6 +36,13 @@ type (
 			rowsAffected int64, nftAssets []*L2NftHistory, err error,
 		)
 		CreateNftHistoriesInTransact(tx *gorm.DB, histories []*L2NftHistory) error
+		GetLatestNftHistory(nftIndex, height int64) (nftHistory *L2NftHistory, err error)
+		GetNftsCountByOwnerAtHeight(ownerAccountIndex, height int64) (count int64, err error)
+		GetNftsByOwnerAtHeight(ownerAccountIndex, height int64, limit, offset int64) (nftList []*L2NftHistory, err error)
+		GetNftHistories(nftIndex, limit, offset int64) (nftHistories []*L2NftHistory, err error)
+		GetNftHistoriesCount(nftIndex int64) (count int64, err error)
+		GetNftIndexesAboveHeight(height int64) (nftIndexes []int64, err error)
+		DeleteNftHistoriesAboveHeightInTransact(tx *gorm.DB, height int64) error
 	}
 	defaultL2NftHistoryModel struct {
 		table string
@@ -119,3 +126,83 @@ func (m *defaultL2NftHistoryModel) CreateNftHistoriesInTransact(tx *gorm.DB, his
 	}
 	return nil
 }
+
+func (m *defaultL2NftHistoryModel) GetLatestNftHistory(nftIndex, height int64) (nftHistory *L2NftHistory, err error) {
+	dbTx := m.DB.Table(m.table).Where("nft_index = ? and l2_block_height < ?", nftIndex, height).Order("l2_block_height desc").Limit(1).Find(&nftHistory)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return nftHistory, nil
+}
+
+// GetNftsCountByOwnerAtHeight counts the nfts owned by the account at the height.
+func (m *defaultL2NftHistoryModel) GetNftsCountByOwnerAtHeight(ownerAccountIndex, height int64) (count int64, err error) {
+	subQuery := m.DB.Table(m.table).Select("*").
+		Where("nft_index = a.nft_index AND l2_block_height <= ? AND l2_block_height > a.l2_block_height", height)
+
+	dbTx := m.DB.Table(m.table+" as a").
+		Where("NOT EXISTS (?) AND l2_block_height <= ? AND owner_account_index = ?", subQuery, height, ownerAccountIndex).
+		Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+// GetNftsByOwnerAtHeight returns the latest histories of the nfts owned by the account at the height.
+func (m *defaultL2NftHistoryModel) GetNftsByOwnerAtHeight(ownerAccountIndex, height int64, limit, offset int64) (nftList []*L2NftHistory, err error) {
+	subQuery := m.DB.Table(m.table).Select("*").
+		Where("nft_index = a.nft_index AND l2_block_height <= ? AND l2_block_height > a.l2_block_height", height)
+
+	dbTx := m.DB.Table(m.table+" as a").Select("*").
+		Where("NOT EXISTS (?) AND l2_block_height <= ? AND owner_account_index = ?", subQuery, height, ownerAccountIndex).
+		Limit(int(limit)).Offset(int(offset)).
+		Order("nft_index desc").
+		Find(&nftList)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return nftList, nil
+}
+
+// GetNftHistories returns the histories of the nft, the latest first.
+func (m *defaultL2NftHistoryModel) GetNftHistories(nftIndex, limit, offset int64) (nftHistories []*L2NftHistory, err error) {
+	dbTx := m.DB.Table(m.table).Where("nft_index = ?", nftIndex).
+		Limit(int(limit)).Offset(int(offset)).
+		Order("l2_block_height desc, id desc").
+		Find(&nftHistories)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return nftHistories, nil
+}
+
+func (m *defaultL2NftHistoryModel) GetNftHistoriesCount(nftIndex int64) (count int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("nft_index = ?", nftIndex).Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+// GetNftIndexesAboveHeight returns the nfts updated above the height.
+func (m *defaultL2NftHistoryModel) GetNftIndexesAboveHeight(height int64) (nftIndexes []int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("l2_block_height > ?", height).
+		Distinct("nft_index").Pluck("nft_index", &nftIndexes)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return nftIndexes, nil
+}
+
+func (m *defaultL2NftHistoryModel) DeleteNftHistoriesAboveHeightInTransact(tx *gorm.DB, height int64) error {
+	dbTx := tx.Table(m.table).Unscoped().Where("l2_block_height > ?", height).Delete(&L2NftHistory{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
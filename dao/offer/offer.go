@@ -0,0 +1,285 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package offer
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	OfferTableName = `offer`
+
+	// the postgres error code of a unique index violation
+	uniqueViolation = "23505"
+)
+
+const (
+	StatusActive = iota
+	// matched by an AtomicMatch tx
+	StatusFinalized
+	// canceled by a CancelOffer tx, or the offer id is used by another offer
+	StatusCanceled
+	StatusExpired
+)
+
+type getOfferOption struct {
+	Type                *int64
+	AccountIndex        *int64
+	NftIndex            *int64
+	CreatorAccountIndex *int64
+	CollectionId        *int64
+	ActiveAt            int64
+}
+
+type GetOfferOptionFunc func(*getOfferOption)
+
+func GetOfferWithType(offerType int64) GetOfferOptionFunc {
+	return func(o *getOfferOption) {
+		o.Type = &offerType
+	}
+}
+
+func GetOfferWithAccountIndex(accountIndex int64) GetOfferOptionFunc {
+	return func(o *getOfferOption) {
+		o.AccountIndex = &accountIndex
+	}
+}
+
+func GetOfferWithNftIndex(nftIndex int64) GetOfferOptionFunc {
+	return func(o *getOfferOption) {
+		o.NftIndex = &nftIndex
+	}
+}
+
+func GetOfferWithCollection(creatorAccountIndex, collectionId int64) GetOfferOptionFunc {
+	return func(o *getOfferOption) {
+		o.CreatorAccountIndex = &creatorAccountIndex
+		o.CollectionId = &collectionId
+	}
+}
+
+type (
+	OfferModel interface {
+		CreateOfferTable() error
+		DropOfferTable() error
+		CreateOffer(offer *Offer) error
+		UpdateOffer(offer *Offer) error
+		GetOffer(accountIndex, offerId int64) (offer *Offer, err error)
+		GetActiveOffers(now int64, limit int64, offset int64, options ...GetOfferOptionFunc) (offers []*Offer, err error)
+		GetActiveOffersCount(now int64, options ...GetOfferOptionFunc) (count int64, err error)
+		GetActiveOffersByAccountIndexes(accountIndexes []int64) (offers []*Offer, err error)
+		GetAccountIndexesWithActiveOffers() (accountIndexes []int64, err error)
+		UpdateOffersStatus(ids []uint, status int, l2TxHash string, l2BlockHeight int64) error
+		ExpireOffers(now int64) (rowsAffected int64, err error)
+		GetInvalidatedOffersAboveHeight(height int64, now int64) (offers []*Offer, err error)
+		ReactivateOffers(ids []uint) error
+	}
+
+	defaultOfferModel struct {
+		table string
+		DB    *gorm.DB
+	}
+
+	// Offer is a signed offer listed in the offer book, it's kept off chain until it's
+	// matched by an AtomicMatch tx.
+	Offer struct {
+		gorm.Model
+		OfferType           int64
+		OfferId             int64 `gorm:"uniqueIndex:idx_offer_account_offer_id"`
+		AccountIndex        int64 `gorm:"uniqueIndex:idx_offer_account_offer_id"`
+		NftIndex            int64 `gorm:"index"`
+		CreatorAccountIndex int64 `gorm:"index:idx_offer_collection"`
+		CollectionId        int64 `gorm:"index:idx_offer_collection"`
+		AssetId             int64
+		AssetAmount         string
+		TreasuryRate        int64
+		ListedAt            int64
+		ExpiredAt           int64
+		// the signed offer, the AtomicMatch txs are built from it
+		TxInfo string
+		Status int `gorm:"index"`
+		// the tx finalizing or canceling the offer
+		L2TxHash string
+		// the height of the block finalizing or canceling the offer
+		L2BlockHeight int64 `gorm:"index"`
+	}
+)
+
+func NewOfferModel(db *gorm.DB) OfferModel {
+	return &defaultOfferModel{
+		table: OfferTableName,
+		DB:    db,
+	}
+}
+
+func (*Offer) TableName() string {
+	return OfferTableName
+}
+
+func (m *defaultOfferModel) CreateOfferTable() error {
+	return m.DB.AutoMigrate(Offer{})
+}
+
+func (m *defaultOfferModel) DropOfferTable() error {
+	return m.DB.Migrator().DropTable(m.table)
+}
+
+func (m *defaultOfferModel) CreateOffer(offer *Offer) error {
+	dbTx := m.DB.Table(m.table).Create(offer)
+	if dbTx.Error != nil {
+		// the offer id of the account is listed concurrently
+		var pgErr *pgconn.PgError
+		if errors.As(dbTx.Error, &pgErr) && pgErr.Code == uniqueViolation {
+			return types.DbErrDuplicateOffer
+		}
+		return types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return types.DbErrFailToCreateOffer
+	}
+	return nil
+}
+
+func (m *defaultOfferModel) UpdateOffer(offer *Offer) error {
+	dbTx := m.DB.Table(m.table).Where("id = ?", offer.ID).Select("*").Updates(offer)
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	return nil
+}
+
+func (m *defaultOfferModel) GetOffer(accountIndex, offerId int64) (offer *Offer, err error) {
+	dbTx := m.DB.Table(m.table).Where("account_index = ? AND offer_id = ?", accountIndex, offerId).Find(&offer)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return offer, nil
+}
+
+func (m *defaultOfferModel) activeOffersQuery(now int64, options ...GetOfferOptionFunc) *gorm.DB {
+	opt := &getOfferOption{}
+	for _, f := range options {
+		f(opt)
+	}
+
+	dbTx := m.DB.Table(m.table).Where("status = ? AND expired_at > ?", StatusActive, now)
+	if opt.Type != nil {
+		dbTx = dbTx.Where("offer_type = ?", *opt.Type)
+	}
+	if opt.AccountIndex != nil {
+		dbTx = dbTx.Where("account_index = ?", *opt.AccountIndex)
+	}
+	if opt.NftIndex != nil {
+		dbTx = dbTx.Where("nft_index = ?", *opt.NftIndex)
+	}
+	if opt.CreatorAccountIndex != nil {
+		dbTx = dbTx.Where("creator_account_index = ? AND collection_id = ?", *opt.CreatorAccountIndex, *opt.CollectionId)
+	}
+	return dbTx
+}
+
+// GetActiveOffers returns the offers neither expired at now nor invalidated, the latest
+// listed first.
+func (m *defaultOfferModel) GetActiveOffers(now int64, limit int64, offset int64, options ...GetOfferOptionFunc) (offers []*Offer, err error) {
+	dbTx := m.activeOffersQuery(now, options...).
+		Limit(int(limit)).Offset(int(offset)).Order("listed_at desc, id desc").Find(&offers)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return offers, nil
+}
+
+func (m *defaultOfferModel) GetActiveOffersCount(now int64, options ...GetOfferOptionFunc) (count int64, err error) {
+	dbTx := m.activeOffersQuery(now, options...).Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+func (m *defaultOfferModel) GetActiveOffersByAccountIndexes(accountIndexes []int64) (offers []*Offer, err error) {
+	dbTx := m.DB.Table(m.table).Where("status = ? AND account_index IN ?", StatusActive, accountIndexes).Find(&offers)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return offers, nil
+}
+
+func (m *defaultOfferModel) GetAccountIndexesWithActiveOffers() (accountIndexes []int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("status = ? AND deleted_at is NULL", StatusActive).
+		Distinct("account_index").Pluck("account_index", &accountIndexes)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return accountIndexes, nil
+}
+
+func (m *defaultOfferModel) UpdateOffersStatus(ids []uint, status int, l2TxHash string, l2BlockHeight int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	dbTx := m.DB.Table(m.table).Where("id IN ? AND status = ?", ids, StatusActive).
+		Updates(map[string]interface{}{"status": status, "l2_tx_hash": l2TxHash, "l2_block_height": l2BlockHeight})
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	return nil
+}
+
+// ExpireOffers marks the active offers expired at now.
+func (m *defaultOfferModel) ExpireOffers(now int64) (rowsAffected int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("status = ? AND expired_at <= ?", StatusActive, now).
+		Update("status", StatusExpired)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return dbTx.RowsAffected, nil
+}
+
+// GetInvalidatedOffersAboveHeight returns the offers finalized or canceled by the blocks
+// above the height, which aren't expired at now.
+func (m *defaultOfferModel) GetInvalidatedOffersAboveHeight(height int64, now int64) (offers []*Offer, err error) {
+	dbTx := m.DB.Table(m.table).Where("status IN ? AND l2_block_height > ? AND expired_at > ?",
+		[]int{StatusFinalized, StatusCanceled}, height, now).Find(&offers)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return offers, nil
+}
+
+// ReactivateOffers marks the finalized or canceled offers active again.
+func (m *defaultOfferModel) ReactivateOffers(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	dbTx := m.DB.Table(m.table).Where("id IN ? AND status IN ?", ids, []int{StatusFinalized, StatusCanceled}).
+		Updates(map[string]interface{}{"status": StatusActive, "l2_tx_hash": "", "l2_block_height": 0})
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	return nil
+}
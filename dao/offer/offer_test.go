@@ -0,0 +1,31 @@
+package offer
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+func TestCreateOfferDuplicate(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	require.NoError(t, err)
+
+	// the offer id of the account is inserted by a concurrent request
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "offer"`).
+		WillReturnError(&pgconn.PgError{Code: uniqueViolation, ConstraintName: "idx_offer_account_offer_id"})
+	mock.ExpectRollback()
+	err = NewOfferModel(db).CreateOffer(&Offer{OfferId: 1, AccountIndex: 2})
+	assert.Equal(t, types.DbErrDuplicateOffer, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -39,6 +39,11 @@ type (
 		UpdateHandledPriorityRequestsInTransact(tx *gorm.DB, requests []*PriorityRequest) (err error)
 		CreatePriorityRequestsInTransact(tx *gorm.DB, requests []*PriorityRequest) (err error)
 		GetPriorityRequestsByL2TxHash(txHash string) (tx *PriorityRequest, err error)
+		GetPriorityRequestByL1TxHash(txHash string) (request *PriorityRequest, err error)
+		GetPriorityRequestsByAccountNameHash(accountNameHash string, limit int64, offset int64) (requests []*PriorityRequest, err error)
+		GetPriorityRequestsCountByAccountNameHash(accountNameHash string) (count int64, err error)
+		GetPriorityRequestsWithoutAccountNameHash(fromId uint, limit int) (requests []*PriorityRequest, err error)
+		UpdateAccountNameHash(id uint, accountNameHash string) (err error)
 	}
 
 	defaultPriorityRequestModel struct {
@@ -49,7 +54,7 @@ type (
 	PriorityRequest struct {
 		gorm.Model
 		// related txVerification hash
-		L1TxHash string
+		L1TxHash string `gorm:"index"`
 		// related block height
 		L1BlockHeight int64
 		// sender
@@ -66,6 +71,9 @@ type (
 		Status int
 		// L2TxHash for the relation to tx table
 		L2TxHash string `gorm:"index"`
+		// hash of the account name in the pub data, the requests are related to the
+		// accounts by it as the account index is resolved by the executors
+		AccountNameHash string `gorm:"index"`
 	}
 )
 
@@ -161,3 +169,56 @@ func (m *defaultPriorityRequestModel) GetPriorityRequestsByL2TxHash(txHash strin
 
 	return tx, nil
 }
+
+func (m *defaultPriorityRequestModel) GetPriorityRequestByL1TxHash(txHash string) (request *PriorityRequest, err error) {
+	dbTx := m.DB.Table(m.table).Where("l1_tx_hash = ?", txHash).Order("request_id").Limit(1).Find(&request)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return request, nil
+}
+
+func (m *defaultPriorityRequestModel) GetPriorityRequestsByAccountNameHash(accountNameHash string, limit int64, offset int64) (requests []*PriorityRequest, err error) {
+	dbTx := m.DB.Table(m.table).Where("account_name_hash = ?", accountNameHash).
+		Limit(int(limit)).Offset(int(offset)).Order("request_id desc").Find(&requests)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return requests, nil
+}
+
+func (m *defaultPriorityRequestModel) GetPriorityRequestsCountByAccountNameHash(accountNameHash string) (count int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("account_name_hash = ? AND deleted_at is NULL", accountNameHash).Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
+// GetPriorityRequestsWithoutAccountNameHash returns the requests above the id created before
+// the AccountNameHash is added.
+func (m *defaultPriorityRequestModel) GetPriorityRequestsWithoutAccountNameHash(fromId uint, limit int) (requests []*PriorityRequest, err error) {
+	dbTx := m.DB.Table(m.table).Where("id > ? AND (account_name_hash IS NULL OR account_name_hash = '')", fromId).
+		Order("id").Limit(limit).Find(&requests)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return requests, nil
+}
+
+func (m *defaultPriorityRequestModel) UpdateAccountNameHash(id uint, accountNameHash string) (err error) {
+	dbTx := m.DB.Table(m.table).Where("id = ?", id).Update("account_name_hash", accountNameHash)
+	if dbTx.Error != nil {
+		return types.DbErrSqlOperation
+	}
+	return nil
+}
@@ -39,6 +39,7 @@ type (
 		CreateProofTable() error
 		DropProofTable() error
 		CreateProof(row *Proof) error
+		CreateProofInTransact(tx *gorm.DB, row *Proof) error
 		GetProofsBetween(start int64, end int64) (proofs []*Proof, err error)
 		GetLatestProof() (p *Proof, err error)
 		GetLatestConfirmedProof() (p *Proof, err error)
@@ -89,6 +90,17 @@ func (m *defaultProofModel) CreateProof(row *Proof) error {
 	return nil
 }
 
+func (m *defaultProofModel) CreateProofInTransact(tx *gorm.DB, row *Proof) error {
+	dbTx := tx.Table(m.table).Create(row)
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	if dbTx.RowsAffected == 0 {
+		return types.DbErrFailToCreateProof
+	}
+	return nil
+}
+
 func (m *defaultProofModel) GetProofsBetween(start int64, end int64) (proofs []*Proof, err error) {
 	dbTx := m.DB.Debug().Table(m.table).Where("block_number >= ? AND block_number <= ? AND status = ?",
 		start,
@@ -79,10 +79,14 @@ type (
 		GetTxs(limit int64, offset int64, options ...GetTxOptionFunc) (txList []*Tx, err error)
 		GetTxsByAccountIndex(accountIndex int64, limit int64, offset int64, options ...GetTxOptionFunc) (txList []*Tx, err error)
 		GetTxsCountByAccountIndex(accountIndex int64, options ...GetTxOptionFunc) (count int64, err error)
+		GetTxsByNftIndex(nftIndex int64, limit int64, offset int64) (txList []*Tx, err error)
+		GetTxsCountByNftIndex(nftIndex int64) (count int64, err error)
 		GetTxByHash(txHash string) (tx *Tx, err error)
 		GetTxsTotalCountBetween(from, to time.Time) (count int64, err error)
 		GetDistinctAccountsCountBetween(from, to time.Time) (count int64, err error)
+		GetMostActiveAccounts(fromHeight int64, limit int64) (accountIndexes []int64, err error)
 		UpdateTxsStatusInTransact(tx *gorm.DB, blockTxStatus map[int64]int) error
+		DeleteTxsAboveHeightInTransact(tx *gorm.DB, height int64) error
 	}
 
 	defaultTxModel struct {
@@ -104,7 +108,7 @@ type (
 		// Assigned after executed.
 		GasFee        string
 		GasFeeAssetId int64
-		NftIndex      int64
+		NftIndex      int64 `gorm:"index"`
 		CollectionId  int64
 		AssetId       int64
 		TxAmount      string
@@ -220,6 +224,26 @@ func (m *defaultTxModel) GetTxsCountByAccountIndex(accountIndex int64, options .
 	return count, nil
 }
 
+// GetTxsByNftIndex returns the txs executed on the nft, the latest first.
+func (m *defaultTxModel) GetTxsByNftIndex(nftIndex int64, limit int64, offset int64) (txList []*Tx, err error) {
+	dbTx := m.DB.Table(m.table).Where("nft_index = ? AND tx_type IN ?", nftIndex, types.NftTxTypes).
+		Limit(int(limit)).Offset(int(offset)).Order("created_at desc").Find(&txList)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	} else if dbTx.RowsAffected == 0 {
+		return nil, types.DbErrNotFound
+	}
+	return txList, nil
+}
+
+func (m *defaultTxModel) GetTxsCountByNftIndex(nftIndex int64) (count int64, err error) {
+	dbTx := m.DB.Table(m.table).Where("nft_index = ? AND tx_type IN ?", nftIndex, types.NftTxTypes).Count(&count)
+	if dbTx.Error != nil {
+		return 0, types.DbErrSqlOperation
+	}
+	return count, nil
+}
+
 func (m *defaultTxModel) GetTxByHash(txHash string) (tx *Tx, err error) {
 	dbTx := m.DB.Table(m.table).Where("tx_hash = ?", txHash).Find(&tx)
 	if dbTx.Error != nil {
@@ -251,6 +275,18 @@ func (m *defaultTxModel) GetDistinctAccountsCountBetween(from, to time.Time) (co
 	return count, nil
 }
 
+// GetMostActiveAccounts returns the accounts sending the most txs in the blocks above the height.
+func (m *defaultTxModel) GetMostActiveAccounts(fromHeight int64, limit int64) (accountIndexes []int64, err error) {
+	dbTx := m.DB.Table(m.table).Select("account_index").
+		Where("block_height > ? AND account_index != -1 AND deleted_at is NULL", fromHeight).
+		Group("account_index").Order("count(*) desc").Limit(int(limit)).
+		Pluck("account_index", &accountIndexes)
+	if dbTx.Error != nil {
+		return nil, types.DbErrSqlOperation
+	}
+	return accountIndexes, nil
+}
+
 func (m *defaultTxModel) UpdateTxsStatusInTransact(tx *gorm.DB, blockTxStatus map[int64]int) error {
 	for height, status := range blockTxStatus {
 		dbTx := tx.Table(m.table).Where("block_height = ?", height).Update("tx_status", status)
@@ -263,3 +299,17 @@ func (m *defaultTxModel) UpdateTxsStatusInTransact(tx *gorm.DB, blockTxStatus ma
 	}
 	return nil
 }
+
+// DeleteTxsAboveHeightInTransact deletes the txs packed above the height with their details.
+func (m *defaultTxModel) DeleteTxsAboveHeightInTransact(tx *gorm.DB, height int64) error {
+	txIds := tx.Table(m.table).Select("id").Where("block_height > ?", height)
+	dbTx := tx.Table(TxDetailTableName).Unscoped().Where("tx_id IN (?)", txIds).Delete(&TxDetail{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	dbTx = tx.Table(m.table).Unscoped().Where("block_height > ?", height).Delete(&Tx{})
+	if dbTx.Error != nil {
+		return dbTx.Error
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	accdao "github.com/bnb-chain/zkbnb/dao/account"
 	assetdao "github.com/bnb-chain/zkbnb/dao/asset"
 	blockdao "github.com/bnb-chain/zkbnb/dao/block"
+	nftdao "github.com/bnb-chain/zkbnb/dao/nft"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 )
@@ -34,6 +35,9 @@ const (
 	AssetBySymbolKeyPrefix     = "S:"  //key for cache: assetSymbol -> asset
 	PriceKeyPrefix             = "p:"  //key for cache: symbol -> price
 	SysConfigKeyPrefix         = "s:"  //key for cache: configName -> sysconfig
+	AccountHistoryKeyPrefix    = "ah:" //key for cache: accountIndex:height -> account history
+	AccountNftCountKeyPrefix   = "nc:" //key for cache: accountIndex:height -> nft count
+	AccountNftsKeyPrefix       = "na:" //key for cache: accountIndex:height:offset:limit -> nft histories
 )
 
 type fallback func() (interface{}, error)
@@ -321,3 +325,32 @@ func (m *MemCache) GetSysConfigWithFallback(configName string, f fallback) (*sys
 	}
 	return c.(*sysconfig.SysConfig), nil
 }
+
+// The states at the committed heights don't change, they are kept with the default expiration.
+
+func (m *MemCache) GetAccountHistoryWithFallback(accountIndex, height int64, f fallback) (*accdao.AccountHistory, error) {
+	key := fmt.Sprintf("%s%d:%d", AccountHistoryKeyPrefix, accountIndex, height)
+	h, err := m.getWithSet(key, cacheDefaultExpiration, f)
+	if err != nil {
+		return nil, err
+	}
+	return h.(*accdao.AccountHistory), nil
+}
+
+func (m *MemCache) GetAccountNftCountWithFallback(accountIndex, height int64, f fallback) (int64, error) {
+	key := fmt.Sprintf("%s%d:%d", AccountNftCountKeyPrefix, accountIndex, height)
+	count, err := m.getWithSet(key, cacheDefaultExpiration, f)
+	if err != nil {
+		return 0, err
+	}
+	return count.(int64), nil
+}
+
+func (m *MemCache) GetAccountNftsWithFallback(accountIndex, height, offset, limit int64, f fallback) ([]*nftdao.L2NftHistory, error) {
+	key := fmt.Sprintf("%s%d:%d:%d:%d", AccountNftsKeyPrefix, accountIndex, height, offset, limit)
+	nfts, err := m.getWithSet(key, cacheDefaultExpiration, f)
+	if err != nil {
+		return nil, err
+	}
+	return nfts.([]*nftdao.L2NftHistory), nil
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/stores/cache"
 	"github.com/zeromicro/go-zero/rest"
+
+	"github.com/bnb-chain/zkbnb/tree"
 )
 
 type Config struct {
@@ -33,4 +35,67 @@ type Config struct {
 		MaxCounterNum int64
 		MaxKeyNum     int64
 	}
+	// The trees of the service are opened read only to serve the merkle proofs,
+	// the proof apis are disabled if it's not set.
+	//nolint:staticcheck
+	TreeDB struct {
+		//nolint:staticcheck
+		Service string `json:",optional"`
+		//nolint:staticcheck
+		Driver tree.Driver `json:",optional"`
+		//nolint:staticcheck
+		LevelDBOption tree.LevelDBOption `json:",optional"`
+		//nolint:staticcheck
+		PebbleDBOption tree.PebbleDBOption `json:",optional"`
+		//nolint:staticcheck
+		RedisDBOption tree.RedisDBOption `json:",optional"`
+	} `json:",optional"`
+	// Set on the read only apiservers serving the local replica of a fullnode, the txs
+	// are forwarded to L2EndPoint, they are rejected if it's not set.
+	//nolint:staticcheck
+	ReadOnly struct {
+		Enabled bool
+		//nolint:staticcheck
+		L2EndPoint string `json:",optional"`
+	} `json:",optional"`
+	// The subscriptions served on /api/v1/ws, the events are polled from the database.
+	//nolint:staticcheck
+	Subscription struct {
+		// Serve the subscriptions, the block changes are only polled if it's enabled.
+		//nolint:staticcheck
+		Enabled bool `json:",optional"`
+		// Interval of polling the block changes in milliseconds.
+		//nolint:staticcheck
+		PollInterval int64 `json:",optional"`
+		// Max number of subscriptions of a connection.
+		//nolint:staticcheck
+		MaxSubscriptions int `json:",optional"`
+	} `json:",optional"`
+	// The JSON-RPC 2.0 interface served on /rpc.
+	//nolint:staticcheck
+	Rpc struct {
+		// Max number of calls in a batch.
+		//nolint:staticcheck
+		MaxBatchSize int `json:",optional"`
+	} `json:",optional"`
+	// The offer book keeping the signed nft offers.
+	//nolint:staticcheck
+	OfferBook struct {
+		// Accept the offers and keep them valid, it's ignored by the read only api server.
+		//nolint:staticcheck
+		Enabled bool `json:",optional"`
+		// Interval of polling the blocks invalidating the offers in milliseconds.
+		//nolint:staticcheck
+		PollInterval int64 `json:",optional"`
+	} `json:",optional"`
+	// The ZkBNB contract the pending balances of the withdrawals are read from, the
+	// withdrawals executed on L1 are rejected if it's not set.
+	//nolint:staticcheck
+	L1 struct {
+		// Rpc endpoint of L1.
+		//nolint:staticcheck
+		NetworkRPC string `json:",optional"`
+		//nolint:staticcheck
+		ZkBNBContract string `json:",optional"`
+	} `json:",optional"`
 }
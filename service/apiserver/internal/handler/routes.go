@@ -9,6 +9,9 @@ import (
 	block "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/block"
 	info "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/info"
 	nft "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/nft"
+	offer "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/offer"
+	priorityrequest "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/priorityrequest"
+	proof "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/proof"
 	root "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/root"
 	transaction "github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/transaction"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
@@ -39,6 +42,11 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/account",
 				Handler: account.GetAccountHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountAsset",
+				Handler: account.GetAccountAssetHandler(serverCtx),
+			},
 		},
 	)
 
@@ -144,6 +152,11 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/accountPendingTxs",
 				Handler: transaction.GetAccountPendingTxsHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountWithdrawals",
+				Handler: transaction.GetAccountWithdrawalsHandler(serverCtx),
+			},
 			{
 				Method:  http.MethodGet,
 				Path:    "/api/v1/nextNonce",
@@ -169,6 +182,91 @@ func RegisterHandlers(server *rest.Server, serverCtx *svc.ServiceContext) {
 				Path:    "/api/v1/accountNfts",
 				Handler: nft.GetAccountNftsHandler(serverCtx),
 			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/nft",
+				Handler: nft.GetNftHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/collectionNfts",
+				Handler: nft.GetCollectionNftsHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountCollections",
+				Handler: nft.GetAccountCollectionsHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/nftHistories",
+				Handler: nft.GetNftHistoriesHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/nftTxs",
+				Handler: nft.GetNftTxsHandler(serverCtx),
+			},
+		},
+	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountProof",
+				Handler: proof.GetAccountProofHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/nftProof",
+				Handler: proof.GetNftProofHandler(serverCtx),
+			},
+		},
+	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodPost,
+				Path:    "/api/v1/createOffer",
+				Handler: offer.CreateOfferHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/offer",
+				Handler: offer.GetOfferHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/nftOffers",
+				Handler: offer.GetNftOffersHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/collectionOffers",
+				Handler: offer.GetCollectionOffersHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountOffers",
+				Handler: offer.GetAccountOffersHandler(serverCtx),
+			},
+		},
+	)
+
+	server.AddRoutes(
+		[]rest.Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/priorityRequest",
+				Handler: priorityrequest.GetPriorityRequestHandler(serverCtx),
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/api/v1/accountPriorityRequests",
+				Handler: priorityrequest.GetAccountPriorityRequestsHandler(serverCtx),
+			},
 		},
 	)
 }
@@ -0,0 +1,296 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/account"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/asset"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/block"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/info"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/nft"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/priorityrequest"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/proof"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/root"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/transaction"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+// method calls the logic of a rest route, the params are the query of the route.
+type method func(ctx context.Context, svcCtx *svc.ServiceContext, params *params) (interface{}, error)
+
+// methods are named after the rest routes, e.g. zkbnb_getAccount for /api/v1/account.
+var methods = map[string]method{
+	"zkbnb_getStatus": func(ctx context.Context, svcCtx *svc.ServiceContext, _ *params) (interface{}, error) {
+		return root.NewGetStatusLogic(ctx, svcCtx).GetStatus()
+	},
+
+	// account
+	"zkbnb_getAccounts": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetRange
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return account.NewGetAccountsLogic(ctx, svcCtx).GetAccounts(&req)
+	},
+	"zkbnb_getAccount": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccount
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return account.NewGetAccountLogic(ctx, svcCtx).GetAccount(&req)
+	},
+
+	// asset
+	"zkbnb_getAssets": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetRange
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return asset.NewGetAssetsLogic(ctx, svcCtx).GetAssets(&req)
+	},
+	"zkbnb_getAsset": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAsset
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return asset.NewGetAssetLogic(ctx, svcCtx).GetAsset(&req)
+	},
+
+	// block
+	"zkbnb_getBlocks": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetRange
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return block.NewGetBlocksLogic(ctx, svcCtx).GetBlocks(&req)
+	},
+	"zkbnb_getBlock": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetBlock
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return block.NewGetBlockLogic(ctx, svcCtx).GetBlock(&req)
+	},
+	"zkbnb_getCurrentHeight": func(ctx context.Context, svcCtx *svc.ServiceContext, _ *params) (interface{}, error) {
+		return block.NewGetCurrentHeightLogic(ctx, svcCtx).GetCurrentHeight()
+	},
+
+	// info
+	"zkbnb_getLayer2BasicInfo": func(ctx context.Context, svcCtx *svc.ServiceContext, _ *params) (interface{}, error) {
+		return info.NewGetLayer2BasicInfoLogic(ctx, svcCtx).GetLayer2BasicInfo()
+	},
+	"zkbnb_getGasFee": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetGasFee
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return info.NewGetGasFeeLogic(ctx, svcCtx).GetGasFee(&req)
+	},
+	"zkbnb_getGasFeeAssets": func(ctx context.Context, svcCtx *svc.ServiceContext, _ *params) (interface{}, error) {
+		return info.NewGetGasFeeAssetsLogic(ctx, svcCtx).GetGasFeeAssets()
+	},
+	"zkbnb_getGasAccount": func(ctx context.Context, svcCtx *svc.ServiceContext, _ *params) (interface{}, error) {
+		return info.NewGetGasAccountLogic(ctx, svcCtx).GetGasAccount()
+	},
+	"zkbnb_search": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqSearch
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return info.NewSearchLogic(ctx, svcCtx).Search(&req)
+	},
+
+	// transaction
+	"zkbnb_getTxs": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetRange
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetTxsLogic(ctx, svcCtx).GetTxs(&req)
+	},
+	"zkbnb_getBlockTxs": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetBlockTxs
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetBlockTxsLogic(ctx, svcCtx).GetBlockTxs(&req)
+	},
+	"zkbnb_getAccountTxs": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountTxs
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetAccountTxsLogic(ctx, svcCtx).GetAccountTxs(&req)
+	},
+	"zkbnb_getTx": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetTx
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetTxLogic(ctx, svcCtx).GetTx(&req)
+	},
+	"zkbnb_getPendingTxs": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetRange
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetPendingTxsLogic(ctx, svcCtx).GetPendingTxs(&req)
+	},
+	"zkbnb_getExecutedTxs": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetRangeWithFromHash
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetExecutedTxsLogic(ctx, svcCtx).GetExecutedTxs(&req)
+	},
+	"zkbnb_getAccountPendingTxs": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountPendingTxs
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetAccountPendingTxsLogic(ctx, svcCtx).GetAccountPendingTxs(&req)
+	},
+	"zkbnb_getAccountWithdrawals": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountWithdrawals
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetAccountWithdrawalsLogic(ctx, svcCtx).GetAccountWithdrawals(&req)
+	},
+	"zkbnb_getNextNonce": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetNextNonce
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewGetNextNonceLogic(ctx, svcCtx).GetNextNonce(&req)
+	},
+	"zkbnb_sendTx": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqSendTx
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return transaction.NewSendTxLogic(ctx, svcCtx).SendTx(&req)
+	},
+
+	// nft
+	"zkbnb_getMaxOfferId": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetMaxOfferId
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return nft.NewGetMaxOfferIdLogic(ctx, svcCtx).GetMaxOfferId(&req)
+	},
+	"zkbnb_getAccountNfts": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountNfts
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return nft.NewGetAccountNftsLogic(ctx, svcCtx).GetAccountNfts(&req)
+	},
+	"zkbnb_getNft": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetNft
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return nft.NewGetNftLogic(ctx, svcCtx).GetNft(&req)
+	},
+	"zkbnb_getCollectionNfts": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetCollectionNfts
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return nft.NewGetCollectionNftsLogic(ctx, svcCtx).GetCollectionNfts(&req)
+	},
+	"zkbnb_getAccountCollections": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountCollections
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return nft.NewGetAccountCollectionsLogic(ctx, svcCtx).GetAccountCollections(&req)
+	},
+	"zkbnb_getNftHistories": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetNftHistories
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return nft.NewGetNftHistoriesLogic(ctx, svcCtx).GetNftHistories(&req)
+	},
+	"zkbnb_getNftTxs": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetNftTxs
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return nft.NewGetNftTxsLogic(ctx, svcCtx).GetNftTxs(&req)
+	},
+
+	// offer
+	"zkbnb_createOffer": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqCreateOffer
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return offer.NewCreateOfferLogic(ctx, svcCtx).CreateOffer(&req)
+	},
+	"zkbnb_getOffer": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetOffer
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return offer.NewGetOfferLogic(ctx, svcCtx).GetOffer(&req)
+	},
+	"zkbnb_getNftOffers": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetNftOffers
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return offer.NewGetNftOffersLogic(ctx, svcCtx).GetNftOffers(&req)
+	},
+	"zkbnb_getCollectionOffers": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetCollectionOffers
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return offer.NewGetCollectionOffersLogic(ctx, svcCtx).GetCollectionOffers(&req)
+	},
+	"zkbnb_getAccountOffers": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountOffers
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return offer.NewGetAccountOffersLogic(ctx, svcCtx).GetAccountOffers(&req)
+	},
+
+	// priority request
+	"zkbnb_getPriorityRequest": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetPriorityRequest
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return priorityrequest.NewGetPriorityRequestLogic(ctx, svcCtx).GetPriorityRequest(&req)
+	},
+	"zkbnb_getAccountPriorityRequests": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountPriorityRequests
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return priorityrequest.NewGetAccountPriorityRequestsLogic(ctx, svcCtx).GetAccountPriorityRequests(&req)
+	},
+
+	// proof
+	"zkbnb_getAccountProof": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccountProof
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return proof.NewGetAccountProofLogic(ctx, svcCtx).GetAccountProof(&req)
+	},
+	"zkbnb_getNftProof": func(ctx context.Context, svcCtx *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetNftProof
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		return proof.NewGetNftProofLogic(ctx, svcCtx).GetNftProof(&req)
+	},
+}
@@ -0,0 +1,202 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/mapping"
+	"github.com/zeromicro/go-zero/rest/httpx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	Version = "2.0"
+
+	defaultMaxBatchSize = 100
+)
+
+// the params are unmarshalled with the form tags of the requests, the same as the rest routes
+var paramsUnmarshaler = mapping.NewUnmarshaler("form")
+
+type Request struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// a request without id is a notification, which isn't responded
+	Id json.RawMessage `json:"id,omitempty"`
+}
+
+// Response holds exactly one of the result and the error, the result of a successful call
+// is present even if it's null.
+type Response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result"`
+	Error   *Error          `json:"error,omitempty"`
+	Id      json.RawMessage `json:"id"`
+}
+
+type errorResult struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Error   *Error          `json:"error"`
+	Id      json.RawMessage `json:"id"`
+}
+
+func (r *Response) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(&errorResult{JsonRpc: r.JsonRpc, Error: r.Error, Id: r.Id})
+	}
+	type response Response
+	return json.Marshal((*response)(r))
+}
+
+// Error carries the code and the message of a types.Error.
+type Error struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+func newError(err error) *Error {
+	appErr, ok := err.(types2.Error)
+	if !ok {
+		logx.Errorf("rpc call failed: %v", err)
+		appErr = types2.AppErrInternal
+	}
+	return &Error{
+		Code:    appErr.Code(),
+		Message: strings.TrimPrefix(appErr.Error(), fmt.Sprintf("%d: ", appErr.Code())),
+	}
+}
+
+// params are the named params of a call, a single object in an array is accepted as well.
+type params struct {
+	raw json.RawMessage
+}
+
+func (p *params) parse(req interface{}) error {
+	raw := bytes.TrimSpace(p.raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var list []json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil || len(list) > 1 {
+			return types2.AppErrInvalidParam.RefineError("params must be an object")
+		}
+		raw = nil
+		if len(list) == 1 {
+			raw = bytes.TrimSpace(list[0])
+		}
+	}
+
+	m := make(map[string]interface{})
+	if len(raw) > 0 && !bytes.Equal(raw, []byte("null")) {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&m); err != nil {
+			return types2.AppErrInvalidParam.RefineError("params must be an object")
+		}
+	}
+	if err := paramsUnmarshaler.Unmarshal(m, req); err != nil {
+		return types2.AppErrInvalidParam.RefineError(err.Error())
+	}
+	return nil
+}
+
+// RpcHandler serves the logic of the rest routes as JSON-RPC 2.0 methods, the batches are
+// served in order.
+func RpcHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			httpx.OkJson(w, errorResponse(nil, types2.RpcErrParse))
+			return
+		}
+		body = bytes.TrimSpace(body)
+
+		if len(body) == 0 || body[0] != '[' {
+			var req Request
+			if err := json.Unmarshal(body, &req); err != nil {
+				httpx.OkJson(w, errorResponse(nil, types2.RpcErrParse))
+				return
+			}
+			resp := call(r.Context(), svcCtx, &req)
+			if resp == nil {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			httpx.OkJson(w, resp)
+			return
+		}
+
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			httpx.OkJson(w, errorResponse(nil, types2.RpcErrParse))
+			return
+		}
+		if len(batch) == 0 {
+			httpx.OkJson(w, errorResponse(nil, types2.RpcErrInvalidRequest))
+			return
+		}
+		maxBatchSize := svcCtx.Config.Rpc.MaxBatchSize
+		if maxBatchSize <= 0 {
+			maxBatchSize = defaultMaxBatchSize
+		}
+		if len(batch) > maxBatchSize {
+			httpx.OkJson(w, errorResponse(nil, types2.RpcErrTooManyCalls))
+			return
+		}
+
+		resps := make([]*Response, 0, len(batch))
+		for _, raw := range batch {
+			var req Request
+			if err := json.Unmarshal(raw, &req); err != nil {
+				resps = append(resps, errorResponse(nil, types2.RpcErrInvalidRequest))
+				continue
+			}
+			if resp := call(r.Context(), svcCtx, &req); resp != nil {
+				resps = append(resps, resp)
+			}
+		}
+		if len(resps) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		httpx.OkJson(w, resps)
+	}
+}
+
+// call runs the method of the request, nil is returned for the notifications.
+func call(ctx context.Context, svcCtx *svc.ServiceContext, req *Request) *Response {
+	if req.JsonRpc != Version || req.Method == "" {
+		return errorResponse(req.Id, types2.RpcErrInvalidRequest)
+	}
+	m, ok := methods[req.Method]
+	var (
+		result interface{}
+		err    error
+	)
+	if !ok {
+		err = types2.RpcErrMethodNotFound
+	} else {
+		result, err = m(ctx, svcCtx, &params{raw: req.Params})
+	}
+	if req.Id == nil {
+		return nil
+	}
+	if err != nil {
+		return errorResponse(req.Id, err)
+	}
+	return &Response{JsonRpc: Version, Result: result, Id: req.Id}
+}
+
+func errorResponse(id json.RawMessage, err error) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Response{JsonRpc: Version, Error: newError(err), Id: id}
+}
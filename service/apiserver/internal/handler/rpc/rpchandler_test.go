@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+func init() {
+	methods["test_getAccount"] = func(_ context.Context, _ *svc.ServiceContext, p *params) (interface{}, error) {
+		var req types.ReqGetAccount
+		if err := p.parse(&req); err != nil {
+			return nil, err
+		}
+		if req.Value == "0" {
+			return nil, types2.AppErrAccountNotFound
+		}
+		return &req, nil
+	}
+	methods["test_getNothing"] = func(_ context.Context, _ *svc.ServiceContext, _ *params) (interface{}, error) {
+		return nil, nil
+	}
+}
+
+func serve(body string) (int, string) {
+	w := httptest.NewRecorder()
+	RpcHandler(&svc.ServiceContext{})(w, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+	return w.Code, w.Body.String()
+}
+
+func TestRpcHandler(t *testing.T) {
+	code, body := serve(`{"jsonrpc":"2.0","method":"test_getAccount","params":{"by":"index","value":"1"},"id":1}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":{"By":"index","Value":"1","Height":0},"id":1}`, body)
+
+	code, body = serve(`{"jsonrpc":"2.0","method":"test_getAccount","params":{"by":"index","value":"0"},"id":"a"}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":21100,"message":"account not found"},"id":"a"}`, body)
+
+	// the result of a successful call is present even if it's null
+	code, body = serve(`{"jsonrpc":"2.0","method":"test_getNothing","id":1}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":null,"id":1}`, body)
+
+	code, body = serve(`{"jsonrpc":"2.0","method":"test_getAccount","params":{"by":"height","value":"1"},"id":1}`)
+	require.Equal(t, http.StatusOK, code)
+	resp := &Response{}
+	require.NoError(t, json.Unmarshal([]byte(body), resp))
+	assert.Equal(t, types2.AppErrInvalidParam.Code(), resp.Error.Code)
+
+	code, body = serve(`{"jsonrpc":"2.0","method":"test_getAccount"`)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`, body)
+
+	// notification
+	code, _ = serve(`{"jsonrpc":"2.0","method":"test_getAccount","params":{"by":"index","value":"1"}}`)
+	assert.Equal(t, http.StatusNoContent, code)
+}
+
+func TestRpcHandlerBatch(t *testing.T) {
+	code, body := serve(`[
+		{"jsonrpc":"2.0","method":"test_getAccount","params":[{"by":"index","value":"1"}],"id":1},
+		{"jsonrpc":"2.0","method":"test_getAccount","params":{"by":"index","value":"2"}},
+		{"jsonrpc":"2.0","method":"test_unknown","id":2},
+		{"jsonrpc":"1.0","method":"test_getAccount","id":3},
+		1
+	]`)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `[
+		{"jsonrpc":"2.0","result":{"By":"index","Value":"1","Height":0},"id":1},
+		{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":2},
+		{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":3},
+		{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}
+	]`, body)
+
+	code, body = serve(`[]`)
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":null}`, body)
+
+	code, body = serve("[" + strings.Repeat(`{"jsonrpc":"2.0","method":"test_getAccount"},`, defaultMaxBatchSize) + "1]")
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","error":{"code":-32005,"message":"too many calls in the batch"},"id":null}`, body)
+}
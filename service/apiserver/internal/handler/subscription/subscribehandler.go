@@ -0,0 +1,240 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/transaction"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/notifier"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+
+	defaultMaxSubscriptions = 100
+
+	writeTimeout   = 10 * time.Second
+	pongTimeout    = 60 * time.Second
+	pingInterval   = pongTimeout * 9 / 10
+	maxMessageSize = 1024
+)
+
+// Request is the message sent by the clients to manage the subscriptions.
+type Request struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+	Key    string `json:"key,omitempty"`
+}
+
+// Response acknowledges a request, Error is set if the request is rejected.
+type Response struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+	Key    string `json:"key,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the apiserver serves all the origins, the same as the cors of the http apis
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func SubscribeHandler(svcCtx *svc.ServiceContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logx.Errorf("upgrade websocket failed: %v", err)
+			return
+		}
+		c := &connection{
+			svcCtx:     svcCtx,
+			conn:       conn,
+			subscriber: svcCtx.Notifier.NewSubscriber(),
+			sendCh:     make(chan interface{}, 16),
+			quitCh:     make(chan struct{}),
+			writeDone:  make(chan struct{}),
+		}
+		go c.writeLoop()
+		c.readLoop()
+	}
+}
+
+type connection struct {
+	svcCtx     *svc.ServiceContext
+	conn       *websocket.Conn
+	subscriber *notifier.Subscriber
+	// responses to the requests, written by the write loop
+	sendCh chan interface{}
+	// closed once the read loop exits
+	quitCh chan struct{}
+	// closed once the write loop exits
+	writeDone chan struct{}
+}
+
+func (c *connection) readLoop() {
+	defer func() {
+		close(c.quitCh)
+		c.svcCtx.Notifier.Close(c.subscriber)
+		_ = c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	for {
+		var req Request
+		err := c.conn.ReadJSON(&req)
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logx.Errorf("read websocket failed: %v", err)
+			}
+			return
+		}
+		resp, snapshot := c.handle(&req)
+		if !c.send(resp) {
+			return
+		}
+		if snapshot != nil && !c.send(snapshot) {
+			return
+		}
+	}
+}
+
+func (c *connection) send(msg interface{}) bool {
+	select {
+	case c.sendCh <- msg:
+		return true
+	case <-c.writeDone:
+		return false
+	}
+}
+
+func (c *connection) writeLoop() {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		close(c.writeDone)
+		_ = c.conn.Close()
+	}()
+
+	for {
+		var msg interface{}
+		select {
+		case msg = <-c.sendCh:
+		case event := <-c.subscriber.Events():
+			msg = event
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			continue
+		case <-c.subscriber.Dropped():
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "subscriber is too slow"),
+				time.Now().Add(writeTimeout))
+			return
+		case <-c.quitCh:
+			return
+		}
+		_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := c.conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+// handle applies the request, the current state of the tx or the account subscribed is
+// returned as the first event.
+func (c *connection) handle(req *Request) (*Response, *notifier.Event) {
+	resp := &Response{Action: req.Action, Topic: req.Topic, Key: req.Key}
+	switch req.Topic {
+	case notifier.TopicNewBlock, notifier.TopicBlockStatus:
+		if req.Key != "" {
+			resp.Error = types2.AppErrInvalidSubscriptionKey.Error()
+			return resp, nil
+		}
+	case notifier.TopicTxStatus:
+		if req.Key == "" {
+			resp.Error = types2.AppErrInvalidSubscriptionKey.Error()
+			return resp, nil
+		}
+	case notifier.TopicAccount:
+		if _, err := strconv.ParseInt(req.Key, 10, 64); err != nil {
+			resp.Error = types2.AppErrInvalidAccountIndex.Error()
+			return resp, nil
+		}
+	default:
+		resp.Error = types2.AppErrInvalidTopic.Error()
+		return resp, nil
+	}
+
+	switch req.Action {
+	case ActionSubscribe:
+		maxSubscriptions := c.svcCtx.Config.Subscription.MaxSubscriptions
+		if maxSubscriptions <= 0 {
+			maxSubscriptions = defaultMaxSubscriptions
+		}
+		if c.svcCtx.Notifier.SubscriptionCount(c.subscriber) >= maxSubscriptions {
+			resp.Error = types2.AppErrTooManySubscriptions.Error()
+			return resp, nil
+		}
+		snapshot, err := c.snapshot(req)
+		if err != nil {
+			resp.Error = err.Error()
+			return resp, nil
+		}
+		c.svcCtx.Notifier.Subscribe(c.subscriber, req.Topic, req.Key)
+		return resp, snapshot
+	case ActionUnsubscribe:
+		c.svcCtx.Notifier.Unsubscribe(c.subscriber, req.Topic, req.Key)
+		return resp, nil
+	default:
+		resp.Error = types2.AppErrInvalidSubscriptionAction.Error()
+		return resp, nil
+	}
+}
+
+func (c *connection) snapshot(req *Request) (*notifier.Event, error) {
+	switch req.Topic {
+	case notifier.TopicTxStatus:
+		tx, err := transaction.NewGetTxLogic(context.Background(), c.svcCtx).GetTx(&types.ReqGetTx{Hash: req.Key})
+		if err != nil {
+			return nil, err
+		}
+		return &notifier.Event{Topic: req.Topic, Key: req.Key, Data: &notifier.TxStatusEvent{
+			Hash:        tx.Tx.Hash,
+			Status:      tx.Tx.Status,
+			BlockHeight: tx.Tx.BlockHeight,
+		}}, nil
+	case notifier.TopicAccount:
+		accountIndex, _ := strconv.ParseInt(req.Key, 10, 64)
+		height, err := c.svcCtx.BlockModel.GetLatestHeightByStatus(block.StatusPending)
+		if err != nil && err != types2.DbErrNotFound {
+			return nil, types2.AppErrInternal
+		}
+		event, err := notifier.NewAccountEvent(c.svcCtx.AccountHistoryModel, accountIndex, height)
+		if err != nil {
+			if err == types2.DbErrNotFound {
+				return nil, types2.AppErrAccountNotFound
+			}
+			return nil, types2.AppErrInternal
+		}
+		return &notifier.Event{Topic: req.Topic, Key: req.Key, Data: event}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,94 @@
+package account
+
+import (
+	"strconv"
+
+	"github.com/bnb-chain/zkbnb/common/chain"
+	accdao "github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	queryByIndex = "index"
+	queryByName  = "name"
+	queryByPk    = "pk"
+)
+
+// getAccount returns the account queried by its index, name or pk, with the states
+// at the height if it's set.
+func getAccount(svcCtx *svc.ServiceContext, by, value string, height int64) (*types2.AccountInfo, error) {
+	var (
+		index int64
+		err   error
+	)
+	switch by {
+	case queryByIndex:
+		index, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || index < 0 {
+			return nil, types2.AppErrInvalidAccountIndex
+		}
+	case queryByName:
+		index, err = svcCtx.MemCache.GetAccountIndexByName(value)
+	case queryByPk:
+		index, err = svcCtx.MemCache.GetAccountIndexByPk(value)
+	default:
+		return nil, types2.AppErrInvalidParam.RefineError("param by should be index|name|pk")
+	}
+
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrAccountNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	account, err := svcCtx.StateFetcher.GetLatestAccount(index)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrAccountNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	if height > 0 {
+		return getAccountAtHeight(svcCtx, account, height)
+	}
+	return account, nil
+}
+
+// getAccountAtHeight replaces the states of the latest account with the ones at the height,
+// the states are taken from the latest account history at or below the height.
+func getAccountAtHeight(svcCtx *svc.ServiceContext, latest *types2.AccountInfo, height int64) (*types2.AccountInfo, error) {
+	err := utils.CheckHistoryHeight(svcCtx, height)
+	if err != nil {
+		return nil, err
+	}
+	history, err := svcCtx.MemCache.GetAccountHistoryWithFallback(latest.AccountIndex, height, func() (interface{}, error) {
+		return svcCtx.AccountHistoryModel.GetLatestAccountHistory(latest.AccountIndex, height+1)
+	})
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrAccountNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	account, err := chain.ToFormatAccountInfo(&accdao.Account{
+		AccountIndex:    latest.AccountIndex,
+		AccountName:     latest.AccountName,
+		PublicKey:       latest.PublicKey,
+		AccountNameHash: latest.AccountNameHash,
+		L1Address:       latest.L1Address,
+		Nonce:           history.Nonce,
+		CollectionNonce: history.CollectionNonce,
+		AssetInfo:       history.AssetInfo,
+		AssetRoot:       history.AssetRoot,
+		Status:          latest.Status,
+	})
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	account.AccountId = latest.AccountId
+	return account, nil
+}
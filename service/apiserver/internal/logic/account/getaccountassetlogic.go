@@ -0,0 +1,59 @@
+package account
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountAssetLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountAssetLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountAssetLogic {
+	return &GetAccountAssetLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetAccountAssetLogic) GetAccountAsset(req *types.ReqGetAccountAsset) (resp *types.AccountAsset, err error) {
+	assetId := int64(req.AssetId)
+	assetInfo, err := l.svcCtx.MemCache.GetAssetByIdWithFallback(assetId, func() (interface{}, error) {
+		return l.svcCtx.AssetModel.GetAssetById(assetId)
+	})
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrAssetNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	account, err := getAccount(l.svcCtx, req.By, req.Value, req.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	assetPrice, err := l.svcCtx.PriceFetcher.GetCurrencyPrice(l.ctx, assetInfo.AssetSymbol)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	resp = &types.AccountAsset{
+		Id:      req.AssetId,
+		Name:    assetInfo.AssetName,
+		Balance: types2.ZeroBigInt.String(),
+		Price:   strconv.FormatFloat(assetPrice, 'E', -1, 64),
+	}
+	if asset, ok := account.AssetInfo[assetId]; ok && asset.Balance != nil {
+		resp.Balance = asset.Balance.String()
+	}
+	return resp, nil
+}
@@ -13,12 +13,6 @@ import (
 	types2 "github.com/bnb-chain/zkbnb/types"
 )
 
-const (
-	queryByIndex = "index"
-	queryByName  = "name"
-	queryByPk    = "pk"
-)
-
 type GetAccountLogic struct {
 	logx.Logger
 	ctx    context.Context
@@ -34,34 +28,9 @@ func NewGetAccountLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAcc
 }
 
 func (l *GetAccountLogic) GetAccount(req *types.ReqGetAccount) (resp *types.Account, err error) {
-	index := int64(0)
-	switch req.By {
-	case queryByIndex:
-		index, err = strconv.ParseInt(req.Value, 10, 64)
-		if err != nil || index < 0 {
-			return nil, types2.AppErrInvalidAccountIndex
-		}
-	case queryByName:
-		index, err = l.svcCtx.MemCache.GetAccountIndexByName(req.Value)
-	case queryByPk:
-		index, err = l.svcCtx.MemCache.GetAccountIndexByPk(req.Value)
-	default:
-		return nil, types2.AppErrInvalidParam.RefineError("param by should be index|name|pk")
-	}
-
-	if err != nil {
-		if err == types2.DbErrNotFound {
-			return nil, types2.AppErrAccountNotFound
-		}
-		return nil, types2.AppErrInternal
-	}
-
-	account, err := l.svcCtx.StateFetcher.GetLatestAccount(index)
+	account, err := getAccount(l.svcCtx, req.By, req.Value, req.Height)
 	if err != nil {
-		if err == types2.DbErrNotFound {
-			return nil, types2.AppErrAccountNotFound
-		}
-		return nil, types2.AppErrInternal
+		return nil, err
 	}
 
 	maxAssetId, err := l.svcCtx.AssetModel.GetMaxAssetId()
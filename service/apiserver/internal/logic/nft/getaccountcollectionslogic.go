@@ -0,0 +1,96 @@
+package nft
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountCollectionsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountCollectionsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountCollectionsLogic {
+	return &GetAccountCollectionsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetAccountCollections returns the collections created by the account, the names and the
+// introductions are taken from the CreateCollection txs.
+func (l *GetAccountCollectionsLogic) GetAccountCollections(req *types.ReqGetAccountCollections) (resp *types.Collections, err error) {
+	resp = &types.Collections{
+		Collections: make([]*types.Collection, 0, int64(req.Limit)),
+	}
+
+	accountIndex := int64(0)
+	switch req.By {
+	case queryByAccountIndex:
+		accountIndex, err = strconv.ParseInt(req.Value, 10, 64)
+		if err != nil || accountIndex < 0 {
+			return nil, types2.AppErrInvalidAccountIndex
+		}
+	case queryByAccountName:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByName(req.Value)
+	case queryByAccountPk:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByPk(req.Value)
+	default:
+		return nil, types2.AppErrInvalidParam.RefineError("param by should be account_index|account_name|account_pk")
+	}
+
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	options := []tx.GetTxOptionFunc{tx.GetTxWithTypes([]int64{types2.TxTypeCreateCollection})}
+	total, err := l.svcCtx.TxModel.GetTxsCountByAccountIndex(accountIndex, options...)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	txs, err := l.svcCtx.TxModel.GetTxsByAccountIndex(accountIndex, int64(req.Limit), int64(req.Offset), options...)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	accountName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(accountIndex)
+	for _, collectionTx := range txs {
+		txInfo, err := types2.ParseCreateCollectionTxInfo(collectionTx.TxInfo)
+		if err != nil {
+			logx.Errorf("parse create collection tx %s failed: %v", collectionTx.TxHash, err)
+			return nil, types2.AppErrInternal
+		}
+		resp.Collections = append(resp.Collections, &types.Collection{
+			Id:           collectionTx.CollectionId,
+			AccountIndex: accountIndex,
+			AccountName:  accountName,
+			Name:         txInfo.Name,
+			Introduction: txInfo.Introduction,
+			TxHash:       collectionTx.TxHash,
+			BlockHeight:  collectionTx.BlockHeight,
+			CreatedAt:    collectionTx.CreatedAt.Unix(),
+		})
+	}
+	return resp, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/zeromicro/go-zero/core/logx"
 
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
 	types2 "github.com/bnb-chain/zkbnb/types"
@@ -58,6 +59,10 @@ func (l *GetAccountNftsLogic) GetAccountNfts(req *types.ReqGetAccountNfts) (resp
 		return nil, types2.AppErrInternal
 	}
 
+	if req.Height > 0 {
+		return l.getAccountNftsAtHeight(accountIndex, req)
+	}
+
 	total, err := l.svcCtx.NftModel.GetNftsCountByAccountIndex(accountIndex)
 	if err != nil {
 		if err != types2.DbErrNotFound {
@@ -93,3 +98,52 @@ func (l *GetAccountNftsLogic) GetAccountNfts(req *types.ReqGetAccountNfts) (resp
 	}
 	return resp, nil
 }
+
+// getAccountNftsAtHeight returns the nfts owned by the account at the height, the nfts are
+// taken from the latest nft histories at or below the height.
+func (l *GetAccountNftsLogic) getAccountNftsAtHeight(accountIndex int64, req *types.ReqGetAccountNfts) (*types.Nfts, error) {
+	err := utils.CheckHistoryHeight(l.svcCtx, req.Height)
+	if err != nil {
+		return nil, err
+	}
+	resp := &types.Nfts{
+		Nfts: make([]*types.Nft, 0, int64(req.Limit)),
+	}
+
+	total, err := l.svcCtx.MemCache.GetAccountNftCountWithFallback(accountIndex, req.Height, func() (interface{}, error) {
+		return l.svcCtx.NftHistoryModel.GetNftsCountByOwnerAtHeight(accountIndex, req.Height)
+	})
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	nfts, err := l.svcCtx.MemCache.GetAccountNftsWithFallback(accountIndex, req.Height, int64(req.Offset), int64(req.Limit), func() (interface{}, error) {
+		return l.svcCtx.NftHistoryModel.GetNftsByOwnerAtHeight(accountIndex, req.Height, int64(req.Limit), int64(req.Offset))
+	})
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	for _, nft := range nfts {
+		creatorName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(nft.CreatorAccountIndex)
+		ownerName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(nft.OwnerAccountIndex)
+		resp.Nfts = append(resp.Nfts, &types.Nft{
+			Index:               nft.NftIndex,
+			CreatorAccountIndex: nft.CreatorAccountIndex,
+			CreatorAccountName:  creatorName,
+			OwnerAccountIndex:   nft.OwnerAccountIndex,
+			OwnerAccountName:    ownerName,
+			ContentHash:         nft.NftContentHash,
+			L1Address:           nft.NftL1Address,
+			L1TokenId:           nft.NftL1TokenId,
+			CreatorTreasuryRate: nft.CreatorTreasuryRate,
+			CollectionId:        nft.CollectionId,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package nft
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetCollectionNftsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetCollectionNftsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetCollectionNftsLogic {
+	return &GetCollectionNftsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetCollectionNftsLogic) GetCollectionNfts(req *types.ReqGetCollectionNfts) (resp *types.Nfts, err error) {
+	if req.AccountIndex < 0 {
+		return nil, types2.AppErrInvalidAccountIndex
+	}
+	if req.CollectionId < 0 {
+		return nil, types2.AppErrInvalidCollectionId
+	}
+	resp = &types.Nfts{
+		Nfts: make([]*types.Nft, 0, int64(req.Limit)),
+	}
+
+	total, err := l.svcCtx.NftModel.GetNftsCountByCollection(req.AccountIndex, req.CollectionId)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	nfts, err := l.svcCtx.NftModel.GetNftsByCollection(req.AccountIndex, req.CollectionId, int64(req.Limit), int64(req.Offset))
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	creatorName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(req.AccountIndex)
+	for _, nft := range nfts {
+		ownerName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(nft.OwnerAccountIndex)
+		resp.Nfts = append(resp.Nfts, &types.Nft{
+			Index:               nft.NftIndex,
+			CreatorAccountIndex: nft.CreatorAccountIndex,
+			CreatorAccountName:  creatorName,
+			OwnerAccountIndex:   nft.OwnerAccountIndex,
+			OwnerAccountName:    ownerName,
+			ContentHash:         nft.NftContentHash,
+			L1Address:           nft.NftL1Address,
+			L1TokenId:           nft.NftL1TokenId,
+			CreatorTreasuryRate: nft.CreatorTreasuryRate,
+			CollectionId:        nft.CollectionId,
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,54 @@
+package nft
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetNftLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetNftLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetNftLogic {
+	return &GetNftLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetNftLogic) GetNft(req *types.ReqGetNft) (resp *types.Nft, err error) {
+	if req.Index < 0 {
+		return nil, types2.AppErrInvalidNftIndex
+	}
+
+	nft, err := l.svcCtx.StateFetcher.GetLatestNft(req.Index)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrNftNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	creatorName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(nft.CreatorAccountIndex)
+	ownerName, _ := l.svcCtx.MemCache.GetAccountNameByIndex(nft.OwnerAccountIndex)
+	return &types.Nft{
+		Index:               nft.NftIndex,
+		CreatorAccountIndex: nft.CreatorAccountIndex,
+		CreatorAccountName:  creatorName,
+		OwnerAccountIndex:   nft.OwnerAccountIndex,
+		OwnerAccountName:    ownerName,
+		ContentHash:         nft.NftContentHash,
+		L1Address:           nft.NftL1Address,
+		L1TokenId:           nft.NftL1TokenId,
+		CreatorTreasuryRate: nft.CreatorTreasuryRate,
+		CollectionId:        nft.CollectionId,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package nft
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetNftTxsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetNftTxsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetNftTxsLogic {
+	return &GetNftTxsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetNftTxsLogic) GetNftTxs(req *types.ReqGetNftTxs) (resp *types.Txs, err error) {
+	if req.Index < 0 {
+		return nil, types2.AppErrInvalidNftIndex
+	}
+	resp = &types.Txs{
+		Txs: make([]*types.Tx, 0, req.Limit),
+	}
+
+	total, err := l.svcCtx.TxModel.GetTxsCountByNftIndex(req.Index)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = uint32(total)
+	if total == 0 || total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	txs, err := l.svcCtx.TxModel.GetTxsByNftIndex(req.Index, int64(req.Limit), int64(req.Offset))
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	for _, dbTx := range txs {
+		tx := utils.ConvertTx(dbTx)
+		tx.AccountName, _ = l.svcCtx.MemCache.GetAccountNameByIndex(tx.AccountIndex)
+		tx.AssetName, _ = l.svcCtx.MemCache.GetAssetNameById(tx.AssetId)
+		if tx.ToAccountIndex >= 0 {
+			tx.ToAccountName, _ = l.svcCtx.MemCache.GetAccountNameByIndex(tx.ToAccountIndex)
+		}
+		resp.Txs = append(resp.Txs, tx)
+	}
+	return resp, nil
+}
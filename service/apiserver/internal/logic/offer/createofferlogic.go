@@ -0,0 +1,127 @@
+package offer
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	offerdao "github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/offerbook"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type CreateOfferLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewCreateOfferLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreateOfferLogic {
+	return &CreateOfferLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreateOfferLogic) CreateOffer(req *types.ReqCreateOffer) (resp *types.Offer, err error) {
+	if l.svcCtx.Config.ReadOnly.Enabled {
+		return nil, types2.AppErrReadOnly
+	}
+	if l.svcCtx.OfferBook == nil {
+		return nil, types2.AppErrOfferBookDisabled
+	}
+
+	txInfo, err := types2.ParseOfferTxInfo(req.TxInfo)
+	if err != nil {
+		return nil, types2.AppErrInvalidTxInfo
+	}
+	if err := txInfo.Validate(); err != nil {
+		return nil, types2.AppErrInvalidTxField.RefineError(err.Error())
+	}
+	if txInfo.ExpiredAt <= time.Now().UnixMilli() {
+		return nil, types2.AppErrInvalidExpireTime
+	}
+	if txInfo.AssetId != types2.GasAssets[0] && txInfo.AssetId != types2.GasAssets[1] {
+		return nil, types2.AppErrInvalidAssetOfOffer
+	}
+
+	account, err := l.svcCtx.StateFetcher.GetLatestAccount(txInfo.AccountIndex)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrAccountNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	if err := txInfo.VerifySignature(account.PublicKey); err != nil {
+		return nil, types2.AppErrInvalidOfferSig
+	}
+	if offerbook.IsOfferCanceledOrFinalized(account, txInfo.OfferId) {
+		return nil, types2.AppErrInvalidOfferState
+	}
+
+	nft, err := l.svcCtx.StateFetcher.GetLatestNft(txInfo.NftIndex)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrNftNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	if txInfo.Type == types2.SellOfferType {
+		if nft.OwnerAccountIndex != txInfo.AccountIndex {
+			return nil, types2.AppErrSellerNotOwner
+		}
+	} else {
+		if nft.OwnerAccountIndex == txInfo.AccountIndex {
+			return nil, types2.AppErrSameBuyerAndSeller
+		}
+		asset, ok := account.AssetInfo[txInfo.AssetId]
+		if !ok || asset.Balance == nil || asset.Balance.Cmp(txInfo.AssetAmount) < 0 {
+			return nil, types2.AppErrBuyerBalanceNotEnough
+		}
+	}
+
+	o, err := l.svcCtx.OfferModel.GetOffer(txInfo.AccountIndex, txInfo.OfferId)
+	if err != nil && err != types2.DbErrNotFound {
+		return nil, types2.AppErrInternal
+	}
+	// the offer id can be listed again once the previous offer with it is expired,
+	// a canceled or finalized one is rejected by the bitmap above
+	if o != nil && o.Status == offerdao.StatusActive && o.ExpiredAt > time.Now().UnixMilli() {
+		return nil, types2.AppErrOfferAlreadyListed
+	}
+
+	newOffer := &offerdao.Offer{
+		OfferType:           txInfo.Type,
+		OfferId:             txInfo.OfferId,
+		AccountIndex:        txInfo.AccountIndex,
+		NftIndex:            txInfo.NftIndex,
+		CreatorAccountIndex: nft.CreatorAccountIndex,
+		CollectionId:        nft.CollectionId,
+		AssetId:             txInfo.AssetId,
+		AssetAmount:         txInfo.AssetAmount.String(),
+		TreasuryRate:        txInfo.TreasuryRate,
+		ListedAt:            txInfo.ListedAt,
+		ExpiredAt:           txInfo.ExpiredAt,
+		TxInfo:              req.TxInfo,
+		Status:              offerdao.StatusActive,
+	}
+	if o != nil {
+		newOffer.ID = o.ID
+		newOffer.CreatedAt = o.CreatedAt
+		err = l.svcCtx.OfferModel.UpdateOffer(newOffer)
+	} else {
+		err = l.svcCtx.OfferModel.CreateOffer(newOffer)
+	}
+	if err == types2.DbErrDuplicateOffer {
+		return nil, types2.AppErrOfferAlreadyListed
+	}
+	if err != nil {
+		logx.Errorf("fail to save offer %d of account %d: %s", txInfo.OfferId, txInfo.AccountIndex, err.Error())
+		return nil, types2.AppErrInternal
+	}
+	return convertOffer(l.svcCtx, newOffer), nil
+}
@@ -0,0 +1,199 @@
+package offer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/zkbnb-crypto/ecc/ztwistededwards/tebn254"
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	offerdao "github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/offerbook"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type mockAccountModel struct {
+	account.AccountModel
+}
+
+func (m *mockAccountModel) GetAccountByIndex(_ int64) (*account.Account, error) {
+	return nil, types2.DbErrNotFound
+}
+
+type mockOfferModel struct {
+	offerdao.OfferModel
+	offers map[int64]*offerdao.Offer
+	// the offer ids listed by a concurrent request once GetOffer is done
+	listing map[int64]bool
+}
+
+func (m *mockOfferModel) GetOffer(_ int64, offerId int64) (*offerdao.Offer, error) {
+	o, ok := m.offers[offerId]
+	if !ok {
+		return nil, types2.DbErrNotFound
+	}
+	return o, nil
+}
+
+func (m *mockOfferModel) CreateOffer(o *offerdao.Offer) error {
+	if _, ok := m.offers[o.OfferId]; ok || m.listing[o.OfferId] {
+		return types2.DbErrDuplicateOffer
+	}
+	o.ID = uint(len(m.offers) + 1)
+	m.offers[o.OfferId] = o
+	return nil
+}
+
+func (m *mockOfferModel) UpdateOffer(o *offerdao.Offer) error {
+	m.offers[o.OfferId] = o
+	return nil
+}
+
+type mockStateFetcher struct {
+	account *types2.AccountInfo
+	nft     *types2.NftInfo
+}
+
+func (m *mockStateFetcher) GetLatestAccount(_ int64) (*types2.AccountInfo, error) {
+	return m.account, nil
+}
+
+func (m *mockStateFetcher) GetLatestNft(_ int64) (*types2.NftInfo, error) {
+	return m.nft, nil
+}
+
+// testOffers signs the offers of account 1, which owns 100 of asset 0 and nft 0, nft 1 is
+// owned by account 2.
+type testOffers struct {
+	key          *tebn254.PrivateKey
+	svcCtx       *svc.ServiceContext
+	offerModel   *mockOfferModel
+	stateFetcher *mockStateFetcher
+}
+
+func newTestOffers(t *testing.T) *testOffers {
+	key, err := tebn254.GenerateEddsaPrivateKey("offer seed")
+	require.NoError(t, err)
+	offerModel := &mockOfferModel{offers: make(map[int64]*offerdao.Offer), listing: make(map[int64]bool)}
+	stateFetcher := &mockStateFetcher{
+		account: &types2.AccountInfo{
+			AccountIndex: 1,
+			PublicKey:    hex.EncodeToString(key.PublicKey.Bytes()),
+			AssetInfo: map[int64]*types2.AccountAsset{
+				0: {AssetId: 0, Balance: big.NewInt(100), OfferCanceledOrFinalized: big.NewInt(0)},
+			},
+		},
+		nft: &types2.NftInfo{NftIndex: 0, OwnerAccountIndex: 1},
+	}
+	return &testOffers{
+		key: key,
+		svcCtx: &svc.ServiceContext{
+			MemCache:     cache.MustNewMemCache(&mockAccountModel{}, nil, 10, 10, 10, 10, 10, 100, 100),
+			OfferModel:   offerModel,
+			StateFetcher: stateFetcher,
+			OfferBook:    offerbook.NewOfferBook(offerModel, nil, stateFetcher, 0),
+		},
+		offerModel:   offerModel,
+		stateFetcher: stateFetcher,
+	}
+}
+
+func (o *testOffers) sign(t *testing.T, txInfo *txtypes.OfferTxInfo) string {
+	msgHash, err := txInfo.Hash(mimc.NewMiMC())
+	require.NoError(t, err)
+	txInfo.Sig, err = o.key.Sign(msgHash, mimc.NewMiMC())
+	require.NoError(t, err)
+	info, err := json.Marshal(txInfo)
+	require.NoError(t, err)
+	return string(info)
+}
+
+func (o *testOffers) create(t *testing.T, txInfo *txtypes.OfferTxInfo) (*types.Offer, error) {
+	return NewCreateOfferLogic(context.Background(), o.svcCtx).CreateOffer(&types.ReqCreateOffer{TxInfo: o.sign(t, txInfo)})
+}
+
+func newOfferTxInfo(offerType, offerId, amount int64) *txtypes.OfferTxInfo {
+	now := time.Now()
+	return &txtypes.OfferTxInfo{
+		Type:         offerType,
+		OfferId:      offerId,
+		AccountIndex: 1,
+		AssetId:      0,
+		AssetAmount:  big.NewInt(amount),
+		ListedAt:     now.UnixMilli(),
+		ExpiredAt:    now.Add(time.Hour).UnixMilli(),
+	}
+}
+
+func TestCreateOffer(t *testing.T) {
+	o := newTestOffers(t)
+	resp, err := o.create(t, newOfferTxInfo(types2.SellOfferType, 0, 10))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), resp.OfferId)
+	assert.Equal(t, int64(offerdao.StatusActive), resp.Status)
+
+	// the amount is changed once the offer is signed
+	txInfo := newOfferTxInfo(types2.SellOfferType, 1, 10)
+	o.sign(t, txInfo)
+	txInfo.AssetAmount = big.NewInt(20)
+	tampered, err := json.Marshal(txInfo)
+	require.NoError(t, err)
+	_, err = NewCreateOfferLogic(context.Background(), o.svcCtx).CreateOffer(&types.ReqCreateOffer{TxInfo: string(tampered)})
+	assert.Equal(t, types2.AppErrInvalidOfferSig, err)
+
+	txInfo = newOfferTxInfo(types2.SellOfferType, 1, 10)
+	txInfo.ExpiredAt = time.Now().Add(-time.Second).UnixMilli()
+	_, err = o.create(t, txInfo)
+	assert.Equal(t, types2.AppErrInvalidExpireTime, err)
+
+	o.stateFetcher.nft = &types2.NftInfo{NftIndex: 1, OwnerAccountIndex: 2}
+	txInfo = newOfferTxInfo(types2.BuyOfferType, 1, 200)
+	txInfo.NftIndex = 1
+	_, err = o.create(t, txInfo)
+	assert.Equal(t, types2.AppErrBuyerBalanceNotEnough, err)
+	txInfo.AssetAmount = big.NewInt(100)
+	_, err = o.create(t, txInfo)
+	assert.NoError(t, err)
+
+	o.svcCtx.OfferBook = nil
+	_, err = o.create(t, newOfferTxInfo(types2.BuyOfferType, 2, 10))
+	assert.Equal(t, types2.AppErrOfferBookDisabled, err)
+}
+
+func TestCreateOfferRelisting(t *testing.T) {
+	o := newTestOffers(t)
+	_, err := o.create(t, newOfferTxInfo(types2.SellOfferType, 0, 10))
+	require.NoError(t, err)
+	_, err = o.create(t, newOfferTxInfo(types2.SellOfferType, 0, 20))
+	assert.Equal(t, types2.AppErrOfferAlreadyListed, err)
+
+	// the offer id is listed by a concurrent request after it's looked up
+	o.offerModel.listing[1] = true
+	_, err = o.create(t, newOfferTxInfo(types2.SellOfferType, 1, 10))
+	assert.Equal(t, types2.AppErrOfferAlreadyListed, err)
+
+	// the offer id is listed again once the offer is expired
+	listed := o.offerModel.offers[0]
+	listed.ExpiredAt = time.Now().Add(-time.Second).UnixMilli()
+	resp, err := o.create(t, newOfferTxInfo(types2.SellOfferType, 0, 20))
+	require.NoError(t, err)
+	assert.Equal(t, "20", resp.AssetAmount)
+	assert.Equal(t, listed.ID, o.offerModel.offers[0].ID)
+
+	// the offer id used on chain can't be listed again
+	o.stateFetcher.account.AssetInfo[0].OfferCanceledOrFinalized = big.NewInt(1)
+	o.offerModel.offers[0].Status = offerdao.StatusFinalized
+	_, err = o.create(t, newOfferTxInfo(types2.SellOfferType, 0, 30))
+	assert.Equal(t, types2.AppErrInvalidOfferState, err)
+}
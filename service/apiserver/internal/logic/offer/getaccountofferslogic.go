@@ -0,0 +1,55 @@
+package offer
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	offerdao "github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountOffersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountOffersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountOffersLogic {
+	return &GetAccountOffersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetAccountOffersLogic) GetAccountOffers(req *types.ReqGetAccountOffers) (resp *types.Offers, err error) {
+	accountIndex := int64(0)
+	switch req.By {
+	case queryByAccountIndex:
+		accountIndex, err = strconv.ParseInt(req.Value, 10, 64)
+		if err != nil || accountIndex < 0 {
+			return nil, types2.AppErrInvalidAccountIndex
+		}
+	case queryByAccountName:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByName(req.Value)
+	case queryByAccountPk:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByPk(req.Value)
+	default:
+		return nil, types2.AppErrInvalidParam.RefineError("param by should be account_index|account_name|account_pk")
+	}
+
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return &types.Offers{Offers: make([]*types.Offer, 0)}, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	options := append(offerOptions(req.Type), offerdao.GetOfferWithAccountIndex(accountIndex))
+	return getOffers(l.svcCtx, time.Now().UnixMilli(), req.Offset, req.Limit, options...)
+}
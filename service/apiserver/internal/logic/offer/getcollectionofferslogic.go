@@ -0,0 +1,38 @@
+package offer
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	offerdao "github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetCollectionOffersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetCollectionOffersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetCollectionOffersLogic {
+	return &GetCollectionOffersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetCollectionOffersLogic) GetCollectionOffers(req *types.ReqGetCollectionOffers) (resp *types.Offers, err error) {
+	if req.AccountIndex < 0 {
+		return nil, types2.AppErrInvalidAccountIndex
+	}
+	if req.CollectionId < 0 {
+		return nil, types2.AppErrInvalidCollectionId
+	}
+	options := append(offerOptions(req.Type), offerdao.GetOfferWithCollection(req.AccountIndex, req.CollectionId))
+	return getOffers(l.svcCtx, time.Now().UnixMilli(), req.Offset, req.Limit, options...)
+}
@@ -0,0 +1,35 @@
+package offer
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	offerdao "github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetNftOffersLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetNftOffersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetNftOffersLogic {
+	return &GetNftOffersLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetNftOffersLogic) GetNftOffers(req *types.ReqGetNftOffers) (resp *types.Offers, err error) {
+	if req.NftIndex < 0 {
+		return nil, types2.AppErrInvalidNftIndex
+	}
+	options := append(offerOptions(req.Type), offerdao.GetOfferWithNftIndex(req.NftIndex))
+	return getOffers(l.svcCtx, time.Now().UnixMilli(), req.Offset, req.Limit, options...)
+}
@@ -0,0 +1,43 @@
+package offer
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetOfferLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetOfferLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetOfferLogic {
+	return &GetOfferLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetOfferLogic) GetOffer(req *types.ReqGetOffer) (resp *types.Offer, err error) {
+	if req.AccountIndex < 0 {
+		return nil, types2.AppErrInvalidAccountIndex
+	}
+	if req.OfferId < 0 {
+		return nil, types2.AppErrInvalidOfferId
+	}
+
+	o, err := l.svcCtx.OfferModel.GetOffer(req.AccountIndex, req.OfferId)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrOfferNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	return convertOffer(l.svcCtx, o), nil
+}
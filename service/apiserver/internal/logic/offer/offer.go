@@ -0,0 +1,78 @@
+package offer
+
+import (
+	offerdao "github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	queryByAccountIndex = "account_index"
+	queryByAccountName  = "account_name"
+	queryByAccountPk    = "account_pk"
+
+	offerTypeBuy  = "buy"
+	offerTypeSell = "sell"
+)
+
+func offerOptions(offerType string) []offerdao.GetOfferOptionFunc {
+	switch offerType {
+	case offerTypeBuy:
+		return []offerdao.GetOfferOptionFunc{offerdao.GetOfferWithType(types2.BuyOfferType)}
+	case offerTypeSell:
+		return []offerdao.GetOfferOptionFunc{offerdao.GetOfferWithType(types2.SellOfferType)}
+	}
+	return nil
+}
+
+func convertOffer(svcCtx *svc.ServiceContext, o *offerdao.Offer) *types.Offer {
+	accountName, _ := svcCtx.MemCache.GetAccountNameByIndex(o.AccountIndex)
+	return &types.Offer{
+		Type:                o.OfferType,
+		OfferId:             o.OfferId,
+		AccountIndex:        o.AccountIndex,
+		AccountName:         accountName,
+		NftIndex:            o.NftIndex,
+		CreatorAccountIndex: o.CreatorAccountIndex,
+		CollectionId:        o.CollectionId,
+		AssetId:             o.AssetId,
+		AssetAmount:         o.AssetAmount,
+		TreasuryRate:        o.TreasuryRate,
+		ListedAt:            o.ListedAt,
+		ExpiredAt:           o.ExpiredAt,
+		Status:              int64(o.Status),
+		TxInfo:              o.TxInfo,
+		L2TxHash:            o.L2TxHash,
+	}
+}
+
+// getOffers returns a page of the active offers matching the options.
+func getOffers(svcCtx *svc.ServiceContext, now int64, offset, limit uint16,
+	options ...offerdao.GetOfferOptionFunc) (*types.Offers, error) {
+	resp := &types.Offers{
+		Offers: make([]*types.Offer, 0, int64(limit)),
+	}
+
+	total, err := svcCtx.OfferModel.GetActiveOffersCount(now, options...)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(offset) {
+		return resp, nil
+	}
+
+	offers, err := svcCtx.OfferModel.GetActiveOffers(now, int64(limit), int64(offset), options...)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+	for _, o := range offers {
+		resp.Offers = append(resp.Offers, convertOffer(svcCtx, o))
+	}
+	return resp, nil
+}
@@ -0,0 +1,96 @@
+package priorityrequest
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	accdao "github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountPriorityRequestsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountPriorityRequestsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountPriorityRequestsLogic {
+	return &GetAccountPriorityRequestsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetAccountPriorityRequestsLogic) GetAccountPriorityRequests(req *types.ReqGetAccountPriorityRequests) (resp *types.PriorityRequests, err error) {
+	resp = &types.PriorityRequests{
+		PriorityRequests: make([]*types.PriorityRequest, 0, int64(req.Limit)),
+	}
+
+	accountIndex := int64(0)
+	switch req.By {
+	case queryByAccountIndex:
+		accountIndex, err = strconv.ParseInt(req.Value, 10, 64)
+		if err != nil || accountIndex < 0 {
+			return nil, types2.AppErrInvalidAccountIndex
+		}
+	case queryByAccountName:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByName(req.Value)
+	case queryByAccountPk:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByPk(req.Value)
+	default:
+		return nil, types2.AppErrInvalidParam.RefineError("param by should be account_index|account_name|account_pk")
+	}
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	account, err := l.svcCtx.MemCache.GetAccountWithFallback(accountIndex, func() (interface{}, error) {
+		return l.svcCtx.AccountModel.GetAccountByIndex(accountIndex)
+	})
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	return l.getPriorityRequests(account, req.Offset, req.Limit, resp)
+}
+
+func (l *GetAccountPriorityRequestsLogic) getPriorityRequests(account *accdao.Account, offset, limit uint16,
+	resp *types.PriorityRequests) (*types.PriorityRequests, error) {
+	total, err := l.svcCtx.PriorityRequestModel.GetPriorityRequestsCountByAccountNameHash(account.AccountNameHash)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = total
+	if total == 0 || total <= int64(offset) {
+		return resp, nil
+	}
+
+	requests, err := l.svcCtx.PriorityRequestModel.GetPriorityRequestsByAccountNameHash(account.AccountNameHash,
+		int64(limit), int64(offset))
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+	for _, request := range requests {
+		r, err := convertPriorityRequest(l.svcCtx, request)
+		if err != nil {
+			return nil, err
+		}
+		resp.PriorityRequests = append(resp.PriorityRequests, r)
+	}
+	return resp, nil
+}
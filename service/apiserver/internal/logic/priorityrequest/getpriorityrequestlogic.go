@@ -0,0 +1,51 @@
+package priorityrequest
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetPriorityRequestLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetPriorityRequestLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetPriorityRequestLogic {
+	return &GetPriorityRequestLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetPriorityRequestLogic) GetPriorityRequest(req *types.ReqGetPriorityRequest) (resp *types.PriorityRequest, err error) {
+	hash := req.L1TxHash
+	if !strings.HasPrefix(hash, "0x") {
+		hash = "0x" + hash
+	}
+	if len(hash) != 2+2*common.HashLength {
+		return nil, types2.AppErrInvalidL1TxHash
+	}
+	if _, err := hex.DecodeString(hash[2:]); err != nil {
+		return nil, types2.AppErrInvalidL1TxHash
+	}
+
+	// the hashes are stored in lower case with the 0x prefix by the monitor
+	request, err := l.svcCtx.PriorityRequestModel.GetPriorityRequestByL1TxHash(common.HexToHash(hash).Hex())
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrPriorityRequestNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	return convertPriorityRequest(l.svcCtx, request)
+}
@@ -0,0 +1,63 @@
+package priorityrequest
+
+import (
+	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	queryByAccountIndex = "account_index"
+	queryByAccountName  = "account_name"
+	queryByAccountPk    = "account_pk"
+
+	// the request is seen by the monitor, but it's not handled to a L2 tx yet
+	stageMonitored = "monitored"
+)
+
+// stages are the stages of a priority request in order, the progress of a request is
+// estimated by the stages it has gone through.
+var stages = []string{stageMonitored, utils.StagePending, utils.StagePacked, utils.StageCommitted, utils.StageVerified}
+
+func progress(stage string) int64 {
+	for i, s := range stages {
+		if s == stage {
+			return int64(i+1) * 100 / int64(len(stages))
+		}
+	}
+	return 0
+}
+
+func convertPriorityRequest(svcCtx *svc.ServiceContext, request *priorityrequest.PriorityRequest) (*types.PriorityRequest, error) {
+	resp := &types.PriorityRequest{
+		RequestId:       request.RequestId,
+		TxType:          request.TxType,
+		L1TxHash:        request.L1TxHash,
+		L1BlockHeight:   request.L1BlockHeight,
+		SenderAddress:   request.SenderAddress,
+		ExpirationBlock: request.ExpirationBlock,
+		Status:          int64(request.Status),
+		L2TxHash:        request.L2TxHash,
+		AccountIndex:    types2.NilAccountIndex,
+		BlockHeight:     types2.NilBlockHeight,
+		Stage:           stageMonitored,
+	}
+
+	if request.Status == priorityrequest.HandledStatus {
+		resp.Stage = utils.StagePending
+		tx, err := utils.GetLatestTx(svcCtx, request.L2TxHash)
+		if err != nil && err != types2.DbErrNotFound {
+			return nil, types2.AppErrInternal
+		}
+		if tx != nil {
+			resp.L2TxStatus = int64(tx.TxStatus)
+			resp.AccountIndex = tx.AccountIndex
+			resp.BlockHeight = tx.BlockHeight
+			resp.Stage = utils.TxStage(tx.TxStatus)
+		}
+	}
+	resp.Progress = progress(resp.Stage)
+	return resp, nil
+}
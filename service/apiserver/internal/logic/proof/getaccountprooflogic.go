@@ -0,0 +1,133 @@
+package proof
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	"github.com/bnb-chain/zkbnb/tree"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetAccountProofLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountProofLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountProofLogic {
+	return &GetAccountProofLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetAccountProofLogic) GetAccountProof(req *types.ReqGetAccountProof) (resp *types.AccountProof, err error) {
+	if req.Index < 0 || req.Index >= 1<<tree.AccountTreeHeight {
+		return nil, types2.AppErrInvalidAccountIndex
+	}
+	if req.AssetId < 0 || req.AssetId >= 1<<tree.AssetTreeHeight {
+		return nil, types2.AppErrInvalidAssetId
+	}
+	blockInfo, err := getVerifiedBlock(l.svcCtx, req.Height)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := getStateRoots(l.svcCtx, blockInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	accountHistory, err := l.svcCtx.AccountHistoryModel.GetLatestAccountHistory(req.Index, roots.height+1)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrAccountNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	accountInfo, err := l.svcCtx.AccountModel.GetAccountByIndex(req.Index)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrAccountNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	formatAccount, err := chain.ToFormatAccountInfo(&account.Account{
+		AccountIndex: req.Index,
+		AssetInfo:    accountHistory.AssetInfo,
+	})
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	// the asset tree is versioned by its own commits, find the version of the asset root at the height
+	assetTree := tree.NewVersionedAccountAssetTree(l.svcCtx.TreeCtx, req.Index)
+	assetRoot := common.FromHex(accountHistory.AssetRoot)
+	assetVersion, err := assetTree.FindVersion(assetRoot)
+	if err != nil {
+		return nil, treeError(err)
+	}
+	assetProof, err := assetTree.GetProof(uint64(req.AssetId), assetVersion)
+	if err != nil {
+		return nil, treeError(err)
+	}
+	asset := &types.AssetLeaf{
+		Id:                       req.AssetId,
+		Balance:                  types2.ZeroBigInt.String(),
+		OfferCanceledOrFinalized: types2.ZeroBigInt.String(),
+	}
+	assetLeaf := tree.NilAccountAssetNodeHash
+	if accountAsset, ok := formatAccount.AssetInfo[req.AssetId]; ok {
+		asset.Balance = accountAsset.Balance.String()
+		asset.OfferCanceledOrFinalized = accountAsset.OfferCanceledOrFinalized.String()
+		assetLeaf, err = tree.ComputeAccountAssetLeafHash(asset.Balance, asset.OfferCanceledOrFinalized)
+		if err != nil {
+			return nil, types2.AppErrInternal
+		}
+	}
+	err = checkProof(assetProof, assetLeaf, assetRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	accountProof, err := tree.NewVersionedAccountTree(l.svcCtx.TreeCtx).GetProof(uint64(req.Index), bsmt.Version(roots.height))
+	if err != nil {
+		return nil, treeError(err)
+	}
+	accountLeaf, err := tree.ComputeAccountLeafHash(accountInfo.AccountNameHash, accountInfo.PublicKey,
+		accountHistory.Nonce, accountHistory.CollectionNonce, assetRoot)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	err = checkProof(accountProof, accountLeaf, roots.accountRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.AccountProof{
+		Height: roots.height,
+		Account: &types.AccountLeaf{
+			Index:           req.Index,
+			AccountNameHash: accountInfo.AccountNameHash,
+			Pk:              accountInfo.PublicKey,
+			Nonce:           accountHistory.Nonce,
+			CollectionNonce: accountHistory.CollectionNonce,
+			AssetRoot:       accountHistory.AssetRoot,
+		},
+		AccountLeafHash:    common.Bytes2Hex(accountLeaf),
+		AccountMerkleProof: toHexes(accountProof.Proof),
+		Asset:              asset,
+		AssetLeafHash:      common.Bytes2Hex(assetLeaf),
+		AssetMerkleProof:   toHexes(assetProof.Proof),
+		AccountRoot:        common.Bytes2Hex(roots.accountRoot),
+		NftRoot:            common.Bytes2Hex(roots.nftRoot),
+		StateRoot:          roots.stateRoot,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package proof
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	"github.com/bnb-chain/zkbnb/tree"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type GetNftProofLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetNftProofLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetNftProofLogic {
+	return &GetNftProofLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetNftProofLogic) GetNftProof(req *types.ReqGetNftProof) (resp *types.NftProof, err error) {
+	if req.Index < 0 || req.Index >= 1<<tree.NftTreeHeight {
+		return nil, types2.AppErrInvalidNftIndex
+	}
+	blockInfo, err := getVerifiedBlock(l.svcCtx, req.Height)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := getStateRoots(l.svcCtx, blockInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	nftHistory, err := l.svcCtx.NftHistoryModel.GetLatestNftHistory(req.Index, roots.height+1)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrNftNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	nftProof, err := tree.NewVersionedNftTree(l.svcCtx.TreeCtx).GetProof(uint64(req.Index), bsmt.Version(roots.height))
+	if err != nil {
+		return nil, treeError(err)
+	}
+	nftLeaf, err := tree.ComputeNftAssetLeafHash(
+		nftHistory.CreatorAccountIndex,
+		nftHistory.OwnerAccountIndex,
+		nftHistory.NftContentHash,
+		nftHistory.NftL1Address,
+		nftHistory.NftL1TokenId,
+		nftHistory.CreatorTreasuryRate,
+		nftHistory.CollectionId,
+	)
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+	err = checkProof(nftProof, nftLeaf, roots.nftRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.NftProof{
+		Height: roots.height,
+		Nft: &types.NftLeaf{
+			Index:               nftHistory.NftIndex,
+			CreatorAccountIndex: nftHistory.CreatorAccountIndex,
+			OwnerAccountIndex:   nftHistory.OwnerAccountIndex,
+			ContentHash:         nftHistory.NftContentHash,
+			L1Address:           nftHistory.NftL1Address,
+			L1TokenId:           nftHistory.NftL1TokenId,
+			CreatorTreasuryRate: nftHistory.CreatorTreasuryRate,
+			CollectionId:        nftHistory.CollectionId,
+		},
+		NftLeafHash:    common.Bytes2Hex(nftLeaf),
+		NftMerkleProof: toHexes(nftProof.Proof),
+		AccountRoot:    common.Bytes2Hex(roots.accountRoot),
+		NftRoot:        common.Bytes2Hex(roots.nftRoot),
+		StateRoot:      roots.stateRoot,
+	}, nil
+}
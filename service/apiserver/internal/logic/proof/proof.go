@@ -0,0 +1,144 @@
+package proof
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/tree"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+// stateRoots are the roots of the trees at a verified height.
+type stateRoots struct {
+	height      int64
+	accountRoot []byte
+	nftRoot     []byte
+	stateRoot   string
+}
+
+// getVerifiedBlock returns the block of the proof, the latest verified block kept by the
+// trees by default. Only the state roots of the verified blocks can be checked against the L1 contract,
+// the trees must be committed with the verified retention policy to keep them.
+func getVerifiedBlock(svcCtx *svc.ServiceContext, height int64) (*block.Block, error) {
+	if svcCtx.TreeCtx == nil {
+		return nil, types2.AppErrProofNotSupported
+	}
+	policy, err := tree.CommittedRetention(svcCtx.TreeCtx)
+	if err != nil {
+		logx.Errorf("unable to read retention policy: %s", err.Error())
+		return nil, types2.AppErrInternal
+	}
+	if policy != tree.RetainVerified {
+		return nil, types2.AppErrProofNotRetained
+	}
+	verifiedHeight, err := svcCtx.BlockModel.GetLatestVerifiedHeight()
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrProofNotAvailable
+		}
+		return nil, types2.AppErrInternal
+	}
+	if height == 0 {
+		// a leveldb or pebble tree is a static copy, it can be behind the verified blocks
+		latestVersion, err := latestTreeVersion(svcCtx.TreeCtx)
+		if err != nil {
+			logx.Errorf("unable to read tree versions: %s", err.Error())
+			return nil, types2.AppErrInternal
+		}
+		height = verifiedHeight
+		if latestVersion < height {
+			height = latestVersion
+		}
+	}
+	if height < 0 || height > verifiedHeight {
+		return nil, types2.AppErrInvalidBlockHeight
+	}
+	blockInfo, err := svcCtx.BlockModel.GetBlockByHeightWithoutTx(height)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return nil, types2.AppErrBlockNotFound
+		}
+		return nil, types2.AppErrInternal
+	}
+	return blockInfo, nil
+}
+
+// latestTreeVersion returns the latest version committed to both the account and the nft tree.
+func latestTreeVersion(treeCtx *tree.Context) (int64, error) {
+	accountVersion, _, err := tree.NewVersionedAccountTree(treeCtx).Versions()
+	if err != nil {
+		return 0, err
+	}
+	nftVersion, _, err := tree.NewVersionedNftTree(treeCtx).Versions()
+	if err != nil {
+		return 0, err
+	}
+	if nftVersion < accountVersion {
+		return int64(nftVersion), nil
+	}
+	return int64(accountVersion), nil
+}
+
+// getStateRoots reads the roots of the account and nft trees at the height of the block,
+// and checks them against the state root of the block.
+func getStateRoots(svcCtx *svc.ServiceContext, blockInfo *block.Block) (*stateRoots, error) {
+	version := bsmt.Version(blockInfo.BlockHeight)
+	accountRoot, err := tree.NewVersionedAccountTree(svcCtx.TreeCtx).Root(version)
+	if err != nil {
+		return nil, treeError(err)
+	}
+	nftRoot, err := tree.NewVersionedNftTree(svcCtx.TreeCtx).Root(version)
+	if err != nil {
+		return nil, treeError(err)
+	}
+	stateRoot := common.Bytes2Hex(tree.ComputeStateRootHash(accountRoot, nftRoot))
+	if stateRoot != blockInfo.StateRoot {
+		logx.Errorf("state root of the trees at height %d is %s, block: %s", blockInfo.BlockHeight, stateRoot, blockInfo.StateRoot)
+		return nil, types2.AppErrInternal
+	}
+	return &stateRoots{
+		height:      blockInfo.BlockHeight,
+		accountRoot: accountRoot,
+		nftRoot:     nftRoot,
+		stateRoot:   stateRoot,
+	}, nil
+}
+
+// checkProof checks the leaf computed from the database against the tree, and the root of the proof.
+func checkProof(proof *tree.VersionedProof, leaf, root []byte) error {
+	if !bytes.Equal(proof.Leaf, leaf) {
+		logx.Errorf("leaf mismatch, tree: %x, database: %x", proof.Leaf, leaf)
+		return types2.AppErrInternal
+	}
+	if root != nil && !bytes.Equal(proof.Root, root) {
+		logx.Errorf("root mismatch, proof: %x, expected: %x", proof.Root, root)
+		return types2.AppErrInternal
+	}
+	return nil
+}
+
+func treeError(err error) error {
+	// the asset trees are searched for the asset root, it's not found once the version is pruned
+	if errors.Is(err, bsmt.ErrVersionTooOld) || errors.Is(err, tree.ErrVersionNotFound) {
+		return types2.AppErrProofPruned
+	}
+	if errors.Is(err, bsmt.ErrVersionTooHigh) {
+		return types2.AppErrProofNotAvailable
+	}
+	logx.Errorf("unable to read tree: %s", err.Error())
+	return types2.AppErrInternal
+}
+
+func toHexes(proof [][]byte) []string {
+	hexes := make([]string, 0, len(proof))
+	for _, p := range proof {
+		hexes = append(hexes, common.Bytes2Hex(p))
+	}
+	return hexes
+}
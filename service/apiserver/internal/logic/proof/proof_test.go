@@ -0,0 +1,91 @@
+package proof
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/tree"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+type mockBlockModel struct {
+	block.BlockModel
+	verifiedHeight int64
+	stateRoots     map[int64]string
+}
+
+func (m *mockBlockModel) GetLatestVerifiedHeight() (int64, error) {
+	return m.verifiedHeight, nil
+}
+
+func (m *mockBlockModel) GetBlockByHeightWithoutTx(height int64) (*block.Block, error) {
+	return &block.Block{BlockHeight: height, StateRoot: m.stateRoots[height]}, nil
+}
+
+// newBehindTrees commits the trees of the blocks 1 and 2 while the blocks are verified up
+// to height 3 in the database, as a static copy of the trees taken before block 3.
+func newBehindTrees(t *testing.T) *svc.ServiceContext {
+	treeCtx, err := tree.NewContext("apiserver", tree.PebbleDB, false, 0,
+		nil, &tree.PebbleDBOption{File: t.TempDir()}, nil)
+	require.NoError(t, err)
+	treeCtx.SetRetention(tree.RetentionOption{Policy: tree.RetainVerified})
+	require.NoError(t, tree.SetupTreeDB(treeCtx))
+	t.Cleanup(func() {
+		_ = treeCtx.TreeDB.Close()
+	})
+
+	accountTree, err := tree.NewAccountTree(treeCtx, 0)
+	require.NoError(t, err)
+	nftTree, err := tree.NewNftTree(treeCtx, 0)
+	require.NoError(t, err)
+	assetTrees := tree.NewLazyTreeCache(16, 1, 0, func(index, block int64) bsmt.SparseMerkleTree {
+		assetTree, err := tree.NewAccountAssetTree(treeCtx, index, block)
+		require.NoError(t, err)
+		return assetTree
+	})
+
+	blockModel := &mockBlockModel{verifiedHeight: 3, stateRoots: make(map[int64]string)}
+	for height := int64(1); height <= 2; height++ {
+		leaf, err := tree.AssetToNode(fmt.Sprint(height), "0")
+		require.NoError(t, err)
+		require.NoError(t, assetTrees.Get(0).Set(0, leaf))
+		require.NoError(t, accountTree.Set(0, assetTrees.Get(0).Root()))
+		verifiedHeight := func() (int64, error) { return height, nil }
+		prunedVersion, err := treeCtx.PrunedVersion(height, verifiedHeight, height)
+		require.NoError(t, err)
+		require.NoError(t, tree.CommitTrees(treeCtx, prunedVersion, accountTree, assetTrees, nftTree))
+		blockModel.stateRoots[height] = common.Bytes2Hex(tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root()))
+	}
+	return &svc.ServiceContext{
+		BlockModel: blockModel,
+		TreeCtx:    treeCtx,
+	}
+}
+
+func TestGetVerifiedBlockTreeBehind(t *testing.T) {
+	svcCtx := newBehindTrees(t)
+
+	// the latest tree version is the default instead of the verified height
+	blockInfo, err := getVerifiedBlock(svcCtx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), blockInfo.BlockHeight)
+	roots, err := getStateRoots(svcCtx, blockInfo)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), roots.height)
+
+	// a verified height the trees don't have yet isn't available
+	blockInfo, err = getVerifiedBlock(svcCtx, 3)
+	require.NoError(t, err)
+	_, err = getStateRoots(svcCtx, blockInfo)
+	assert.Equal(t, types2.AppErrProofNotAvailable, err)
+
+	_, err = getVerifiedBlock(svcCtx, 4)
+	assert.Equal(t, types2.AppErrInvalidBlockHeight, err)
+}
@@ -0,0 +1,197 @@
+package transaction
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/logic/utils"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+var withdrawalTxTypes = []int64{types2.TxTypeWithdraw, types2.TxTypeWithdrawNft}
+
+type GetAccountWithdrawalsLogic struct {
+	logx.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+func NewGetAccountWithdrawalsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetAccountWithdrawalsLogic {
+	return &GetAccountWithdrawalsLogic{
+		Logger: logx.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetAccountWithdrawals returns the withdrawals of the account, the pending ones in the tx
+// pool first, then the packed ones, the latest first.
+func (l *GetAccountWithdrawalsLogic) GetAccountWithdrawals(req *types.ReqGetAccountWithdrawals) (resp *types.Withdrawals, err error) {
+	resp = &types.Withdrawals{
+		Withdrawals: make([]*types.Withdrawal, 0, int64(req.Limit)),
+	}
+
+	accountIndex := int64(0)
+	switch req.By {
+	case queryByAccountIndex:
+		accountIndex, err = strconv.ParseInt(req.Value, 10, 64)
+		if err != nil || accountIndex < 0 {
+			return nil, types2.AppErrInvalidAccountIndex
+		}
+	case queryByAccountName:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByName(req.Value)
+	case queryByAccountPk:
+		accountIndex, err = l.svcCtx.MemCache.GetAccountIndexByPk(req.Value)
+	default:
+		return nil, types2.AppErrInvalidParam.RefineError("param by should be account_index|account_name|account_pk")
+	}
+
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return resp, nil
+		}
+		return nil, types2.AppErrInternal
+	}
+
+	poolTxs, err := l.svcCtx.TxPoolModel.GetPendingTxsByAccountIndex(accountIndex, tx.GetTxWithTypes(withdrawalTxTypes))
+	if err != nil && err != types2.DbErrNotFound {
+		return nil, types2.AppErrInternal
+	}
+	sort.SliceStable(poolTxs, func(i, j int) bool {
+		return poolTxs[i].CreatedAt.After(poolTxs[j].CreatedAt)
+	})
+
+	total, err := l.svcCtx.TxModel.GetTxsCountByAccountIndex(accountIndex, tx.GetTxWithTypes(withdrawalTxTypes))
+	if err != nil {
+		return nil, types2.AppErrInternal
+	}
+
+	resp.Total = int64(len(poolTxs)) + total
+	if resp.Total == 0 || resp.Total <= int64(req.Offset) {
+		return resp, nil
+	}
+
+	offset, limit := int64(req.Offset), int64(req.Limit)
+	txs := make([]*tx.Tx, 0, limit)
+	if offset < int64(len(poolTxs)) {
+		end := offset + limit
+		if end > int64(len(poolTxs)) {
+			end = int64(len(poolTxs))
+		}
+		txs = append(txs, poolTxs[offset:end]...)
+		limit -= end - offset
+		offset = 0
+	} else {
+		offset -= int64(len(poolTxs))
+	}
+	if limit > 0 && total > offset {
+		packedTxs, err := l.svcCtx.TxModel.GetTxsByAccountIndex(accountIndex, limit, offset, tx.GetTxWithTypes(withdrawalTxTypes))
+		if err != nil && err != types2.DbErrNotFound {
+			return nil, types2.AppErrInternal
+		}
+		txs = append(txs, packedTxs...)
+	}
+
+	for _, t := range txs {
+		withdrawal, err := l.convertWithdrawal(t)
+		if err != nil {
+			return nil, err
+		}
+		resp.Withdrawals = append(resp.Withdrawals, withdrawal)
+	}
+	return resp, nil
+}
+
+func (l *GetAccountWithdrawalsLogic) convertWithdrawal(t *tx.Tx) (*types.Withdrawal, error) {
+	withdrawal := &types.Withdrawal{
+		Tx:    *utils.ConvertTx(t),
+		Stage: utils.TxStage(t.TxStatus),
+	}
+	withdrawal.AccountName, _ = l.svcCtx.MemCache.GetAccountNameByIndex(t.AccountIndex)
+	withdrawal.AssetName, _ = l.svcCtx.MemCache.GetAssetNameById(t.AssetId)
+
+	isWithdraw := false
+	switch t.TxType {
+	case types2.TxTypeWithdraw:
+		txInfo, err := types2.ParseWithdrawTxInfo(t.TxInfo)
+		if err == nil {
+			withdrawal.ToAddress = txInfo.ToAddress
+			isWithdraw = true
+		}
+	case types2.TxTypeWithdrawNft:
+		txInfo, err := types2.ParseWithdrawNftTxInfo(t.TxInfo)
+		if err == nil {
+			withdrawal.ToAddress = txInfo.ToAddress
+		}
+	}
+
+	if t.TxStatus < tx.StatusPacked {
+		return withdrawal, nil
+	}
+
+	// the rollup txs are sent once the blocks are packed, they are pending until
+	// they are confirmed on L1
+	var err error
+	withdrawal.CommittedTxHash, err = l.getL1RollupTxHash(l1rolluptx.TxTypeCommit, t.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	withdrawal.VerifiedTxHash, err = l.getL1RollupTxHash(l1rolluptx.TxTypeVerifyAndExecute, t.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	// the withdrawals are executed by the contract along with the verified blocks, the funds
+	// are either transferred to the to address or kept in its pending balance on L1. The
+	// contract only exposes the pending balances of the assets, not the ones of the nfts.
+	if isWithdraw && t.TxStatus == tx.StatusVerified {
+		withdrawal.InPendingBalance, err = l.inPendingBalance(withdrawal.ToAddress, t.AssetId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return withdrawal, nil
+}
+
+// inPendingBalance returns whether the to address has a pending balance of the asset to claim
+// on L1, the balance may hold the funds of several withdrawals.
+func (l *GetAccountWithdrawalsLogic) inPendingBalance(toAddress string, assetId int64) (bool, error) {
+	if l.svcCtx.PendingBalanceCaller == nil {
+		return false, types2.AppErrPendingBalanceNotAvailable
+	}
+	asset, err := l.svcCtx.MemCache.GetAssetByIdWithFallback(assetId, func() (interface{}, error) {
+		return l.svcCtx.AssetModel.GetAssetById(assetId)
+	})
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return false, types2.AppErrAssetNotFound
+		}
+		return false, types2.AppErrInternal
+	}
+	balance, err := l.svcCtx.PendingBalanceCaller.GetPendingBalance(&bind.CallOpts{Context: l.ctx},
+		common.HexToAddress(toAddress), common.HexToAddress(asset.L1Address))
+	if err != nil {
+		logx.Errorf("fail to get pending balance of %s, asset %d: %s", toAddress, assetId, err.Error())
+		return false, types2.AppErrPendingBalanceNotAvailable
+	}
+	return balance.Sign() > 0, nil
+}
+
+func (l *GetAccountWithdrawalsLogic) getL1RollupTxHash(txType int64, blockHeight int64) (string, error) {
+	rollupTx, err := l.svcCtx.L1RollupTxModel.GetL1RollupTxByL2BlockHeight(txType, blockHeight)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return "", nil
+		}
+		return "", types2.AppErrInternal
+	}
+	return rollupTx.L1TxHash, nil
+}
@@ -0,0 +1,126 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/asset"
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	testToAddress = "0x0000000000000000000000000000000000000001"
+	testBusd      = "0x0000000000000000000000000000000000000002"
+)
+
+type mockAccountModel struct {
+	account.AccountModel
+}
+
+func (m *mockAccountModel) GetAccountByIndex(_ int64) (*account.Account, error) {
+	return nil, types2.DbErrNotFound
+}
+
+type mockAssetModel struct {
+	asset.AssetModel
+}
+
+func (m *mockAssetModel) GetAssetById(assetId int64) (*asset.Asset, error) {
+	switch assetId {
+	case 0:
+		return &asset.Asset{AssetId: 0, AssetSymbol: "BNB", L1Address: "0x00"}, nil
+	case 1:
+		return &asset.Asset{AssetId: 1, AssetSymbol: "BUSD", L1Address: testBusd}, nil
+	}
+	return nil, types2.DbErrNotFound
+}
+
+type mockL1RollupTxModel struct {
+	l1rolluptx.L1RollupTxModel
+}
+
+func (m *mockL1RollupTxModel) GetL1RollupTxByL2BlockHeight(txType int64, _ int64) (*l1rolluptx.L1RollupTx, error) {
+	if txType == l1rolluptx.TxTypeCommit {
+		return &l1rolluptx.L1RollupTx{L1TxHash: "0x01"}, nil
+	}
+	return &l1rolluptx.L1RollupTx{L1TxHash: "0x02"}, nil
+}
+
+// mockPendingBalanceCaller keeps the pending balances of the assets of testToAddress.
+type mockPendingBalanceCaller struct {
+	balances map[common.Address]*big.Int
+	err      error
+}
+
+func (m *mockPendingBalanceCaller) GetPendingBalance(_ *bind.CallOpts, address common.Address, assetAddr common.Address) (*big.Int, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if address != common.HexToAddress(testToAddress) || m.balances[assetAddr] == nil {
+		return big.NewInt(0), nil
+	}
+	return m.balances[assetAddr], nil
+}
+
+func newWithdrawalsLogic(caller svc.PendingBalanceCaller) *GetAccountWithdrawalsLogic {
+	svcCtx := &svc.ServiceContext{
+		MemCache:        cache.MustNewMemCache(&mockAccountModel{}, &mockAssetModel{}, 10, 10, 10, 10, 10, 100, 100),
+		AssetModel:      &mockAssetModel{},
+		L1RollupTxModel: &mockL1RollupTxModel{},
+
+		PendingBalanceCaller: caller,
+	}
+	return NewGetAccountWithdrawalsLogic(context.Background(), svcCtx)
+}
+
+func newWithdrawTx(txStatus int, assetId int64) *tx.Tx {
+	return &tx.Tx{
+		TxType:      types2.TxTypeWithdraw,
+		TxInfo:      `{"ToAddress":"` + testToAddress + `"}`,
+		TxStatus:    txStatus,
+		AssetId:     assetId,
+		BlockHeight: 1,
+	}
+}
+
+func TestConvertWithdrawalPendingBalance(t *testing.T) {
+	caller := &mockPendingBalanceCaller{
+		balances: map[common.Address]*big.Int{common.Address{}: big.NewInt(10)},
+	}
+	l := newWithdrawalsLogic(caller)
+
+	withdrawal, err := l.convertWithdrawal(newWithdrawTx(tx.StatusVerified, 0))
+	require.NoError(t, err)
+	assert.Equal(t, testToAddress, withdrawal.ToAddress)
+	assert.Equal(t, "0x02", withdrawal.VerifiedTxHash)
+	assert.True(t, withdrawal.InPendingBalance)
+
+	// the funds of the asset are transferred to the to address
+	withdrawal, err = l.convertWithdrawal(newWithdrawTx(tx.StatusVerified, 1))
+	require.NoError(t, err)
+	assert.False(t, withdrawal.InPendingBalance)
+
+	// the pending balance isn't read before the block is verified
+	caller.err = errors.New("connection refused")
+	withdrawal, err = l.convertWithdrawal(newWithdrawTx(tx.StatusPacked, 0))
+	require.NoError(t, err)
+	assert.False(t, withdrawal.InPendingBalance)
+
+	_, err = l.convertWithdrawal(newWithdrawTx(tx.StatusVerified, 0))
+	assert.Equal(t, types2.AppErrPendingBalanceNotAvailable, err)
+
+	_, err = newWithdrawalsLogic(nil).convertWithdrawal(newWithdrawTx(tx.StatusVerified, 0))
+	assert.Equal(t, types2.AppErrPendingBalanceNotAvailable, err)
+}
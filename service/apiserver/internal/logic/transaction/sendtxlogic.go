@@ -2,6 +2,8 @@ package transaction
 
 import (
 	"context"
+	"strconv"
+	"strings"
 
 	"github.com/zeromicro/go-zero/core/logx"
 
@@ -27,6 +29,10 @@ func NewSendTxLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SendTxLogi
 }
 
 func (s *SendTxLogic) SendTx(req *types.ReqSendTx) (resp *types.TxHash, err error) {
+	if s.svcCtx.Config.ReadOnly.Enabled {
+		return s.forwardTx(req)
+	}
+
 	pendingTxCount, err := s.svcCtx.TxPoolModel.GetTxsTotalCount()
 	if err != nil {
 		return nil, types2.AppErrInternal
@@ -72,3 +78,31 @@ func (s *SendTxLogic) SendTx(req *types.ReqSendTx) (resp *types.TxHash, err erro
 	resp.TxHash = newTx.TxHash
 	return resp, nil
 }
+
+// forwardTx sends the tx to the upstream of the read only api server.
+func (s *SendTxLogic) forwardTx(req *types.ReqSendTx) (*types.TxHash, error) {
+	if s.svcCtx.TxForwarder == nil {
+		return nil, types2.AppErrReadOnly
+	}
+	txHash, err := s.svcCtx.TxForwarder.SendRawTx(req.TxType, req.TxInfo)
+	if err != nil {
+		return nil, parseForwardError(err)
+	}
+	return &types.TxHash{TxHash: txHash}, nil
+}
+
+// parseForwardError recovers the app error returned by the upstream as "code: message",
+// the body written by httpx.Error ends with a new line.
+func parseForwardError(err error) error {
+	parts := strings.SplitN(strings.TrimSpace(err.Error()), ": ", 2)
+	if len(parts) != 2 {
+		logx.Errorf("forward tx failed: %v", err)
+		return types2.AppErrInternal
+	}
+	code, parseErr := strconv.ParseInt(parts[0], 10, 32)
+	if parseErr != nil {
+		logx.Errorf("forward tx failed: %v", err)
+		return types2.AppErrInternal
+	}
+	return types2.New(int32(code), parts[1])
+}
@@ -0,0 +1,66 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bnb-chain/zkbnb-go-sdk/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeromicro/go-zero/rest/httpx"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/config"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+func TestParseForwardError(t *testing.T) {
+	err := parseForwardError(errors.New("21103: invalid nonce\n"))
+	assert.Equal(t, types2.AppErrInvalidNonce.Error(), err.Error())
+	assert.Equal(t, types2.AppErrInvalidNonce.Code(), err.(types2.Error).Code())
+
+	err = parseForwardError(errors.New("21103: invalid nonce: expected 2"))
+	assert.Equal(t, types2.AppErrInvalidNonce.Code(), err.(types2.Error).Code())
+	assert.Equal(t, "21103: invalid nonce: expected 2", err.Error())
+
+	assert.Equal(t, types2.AppErrInternal, parseForwardError(errors.New("connection refused")))
+	assert.Equal(t, types2.AppErrInternal, parseForwardError(errors.New("dial tcp: i/o timeout")))
+}
+
+func newReadOnlyLogic(l2EndPoint string) *SendTxLogic {
+	var c config.Config
+	c.ReadOnly.Enabled = true
+	c.ReadOnly.L2EndPoint = l2EndPoint
+	svcCtx := &svc.ServiceContext{Config: c}
+	if l2EndPoint != "" {
+		svcCtx.TxForwarder = client.NewZkBNBClient(l2EndPoint)
+	}
+	return NewSendTxLogic(context.Background(), svcCtx)
+}
+
+func TestForwardTx(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/sendTx", r.URL.Path)
+		if r.FormValue("tx_info") == "invalid" {
+			httpx.Error(w, types2.AppErrInvalidNonce)
+			return
+		}
+		httpx.OkJson(w, &types.TxHash{TxHash: "0x01"})
+	}))
+	defer upstream.Close()
+
+	resp, err := newReadOnlyLogic(upstream.URL).SendTx(&types.ReqSendTx{TxType: 4, TxInfo: "{}"})
+	require.NoError(t, err)
+	assert.Equal(t, "0x01", resp.TxHash)
+
+	_, err = newReadOnlyLogic(upstream.URL).SendTx(&types.ReqSendTx{TxType: 4, TxInfo: "invalid"})
+	assert.Equal(t, types2.AppErrInvalidNonce.Error(), err.Error())
+	assert.Equal(t, types2.AppErrInvalidNonce.Code(), err.(types2.Error).Code())
+
+	_, err = newReadOnlyLogic("").SendTx(&types.ReqSendTx{TxType: 4, TxInfo: "{}"})
+	assert.Equal(t, types2.AppErrReadOnly, err)
+}
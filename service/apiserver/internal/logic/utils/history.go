@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+// CheckHistoryHeight checks the height of a historical query. The histories of the blocks
+// above the latest committed block can still be rolled back, so they can not be queried,
+// the states at the committed heights don't change and can be cached.
+func CheckHistoryHeight(svcCtx *svc.ServiceContext, height int64) error {
+	if height < 0 {
+		return types2.AppErrInvalidBlockHeight
+	}
+	committedHeight, err := svcCtx.BlockModel.GetLatestHeightByStatus(block.StatusCommitted)
+	if err != nil {
+		if err == types2.DbErrNotFound {
+			return types2.AppErrInvalidBlockHeight
+		}
+		return types2.AppErrInternal
+	}
+	if height > committedHeight {
+		return types2.AppErrInvalidBlockHeight
+	}
+	return nil
+}
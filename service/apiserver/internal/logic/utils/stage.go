@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
+	types2 "github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	StageFailed    = "failed"
+	StagePending   = "pending"
+	StagePacked    = "packed"
+	StageCommitted = "committed"
+	StageVerified  = "verified"
+)
+
+// TxStage returns the stage of a tx on its way to L1 by the tx status.
+func TxStage(txStatus int) string {
+	switch txStatus {
+	case tx.StatusFailed:
+		return StageFailed
+	case tx.StatusPacked:
+		return StagePacked
+	case tx.StatusCommitted:
+		return StageCommitted
+	case tx.StatusVerified:
+		return StageVerified
+	}
+	return StagePending
+}
+
+// GetLatestTx returns the tx in the tx table, or in the tx pool if it's not packed yet.
+// The caches are bypassed as the status of the tx changes over time.
+func GetLatestTx(svcCtx *svc.ServiceContext, txHash string) (*tx.Tx, error) {
+	t, err := svcCtx.TxModel.GetTxByHash(txHash)
+	if err != types2.DbErrNotFound {
+		return t, err
+	}
+	return svcCtx.TxPoolModel.GetTxByTxHash(txHash)
+}
@@ -0,0 +1,364 @@
+package notifier
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	TopicNewBlock    = "newBlock"
+	TopicBlockStatus = "blockStatus"
+	TopicTxStatus    = "txStatus"
+	TopicAccount     = "account"
+
+	DefaultPollInterval = time.Second
+
+	// events buffered for a subscriber, a subscriber too slow to keep up is dropped
+	subscriberBufferSize = 256
+)
+
+type Event struct {
+	Topic string      `json:"topic"`
+	Key   string      `json:"key,omitempty"`
+	Data  interface{} `json:"data"`
+}
+
+type BlockEvent struct {
+	Height          int64  `json:"height"`
+	StateRoot       string `json:"state_root"`
+	Status          int64  `json:"status"`
+	Size            uint16 `json:"size"`
+	TxCount         int    `json:"tx_count"`
+	CommittedTxHash string `json:"committed_tx_hash"`
+	CommittedAt     int64  `json:"committed_at"`
+	VerifiedTxHash  string `json:"verified_tx_hash"`
+	VerifiedAt      int64  `json:"verified_at"`
+	CreatedAt       int64  `json:"created_at"`
+}
+
+type TxStatusEvent struct {
+	Hash        string `json:"hash"`
+	Status      int64  `json:"status"`
+	BlockHeight int64  `json:"block_height"`
+}
+
+type AccountAsset struct {
+	Id      int64  `json:"id"`
+	Balance string `json:"balance"`
+}
+
+type AccountEvent struct {
+	Index       int64           `json:"index"`
+	Nonce       int64           `json:"nonce"`
+	Assets      []*AccountAsset `json:"assets"`
+	BlockHeight int64           `json:"block_height"`
+}
+
+type subscription struct {
+	topic string
+	key   string
+}
+
+type Subscriber struct {
+	events   chan *Event
+	dropped  chan struct{}
+	dropOnce sync.Once
+	subs     map[subscription]struct{}
+}
+
+// Events returns the events of the subscriptions.
+func (s *Subscriber) Events() <-chan *Event {
+	return s.events
+}
+
+// Dropped is closed once the subscriber is dropped for not keeping up with the events.
+func (s *Subscriber) Dropped() <-chan struct{} {
+	return s.dropped
+}
+
+// Notifier derives the events from the changes of the blocks in the database, every
+// replica of the apiserver watches the database on its own, so any of them can serve
+// the subscriptions.
+type Notifier struct {
+	blockModel          block.BlockModel
+	accountHistoryModel account.AccountHistoryModel
+	pollInterval        time.Duration
+
+	mu   sync.RWMutex
+	subs map[subscription]map[*Subscriber]struct{}
+
+	packedHeight    int64
+	committedHeight int64
+	verifiedHeight  int64
+	quitCh          chan struct{}
+}
+
+func NewNotifier(blockModel block.BlockModel, accountHistoryModel account.AccountHistoryModel,
+	pollInterval time.Duration) *Notifier {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &Notifier{
+		blockModel:          blockModel,
+		accountHistoryModel: accountHistoryModel,
+		pollInterval:        pollInterval,
+		subs:                make(map[subscription]map[*Subscriber]struct{}),
+		quitCh:              make(chan struct{}),
+	}
+}
+
+func (n *Notifier) NewSubscriber() *Subscriber {
+	return &Subscriber{
+		events:  make(chan *Event, subscriberBufferSize),
+		dropped: make(chan struct{}),
+		subs:    make(map[subscription]struct{}),
+	}
+}
+
+func (n *Notifier) Subscribe(s *Subscriber, topic, key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sub := subscription{topic: topic, key: key}
+	if n.subs[sub] == nil {
+		n.subs[sub] = make(map[*Subscriber]struct{})
+	}
+	n.subs[sub][s] = struct{}{}
+	s.subs[sub] = struct{}{}
+}
+
+func (n *Notifier) Unsubscribe(s *Subscriber, topic, key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.unsubscribe(s, subscription{topic: topic, key: key})
+}
+
+// Close removes all the subscriptions of the subscriber.
+func (n *Notifier) Close(s *Subscriber) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for sub := range s.subs {
+		n.unsubscribe(s, sub)
+	}
+}
+
+// SubscriptionCount returns the number of subscriptions of the subscriber.
+func (n *Notifier) SubscriptionCount(s *Subscriber) int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(s.subs)
+}
+
+func (n *Notifier) unsubscribe(s *Subscriber, sub subscription) {
+	delete(s.subs, sub)
+	subscribers := n.subs[sub]
+	delete(subscribers, s)
+	if len(subscribers) == 0 {
+		delete(n.subs, sub)
+	}
+}
+
+func (n *Notifier) hasSubscribers(topic string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for sub := range n.subs {
+		if sub.topic == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) hasSubscriber(topic, key string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.subs[subscription{topic: topic, key: key}]) > 0
+}
+
+// Publish sends the event to the subscribers of its topic and key.
+func (n *Notifier) Publish(event *Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for s := range n.subs[subscription{topic: event.Topic, key: event.Key}] {
+		select {
+		case s.events <- event:
+		default:
+			for sub := range s.subs {
+				n.unsubscribe(s, sub)
+			}
+			s.dropOnce.Do(func() { close(s.dropped) })
+		}
+	}
+}
+
+func (n *Notifier) Start() {
+	n.packedHeight = n.latestHeight(block.StatusPending)
+	n.committedHeight = n.latestHeight(block.StatusCommitted)
+	n.verifiedHeight = n.latestHeight(block.StatusVerifiedAndExecuted)
+
+	go func() {
+		ticker := time.NewTicker(n.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := n.poll()
+				if err != nil {
+					logx.Errorf("poll block changes failed: %v", err)
+				}
+			case <-n.quitCh:
+				return
+			}
+		}
+	}()
+}
+
+func (n *Notifier) Stop() {
+	close(n.quitCh)
+}
+
+func (n *Notifier) latestHeight(status int) int64 {
+	height, err := n.blockModel.GetLatestHeightByStatus(status)
+	if err != nil {
+		if err != types.DbErrNotFound {
+			logx.Errorf("get latest block height failed, status: %d, err: %v", status, err)
+		}
+		return 0
+	}
+	return height
+}
+
+func (n *Notifier) poll() error {
+	packedHeight, err := n.pollStatus(n.packedHeight, block.StatusPending)
+	if packedHeight > n.packedHeight && n.hasSubscribers(TopicAccount) {
+		accountErr := n.publishAccounts(n.packedHeight, packedHeight)
+		if accountErr != nil {
+			logx.Errorf("publish account changes failed: %v", accountErr)
+		}
+	}
+	n.packedHeight = packedHeight
+	if err != nil {
+		return err
+	}
+
+	n.committedHeight, err = n.pollStatus(n.committedHeight, block.StatusCommitted)
+	if err != nil {
+		return err
+	}
+	n.verifiedHeight, err = n.pollStatus(n.verifiedHeight, block.StatusVerifiedAndExecuted)
+	return err
+}
+
+// pollStatus publishes the events of the blocks reaching the status above the height, it
+// returns the height of the last block reaching the status.
+func (n *Notifier) pollStatus(fromHeight int64, status int) (int64, error) {
+	height, err := n.blockModel.GetLatestHeightByStatus(status)
+	if err == types.DbErrNotFound {
+		return fromHeight, nil
+	}
+	if err != nil {
+		return fromHeight, err
+	}
+
+	topic := TopicBlockStatus
+	txStatus := int64(tx.StatusCommitted)
+	switch status {
+	case block.StatusPending:
+		topic = TopicNewBlock
+		txStatus = tx.StatusPacked
+	case block.StatusVerifiedAndExecuted:
+		txStatus = tx.StatusVerified
+	}
+	if !n.hasSubscribers(topic) && !n.hasSubscribers(TopicTxStatus) {
+		// the blocks are only loaded for the subscribers
+		return height, nil
+	}
+
+	for h := fromHeight + 1; h <= height; h++ {
+		b, err := n.blockModel.GetBlockByHeight(h)
+		if err != nil {
+			return h - 1, err
+		}
+		n.Publish(&Event{Topic: topic, Data: &BlockEvent{
+			Height:          b.BlockHeight,
+			StateRoot:       b.StateRoot,
+			Status:          int64(status),
+			Size:            b.BlockSize,
+			TxCount:         len(b.Txs),
+			CommittedTxHash: b.CommittedTxHash,
+			CommittedAt:     b.CommittedAt,
+			VerifiedTxHash:  b.VerifiedTxHash,
+			VerifiedAt:      b.VerifiedAt,
+			CreatedAt:       b.CreatedAt.Unix(),
+		}})
+		for _, blockTx := range b.Txs {
+			if !n.hasSubscriber(TopicTxStatus, blockTx.TxHash) {
+				continue
+			}
+			n.Publish(&Event{Topic: TopicTxStatus, Key: blockTx.TxHash, Data: &TxStatusEvent{
+				Hash:        blockTx.TxHash,
+				Status:      txStatus,
+				BlockHeight: b.BlockHeight,
+			}})
+		}
+	}
+	return height, nil
+}
+
+// publishAccounts publishes the accounts updated by the blocks packed above the height.
+func (n *Notifier) publishAccounts(fromHeight, toHeight int64) error {
+	accountIndexes, err := n.accountHistoryModel.GetAccountIndexesAboveHeight(fromHeight)
+	if err != nil {
+		return err
+	}
+	for _, accountIndex := range accountIndexes {
+		key := strconv.FormatInt(accountIndex, 10)
+		if !n.hasSubscriber(TopicAccount, key) {
+			continue
+		}
+		event, err := NewAccountEvent(n.accountHistoryModel, accountIndex, toHeight)
+		if err != nil {
+			return err
+		}
+		n.Publish(&Event{Topic: TopicAccount, Key: key, Data: event})
+	}
+	return nil
+}
+
+// NewAccountEvent returns the balances and nonce of the account at the height.
+func NewAccountEvent(accountHistoryModel account.AccountHistoryModel, accountIndex, blockHeight int64) (*AccountEvent, error) {
+	history, err := accountHistoryModel.GetLatestAccountHistory(accountIndex, blockHeight+1)
+	if err != nil {
+		return nil, err
+	}
+	var assetInfo map[int64]*types.AccountAsset
+	err = json.Unmarshal([]byte(history.AssetInfo), &assetInfo)
+	if err != nil {
+		return nil, types.JsonErrUnmarshal
+	}
+	event := &AccountEvent{
+		Index:       accountIndex,
+		Nonce:       history.Nonce,
+		Assets:      make([]*AccountAsset, 0, len(assetInfo)),
+		BlockHeight: blockHeight,
+	}
+	for assetId, asset := range assetInfo {
+		if asset.Balance == nil || asset.Balance.Cmp(types.ZeroBigInt) == 0 {
+			continue
+		}
+		event.Assets = append(event.Assets, &AccountAsset{Id: assetId, Balance: asset.Balance.String()})
+	}
+	sort.Slice(event.Assets, func(i, j int) bool {
+		return event.Assets[i].Id < event.Assets[j].Id
+	})
+	return event, nil
+}
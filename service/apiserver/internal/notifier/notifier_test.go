@@ -0,0 +1,157 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type mockBlockModel struct {
+	block.BlockModel
+	blocks []*block.Block
+	loads  int
+}
+
+func (m *mockBlockModel) GetLatestHeightByStatus(status int) (int64, error) {
+	height := int64(-1)
+	for _, b := range m.blocks {
+		if b.BlockStatus >= int64(status) {
+			height = b.BlockHeight
+		}
+	}
+	if height < 0 {
+		return 0, types.DbErrNotFound
+	}
+	return height, nil
+}
+
+func (m *mockBlockModel) GetBlockByHeight(height int64) (*block.Block, error) {
+	m.loads++
+	for _, b := range m.blocks {
+		if b.BlockHeight == height {
+			return b, nil
+		}
+	}
+	return nil, types.DbErrNotFound
+}
+
+type mockAccountHistoryModel struct {
+	account.AccountHistoryModel
+	histories []*account.AccountHistory
+}
+
+func (m *mockAccountHistoryModel) GetLatestAccountHistory(accountIndex, height int64) (*account.AccountHistory, error) {
+	var latest *account.AccountHistory
+	for _, history := range m.histories {
+		if history.AccountIndex == accountIndex && history.L2BlockHeight < height {
+			latest = history
+		}
+	}
+	if latest == nil {
+		return nil, types.DbErrNotFound
+	}
+	return latest, nil
+}
+
+func (m *mockAccountHistoryModel) GetAccountIndexesAboveHeight(height int64) ([]int64, error) {
+	var accountIndexes []int64
+	updated := make(map[int64]bool)
+	for _, history := range m.histories {
+		if history.L2BlockHeight > height && !updated[history.AccountIndex] {
+			updated[history.AccountIndex] = true
+			accountIndexes = append(accountIndexes, history.AccountIndex)
+		}
+	}
+	return accountIndexes, nil
+}
+
+func TestPollStatus(t *testing.T) {
+	blockModel := &mockBlockModel{blocks: []*block.Block{
+		{BlockHeight: 1, BlockStatus: block.StatusCommitted, Txs: []*tx.Tx{{TxHash: "a"}}},
+		{BlockHeight: 2, BlockStatus: block.StatusPending, Txs: []*tx.Tx{{TxHash: "b"}}},
+	}}
+	n := NewNotifier(blockModel, nil, 0)
+	n.Start()
+	defer n.Stop()
+	require.Equal(t, int64(2), n.packedHeight)
+	require.Equal(t, int64(1), n.committedHeight)
+	require.Equal(t, int64(0), n.verifiedHeight)
+	assert.Equal(t, 0, blockModel.loads)
+
+	s := n.NewSubscriber()
+	n.Subscribe(s, TopicBlockStatus, "")
+	n.Subscribe(s, TopicTxStatus, "b")
+
+	// no changes
+	height, err := n.pollStatus(n.committedHeight, block.StatusCommitted)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), height)
+	assert.Len(t, s.Events(), 0)
+
+	blockModel.blocks[1].BlockStatus = block.StatusCommitted
+	height, err = n.pollStatus(n.committedHeight, block.StatusCommitted)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), height)
+	require.Len(t, s.Events(), 2)
+
+	event := <-s.Events()
+	assert.Equal(t, TopicBlockStatus, event.Topic)
+	assert.Equal(t, int64(2), event.Data.(*BlockEvent).Height)
+	assert.Equal(t, int64(block.StatusCommitted), event.Data.(*BlockEvent).Status)
+	event = <-s.Events()
+	assert.Equal(t, TopicTxStatus, event.Topic)
+	assert.Equal(t, "b", event.Key)
+	assert.Equal(t, int64(tx.StatusCommitted), event.Data.(*TxStatusEvent).Status)
+}
+
+func TestDropSlowSubscriber(t *testing.T) {
+	n := NewNotifier(nil, nil, 0)
+	slow := n.NewSubscriber()
+	n.Subscribe(slow, TopicNewBlock, "")
+	n.Subscribe(slow, TopicAccount, "1")
+	other := n.NewSubscriber()
+	n.Subscribe(other, TopicAccount, "1")
+
+	for i := 0; i <= subscriberBufferSize; i++ {
+		n.Publish(&Event{Topic: TopicNewBlock})
+	}
+	select {
+	case <-slow.Dropped():
+	default:
+		t.Fatal("slow subscriber is not dropped")
+	}
+	assert.Equal(t, 0, n.SubscriptionCount(slow))
+	assert.False(t, n.hasSubscribers(TopicNewBlock))
+
+	n.Publish(&Event{Topic: TopicAccount, Key: "1"})
+	assert.Len(t, other.Events(), 1)
+	assert.Len(t, slow.Events(), subscriberBufferSize)
+}
+
+func TestPublishAccounts(t *testing.T) {
+	accountHistoryModel := &mockAccountHistoryModel{histories: []*account.AccountHistory{
+		{AccountIndex: 1, Nonce: 1, AssetInfo: `{"0":{"AssetId":0,"Balance":100},"1":{"AssetId":1,"Balance":0}}`, L2BlockHeight: 1},
+		{AccountIndex: 1, Nonce: 2, AssetInfo: `{"0":{"AssetId":0,"Balance":90},"2":{"AssetId":2,"Balance":5}}`, L2BlockHeight: 2},
+		{AccountIndex: 1, Nonce: 3, AssetInfo: `{"0":{"AssetId":0,"Balance":80}}`, L2BlockHeight: 3},
+	}}
+	n := NewNotifier(nil, accountHistoryModel, 0)
+	s := n.NewSubscriber()
+	n.Subscribe(s, TopicAccount, "1")
+
+	// the account is published as of the last block polled, not the latest one
+	require.NoError(t, n.publishAccounts(1, 2))
+	require.Len(t, s.Events(), 1)
+	event := (<-s.Events()).Data.(*AccountEvent)
+	assert.Equal(t, int64(2), event.BlockHeight)
+	assert.Equal(t, int64(2), event.Nonce)
+	assert.Equal(t, []*AccountAsset{{Id: 0, Balance: "90"}, {Id: 2, Balance: "5"}}, event.Assets)
+
+	_, err := NewAccountEvent(accountHistoryModel, 2, 3)
+	assert.Equal(t, types.DbErrNotFound, err)
+}
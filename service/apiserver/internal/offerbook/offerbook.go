@@ -0,0 +1,376 @@
+package offerbook
+
+import (
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/core/executor"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/state"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const DefaultPollInterval = time.Second
+
+// maxRollbackDepth is the number of the latest scanned blocks whose state roots are kept to
+// find the height the chain is rolled back to.
+const maxRollbackDepth = 128
+
+type offerKey struct {
+	accountIndex int64
+	offerId      int64
+}
+
+// invalidation is how the offer is invalidated by the block at the height.
+type invalidation struct {
+	status   int
+	l2TxHash string
+	height   int64
+}
+
+// OfferBook keeps the offers of the offer book valid, the offers are invalidated once
+// their bits are set in the OfferCanceledOrFinalized bitmaps of the accounts by the
+// AtomicMatch and CancelOffer txs, and expired by their ExpiredAt. The offers invalidated
+// by the blocks rolled back are reactivated.
+type OfferBook struct {
+	offerModel   offer.OfferModel
+	blockModel   block.BlockModel
+	stateFetcher state.Fetcher
+	pollInterval time.Duration
+
+	// the offers are reconciled with the accounts before the blocks are scanned
+	started       bool
+	scannedHeight int64
+	scannedRoots  map[int64]string
+	// the accounts failed to be fetched, their offers are checked again by the next poll
+	retryAccounts map[int64]bool
+	retryTxs      map[offerKey]invalidation
+	quitCh        chan struct{}
+}
+
+func NewOfferBook(offerModel offer.OfferModel, blockModel block.BlockModel, stateFetcher state.Fetcher,
+	pollInterval time.Duration) *OfferBook {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &OfferBook{
+		offerModel:    offerModel,
+		blockModel:    blockModel,
+		stateFetcher:  stateFetcher,
+		pollInterval:  pollInterval,
+		scannedRoots:  make(map[int64]string),
+		retryAccounts: make(map[int64]bool),
+		retryTxs:      make(map[offerKey]invalidation),
+		quitCh:        make(chan struct{}),
+	}
+}
+
+func (b *OfferBook) Start() {
+	go func() {
+		ticker := time.NewTicker(b.pollInterval)
+		defer ticker.Stop()
+		for {
+			err := b.poll()
+			if err != nil {
+				logx.Errorf("poll offer changes failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-b.quitCh:
+				return
+			}
+		}
+	}()
+}
+
+func (b *OfferBook) Stop() {
+	close(b.quitCh)
+}
+
+func (b *OfferBook) poll() error {
+	_, err := b.offerModel.ExpireOffers(time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	height, err := b.blockModel.GetLatestHeightByStatus(block.StatusPending)
+	if err == types.DbErrNotFound {
+		height = 0
+	} else if err != nil {
+		return err
+	}
+
+	if b.started {
+		err = b.rollback()
+		if err != nil {
+			return err
+		}
+	}
+	if !b.started {
+		// the blocks packed while the offer book is stopped aren't scanned, so all the
+		// accounts with active offers are checked once started
+		err = b.reconcile(height)
+		if err != nil {
+			return err
+		}
+		b.started = true
+	}
+
+	err = b.retry()
+	if err != nil {
+		return err
+	}
+	for h := b.scannedHeight + 1; h <= height; h++ {
+		l2Block, err := b.blockModel.GetBlockByHeight(h)
+		if err != nil {
+			return err
+		}
+		err = b.scanBlock(l2Block)
+		if err != nil {
+			return err
+		}
+		b.setScanned(h, l2Block.StateRoot)
+	}
+	return nil
+}
+
+func (b *OfferBook) setScanned(height int64, stateRoot string) {
+	b.scannedHeight = height
+	b.scannedRoots[height] = stateRoot
+	delete(b.scannedRoots, height-maxRollbackDepth)
+}
+
+// blockRoot returns the state root of the block at the height, or empty if it's not found.
+func (b *OfferBook) blockRoot(height int64) (string, error) {
+	l2Block, err := b.blockModel.GetBlockByHeightWithoutTx(height)
+	if err == types.DbErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return l2Block.StateRoot, nil
+}
+
+func (b *OfferBook) reconcile(height int64) error {
+	stateRoot, err := b.blockRoot(height)
+	if err != nil {
+		return err
+	}
+	accountIndexes, err := b.offerModel.GetAccountIndexesWithActiveOffers()
+	if err != nil {
+		return err
+	}
+	err = b.invalidate(accountIndexes, nil, height)
+	if err != nil {
+		return err
+	}
+	b.scannedRoots = make(map[int64]string)
+	b.setScanned(height, stateRoot)
+	return nil
+}
+
+// rollback checks the scanned blocks against their state roots. If they are rolled back, the
+// offers invalidated by them are reactivated, and the blocks packed again are scanned from
+// the height the chain is rolled back to. If it's rolled back deeper than the roots kept,
+// the offers are reconciled again.
+func (b *OfferBook) rollback() error {
+	stateRoot, err := b.blockRoot(b.scannedHeight)
+	if err != nil {
+		return err
+	}
+	if stateRoot == b.scannedRoots[b.scannedHeight] {
+		return nil
+	}
+
+	height := int64(-1)
+	for h := b.scannedHeight - 1; h >= 0; h-- {
+		scannedRoot, ok := b.scannedRoots[h]
+		if !ok {
+			break
+		}
+		stateRoot, err = b.blockRoot(h)
+		if err != nil {
+			return err
+		}
+		if stateRoot == scannedRoot {
+			height = h
+			break
+		}
+	}
+	logx.Infof("blocks above %d are rolled back, scanned height: %d", height, b.scannedHeight)
+
+	err = b.reactivate(height)
+	if err != nil {
+		return err
+	}
+	for key, u := range b.retryTxs {
+		if u.height > height {
+			delete(b.retryTxs, key)
+		}
+	}
+	if height < 0 {
+		b.started = false
+		return nil
+	}
+	for h := height + 1; h <= b.scannedHeight; h++ {
+		delete(b.scannedRoots, h)
+	}
+	b.scannedHeight = height
+	return nil
+}
+
+// reactivate reactivates the offers invalidated above the height, whose offer ids aren't
+// used by the accounts anymore.
+func (b *OfferBook) reactivate(height int64) error {
+	offers, err := b.offerModel.GetInvalidatedOffersAboveHeight(height, time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	var ids []uint
+	accounts := make(map[int64]*types.AccountInfo)
+	for _, o := range offers {
+		account, ok := accounts[o.AccountIndex]
+		if !ok {
+			account, err = b.stateFetcher.GetLatestAccount(o.AccountIndex)
+			if err != nil {
+				// the offers are kept invalidated, they are listed again by their accounts
+				logx.Errorf("get account %d failed, its offers aren't reactivated: %v", o.AccountIndex, err)
+			}
+			accounts[o.AccountIndex] = account
+		}
+		if account == nil || IsOfferCanceledOrFinalized(account, o.OfferId) {
+			continue
+		}
+		ids = append(ids, o.ID)
+	}
+	return b.offerModel.ReactivateOffers(ids)
+}
+
+// retry checks the offers of the accounts failed to be fetched again.
+func (b *OfferBook) retry() error {
+	if len(b.retryAccounts) == 0 {
+		return nil
+	}
+	accountIndexes := make([]int64, 0, len(b.retryAccounts))
+	for accountIndex := range b.retryAccounts {
+		accountIndexes = append(accountIndexes, accountIndex)
+	}
+	txs := b.retryTxs
+	b.retryAccounts = make(map[int64]bool)
+	b.retryTxs = make(map[offerKey]invalidation)
+
+	err := b.invalidate(accountIndexes, txs, b.scannedHeight)
+	if err != nil {
+		for _, accountIndex := range accountIndexes {
+			b.retryAccounts[accountIndex] = true
+		}
+		for key, u := range txs {
+			b.retryTxs[key] = u
+		}
+	}
+	return err
+}
+
+// scanBlock invalidates the offers used by the AtomicMatch and CancelOffer txs of the block.
+func (b *OfferBook) scanBlock(l2Block *block.Block) error {
+	var accountIndexes []int64
+	txs := make(map[offerKey]invalidation)
+	for _, blockTx := range l2Block.Txs {
+		switch blockTx.TxType {
+		case types.TxTypeAtomicMatch:
+			txInfo, err := types.ParseAtomicMatchTxInfo(blockTx.TxInfo)
+			if err != nil {
+				return err
+			}
+			for _, o := range []*offerKey{
+				{accountIndex: txInfo.BuyOffer.AccountIndex, offerId: txInfo.BuyOffer.OfferId},
+				{accountIndex: txInfo.SellOffer.AccountIndex, offerId: txInfo.SellOffer.OfferId},
+			} {
+				txs[*o] = invalidation{status: offer.StatusFinalized, l2TxHash: blockTx.TxHash, height: l2Block.BlockHeight}
+				accountIndexes = append(accountIndexes, o.accountIndex)
+			}
+		case types.TxTypeCancelOffer:
+			txInfo, err := types.ParseCancelOfferTxInfo(blockTx.TxInfo)
+			if err != nil {
+				return err
+			}
+			key := offerKey{accountIndex: txInfo.AccountIndex, offerId: txInfo.OfferId}
+			txs[key] = invalidation{status: offer.StatusCanceled, l2TxHash: blockTx.TxHash, height: l2Block.BlockHeight}
+			accountIndexes = append(accountIndexes, txInfo.AccountIndex)
+		}
+	}
+	if len(accountIndexes) == 0 {
+		return nil
+	}
+	return b.invalidate(accountIndexes, txs, l2Block.BlockHeight)
+}
+
+// invalidate checks the active offers of the accounts against their bitmaps, the offers
+// with the bits set are finalized if they are matched by the txs, otherwise canceled at
+// the height. The accounts failed to be fetched are checked again by the next poll.
+func (b *OfferBook) invalidate(accountIndexes []int64, txs map[offerKey]invalidation, height int64) error {
+	if len(accountIndexes) == 0 {
+		return nil
+	}
+	offers, err := b.offerModel.GetActiveOffersByAccountIndexes(accountIndexes)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[invalidation][]uint)
+	accounts := make(map[int64]*types.AccountInfo)
+	failed := make(map[int64]bool)
+	for _, o := range offers {
+		if failed[o.AccountIndex] {
+			continue
+		}
+		account, ok := accounts[o.AccountIndex]
+		if !ok {
+			account, err = b.stateFetcher.GetLatestAccount(o.AccountIndex)
+			if err != nil {
+				logx.Errorf("get account %d failed, its offers are checked later: %v", o.AccountIndex, err)
+				failed[o.AccountIndex] = true
+				continue
+			}
+			accounts[o.AccountIndex] = account
+		}
+		if !IsOfferCanceledOrFinalized(account, o.OfferId) {
+			continue
+		}
+
+		u, ok := txs[offerKey{accountIndex: o.AccountIndex, offerId: o.OfferId}]
+		if !ok {
+			u = invalidation{status: offer.StatusCanceled, height: height}
+		}
+		updates[u] = append(updates[u], o.ID)
+	}
+
+	for u, ids := range updates {
+		err = b.offerModel.UpdateOffersStatus(ids, u.status, u.l2TxHash, u.height)
+		if err != nil {
+			return err
+		}
+	}
+	for accountIndex := range failed {
+		b.retryAccounts[accountIndex] = true
+	}
+	for key, u := range txs {
+		if failed[key.accountIndex] {
+			b.retryTxs[key] = u
+		}
+	}
+	return nil
+}
+
+// IsOfferCanceledOrFinalized returns whether the offer id of the account is used.
+func IsOfferCanceledOrFinalized(account *types.AccountInfo, offerId int64) bool {
+	asset, ok := account.AssetInfo[offerId/executor.OfferPerAsset]
+	if !ok || asset.OfferCanceledOrFinalized == nil {
+		return false
+	}
+	return asset.OfferCanceledOrFinalized.Bit(int(offerId%executor.OfferPerAsset)) == 1
+}
@@ -0,0 +1,294 @@
+package offerbook
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type mockOfferModel struct {
+	offer.OfferModel
+	offers []*offer.Offer
+}
+
+func (m *mockOfferModel) ExpireOffers(_ int64) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockOfferModel) GetActiveOffersByAccountIndexes(accountIndexes []int64) ([]*offer.Offer, error) {
+	var offers []*offer.Offer
+	for _, o := range m.offers {
+		for _, accountIndex := range accountIndexes {
+			if o.Status == offer.StatusActive && o.AccountIndex == accountIndex {
+				offers = append(offers, o)
+				break
+			}
+		}
+	}
+	return offers, nil
+}
+
+func (m *mockOfferModel) GetAccountIndexesWithActiveOffers() ([]int64, error) {
+	var accountIndexes []int64
+	for _, o := range m.offers {
+		if o.Status == offer.StatusActive {
+			accountIndexes = append(accountIndexes, o.AccountIndex)
+		}
+	}
+	return accountIndexes, nil
+}
+
+func (m *mockOfferModel) UpdateOffersStatus(ids []uint, status int, l2TxHash string, l2BlockHeight int64) error {
+	for _, id := range ids {
+		o := m.offers[id]
+		if o.Status == offer.StatusActive {
+			o.Status, o.L2TxHash, o.L2BlockHeight = status, l2TxHash, l2BlockHeight
+		}
+	}
+	return nil
+}
+
+func (m *mockOfferModel) GetInvalidatedOffersAboveHeight(height int64, _ int64) ([]*offer.Offer, error) {
+	var offers []*offer.Offer
+	for _, o := range m.offers {
+		if o.Status != offer.StatusActive && o.L2BlockHeight > height {
+			offers = append(offers, o)
+		}
+	}
+	return offers, nil
+}
+
+func (m *mockOfferModel) ReactivateOffers(ids []uint) error {
+	for _, id := range ids {
+		o := m.offers[id]
+		o.Status, o.L2TxHash, o.L2BlockHeight = offer.StatusActive, "", 0
+	}
+	return nil
+}
+
+type mockBlockModel struct {
+	block.BlockModel
+	blocks []*block.Block
+	err    error
+}
+
+func (m *mockBlockModel) GetLatestHeightByStatus(_ int) (int64, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if len(m.blocks) == 0 {
+		return 0, types.DbErrNotFound
+	}
+	return m.blocks[len(m.blocks)-1].BlockHeight, nil
+}
+
+func (m *mockBlockModel) GetBlockByHeight(height int64) (*block.Block, error) {
+	for _, b := range m.blocks {
+		if b.BlockHeight == height {
+			return b, nil
+		}
+	}
+	return nil, types.DbErrNotFound
+}
+
+func (m *mockBlockModel) GetBlockByHeightWithoutTx(height int64) (*block.Block, error) {
+	return m.GetBlockByHeight(height)
+}
+
+type mockStateFetcher struct {
+	accounts map[int64]*types.AccountInfo
+	errs     map[int64]error
+}
+
+func (m *mockStateFetcher) GetLatestAccount(accountIndex int64) (*types.AccountInfo, error) {
+	if err := m.errs[accountIndex]; err != nil {
+		return nil, err
+	}
+	return m.accounts[accountIndex], nil
+}
+
+func (m *mockStateFetcher) GetLatestNft(_ int64) (*types.NftInfo, error) {
+	return nil, types.DbErrNotFound
+}
+
+// newTestOfferBook lists the offers 0, 1 and 2 of the accounts 1 and 2, the offer ids are
+// the same as the ids of the rows.
+func newTestOfferBook() (*OfferBook, *mockOfferModel, *mockBlockModel, *mockStateFetcher) {
+	offerModel := &mockOfferModel{}
+	stateFetcher := &mockStateFetcher{
+		accounts: make(map[int64]*types.AccountInfo),
+		errs:     make(map[int64]error),
+	}
+	for accountIndex := int64(1); accountIndex <= 2; accountIndex++ {
+		stateFetcher.accounts[accountIndex] = &types.AccountInfo{
+			AccountIndex: accountIndex,
+			AssetInfo: map[int64]*types.AccountAsset{
+				0: {AssetId: 0, Balance: big.NewInt(0), OfferCanceledOrFinalized: big.NewInt(0)},
+			},
+		}
+	}
+	for offerId := int64(0); offerId <= 2; offerId++ {
+		offerModel.offers = append(offerModel.offers, &offer.Offer{
+			AccountIndex: offerId%2 + 1,
+			OfferId:      offerId,
+			ExpiredAt:    time.Now().Add(time.Hour).UnixMilli(),
+		})
+		offerModel.offers[offerId].ID = uint(offerId)
+	}
+	blockModel := &mockBlockModel{}
+	return NewOfferBook(offerModel, blockModel, stateFetcher, 0), offerModel, blockModel, stateFetcher
+}
+
+func useOfferId(account *types.AccountInfo, offerId int64) {
+	asset := account.AssetInfo[0]
+	asset.OfferCanceledOrFinalized = new(big.Int).SetBit(asset.OfferCanceledOrFinalized, int(offerId), 1)
+}
+
+func newAtomicMatchTx(t *testing.T, hash string, buyer, buyOfferId, seller, sellOfferId int64) *tx.Tx {
+	txInfo, err := json.Marshal(&txtypes.AtomicMatchTxInfo{
+		BuyOffer:  &txtypes.OfferTxInfo{AccountIndex: buyer, OfferId: buyOfferId},
+		SellOffer: &txtypes.OfferTxInfo{AccountIndex: seller, OfferId: sellOfferId},
+	})
+	require.NoError(t, err)
+	return &tx.Tx{TxHash: hash, TxType: types.TxTypeAtomicMatch, TxInfo: string(txInfo)}
+}
+
+func newCancelOfferTx(t *testing.T, hash string, accountIndex, offerId int64) *tx.Tx {
+	txInfo, err := json.Marshal(&txtypes.CancelOfferTxInfo{AccountIndex: accountIndex, OfferId: offerId})
+	require.NoError(t, err)
+	return &tx.Tx{TxHash: hash, TxType: types.TxTypeCancelOffer, TxInfo: string(txInfo)}
+}
+
+func TestIsOfferCanceledOrFinalized(t *testing.T) {
+	account := &types.AccountInfo{
+		AssetInfo: map[int64]*types.AccountAsset{
+			0: {AssetId: 0, Balance: big.NewInt(0), OfferCanceledOrFinalized: big.NewInt(0b101)},
+			1: {AssetId: 1, Balance: big.NewInt(0), OfferCanceledOrFinalized: new(big.Int).Lsh(big.NewInt(1), 1)},
+		},
+	}
+
+	assert.True(t, IsOfferCanceledOrFinalized(account, 0))
+	assert.False(t, IsOfferCanceledOrFinalized(account, 1))
+	assert.True(t, IsOfferCanceledOrFinalized(account, 2))
+	assert.False(t, IsOfferCanceledOrFinalized(account, 128))
+	assert.True(t, IsOfferCanceledOrFinalized(account, 129))
+	assert.False(t, IsOfferCanceledOrFinalized(account, 300))
+}
+
+func TestScanBlock(t *testing.T) {
+	b, offerModel, _, stateFetcher := newTestOfferBook()
+	// offer 0 of account 1 is matched, offer 1 of account 2 is canceled, offer 2 of account 1
+	// is used by another offer
+	for _, offerId := range []int64{0, 2} {
+		useOfferId(stateFetcher.accounts[1], offerId)
+	}
+	useOfferId(stateFetcher.accounts[2], 1)
+	useOfferId(stateFetcher.accounts[2], 5)
+
+	require.NoError(t, b.scanBlock(&block.Block{BlockHeight: 3, Txs: []*tx.Tx{
+		newAtomicMatchTx(t, "match", 1, 0, 2, 5),
+		newCancelOfferTx(t, "cancel", 2, 1),
+	}}))
+	assert.Equal(t, offer.StatusFinalized, offerModel.offers[0].Status)
+	assert.Equal(t, "match", offerModel.offers[0].L2TxHash)
+	assert.Equal(t, offer.StatusCanceled, offerModel.offers[1].Status)
+	assert.Equal(t, "cancel", offerModel.offers[1].L2TxHash)
+	assert.Equal(t, offer.StatusCanceled, offerModel.offers[2].Status)
+	assert.Equal(t, "", offerModel.offers[2].L2TxHash)
+	for _, o := range offerModel.offers {
+		assert.Equal(t, int64(3), o.L2BlockHeight)
+	}
+}
+
+func TestInvalidateRetriesFailedAccounts(t *testing.T) {
+	b, offerModel, blockModel, stateFetcher := newTestOfferBook()
+	blockModel.blocks = []*block.Block{{BlockHeight: 0, StateRoot: "0"}}
+	require.NoError(t, b.poll())
+
+	useOfferId(stateFetcher.accounts[1], 0)
+	useOfferId(stateFetcher.accounts[2], 1)
+	stateFetcher.errs[1] = errors.New("connection reset")
+	blockModel.blocks = append(blockModel.blocks, &block.Block{BlockHeight: 1, StateRoot: "1", Txs: []*tx.Tx{
+		newAtomicMatchTx(t, "match", 2, 1, 1, 0),
+	}})
+
+	// the account failed to be fetched doesn't stop the offers of the others
+	require.NoError(t, b.poll())
+	assert.Equal(t, int64(1), b.scannedHeight)
+	assert.Equal(t, offer.StatusActive, offerModel.offers[0].Status)
+	assert.Equal(t, offer.StatusFinalized, offerModel.offers[1].Status)
+
+	delete(stateFetcher.errs, 1)
+	require.NoError(t, b.poll())
+	assert.Equal(t, offer.StatusFinalized, offerModel.offers[0].Status)
+	assert.Equal(t, "match", offerModel.offers[0].L2TxHash)
+	assert.Equal(t, int64(1), offerModel.offers[0].L2BlockHeight)
+	assert.Len(t, b.retryAccounts, 0)
+	assert.Len(t, b.retryTxs, 0)
+}
+
+func TestStartFromLatestHeight(t *testing.T) {
+	b, offerModel, blockModel, stateFetcher := newTestOfferBook()
+	blockModel.blocks = []*block.Block{
+		{BlockHeight: 1, StateRoot: "1", Txs: []*tx.Tx{newCancelOfferTx(t, "cancel", 1, 0)}},
+	}
+	useOfferId(stateFetcher.accounts[1], 0)
+
+	// the blocks aren't scanned from the genesis if the latest height isn't available
+	blockModel.err = errors.New("connection reset")
+	assert.Error(t, b.poll())
+	assert.False(t, b.started)
+
+	blockModel.err = nil
+	require.NoError(t, b.poll())
+	assert.Equal(t, int64(1), b.scannedHeight)
+	assert.Equal(t, offer.StatusCanceled, offerModel.offers[0].Status)
+	assert.Equal(t, "", offerModel.offers[0].L2TxHash)
+}
+
+func TestRollbackReactivatesOffers(t *testing.T) {
+	b, offerModel, blockModel, stateFetcher := newTestOfferBook()
+	blockModel.blocks = []*block.Block{{BlockHeight: 1, StateRoot: "1"}}
+	require.NoError(t, b.poll())
+
+	useOfferId(stateFetcher.accounts[1], 0)
+	useOfferId(stateFetcher.accounts[1], 2)
+	blockModel.blocks = append(blockModel.blocks,
+		&block.Block{BlockHeight: 2, StateRoot: "2", Txs: []*tx.Tx{newCancelOfferTx(t, "cancel", 1, 0)}},
+		&block.Block{BlockHeight: 3, StateRoot: "3", Txs: []*tx.Tx{newCancelOfferTx(t, "cancel2", 1, 2)}},
+	)
+	require.NoError(t, b.poll())
+	assert.Equal(t, offer.StatusCanceled, offerModel.offers[0].Status)
+	assert.Equal(t, offer.StatusCanceled, offerModel.offers[2].Status)
+
+	// the blocks 2 and 3 are rolled back, block 2 is packed again without canceling offer 0
+	stateFetcher.accounts[1].AssetInfo[0].OfferCanceledOrFinalized = big.NewInt(0)
+	useOfferId(stateFetcher.accounts[1], 2)
+	blockModel.blocks = []*block.Block{blockModel.blocks[0],
+		{BlockHeight: 2, StateRoot: "2'", Txs: []*tx.Tx{newCancelOfferTx(t, "cancel3", 1, 2)}},
+	}
+	require.NoError(t, b.poll())
+	assert.Equal(t, int64(2), b.scannedHeight)
+	assert.Equal(t, offer.StatusActive, offerModel.offers[0].Status)
+	assert.Equal(t, int64(0), offerModel.offers[0].L2BlockHeight)
+	assert.Equal(t, offer.StatusCanceled, offerModel.offers[2].Status)
+	assert.Equal(t, map[int64]string{1: "1", 2: "2'"}, b.scannedRoots)
+
+	// rolled back below the roots kept, the offers are reconciled
+	blockModel.blocks = nil
+	stateFetcher.accounts[1].AssetInfo[0].OfferCanceledOrFinalized = big.NewInt(0)
+	require.NoError(t, b.poll())
+	assert.Equal(t, int64(0), b.scannedHeight)
+	assert.Equal(t, offer.StatusActive, offerModel.offers[2].Status)
+}
@@ -1,25 +1,44 @@
 package svc
 
 import (
+	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/zeromicro/go-zero/core/logx"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
+	"github.com/bnb-chain/zkbnb-go-sdk/client"
+
 	"github.com/bnb-chain/zkbnb/dao/account"
 	"github.com/bnb-chain/zkbnb/dao/asset"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
 	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
+	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
 	"github.com/bnb-chain/zkbnb/dao/tx"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/cache"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/config"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/price"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/fetcher/state"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/notifier"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/offerbook"
+	"github.com/bnb-chain/zkbnb/tree"
 )
 
+// PendingBalanceCaller reads the balances kept by the ZkBNB contract until they are withdrawn,
+// the assets are given by their L1 address.
+type PendingBalanceCaller interface {
+	GetPendingBalance(opts *bind.CallOpts, address common.Address, assetAddr common.Address) (*big.Int, error)
+}
+
 type ServiceContext struct {
 	Config     config.Config
 	RedisCache dbcache.Cache
@@ -32,11 +51,27 @@ type ServiceContext struct {
 	TxModel             tx.TxModel
 	BlockModel          block.BlockModel
 	NftModel            nft.L2NftModel
+	NftHistoryModel     nft.L2NftHistoryModel
 	AssetModel          asset.AssetModel
 	SysConfigModel      sysconfig.SysConfigModel
+	OfferModel          offer.OfferModel
+
+	PriorityRequestModel priorityrequest.PriorityRequestModel
+	L1RollupTxModel      l1rolluptx.L1RollupTxModel
 
 	PriceFetcher price.Fetcher
 	StateFetcher state.Fetcher
+
+	// read only tree context, nil if the tree database is not configured
+	TreeCtx *tree.Context
+	// client of the upstream the txs are forwarded to, nil if the txs aren't forwarded
+	TxForwarder client.ZkBNBClient
+	// publishes the events of the subscriptions
+	Notifier *notifier.Notifier
+	// invalidates the offers of the offer book, nil if the offers aren't accepted
+	OfferBook *offerbook.OfferBook
+	// the ZkBNB contract on L1, nil if L1 is not configured
+	PendingBalanceCaller PendingBalanceCaller
 }
 
 func NewServiceContext(c config.Config) *ServiceContext {
@@ -60,6 +95,50 @@ func NewServiceContext(c config.Config) *ServiceContext {
 	assetModel := asset.NewAssetModel(db)
 	memCache := cache.MustNewMemCache(accountModel, assetModel, c.MemCache.AccountExpiration, c.MemCache.BlockExpiration,
 		c.MemCache.TxExpiration, c.MemCache.AssetExpiration, c.MemCache.PriceExpiration, c.MemCache.MaxCounterNum, c.MemCache.MaxKeyNum)
+	var treeCtx *tree.Context
+	if c.TreeDB.Driver != "" {
+		treeCtx, err = tree.NewContext(c.TreeDB.Service, c.TreeDB.Driver, false, 0,
+			&c.TreeDB.LevelDBOption, &c.TreeDB.PebbleDBOption, &c.TreeDB.RedisDBOption)
+		if err != nil {
+			logx.Must(err)
+		}
+		err = tree.SetupReadOnlyTreeDB(treeCtx)
+		if err != nil {
+			logx.Must(err)
+		}
+	}
+	var txForwarder client.ZkBNBClient
+	if c.ReadOnly.Enabled && c.ReadOnly.L2EndPoint != "" {
+		txForwarder = client.NewZkBNBClient(c.ReadOnly.L2EndPoint)
+	}
+	accountHistoryModel := account.NewAccountHistoryModel(db)
+	blockModel := block.NewBlockModel(db)
+	stateFetcher := state.NewFetcher(redisCache, accountModel, nftModel)
+	var n *notifier.Notifier
+	if c.Subscription.Enabled {
+		n = notifier.NewNotifier(blockModel, accountHistoryModel,
+			time.Duration(c.Subscription.PollInterval)*time.Millisecond)
+		n.Start()
+	}
+	offerModel := offer.NewOfferModel(db)
+	// the offers are only kept by the api server accepting the txs
+	var offerBook *offerbook.OfferBook
+	if c.OfferBook.Enabled && !c.ReadOnly.Enabled {
+		offerBook = offerbook.NewOfferBook(offerModel, blockModel, stateFetcher,
+			time.Duration(c.OfferBook.PollInterval)*time.Millisecond)
+		offerBook.Start()
+	}
+	var pendingBalanceCaller PendingBalanceCaller
+	if c.L1.NetworkRPC != "" && c.L1.ZkBNBContract != "" {
+		cli, err := rpc.NewClient(c.L1.NetworkRPC)
+		if err != nil {
+			logx.Must(err)
+		}
+		pendingBalanceCaller, err = zkbnb.NewZkBNBCaller(common.HexToAddress(c.L1.ZkBNBContract), cli)
+		if err != nil {
+			logx.Must(err)
+		}
+	}
 	return &ServiceContext{
 		Config:              c,
 		RedisCache:          redisCache,
@@ -67,15 +146,26 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		DB:                  db,
 		TxPoolModel:         txPoolModel,
 		AccountModel:        accountModel,
-		AccountHistoryModel: account.NewAccountHistoryModel(db),
+		AccountHistoryModel: accountHistoryModel,
 		TxModel:             tx.NewTxModel(db),
-		BlockModel:          block.NewBlockModel(db),
+		BlockModel:          blockModel,
 		NftModel:            nftModel,
+		NftHistoryModel:     nft.NewL2NftHistoryModel(db),
 		AssetModel:          assetModel,
 		SysConfigModel:      sysconfig.NewSysConfigModel(db),
+		OfferModel:          offerModel,
+
+		PriorityRequestModel: priorityrequest.NewPriorityRequestModel(db),
+		L1RollupTxModel:      l1rolluptx.NewL1RollupTxModel(db),
 
 		PriceFetcher: price.NewFetcher(memCache, assetModel, c.CoinMarketCap.Url, c.CoinMarketCap.Token),
-		StateFetcher: state.NewFetcher(redisCache, accountModel, nftModel),
+		StateFetcher: stateFetcher,
+		TreeCtx:      treeCtx,
+		TxForwarder:  txForwarder,
+		Notifier:     n,
+		OfferBook:    offerBook,
+
+		PendingBalanceCaller: pendingBalanceCaller,
 	}
 }
 
@@ -86,4 +176,13 @@ func (s *ServiceContext) Shutdown() {
 	}
 	_ = s.RedisCache.Close()
 	s.PriceFetcher.Stop()
+	if s.Notifier != nil {
+		s.Notifier.Stop()
+	}
+	if s.OfferBook != nil {
+		s.OfferBook.Stop()
+	}
+	if s.TreeCtx != nil {
+		_ = s.TreeCtx.TreeDB.Close()
+	}
 }
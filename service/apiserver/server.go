@@ -1,6 +1,7 @@
 package apiserver
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/conf"
@@ -10,6 +11,8 @@ import (
 
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/config"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/handler"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/rpc"
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/handler/subscription"
 	"github.com/bnb-chain/zkbnb/service/apiserver/internal/svc"
 )
 
@@ -31,8 +34,55 @@ func Run(configFile string) error {
 
 	server := rest.MustNewServer(c.RestConf, rest.WithCors())
 	handler.RegisterHandlers(server, ctx)
+	registerSubscriptionHandler(server, ctx)
+	registerRpcHandler(server, ctx)
 
 	logx.Infof("apiserver is starting at %s:%d...\n", c.Host, c.Port)
 	server.Start()
 	return nil
 }
+
+// RunReadOnly starts an apiserver serving the read routes in the background, the txs are
+// forwarded to l2EndPoint, or rejected if it's empty. It's used by the fullnode to serve
+// the queries from its local replica, the returned function stops the server.
+func RunReadOnly(configFile string, l2EndPoint string) (stop func()) {
+	var c config.Config
+	conf.MustLoad(configFile, &c)
+	c.ReadOnly.Enabled = true
+	c.ReadOnly.L2EndPoint = l2EndPoint
+
+	ctx := svc.NewServiceContext(c)
+	server := rest.MustNewServer(c.RestConf, rest.WithCors())
+	handler.RegisterHandlers(server, ctx)
+	registerSubscriptionHandler(server, ctx)
+	registerRpcHandler(server, ctx)
+
+	logx.Infof("read only apiserver is starting at %s:%d...\n", c.Host, c.Port)
+	go server.Start()
+	return func() {
+		server.Stop()
+		ctx.Shutdown()
+	}
+}
+
+// registerSubscriptionHandler registers the websocket endpoint of the subscriptions if they
+// are enabled, it's not declared in server.api since goctl doesn't support websocket.
+func registerSubscriptionHandler(server *rest.Server, ctx *svc.ServiceContext) {
+	if ctx.Notifier == nil {
+		return
+	}
+	server.AddRoute(rest.Route{
+		Method:  http.MethodGet,
+		Path:    "/api/v1/ws",
+		Handler: subscription.SubscribeHandler(ctx),
+	})
+}
+
+// registerRpcHandler registers the JSON-RPC 2.0 endpoint serving the logic of the rest routes.
+func registerRpcHandler(server *rest.Server, ctx *svc.ServiceContext) {
+	server.AddRoute(rest.Route{
+		Method:  http.MethodPost,
+		Path:    "/rpc",
+		Handler: rpc.RpcHandler(ctx),
+	})
+}
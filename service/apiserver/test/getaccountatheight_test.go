@@ -0,0 +1,123 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func (s *ApiServerSuite) TestGetAccountAtHeight() {
+	type args struct {
+		index  int64
+		height int64
+	}
+
+	type testcase struct {
+		name     string
+		args     args
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"invalid height", args{0, -1}, 400},
+		{"height too high", args{0, 9999999999}, 400},
+	}
+
+	statusCode, accounts := GetAccounts(s, 0, 100)
+	committedHeight := GetCommittedHeight(s)
+	if statusCode == http.StatusOK && len(accounts.Accounts) > 0 && committedHeight > 0 {
+		tests = append(tests, []testcase{
+			{"found at committed height", args{accounts.Accounts[0].Index, committedHeight}, 200},
+		}...)
+	}
+	if _, height := GetCurrentHeight(s); height != nil && height.Height > committedHeight {
+		tests = append(tests, []testcase{
+			{"height not committed", args{0, height.Height}, 400},
+		}...)
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetAccountAtHeight(s, tt.args.index, tt.args.height)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.Equal(t, tt.args.index, result.Index)
+				assert.True(t, result.Nonce >= 0)
+				assert.NotNil(t, result.Assets)
+				fmt.Printf("result: %+v \n", result)
+			}
+		})
+	}
+
+}
+
+func (s *ApiServerSuite) TestGetAccountAssetAtHeight() {
+	committedHeight := GetCommittedHeight(s)
+	if committedHeight == 0 {
+		return
+	}
+	httpCode, result := GetAccountAssetAtHeight(s, 0, 0, committedHeight)
+	assert.Equal(s.T(), http.StatusOK, httpCode)
+	if httpCode == http.StatusOK {
+		assert.Equal(s.T(), uint32(0), result.Id)
+		assert.NotEmpty(s.T(), result.Balance)
+	}
+	httpCode, _ = GetAccountAssetAtHeight(s, 0, 0, -1)
+	assert.Equal(s.T(), http.StatusBadRequest, httpCode)
+}
+
+// GetCommittedHeight returns the height of the latest committed block, 0 if it's not found.
+func GetCommittedHeight(s *ApiServerSuite) int64 {
+	_, height := GetCurrentHeight(s)
+	if height == nil {
+		return 0
+	}
+	for h := height.Height; h > 0; h-- {
+		httpCode, blockInfo := GetBlock(s, "height", strconv.FormatInt(h, 10))
+		if httpCode == http.StatusOK && blockInfo.Status >= 3 {
+			return h
+		}
+	}
+	return 0
+}
+
+func GetAccountAssetAtHeight(s *ApiServerSuite, index int64, assetId uint32, height int64) (int, *types.AccountAsset) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/accountAsset?by=index&value=%d&asset_id=%d&height=%d", s.url, index, assetId, height))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.AccountAsset{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
+
+func GetAccountAtHeight(s *ApiServerSuite, index, height int64) (int, *types.Account) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/account?by=index&value=%s&height=%d", s.url, strconv.FormatInt(index, 10), height))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.Account{}
+	//nolint: errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
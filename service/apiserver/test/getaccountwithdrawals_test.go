@@ -0,0 +1,77 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func (s *ApiServerSuite) TestGetAccountWithdrawals() {
+	type args struct {
+		by     string
+		value  string
+		offset int
+		limit  int
+	}
+
+	type testcase struct {
+		name     string
+		args     args
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"not found by index", args{"account_index", "9999999", 0, 10}, 200},
+		{"not found by name", args{"account_name", "notexists.legend", 0, 10}, 200},
+		{"invalidby", args{"invalidby", "", 0, 10}, 400},
+		{"invalid limit", args{"account_index", "0", 0, 0}, 400},
+	}
+
+	statusCode, accounts := GetAccounts(s, 0, 100)
+	if statusCode == http.StatusOK {
+		for _, account := range accounts.Accounts {
+			tests = append(tests, testcase{"found by name " + account.Name, args{"account_name", account.Name, 0, 10}, 200})
+		}
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetAccountWithdrawals(s, tt.args.by, tt.args.value, tt.args.offset, tt.args.limit)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode != http.StatusOK {
+				return
+			}
+			for _, withdrawal := range result.Withdrawals {
+				assert.NotEmpty(t, withdrawal.Hash)
+				assert.NotEmpty(t, withdrawal.Stage)
+				if withdrawal.InPendingBalance {
+					assert.NotEmpty(t, withdrawal.VerifiedTxHash)
+				}
+			}
+			fmt.Printf("result: %+v \n", result)
+		})
+	}
+}
+
+func GetAccountWithdrawals(s *ApiServerSuite, by, value string, offset, limit int) (int, *types.Withdrawals) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/accountWithdrawals?by=%s&value=%s&offset=%d&limit=%d",
+		s.url, by, value, offset, limit))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.Withdrawals{}
+	_ = json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
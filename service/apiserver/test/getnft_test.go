@@ -0,0 +1,94 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func (s *ApiServerSuite) TestGetNft() {
+	type testcase struct {
+		name     string
+		index    int64
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"not found", 9999999999, 400},
+		{"invalid index", -1, 400},
+	}
+
+	statusCode, accounts := GetAccounts(s, 0, 100)
+	if statusCode == http.StatusOK {
+		for _, account := range accounts.Accounts {
+			statusCode, nfts := GetAccountNfts(s, "account_index", strconv.Itoa(int(account.Index)), 0, 1)
+			if statusCode == http.StatusOK && len(nfts.Nfts) > 0 {
+				tests = append(tests, testcase{"found", nfts.Nfts[0].Index, 200})
+				break
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetNft(s, tt.index)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.index, result.Index)
+			fmt.Printf("result: %+v \n", result)
+
+			httpCode, nfts := GetCollectionNfts(s, result.CreatorAccountIndex, result.CollectionId, 0, 10)
+			assert.Equal(t, http.StatusOK, httpCode)
+			assert.True(t, nfts.Total > 0)
+
+			httpCode, collections := GetAccountCollections(s, "account_index", strconv.Itoa(int(result.CreatorAccountIndex)), 0, 10)
+			assert.Equal(t, http.StatusOK, httpCode)
+			fmt.Printf("collections: %+v \n", collections)
+
+			httpCode, histories := GetNftHistories(s, result.Index, 0, 10)
+			assert.Equal(t, http.StatusOK, httpCode)
+			assert.True(t, len(histories.Histories) > 0)
+			assert.Equal(t, result.OwnerAccountIndex, histories.Histories[0].OwnerAccountIndex)
+
+			httpCode, txs := GetNftTxs(s, result.Index, 0, 10)
+			assert.Equal(t, http.StatusOK, httpCode)
+			assert.True(t, len(txs.Txs) > 0)
+		})
+	}
+}
+
+func GetNft(s *ApiServerSuite, index int64) (int, *types.Nft) {
+	result := types.Nft{}
+	return getApi(s, fmt.Sprintf("%s/api/v1/nft?index=%d", s.url, index), &result), &result
+}
+
+func GetCollectionNfts(s *ApiServerSuite, accountIndex, collectionId int64, offset, limit int) (int, *types.Nfts) {
+	result := types.Nfts{}
+	return getApi(s, fmt.Sprintf("%s/api/v1/collectionNfts?account_index=%d&collection_id=%d&offset=%d&limit=%d",
+		s.url, accountIndex, collectionId, offset, limit), &result), &result
+}
+
+func GetAccountCollections(s *ApiServerSuite, by, value string, offset, limit int) (int, *types.Collections) {
+	result := types.Collections{}
+	return getApi(s, fmt.Sprintf("%s/api/v1/accountCollections?by=%s&value=%s&offset=%d&limit=%d",
+		s.url, by, value, offset, limit), &result), &result
+}
+
+func GetNftHistories(s *ApiServerSuite, index int64, offset, limit int) (int, *types.NftHistories) {
+	result := types.NftHistories{}
+	return getApi(s, fmt.Sprintf("%s/api/v1/nftHistories?index=%d&offset=%d&limit=%d",
+		s.url, index, offset, limit), &result), &result
+}
+
+func GetNftTxs(s *ApiServerSuite, index int64, offset, limit int) (int, *types.Txs) {
+	result := types.Txs{}
+	return getApi(s, fmt.Sprintf("%s/api/v1/nftTxs?index=%d&offset=%d&limit=%d",
+		s.url, index, offset, limit), &result), &result
+}
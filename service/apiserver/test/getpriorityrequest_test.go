@@ -0,0 +1,56 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func (s *ApiServerSuite) TestGetPriorityRequest() {
+	type testcase struct {
+		name      string
+		l1TxHash  string
+		httpCode  int
+		requestId int64
+	}
+
+	tests := []testcase{
+		{"not found", "0x" + strings.Repeat("0", 64), 400, 0},
+		{"invalid hash", "0x1234", 400, 0},
+	}
+
+	statusCode, requests := GetAccountPriorityRequests(s, "account_index", "0", 0, 1)
+	if statusCode == http.StatusOK && len(requests.PriorityRequests) > 0 {
+		request := requests.PriorityRequests[0]
+		tests = append(tests, testcase{"found", request.L1TxHash, 200, request.RequestId})
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetPriorityRequest(s, tt.l1TxHash)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.requestId, result.RequestId)
+			assert.True(t, result.Progress > 0)
+			fmt.Printf("result: %+v \n", result)
+		})
+	}
+}
+
+func GetPriorityRequest(s *ApiServerSuite, l1TxHash string) (int, *types.PriorityRequest) {
+	result := types.PriorityRequest{}
+	return getApi(s, fmt.Sprintf("%s/api/v1/priorityRequest?l1TxHash=%s", s.url, l1TxHash), &result), &result
+}
+
+func GetAccountPriorityRequests(s *ApiServerSuite, by, value string, offset, limit int) (int, *types.PriorityRequests) {
+	result := types.PriorityRequests{}
+	return getApi(s, fmt.Sprintf("%s/api/v1/accountPriorityRequests?by=%s&value=%s&offset=%d&limit=%d",
+		s.url, by, value, offset, limit), &result), &result
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb/service/apiserver/internal/types"
+)
+
+func (s *ApiServerSuite) TestGetAccountProof() {
+	type args struct {
+		index   int64
+		assetId int64
+	}
+
+	type testcase struct {
+		name     string
+		args     args
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"invalid index", args{-1, 0}, 400},
+		{"invalid asset id", args{0, 1 << 16}, 400},
+		{"not found", args{9999999, 0}, 400},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetAccountProof(s, tt.args.index, tt.args.assetId)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.NotNil(t, result.Account)
+				assert.NotNil(t, result.Asset)
+				assert.Equal(t, 32, len(result.AccountMerkleProof))
+				assert.Equal(t, 16, len(result.AssetMerkleProof))
+				fmt.Printf("result: %+v \n", result)
+			}
+		})
+	}
+}
+
+func (s *ApiServerSuite) TestGetNftProof() {
+	type testcase struct {
+		name     string
+		index    int64
+		httpCode int
+	}
+
+	tests := []testcase{
+		{"invalid index", -1, 400},
+		{"not found", 9999999, 400},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			httpCode, result := GetNftProof(s, tt.index)
+			assert.Equal(t, tt.httpCode, httpCode)
+			if httpCode == http.StatusOK {
+				assert.NotNil(t, result.Nft)
+				assert.Equal(t, 40, len(result.NftMerkleProof))
+				fmt.Printf("result: %+v \n", result)
+			}
+		})
+	}
+}
+
+func GetAccountProof(s *ApiServerSuite, index, assetId int64) (int, *types.AccountProof) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/accountProof?index=%d&assetId=%d", s.url, index, assetId))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.AccountProof{}
+	//nolint:errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
+
+func GetNftProof(s *ApiServerSuite, index int64) (int, *types.NftProof) {
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/nftProof?index=%d", s.url, index))
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	result := types.NftProof{}
+	//nolint:errcheck
+	json.Unmarshal(body, &result)
+	return resp.StatusCode, &result
+}
@@ -1,11 +1,15 @@
 package test
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os/exec"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/service"
@@ -113,3 +117,21 @@ func (s *ApiServerSuite) TearDownSuite() {
 	s.server.Stop()
 	testDBShutdown()
 }
+
+// getApi gets the url and decodes the response into the result if it succeeds, it returns
+// the status code of the response.
+func getApi(s *ApiServerSuite, url string, result interface{}) int {
+	resp, err := http.Get(url)
+	assert.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(s.T(), err)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode
+	}
+	//nolint:errcheck
+	json.Unmarshal(body, result)
+	return resp.StatusCode
+}
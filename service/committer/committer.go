@@ -31,6 +31,10 @@ func Run(configFile string) error {
 		_ = logx.Close()
 	})
 
+	if c.AdminAddr != "" {
+		committer.ServeAdmin(c.AdminAddr)
+	}
+
 	logx.Info("committer is starting......")
 	committer.Run()
 	return nil
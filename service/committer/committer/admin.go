@@ -0,0 +1,53 @@
+package committer
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/dao/tx"
+)
+
+type prefetchResult struct {
+	PendingTxs int `json:"pending_txs"`
+	Prefetched int `json:"prefetched"`
+}
+
+// PrefetchPendingAccounts preloads the asset trees of the accounts referenced by the
+// pending txs of the pool, so that executing them doesn't wait for the trees to open.
+func (c *Committer) PrefetchPendingAccounts() (pendingTxs int, prefetched int, err error) {
+	txs, err := c.bc.TxPoolModel.GetTxsByStatus(tx.StatusPending)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(txs), c.bc.PrefetchAssetTrees(txs), nil
+}
+
+// ServeAdmin serves the admin hooks of the committer on the address.
+func (c *Committer) ServeAdmin(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/prefetch", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		pendingTxs, prefetched, err := c.PrefetchPendingAccounts()
+		if err != nil {
+			logx.Errorf("prefetch pending accounts failed: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logx.Infof("prefetched %d asset trees for %d pending txs", prefetched, pendingTxs)
+		w.Header().Set("Content-Type", "application/json")
+		//nolint: errcheck
+		json.NewEncoder(w).Encode(&prefetchResult{PendingTxs: pendingTxs, Prefetched: prefetched})
+	})
+
+	go func() {
+		logx.Infof("starting committer admin server, addr=%s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logx.Errorf("committer admin server failed: %v", err)
+		}
+	}()
+}
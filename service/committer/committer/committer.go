@@ -3,6 +3,7 @@ package committer
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -67,7 +68,16 @@ type Config struct {
 
 	BlockConfig struct {
 		OptionalBlockSizes []int
+		// Finish and persist each block in the background while the txs of the next
+		// block are executed.
+		//nolint:staticcheck
+		PipelinedCommit bool `json:",optional"`
 	}
+	// Address of the admin hooks, such as POST /admin/prefetch to preload the asset
+	// trees of the accounts referenced by the pending txs. Disabled if not set.
+	//nolint:staticcheck
+	AdminAddr string `json:",optional"`
+
 	LogConf logx.LogConf
 }
 
@@ -76,8 +86,13 @@ type Committer struct {
 	config             *Config
 	maxTxsPerBlock     int
 	optionalBlockSizes []int
+	pipelined          bool
+	inflight           *inflightBlock
+	inflightWg         sync.WaitGroup
 
 	bc *core.BlockChain
+	// persist writes the states of a block to the database, it's persistBlock
+	persist func(blockStates *block.BlockStates) error
 }
 
 func NewCommitter(config *Config) (*Committer, error) {
@@ -120,9 +135,11 @@ func NewCommitter(config *Config) (*Committer, error) {
 		config:             config,
 		maxTxsPerBlock:     config.BlockConfig.OptionalBlockSizes[len(config.BlockConfig.OptionalBlockSizes)-1],
 		optionalBlockSizes: config.BlockConfig.OptionalBlockSizes,
+		pipelined:          config.BlockConfig.PipelinedCommit,
 
 		bc: bc,
 	}
+	committer.persist = committer.persistBlock
 	return committer, nil
 }
 
@@ -160,6 +177,7 @@ func (c *Committer) Run() {
 				break
 			}
 
+			c.finishInflight(false)
 			time.Sleep(100 * time.Millisecond)
 			pendingTxs, err = c.bc.TxPoolModel.GetTxsByStatus(tx.StatusPending)
 			if err != nil {
@@ -171,6 +189,7 @@ func (c *Committer) Run() {
 		pendingTxNumMetrics.Set(float64(len(pendingTxs)))
 		pendingUpdatePoolTxs := make([]*tx.Tx, 0, len(pendingTxs))
 		pendingDeletePoolTxs := make([]*tx.Tx, 0, len(pendingTxs))
+		var firstPoolTx *tx.Tx
 		start := time.Now()
 		for _, poolTx := range pendingTxs {
 			if c.shouldCommit(curBlock) {
@@ -204,9 +223,14 @@ func (c *Committer) Run() {
 
 			// Write the proposed block into database when the first transaction executed.
 			if len(c.bc.Statedb.Txs) == 1 {
-				err = c.createNewBlock(curBlock, poolTx)
-				if err != nil {
-					panic("create new block failed" + err.Error())
+				if c.inflight != nil {
+					// The block is written once the previous block is persisted.
+					firstPoolTx = poolTx
+				} else {
+					err = c.createNewBlock(curBlock, poolTx)
+					if err != nil {
+						panic("create new block failed" + err.Error())
+					}
 				}
 			} else {
 				pendingUpdatePoolTxs = append(pendingUpdatePoolTxs, poolTx)
@@ -214,6 +238,16 @@ func (c *Committer) Run() {
 		}
 		executeTxOperationMetrics.Set(float64(time.Since(start).Milliseconds()))
 
+		// The states of the executed txs are written after the previous block, so that
+		// the blocks are persisted in order.
+		c.finishInflight(true)
+		if firstPoolTx != nil {
+			err = c.createNewBlock(curBlock, firstPoolTx)
+			if err != nil {
+				panic("create new block failed" + err.Error())
+			}
+		}
+
 		err = c.bc.StateDB().SyncStateCacheToRedis()
 		if err != nil {
 			panic("sync redis cache failed: " + err.Error())
@@ -233,7 +267,11 @@ func (c *Committer) Run() {
 		if c.shouldCommit(curBlock) {
 			start := time.Now()
 			logx.Infof("commit new block, height=%d, blockSize=%d", curBlock.BlockHeight, curBlock.BlockSize)
-			curBlock, err = c.commitNewBlock(curBlock)
+			if c.pipelined {
+				curBlock, err = c.detachNewBlock()
+			} else {
+				curBlock, err = c.commitNewBlock(curBlock)
+			}
 			logx.Infof("commit new block success")
 
 			if err != nil {
@@ -247,6 +285,8 @@ func (c *Committer) Run() {
 
 func (c *Committer) Shutdown() {
 	c.running = false
+	// wait for the block committed in the background
+	c.inflightWg.Wait()
 	c.bc.Statedb.Close()
 	c.bc.ChainDB.Close()
 }
@@ -325,8 +365,19 @@ func (c *Committer) commitNewBlock(curBlock *block.Block) (*block.Block, error)
 	stateDBSyncOperationMetics.Set(float64(time.Since(start).Milliseconds()))
 
 	start = time.Now()
+	err = c.persist(blockStates)
+	if err != nil {
+		return nil, err
+	}
+	sqlDBOperationMetics.Set(float64(time.Since(start).Milliseconds()))
+
+	return blockStates.Block, nil
+}
+
+func (c *Committer) persistBlock(blockStates *block.BlockStates) error {
+	var err error
 	// update db
-	err = c.bc.DB().DB.Transaction(func(tx *gorm.DB) error {
+	return c.bc.DB().DB.Transaction(func(tx *gorm.DB) error {
 		// create block for commit
 		if blockStates.CompressedBlock != nil {
 			err = c.bc.DB().CompressedBlockModel.CreateCompressedBlockInTransact(tx, blockStates.CompressedBlock)
@@ -371,12 +422,6 @@ func (c *Committer) commitNewBlock(curBlock *block.Block) (*block.Block, error)
 		blockStates.Block.ClearTxsModel()
 		return c.bc.DB().BlockModel.UpdateBlockInTransact(tx, blockStates.Block)
 	})
-	if err != nil {
-		return nil, err
-	}
-	sqlDBOperationMetics.Set(float64(time.Since(start).Milliseconds()))
-
-	return blockStates.Block, nil
 }
 
 func (c *Committer) computeCurrentBlockSize() int {
@@ -0,0 +1,102 @@
+package committer
+
+import (
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/dao/block"
+)
+
+// inflightBlock is a block finished and persisted in the background, while the txs of
+// the next block are executed.
+type inflightBlock struct {
+	detached *core.DetachedBlock
+	done     chan struct{}
+
+	blockStates *block.BlockStates
+	err         error
+}
+
+// detachNewBlock detaches the executed block and finishes it in the background. Only one
+// block is in flight: it is finished before the states of the next block are written.
+func (c *Committer) detachNewBlock() (*block.Block, error) {
+	blockSize := c.computeCurrentBlockSize()
+	detached, err := c.bc.DetachBlock(blockSize, c.bc.CurrentBlock().CreatedAt.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	inflight := &inflightBlock{
+		detached: detached,
+		done:     make(chan struct{}),
+	}
+	c.inflight = inflight
+	c.inflightWg.Add(1)
+	go func() {
+		defer c.inflightWg.Done()
+		defer close(inflight.done)
+
+		start := time.Now()
+		inflight.blockStates, inflight.err = c.bc.FinishBlock(detached)
+		if inflight.err != nil {
+			return
+		}
+		stateDBOperationMetics.Set(float64(time.Since(start).Milliseconds()))
+
+		start = time.Now()
+		inflight.err = c.persist(inflight.blockStates)
+		if inflight.err != nil {
+			return
+		}
+		sqlDBOperationMetics.Set(float64(time.Since(start).Milliseconds()))
+	}()
+	return c.bc.CurrentBlock(), nil
+}
+
+// finishInflight releases the block in flight once it is persisted, it waits for the
+// block if wait is set. If the block fails to persist, it's persisted again and the
+// committer falls back to commit the blocks serially. A block failing to finish leaves
+// the trees in an unknown state, the committer stops as it does in serial mode.
+func (c *Committer) finishInflight(wait bool) {
+	inflight := c.inflight
+	if inflight == nil {
+		return
+	}
+	if wait {
+		<-inflight.done
+	} else {
+		select {
+		case <-inflight.done:
+		default:
+			return
+		}
+	}
+	c.inflight = nil
+
+	height := inflight.detached.Height()
+	if inflight.err != nil {
+		if inflight.blockStates == nil {
+			panic("finish block failed: " + inflight.err.Error())
+		}
+		logx.Errorf("persist block in background failed, height=%d, err=%v, falling back to serial commit", height, inflight.err)
+		c.pipelined = false
+		err := c.persist(inflight.blockStates)
+		if err != nil {
+			panic("persist block failed: " + err.Error())
+		}
+	}
+	err := c.bc.ReleaseBlock(inflight.detached)
+	if err != nil {
+		panic("release block failed: " + err.Error())
+	}
+
+	start := time.Now()
+	err = c.bc.Statedb.SyncGasAccountToRedis()
+	if err != nil {
+		panic("sync gas account failed: " + err.Error())
+	}
+	stateDBSyncOperationMetics.Set(float64(time.Since(start).Milliseconds()))
+	logx.Infof("block finished in background, height=%d", height)
+}
@@ -0,0 +1,188 @@
+package committer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb/core"
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type mockBlockModel struct {
+	block.BlockModel
+}
+
+func (m *mockBlockModel) GetLatestVerifiedHeight() (int64, error) {
+	return 0, nil
+}
+
+type mockAccountModel struct {
+	account.AccountModel
+}
+
+func (m *mockAccountModel) GetAccountByIndex(_ int64) (*account.Account, error) {
+	return nil, types.DbErrNotFound
+}
+
+type mockAccountHistoryModel struct {
+	account.AccountHistoryModel
+}
+
+func (m *mockAccountHistoryModel) GetValidAccountCount(_ int64) (int64, error) {
+	return 0, nil
+}
+
+type mockNftHistoryModel struct {
+	nft.L2NftHistoryModel
+}
+
+func (m *mockNftHistoryModel) GetLatestNftsCountByBlockHeight(_ int64) (int64, error) {
+	return 0, nil
+}
+
+// testCommitter commits the blocks with the pipeline, the blocks are persisted by persist.
+type testCommitter struct {
+	*Committer
+	persisted []int64
+}
+
+func newTestCommitter(t *testing.T, persist func(blockStates *block.BlockStates) error) *testCommitter {
+	treeCtx, err := tree.NewContext("committer", tree.LevelDB, true, 0,
+		&tree.LevelDBOption{File: t.TempDir(), Cache: 16, Handles: 16}, nil, nil)
+	require.NoError(t, err)
+	chainDb := &sdb.ChainDB{
+		BlockModel:          &mockBlockModel{},
+		AccountModel:        &mockAccountModel{},
+		AccountHistoryModel: &mockAccountHistoryModel{},
+		L2NftHistoryModel:   &mockNftHistoryModel{},
+	}
+	bc, err := core.NewBlockChainForReplay(chainDb, treeCtx, dbcache.NewMemoryCache(), 16,
+		&block.Block{BlockStatus: block.StatusVerifiedAndExecuted})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = treeCtx.TreeDB.Close()
+	})
+	stateRoot := common.Bytes2Hex(tree.ComputeStateRootHash(bc.Statedb.AccountTree.Root(), bc.Statedb.NftTree.Root()))
+	bc.CurrentBlock().StateRoot = stateRoot
+	bc.Statedb.StateRoot = stateRoot
+
+	c := &testCommitter{
+		Committer: &Committer{
+			running:            true,
+			maxTxsPerBlock:     1,
+			optionalBlockSizes: []int{1},
+			pipelined:          true,
+			bc:                 bc,
+		},
+	}
+	c.persist = func(blockStates *block.BlockStates) error {
+		err := persist(blockStates)
+		if err == nil {
+			c.persisted = append(c.persisted, blockStates.Block.BlockHeight)
+		}
+		return err
+	}
+	return c
+}
+
+// executeBlock starts a new block and credits account 2 with the height of the block.
+func (c *testCommitter) executeBlock(t *testing.T) {
+	_, err := c.bc.InitNewBlock()
+	require.NoError(t, err)
+	curBlock := c.bc.CurrentBlock()
+	curBlock.CreatedAt = time.UnixMilli(1660000000000 + curBlock.BlockHeight*1000)
+
+	s := c.bc.Statedb
+	accountInfo, err := s.GetFormatAccount(2)
+	if err == types.AppErrAccountNotFound {
+		accountInfo = &types.AccountInfo{
+			AccountIndex:    2,
+			PublicKey:       fmt.Sprintf("%064x", 3),
+			AccountNameHash: fmt.Sprintf("%064x", 102),
+			AssetInfo: map[int64]*types.AccountAsset{
+				0: {AssetId: 0, Balance: big.NewInt(0), OfferCanceledOrFinalized: big.NewInt(0)},
+			},
+		}
+	} else {
+		require.NoError(t, err)
+	}
+	accountInfo.AssetInfo[0].Balance = new(big.Int).Add(accountInfo.AssetInfo[0].Balance, big.NewInt(curBlock.BlockHeight))
+	accountInfo.Nonce++
+	s.SetPendingAccount(2, accountInfo)
+	s.MarkAccountAssetsDirty(2, []int64{0})
+	require.NoError(t, s.SyncStateCacheToRedis())
+}
+
+func TestPipelinePersistsInOrder(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestCommitter(t, func(*block.BlockStates) error {
+		<-release
+		return nil
+	})
+
+	c.executeBlock(t)
+	_, err := c.detachNewBlock()
+	require.NoError(t, err)
+	c.executeBlock(t)
+
+	// block 2 is executed before block 1 is persisted, block 1 is kept in flight
+	c.finishInflight(false)
+	require.NotNil(t, c.inflight)
+	assert.Equal(t, "", c.bc.CurrentBlock().StateRoot)
+
+	release <- struct{}{}
+	c.finishInflight(true)
+	assert.Nil(t, c.inflight)
+	assert.Equal(t, []int64{1}, c.persisted)
+	assert.NotEqual(t, "", c.bc.CurrentBlock().StateRoot)
+
+	_, err = c.detachNewBlock()
+	require.NoError(t, err)
+	close(release)
+	c.finishInflight(true)
+	assert.Equal(t, []int64{1, 2}, c.persisted)
+	assert.True(t, c.pipelined)
+}
+
+func TestPipelineFallsBackToSerial(t *testing.T) {
+	calls := 0
+	c := newTestCommitter(t, func(*block.BlockStates) error {
+		calls++
+		if calls == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+
+	c.executeBlock(t)
+	_, err := c.detachNewBlock()
+	require.NoError(t, err)
+	c.executeBlock(t)
+	c.finishInflight(true)
+
+	// the block failing in the background is persisted again, the next blocks are serial
+	assert.False(t, c.pipelined)
+	assert.Nil(t, c.inflight)
+	assert.Equal(t, []int64{1}, c.persisted)
+	stateRoot := c.bc.CurrentBlock().StateRoot
+	assert.NotEqual(t, "", stateRoot)
+
+	newBlock, err := c.commitNewBlock(c.bc.CurrentBlock())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), newBlock.BlockHeight)
+	assert.NotEqual(t, stateRoot, newBlock.StateRoot)
+	assert.Equal(t, []int64{1, 2}, c.persisted)
+}
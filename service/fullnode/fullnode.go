@@ -7,6 +7,7 @@ import (
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
 
+	"github.com/bnb-chain/zkbnb/service/apiserver"
 	"github.com/bnb-chain/zkbnb/service/fullnode/fullnode"
 )
 
@@ -24,9 +25,19 @@ func Run(configFile string) error {
 		return err
 	}
 
+	stopAPIServer := func() {}
+	if config.APIServer.ConfigFile != "" {
+		l2EndPoint := ""
+		if config.APIServer.ForwardTxs {
+			l2EndPoint = config.L2EndPoint
+		}
+		stopAPIServer = apiserver.RunReadOnly(config.APIServer.ConfigFile, l2EndPoint)
+	}
+
 	proc.SetTimeToForceQuit(GracefulShutdownTimeout)
 	proc.AddShutdownListener(func() {
 		logx.Info("start to shutdown fullnode......")
+		stopAPIServer()
 		node.Shutdown()
 		_ = logx.Close()
 	})
@@ -34,3 +45,17 @@ func Run(configFile string) error {
 	node.Run()
 	return nil
 }
+
+// Resync rolls back the fullnode to the height, the fullnode must be stopped.
+func Resync(configFile string, height int64) error {
+	var config fullnode.Config
+	conf.MustLoad(configFile, &config)
+	logx.MustSetup(config.LogConf)
+	logx.DisableStat()
+	defer logx.Close()
+
+	if len(config.DivergenceReportDir) == 0 {
+		config.DivergenceReportDir = fullnode.DefaultDivergenceReportDir
+	}
+	return fullnode.Resync(&config, height)
+}
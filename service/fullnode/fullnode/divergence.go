@@ -0,0 +1,218 @@
+package fullnode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	sdkTypes "github.com/bnb-chain/zkbnb-go-sdk/types"
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	DefaultDivergenceReportDir = "./divergence"
+
+	divergenceMarkerFile = "diverged.json"
+	remoteQueryTimeout   = 10 * time.Second
+)
+
+// DivergenceReport records a block whose execution diverges from the remote block. The
+// api doesn't serve the tx details, so the local tx details are reported with the remote
+// txs, and the states of the accounts touched with their remote states at the height.
+type DivergenceReport struct {
+	Height          int64              `json:"height"`
+	Reason          string             `json:"reason"`
+	LocalStateRoot  string             `json:"local_state_root"`
+	RemoteStateRoot string             `json:"remote_state_root"`
+	Txs             []*DivergedTx      `json:"txs"`
+	Accounts        []*DivergedAccount `json:"accounts"`
+	CreatedAt       int64              `json:"created_at"`
+}
+
+type DivergedTx struct {
+	Remote         *sdkTypes.Tx   `json:"remote"`
+	LocalTxHash    string         `json:"local_tx_hash"`
+	LocalTxDetails []*tx.TxDetail `json:"local_tx_details"`
+	Error          string         `json:"error,omitempty"`
+}
+
+type DivergedAccount struct {
+	AccountIndex int64              `json:"account_index"`
+	Local        *types.AccountInfo `json:"local"`
+	Remote       json.RawMessage    `json:"remote,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// divergence is the state of a halted fullnode, it's kept in the marker file of the
+// report dir so that the fullnode stays halted once restarted.
+type divergence struct {
+	Height int64  `json:"height"`
+	Reason string `json:"reason"`
+	Report string `json:"report"`
+}
+
+type health struct {
+	Status     string      `json:"status"`
+	Height     int64       `json:"height"`
+	Divergence *divergence `json:"divergence,omitempty"`
+}
+
+// diverge records the report of the block and halts the fullnode.
+func (c *Fullnode) diverge(report *DivergenceReport) {
+	report.CreatedAt = time.Now().UnixMilli()
+	c.collectAccounts(report)
+
+	d := &divergence{Height: report.Height, Reason: report.Reason}
+	err := func() error {
+		err := os.MkdirAll(c.config.DivergenceReportDir, 0o755)
+		if err != nil {
+			return err
+		}
+		d.Report = filepath.Join(c.config.DivergenceReportDir, fmt.Sprintf("block_%d.json", report.Height))
+		err = writeJSON(d.Report, report)
+		if err != nil {
+			return err
+		}
+		return writeJSON(filepath.Join(c.config.DivergenceReportDir, divergenceMarkerFile), d)
+	}()
+	if err != nil {
+		logx.Errorf("write divergence report failed: %v", err)
+	}
+	c.diverged.Store(d)
+	logx.Errorf("fullnode diverged at height %d: %s, report: %s, run `zkbnb fullnode resync --from %d` to sync again",
+		report.Height, report.Reason, d.Report, report.Height-1)
+}
+
+// collectAccounts adds the accounts touched by the local txs of the report, and the ones
+// referenced by the remote txs, which are not touched locally by the txs failing to apply.
+// The remote states are only queried when syncing from L2EndPoint.
+func (c *Fullnode) collectAccounts(report *DivergenceReport) {
+	touched := make(map[int64]bool)
+	var accountIndexes []int64
+	touch := func(accountIndex int64) {
+		if accountIndex < 0 || touched[accountIndex] {
+			return
+		}
+		touched[accountIndex] = true
+		accountIndexes = append(accountIndexes, accountIndex)
+	}
+	for _, divergedTx := range report.Txs {
+		for _, detail := range divergedTx.LocalTxDetails {
+			touch(detail.AccountIndex)
+		}
+		if divergedTx.Remote != nil {
+			touch(divergedTx.Remote.AccountIndex)
+			for _, accountIndex := range core.TxAccountIndexes(divergedTx.Remote.Info) {
+				touch(accountIndex)
+			}
+		}
+	}
+	sort.Slice(accountIndexes, func(i, j int) bool {
+		return accountIndexes[i] < accountIndexes[j]
+	})
+
+	for _, accountIndex := range accountIndexes {
+		divergedAccount := &DivergedAccount{AccountIndex: accountIndex}
+		local, err := c.bc.Statedb.GetFormatAccount(accountIndex)
+		if err != nil {
+			divergedAccount.Error = err.Error()
+		}
+		divergedAccount.Local = local
+		if c.l1Source == nil {
+			divergedAccount.Remote, err = c.getRemoteAccount(accountIndex, report.Height)
+			if err != nil {
+				divergedAccount.Error = err.Error()
+			}
+		}
+		report.Accounts = append(report.Accounts, divergedAccount)
+	}
+}
+
+func (c *Fullnode) getRemoteAccount(accountIndex, height int64) (json.RawMessage, error) {
+	httpClient := &http.Client{Timeout: remoteQueryTimeout}
+	resp, err := httpClient.Get(fmt.Sprintf("%s/api/v1/account?by=index&value=%d&height=%d",
+		c.config.L2EndPoint, accountIndex, height))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get remote account failed: %s", string(body))
+	}
+	return body, nil
+}
+
+// loadDivergence returns the divergence the fullnode is halted by, nil if there's none.
+func loadDivergence(dir string) (*divergence, error) {
+	content, err := os.ReadFile(filepath.Join(dir, divergenceMarkerFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := &divergence{}
+	err = json.Unmarshal(content, d)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// clearDivergence removes the marker of the divergence, the reports are kept.
+func clearDivergence(dir string) error {
+	err := os.Remove(filepath.Join(dir, divergenceMarkerFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func writeJSON(path string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// startHealthServer serves the sync status on /health, it responds 503 once diverged.
+func (c *Fullnode) startHealthServer() {
+	if c.config.HealthListenOn == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		h := &health{Status: "syncing", Height: c.SyncedHeight()}
+		statusCode := http.StatusOK
+		if d, ok := c.diverged.Load().(*divergence); ok {
+			h.Status = "diverged"
+			h.Divergence = d
+			statusCode = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(h)
+	})
+	c.healthServer = &http.Server{Addr: c.config.HealthListenOn, Handler: mux}
+	go func() {
+		err := c.healthServer.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			logx.Errorf("health server failed: %v", err)
+		}
+	}()
+	logx.Infof("health server is listening on %s", c.config.HealthListenOn)
+}
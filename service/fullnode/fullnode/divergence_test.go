@@ -0,0 +1,98 @@
+package fullnode
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdkTypes "github.com/bnb-chain/zkbnb-go-sdk/types"
+	"github.com/bnb-chain/zkbnb/core"
+	"github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+func TestDivergenceMarker(t *testing.T) {
+	dir := t.TempDir()
+
+	d, err := loadDivergence(dir)
+	require.NoError(t, err)
+	assert.Nil(t, d)
+
+	expected := &divergence{Height: 12, Reason: "state root not matched", Report: filepath.Join(dir, "block_12.json")}
+	require.NoError(t, writeJSON(filepath.Join(dir, divergenceMarkerFile), expected))
+	d, err = loadDivergence(dir)
+	require.NoError(t, err)
+	assert.Equal(t, expected, d)
+
+	require.NoError(t, clearDivergence(dir))
+	d, err = loadDivergence(dir)
+	require.NoError(t, err)
+	assert.Nil(t, d)
+	// clearing twice is allowed, e.g. a resync without divergence
+	require.NoError(t, clearDivergence(dir))
+}
+
+type mockAccountModel struct {
+	account.AccountModel
+}
+
+func (m *mockAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	if accountIndex != 1 {
+		return nil, types.DbErrNotFound
+	}
+	return &account.Account{AccountIndex: 1, AssetInfo: "{}"}, nil
+}
+
+func TestCollectAccounts(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "12", r.FormValue("height"))
+		_, _ = fmt.Fprintf(w, `{"index":%s}`, r.FormValue("value"))
+	}))
+	defer remote.Close()
+
+	stateDB, err := statedb.NewStateDBForDryRun(dbcache.NewMemoryCache(), &statedb.DefaultCacheConfig,
+		&statedb.ChainDB{AccountModel: &mockAccountModel{}})
+	require.NoError(t, err)
+	c := &Fullnode{
+		config: &Config{L2EndPoint: remote.URL},
+		bc:     &core.BlockChain{Statedb: stateDB},
+	}
+	report := &DivergenceReport{
+		Height: 12,
+		Txs: []*DivergedTx{
+			{
+				Remote:         &sdkTypes.Tx{AccountIndex: 1, Info: `{"FromAccountIndex":1,"ToAccountIndex":2}`},
+				LocalTxDetails: []*tx.TxDetail{{AccountIndex: 1}, {AccountIndex: types.NilAccountIndex}},
+			},
+			{
+				// the tx failed to apply locally
+				Remote: &sdkTypes.Tx{AccountIndex: 3, Info: `{"FromAccountIndex":3,"ToAccountIndex":5,"GasAccountIndex":1}`},
+				Error:  "invalid nonce",
+			},
+		},
+	}
+	c.collectAccounts(report)
+
+	require.Len(t, report.Accounts, 4)
+	for i, accountIndex := range []int64{1, 2, 3, 5} {
+		divergedAccount := report.Accounts[i]
+		assert.Equal(t, accountIndex, divergedAccount.AccountIndex)
+		assert.JSONEq(t, fmt.Sprintf(`{"index":%d}`, accountIndex), string(divergedAccount.Remote))
+		if accountIndex == 1 {
+			assert.Equal(t, int64(1), divergedAccount.Local.AccountIndex)
+			assert.Empty(t, divergedAccount.Error)
+		} else {
+			// only the remote side is known
+			assert.Nil(t, divergedAccount.Local)
+			assert.Equal(t, types.AppErrAccountNotFound.Error(), divergedAccount.Error)
+		}
+	}
+}
@@ -2,12 +2,16 @@ package fullnode
 
 import (
 	"fmt"
+	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/zeromicro/go-zero/core/logx"
 	"gorm.io/gorm"
 
 	"github.com/bnb-chain/zkbnb-go-sdk/client"
+	sdkTypes "github.com/bnb-chain/zkbnb-go-sdk/types"
 	"github.com/bnb-chain/zkbnb/core"
 	"github.com/bnb-chain/zkbnb/dao/block"
 	tx "github.com/bnb-chain/zkbnb/dao/tx"
@@ -15,34 +19,84 @@ import (
 )
 
 const (
+	ServiceName       = "fullnode"
 	DefaultL2EndPoint = "http://localhost:8888"
 	SyncInterval      = 100 * time.Millisecond
 )
 
+var (
+	syncLagMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Name:      "fullnode_sync_lag",
+		Help:      "Number of blocks the fullnode is behind the head.",
+	})
+	syncRateMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "zkbnb",
+		Name:      "fullnode_sync_rate",
+		Help:      "Blocks synced by the fullnode per second.",
+	})
+)
+
 type Config struct {
 	core.ChainConfig
 	L2EndPoint      string
 	SyncBlockStatus int64
-	LogConf         logx.LogConf
+	//nolint:staticcheck
+	L1Sync L1SyncConfig `json:",optional"`
+	// Dir of the reports of the blocks diverged, the fullnode halts once a block diverges.
+	//nolint:staticcheck
+	DivergenceReportDir string `json:",optional"`
+	// Address of the health endpoint, e.g. 0.0.0.0:8091, it's disabled if not set.
+	//nolint:staticcheck
+	HealthListenOn string `json:",optional"`
+	// Blocks are downloaded from L2EndPoint ahead of the execution, it's not used when
+	// syncing from L1.
+	//nolint:staticcheck
+	Prefetch PrefetchConfig `json:",optional"`
+	// Serve the read routes of the apiserver from the local replica, the Postgres, CacheRedis
+	// and TreeDB of the apiserver config must be the ones of the fullnode.
+	//nolint:staticcheck
+	APIServer struct {
+		ConfigFile string
+		// Forward the txs sent to the apiserver to L2EndPoint, they are rejected otherwise.
+		//nolint:staticcheck
+		ForwardTxs bool `json:",optional"`
+	} `json:",optional"`
+	LogConf logx.LogConf
 }
 
 type Fullnode struct {
 	config *Config
 	client client.ZkBNBClient
 	bc     *core.BlockChain
+	// set if the blocks are rebuilt from L1 instead of fetched from L2EndPoint
+	l1Source   *l1BlockSource
+	prefetcher *blockPrefetcher
+
+	// blocks synced since rateStart, to compute the sync rate
+	rateStart  time.Time
+	rateBlocks int
+
+	syncedHeight int64 // atomic
+	// set to the *divergence the fullnode is halted by
+	diverged     atomic.Value
+	healthServer *http.Server
 
 	quitCh chan struct{}
 }
 
 func NewFullnode(config *Config) (*Fullnode, error) {
-	bc, err := core.NewBlockChain(&config.ChainConfig, "fullnode")
+	bc, err := core.NewBlockChain(&config.ChainConfig, ServiceName)
 	if err != nil {
 		return nil, fmt.Errorf("new blockchain error: %v", err)
 	}
 
-	l2EndPoint := config.L2EndPoint
-	if len(l2EndPoint) == 0 {
-		l2EndPoint = DefaultL2EndPoint
+	if len(config.L2EndPoint) == 0 {
+		config.L2EndPoint = DefaultL2EndPoint
+	}
+
+	if len(config.DivergenceReportDir) == 0 {
+		config.DivergenceReportDir = DefaultDivergenceReportDir
 	}
 
 	if config.SyncBlockStatus <= block.StatusProposing ||
@@ -50,9 +104,16 @@ func NewFullnode(config *Config) (*Fullnode, error) {
 		config.SyncBlockStatus = block.StatusVerifiedAndExecuted
 	}
 
+	if err := prometheus.Register(syncLagMetric); err != nil {
+		return nil, fmt.Errorf("prometheus.Register syncLagMetric error: %v", err)
+	}
+	if err := prometheus.Register(syncRateMetric); err != nil {
+		return nil, fmt.Errorf("prometheus.Register syncRateMetric error: %v", err)
+	}
+
 	fullnode := &Fullnode{
 		config: config,
-		client: client.NewZkBNBClient(l2EndPoint),
+		client: client.NewZkBNBClient(config.L2EndPoint),
 		bc:     bc,
 
 		quitCh: make(chan struct{}),
@@ -71,95 +132,248 @@ func (c *Fullnode) Run() {
 		panic(fmt.Sprintf("get current block failed, height: %d, error: %v", curHeight, err.Error()))
 	}
 
+	if c.config.L1Sync.Enabled {
+		c.l1Source, err = newL1BlockSource(c.config.L1Sync, curBlock)
+		if err != nil {
+			panic(fmt.Sprintf("new l1 block source failed, error: %v", err.Error()))
+		}
+	} else {
+		c.prefetcher = newBlockPrefetcher(c.client, c.config.Prefetch, c.config.SyncBlockStatus, curHeight+1, c.quitCh)
+		c.prefetcher.Start()
+	}
+	c.rateStart = time.Now()
+	syncedHeight := curHeight
+	if curBlock.BlockStatus == block.StatusProposing {
+		syncedHeight--
+	}
+	atomic.StoreInt64(&c.syncedHeight, syncedHeight)
+	c.startHealthServer()
+
+	d, err := loadDivergence(c.config.DivergenceReportDir)
+	if err != nil {
+		panic(fmt.Sprintf("load divergence failed, error: %v", err.Error()))
+	}
+	if d != nil {
+		c.diverged.Store(d)
+		logx.Errorf("fullnode is halted by the divergence at height %d: %s, report: %s, run `zkbnb fullnode resync --from %d` to sync again",
+			d.Height, d.Reason, d.Report, d.Height-1)
+		<-c.quitCh
+		return
+	}
+
 	ticker := time.NewTicker(SyncInterval)
 	defer ticker.Stop()
 
 	syncBlockStatus := c.config.SyncBlockStatus
+	synced := false
 	for {
-		select {
-		case <-ticker.C:
-
-			// if the latest block have been created
-			if curBlock.BlockStatus > block.StatusProposing {
-				// init new block, set curBlock.status to block.StatusProposing
-				curBlock, err = c.bc.InitNewBlock()
-				if err != nil {
-					panic(fmt.Sprintf("init new block failed, block height: %d, error: %v", curHeight, err.Error()))
-				}
-
-				curHeight++
+		// the blocks are synced one after another when catching up with L2
+		if synced {
+			select {
+			case <-c.quitCh:
+				return
+			default:
+			}
+		} else {
+			select {
+			case <-ticker.C:
+			case <-c.quitCh:
+				return
 			}
+		}
+		synced = false
 
-			l2Block, err := c.client.GetBlockByHeight(curHeight)
+		// if the latest block have been created
+		if curBlock.BlockStatus > block.StatusProposing {
+			// init new block, set curBlock.status to block.StatusProposing
+			curBlock, err = c.bc.InitNewBlock()
 			if err != nil {
-				if err != types.DbErrNotFound {
-					logx.Errorf("get block failed, height: %d, err %v ", curHeight, err)
-				}
-				continue
+				panic(fmt.Sprintf("init new block failed, block height: %d, error: %v", curHeight, err.Error()))
 			}
 
-			if l2Block.Status < syncBlockStatus {
-				continue
-			}
+			curHeight++
+		}
 
-			// create time needs to be set, otherwise tx will fail if expire time is set
-			c.bc.CurrentBlock().CreatedAt = time.UnixMilli(l2Block.CommittedAt)
-			// set info
-			if l2Block.Status >= block.StatusCommitted {
-				c.bc.CurrentBlock().CommittedAt = l2Block.CommittedAt
-				c.bc.CurrentBlock().CommittedTxHash = l2Block.CommittedTxHash
-				c.bc.CurrentBlock().BlockCommitment = l2Block.Commitment
-			}
-			if l2Block.Status == block.StatusVerifiedAndExecuted {
-				c.bc.CurrentBlock().VerifiedAt = l2Block.VerifiedAt
-				c.bc.CurrentBlock().VerifiedTxHash = l2Block.VerifiedTxHash
+		syncBlock, err := c.fetchBlock(curHeight)
+		if err != nil {
+			if err != types.DbErrNotFound {
+				logx.Errorf("get block failed, height: %d, err %v ", curHeight, err)
 			}
+			continue
+		}
+		l2Block := syncBlock.Block
 
-			// clean cache
-			c.bc.Statedb.PurgeCache(curBlock.StateRoot)
+		if l2Block.Status < syncBlockStatus {
+			continue
+		}
 
-			for _, blockTx := range l2Block.Txs {
-				newTx := &tx.Tx{
-					TxHash: blockTx.Hash, // Would be computed in prepare method of executors.
-					TxType: blockTx.Type,
-					TxInfo: blockTx.Info,
-				}
+		// create time needs to be set, otherwise tx will fail if expire time is set
+		c.bc.CurrentBlock().CreatedAt = time.UnixMilli(syncBlock.createdAt)
+		// set info
+		if l2Block.Status >= block.StatusCommitted {
+			c.bc.CurrentBlock().CommittedAt = l2Block.CommittedAt
+			c.bc.CurrentBlock().CommittedTxHash = l2Block.CommittedTxHash
+			c.bc.CurrentBlock().BlockCommitment = l2Block.Commitment
+		}
+		if l2Block.Status == block.StatusVerifiedAndExecuted {
+			c.bc.CurrentBlock().VerifiedAt = l2Block.VerifiedAt
+			c.bc.CurrentBlock().VerifiedTxHash = l2Block.VerifiedTxHash
+		}
 
-				err = c.bc.ApplyTransaction(newTx)
-				if err != nil {
-					logx.Errorf("apply block tx ID: %d failed, err %v ", newTx.ID, err)
-					continue
-				}
-			}
+		// clean cache
+		c.bc.Statedb.PurgeCache(curBlock.StateRoot)
 
-			err = c.bc.Statedb.IntermediateRoot(true)
+		report := &DivergenceReport{
+			Height:          curHeight,
+			RemoteStateRoot: l2Block.StateRoot,
+		}
+		halt := func(reason string) {
+			report.Reason = reason
+			report.LocalStateRoot = c.bc.Statedb.StateRoot
+			c.diverge(report)
+			<-c.quitCh
+		}
+
+		if syncBlock.l1Block != nil {
+			// the txs are rebuilt from the states, after the cache is purged
+			err = syncBlock.l1Block.fillTxs(l2Block, c.bc.Statedb)
 			if err != nil {
-				panic(fmt.Sprint("calculate state root failed, err", err))
+				panic(fmt.Sprintf("rebuild block from l1 failed, height: %d, err: %v", curHeight, err))
 			}
+		}
 
-			if c.bc.Statedb.StateRoot != l2Block.StateRoot {
-				panic(fmt.Sprintf("state root not matched between statedb and l2block: %d, local: %s, remote: %s", l2Block.Height, c.bc.Statedb.StateRoot, l2Block.StateRoot))
+		for _, blockTx := range l2Block.Txs {
+			newTx := &tx.Tx{
+				TxHash:   blockTx.Hash, // Would be computed in prepare method of executors.
+				TxType:   blockTx.Type,
+				TxInfo:   blockTx.Info,
+				NftIndex: types.NilNftIndex,
 			}
 
-			curBlock, err = c.processNewBlock(curBlock, int(l2Block.Size))
+			if syncBlock.l1Block != nil {
+				err = c.bc.ApplyTransactionFromPubData(newTx)
+			} else {
+				err = c.bc.ApplyTransaction(newTx)
+			}
+			divergedTx := &DivergedTx{
+				Remote:         blockTx,
+				LocalTxHash:    newTx.TxHash,
+				LocalTxDetails: newTx.TxDetails,
+			}
+			report.Txs = append(report.Txs, divergedTx)
 			if err != nil {
-				panic(fmt.Sprintf("new block failed, block height: %d, Error: %s", l2Block.Height, err.Error()))
+				divergedTx.Error = err.Error()
+				if syncBlock.l1Block != nil {
+					halt(fmt.Sprintf("apply tx from pub data failed: %v", err))
+					return
+				}
+				logx.Errorf("apply block tx ID: %d failed, err %v ", newTx.ID, err)
+			}
+		}
+
+		err = c.bc.Statedb.IntermediateRoot(true)
+		if err != nil {
+			panic(fmt.Sprint("calculate state root failed, err", err))
+		}
+
+		if c.bc.Statedb.StateRoot != l2Block.StateRoot {
+			halt("state root not matched")
+			return
+		}
+
+		// the block rebuilt from L1 is verified before the trees and the block are stored
+		var verifyErr error
+		var check func(newBlock *block.Block) error
+		if syncBlock.l1Block != nil {
+			pubData := c.bc.Statedb.PubData
+			check = func(newBlock *block.Block) error {
+				verifyErr = syncBlock.l1Block.verify(pubData, newBlock)
+				return verifyErr
 			}
-			logx.Infof("created new block on fullnode, height=%d, blockSize=%d", curBlock.BlockHeight, l2Block.Size)
-		case <-c.quitCh:
+		}
+		curBlock, err = c.processNewBlock(curBlock, int(l2Block.Size), check)
+		if verifyErr != nil {
+			halt(fmt.Sprintf("block not matched with l1: %v", verifyErr))
 			return
 		}
+		if err != nil {
+			panic(fmt.Sprintf("new block failed, block height: %d, Error: %s", l2Block.Height, err.Error()))
+		}
+		logx.Infof("created new block on fullnode, height=%d, blockSize=%d", curBlock.BlockHeight, l2Block.Size)
+		c.updateSyncMetrics(curBlock.BlockHeight)
+		atomic.StoreInt64(&c.syncedHeight, curBlock.BlockHeight)
+		synced = true
+	}
+}
+
+// syncBlock is a block to sync, with the L1 block it's rebuilt from when syncing from L1.
+type syncBlock struct {
+	*sdkTypes.Block
+	createdAt int64
+	l1Block   *l1Block
+}
+
+func (c *Fullnode) fetchBlock(height int64) (*syncBlock, error) {
+	if c.l1Source == nil {
+		l2Block, err := c.prefetcher.GetBlock(height)
+		if err != nil {
+			return nil, err
+		}
+		return &syncBlock{Block: l2Block, createdAt: l2Block.CommittedAt}, nil
+	}
+
+	l1Block, err := c.l1Source.GetBlock(height)
+	if err != nil {
+		return nil, err
+	}
+	// the txs are filled once the block is applied
+	return &syncBlock{
+		Block:     l1Block.header(),
+		createdAt: l1Block.commitInfo.Timestamp.Int64(),
+		l1Block:   l1Block,
+	}, nil
+}
+
+// syncRateInterval is the interval the sync rate is averaged over.
+const syncRateInterval = 10 * time.Second
+
+func (c *Fullnode) updateSyncMetrics(height int64) {
+	var headHeight int64
+	if c.l1Source != nil {
+		headHeight = c.l1Source.HeadHeight()
+	} else {
+		headHeight = c.prefetcher.HeadHeight()
 	}
+	if headHeight > height {
+		syncLagMetric.Set(float64(headHeight - height))
+	} else {
+		syncLagMetric.Set(0)
+	}
+
+	c.rateBlocks++
+	if elapsed := time.Since(c.rateStart); elapsed >= syncRateInterval {
+		syncRateMetric.Set(float64(c.rateBlocks) / elapsed.Seconds())
+		c.rateStart = time.Now()
+		c.rateBlocks = 0
+	}
+}
+
+// SyncedHeight returns the height of the last block synced.
+func (c *Fullnode) SyncedHeight() int64 {
+	return atomic.LoadInt64(&c.syncedHeight)
 }
 
 func (c *Fullnode) Shutdown() {
+	if c.healthServer != nil {
+		_ = c.healthServer.Close()
+	}
 	close(c.quitCh)
 	c.bc.Statedb.Close()
 	c.bc.ChainDB.Close()
 }
 
-func (c *Fullnode) processNewBlock(curBlock *block.Block, blockSize int) (*block.Block, error) {
-	blockStates, err := c.bc.CommitNewBlock(blockSize, curBlock.CreatedAt.UnixMilli())
+func (c *Fullnode) processNewBlock(curBlock *block.Block, blockSize int, check func(newBlock *block.Block) error) (*block.Block, error) {
+	blockStates, err := c.bc.CommitCheckedBlock(blockSize, curBlock.CreatedAt.UnixMilli(), check)
 	if err != nil {
 		return nil, err
 	}
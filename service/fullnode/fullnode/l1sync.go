@@ -0,0 +1,453 @@
+package fullnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	zkbnb "github.com/bnb-chain/zkbnb-eth-rpc/core"
+	"github.com/bnb-chain/zkbnb-eth-rpc/rpc"
+	sdkTypes "github.com/bnb-chain/zkbnb-go-sdk/types"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	// l1PollInterval is the minimum interval between two scans of the L1 logs.
+	l1PollInterval = time.Second
+
+	defaultMaxHandledBlocksCount = 5000
+)
+
+var (
+	zkbnbContractAbi, _ = abi.JSON(strings.NewReader(zkbnb.ZkBNBMetaData.ABI))
+
+	zkbnbLogBlockCommitSigHash       = crypto.Keccak256Hash([]byte("BlockCommit(uint32)"))
+	zkbnbLogBlockVerificationSigHash = crypto.Keccak256Hash([]byte("BlockVerification(uint32)"))
+	zkbnbLogBlocksRevertSigHash      = crypto.Keccak256Hash([]byte("BlocksRevert(uint32,uint32)"))
+)
+
+type L1SyncConfig struct {
+	// Rebuild the blocks from the pub data committed to the ZkBNB contract instead of
+	// fetching them from L2EndPoint.
+	Enabled bool
+	// Rpc endpoint of L1.
+	//nolint:staticcheck
+	NetworkRPC string `json:",optional"`
+	//nolint:staticcheck
+	ZkBNBContract string `json:",optional"`
+	// L1 block to start the scan from when no block is synced yet, the block of the
+	// contract deployment.
+	//nolint:staticcheck
+	StartL1BlockHeight int64 `json:",optional"`
+	//nolint:staticcheck
+	ConfirmBlocksCount uint64 `json:",optional"`
+	//nolint:staticcheck
+	MaxHandledBlocksCount uint64 `json:",optional"`
+}
+
+// l1Block is a block committed to L1, with the infos of its txs parsed from its pub data.
+type l1Block struct {
+	commitInfo      zkbnb.OldZkBNBCommitBlockInfo
+	committedTxHash string
+	committedAt     int64
+
+	// the header stored by the contract, set once the block is verified
+	verifiedInfo   *zkbnb.StorageStoredBlockInfo
+	verifiedTxHash string
+	verifiedAt     int64
+
+	txInfos []txtypes.TxInfo
+}
+
+// l1BlockSource rebuilds the blocks from the calldata of the commitBlocks and the
+// verifyAndExecuteBlocks calls to the ZkBNB contract. The calls are found from the
+// BlockCommit and BlockVerification events, so they must be direct calls to the contract.
+type l1BlockSource struct {
+	cli      *rpc.ProviderClient
+	contract common.Address
+	config   L1SyncConfig
+
+	// last L1 block scanned
+	scannedHeight uint64
+	lastPoll      time.Time
+	blocks        map[int64]*l1Block
+	// last block committed on L1
+	headHeight int64
+}
+
+// newL1BlockSource creates the source of the blocks following curBlock, the scan resumes
+// from the L1 block committing curBlock.
+func newL1BlockSource(config L1SyncConfig, curBlock *block.Block) (*l1BlockSource, error) {
+	if config.NetworkRPC == "" || config.ZkBNBContract == "" {
+		return nil, errors.New("network rpc and zkbnb contract are required to sync from l1")
+	}
+	if config.MaxHandledBlocksCount == 0 {
+		config.MaxHandledBlocksCount = defaultMaxHandledBlocksCount
+	}
+	cli, err := rpc.NewClient(config.NetworkRPC)
+	if err != nil {
+		return nil, fmt.Errorf("connect to l1 failed: %v", err)
+	}
+
+	s := &l1BlockSource{
+		cli:      cli,
+		contract: common.HexToAddress(config.ZkBNBContract),
+		config:   config,
+		blocks:   make(map[int64]*l1Block),
+	}
+	if config.StartL1BlockHeight > 0 {
+		s.scannedHeight = uint64(config.StartL1BlockHeight - 1)
+	}
+	if curBlock.CommittedTxHash != "" {
+		receipt, err := cli.GetTransactionReceipt(curBlock.CommittedTxHash)
+		if err != nil {
+			return nil, fmt.Errorf("get receipt of commit tx %s failed: %v", curBlock.CommittedTxHash, err)
+		}
+		s.scannedHeight = receipt.BlockNumber.Uint64() - 1
+	}
+	return s, nil
+}
+
+// GetBlock returns the block committed at the height, types.DbErrNotFound if the block
+// isn't committed yet.
+func (s *l1BlockSource) GetBlock(height int64) (*l1Block, error) {
+	b, exist := s.blocks[height]
+	if (!exist || b.verifiedInfo == nil) && time.Since(s.lastPoll) >= l1PollInterval {
+		s.lastPoll = time.Now()
+		err := s.poll(height)
+		if err != nil {
+			return nil, err
+		}
+		b, exist = s.blocks[height]
+	}
+	if !exist {
+		return nil, types.DbErrNotFound
+	}
+	for h := range s.blocks {
+		if h < height {
+			delete(s.blocks, h)
+		}
+	}
+	return b, nil
+}
+
+// HeadHeight returns the last block committed on L1 known.
+func (s *l1BlockSource) HeadHeight() int64 {
+	return s.headHeight
+}
+
+// poll scans the logs of the next confirmed L1 blocks, the blocks below minHeight are
+// already synced and dropped.
+func (s *l1BlockSource) poll(minHeight int64) error {
+	latestHeight, err := s.cli.GetHeight()
+	if err != nil {
+		return fmt.Errorf("get l1 height failed: %v", err)
+	}
+	if latestHeight <= s.config.ConfirmBlocksCount {
+		return nil
+	}
+	safeHeight := latestHeight - s.config.ConfirmBlocksCount
+	if safeHeight <= s.scannedHeight {
+		return nil
+	}
+	endHeight := safeHeight
+	if endHeight > s.scannedHeight+s.config.MaxHandledBlocksCount {
+		endHeight = s.scannedHeight + s.config.MaxHandledBlocksCount
+	}
+
+	logs, err := s.cli.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(s.scannedHeight + 1),
+		ToBlock:   new(big.Int).SetUint64(endHeight),
+		Addresses: []common.Address{s.contract},
+		Topics: [][]common.Hash{{
+			zkbnbLogBlockCommitSigHash, zkbnbLogBlockVerificationSigHash, zkbnbLogBlocksRevertSigHash,
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("get contract logs failed: %v", err)
+	}
+
+	// a call commits or verifies several blocks
+	commitCalls := make(map[common.Hash][]zkbnb.OldZkBNBCommitBlockInfo)
+	verifyCalls := make(map[common.Hash][]zkbnb.OldZkBNBVerifyAndExecuteBlockInfo)
+	for _, vlog := range logs {
+		switch vlog.Topics[0] {
+		case zkbnbLogBlockCommitSigHash:
+			var event zkbnb.ZkBNBBlockCommit
+			if err := zkbnbContractAbi.UnpackIntoInterface(&event, "BlockCommit", vlog.Data); err != nil {
+				return fmt.Errorf("unpack BlockCommit event failed: %v", err)
+			}
+			height := int64(event.BlockNumber)
+			if height < minHeight {
+				continue
+			}
+			commitInfos, exist := commitCalls[vlog.TxHash]
+			if !exist {
+				commitInfos, err = s.getCommitCall(vlog.TxHash)
+				if err != nil {
+					return err
+				}
+				commitCalls[vlog.TxHash] = commitInfos
+			}
+			b, err := s.newL1Block(commitInfos, height)
+			if err != nil {
+				return err
+			}
+			header, err := s.cli.GetBlockHeaderByNumber(new(big.Int).SetUint64(vlog.BlockNumber))
+			if err != nil {
+				return fmt.Errorf("get l1 block header failed: %v", err)
+			}
+			b.committedTxHash = vlog.TxHash.Hex()
+			b.committedAt = int64(header.Time)
+			s.blocks[height] = b
+			s.headHeight = height
+		case zkbnbLogBlockVerificationSigHash:
+			var event zkbnb.ZkBNBBlockVerification
+			if err := zkbnbContractAbi.UnpackIntoInterface(&event, "BlockVerification", vlog.Data); err != nil {
+				return fmt.Errorf("unpack BlockVerification event failed: %v", err)
+			}
+			height := int64(event.BlockNumber)
+			if height < minHeight {
+				continue
+			}
+			b, exist := s.blocks[height]
+			if !exist {
+				return fmt.Errorf("block %d is verified before it is committed, check the start l1 block height", height)
+			}
+			verifyInfos, exist := verifyCalls[vlog.TxHash]
+			if !exist {
+				verifyInfos, err = s.getVerifyCall(vlog.TxHash)
+				if err != nil {
+					return err
+				}
+				verifyCalls[vlog.TxHash] = verifyInfos
+			}
+			for _, verifyInfo := range verifyInfos {
+				if int64(verifyInfo.BlockHeader.BlockNumber) == height {
+					header := verifyInfo.BlockHeader
+					b.verifiedInfo = &header
+				}
+			}
+			if b.verifiedInfo == nil {
+				return fmt.Errorf("block %d not found in verify tx %s", height, vlog.TxHash.Hex())
+			}
+			header, err := s.cli.GetBlockHeaderByNumber(new(big.Int).SetUint64(vlog.BlockNumber))
+			if err != nil {
+				return fmt.Errorf("get l1 block header failed: %v", err)
+			}
+			b.verifiedTxHash = vlog.TxHash.Hex()
+			b.verifiedAt = int64(header.Time)
+		case zkbnbLogBlocksRevertSigHash:
+			var event zkbnb.ZkBNBBlocksRevert
+			if err := zkbnbContractAbi.UnpackIntoInterface(&event, "BlocksRevert", vlog.Data); err != nil {
+				return fmt.Errorf("unpack BlocksRevert event failed: %v", err)
+			}
+			if minHeight-1 > int64(event.TotalBlocksCommitted) {
+				return fmt.Errorf("synced blocks are reverted on l1, committed blocks: %d, synced blocks: %d",
+					event.TotalBlocksCommitted, minHeight-1)
+			}
+			for height := range s.blocks {
+				if height > int64(event.TotalBlocksCommitted) {
+					delete(s.blocks, height)
+				}
+			}
+			s.headHeight = int64(event.TotalBlocksCommitted)
+			logx.Infof("blocks reverted on l1, committed blocks: %d", event.TotalBlocksCommitted)
+		}
+	}
+	s.scannedHeight = endHeight
+	return nil
+}
+
+func (s *l1BlockSource) getCommitCall(txHash common.Hash) ([]zkbnb.OldZkBNBCommitBlockInfo, error) {
+	args, err := s.getCallArgs(txHash, "commitBlocks")
+	if err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(args[1], new([]zkbnb.OldZkBNBCommitBlockInfo)).(*[]zkbnb.OldZkBNBCommitBlockInfo), nil
+}
+
+func (s *l1BlockSource) getVerifyCall(txHash common.Hash) ([]zkbnb.OldZkBNBVerifyAndExecuteBlockInfo, error) {
+	args, err := s.getCallArgs(txHash, "verifyAndExecuteBlocks")
+	if err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(args[0], new([]zkbnb.OldZkBNBVerifyAndExecuteBlockInfo)).(*[]zkbnb.OldZkBNBVerifyAndExecuteBlockInfo), nil
+}
+
+func (s *l1BlockSource) getCallArgs(txHash common.Hash, methodName string) ([]interface{}, error) {
+	tx, _, err := s.cli.GetTransactionByHash(txHash.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("get l1 tx %s failed: %v", txHash.Hex(), err)
+	}
+	data := tx.Data()
+	if len(data) < 4 {
+		return nil, fmt.Errorf("l1 tx %s is not a call to %s", txHash.Hex(), methodName)
+	}
+	method, err := zkbnbContractAbi.MethodById(data[:4])
+	if err != nil || method.Name != methodName {
+		return nil, fmt.Errorf("l1 tx %s is not a call to %s", txHash.Hex(), methodName)
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("unpack %s calldata failed: %v", methodName, err)
+	}
+	return args, nil
+}
+
+func (s *l1BlockSource) newL1Block(commitInfos []zkbnb.OldZkBNBCommitBlockInfo, height int64) (*l1Block, error) {
+	for _, commitInfo := range commitInfos {
+		if int64(commitInfo.BlockNumber) != height {
+			continue
+		}
+		txInfos, err := chain.ParseBlockPubData(commitInfo.PublicData)
+		if err != nil {
+			return nil, fmt.Errorf("parse pub data of block %d failed: %v", height, err)
+		}
+		return &l1Block{
+			commitInfo: commitInfo,
+			txInfos:    txInfos,
+		}, nil
+	}
+	return nil, fmt.Errorf("block %d not found in commit call", height)
+}
+
+// header returns the block applied by the fullnode without its txs.
+func (b *l1Block) header() *sdkTypes.Block {
+	l2Block := &sdkTypes.Block{
+		Height:          int64(b.commitInfo.BlockNumber),
+		StateRoot:       common.Bytes2Hex(b.commitInfo.NewStateRoot[:]),
+		CommittedTxHash: b.committedTxHash,
+		CommittedAt:     b.committedAt,
+		Status:          block.StatusCommitted,
+		Size:            b.commitInfo.BlockSize,
+	}
+	if b.verifiedInfo != nil {
+		l2Block.Commitment = common.Bytes2Hex(b.verifiedInfo.Commitment[:])
+		l2Block.VerifiedTxHash = b.verifiedTxHash
+		l2Block.VerifiedAt = b.verifiedAt
+		l2Block.Status = block.StatusVerifiedAndExecuted
+	}
+	return l2Block
+}
+
+// fillTxs sets the txs of the block applied by the fullnode. The nonces aren't in the pub
+// data, they are set from the states the block is applied to.
+func (b *l1Block) fillTxs(l2Block *sdkTypes.Block, stateDB *statedb.StateDB) error {
+	height := l2Block.Height
+	l2Block.Txs = make([]*sdkTypes.Tx, 0, len(b.txInfos))
+	nonces := make(map[int64]int64)
+	for index, txInfo := range b.txInfos {
+		txHash := types.EmptyTxHash
+		from := txInfo.GetFromAccountIndex()
+		if from == types.NilAccountIndex {
+			// the priority operations are hashed with the L1 request, which isn't in the pub data
+			txHash = computePubDataTxHash(height, index, b.committedTxHash)
+		} else {
+			nonce, exist := nonces[from]
+			if !exist {
+				account, err := stateDB.GetFormatAccount(from)
+				if err != nil {
+					return err
+				}
+				nonce = account.Nonce
+			}
+			setNonce(txInfo, nonce)
+			nonces[from] = nonce + 1
+		}
+		txInfoBytes, err := json.Marshal(txInfo)
+		if err != nil {
+			return err
+		}
+		l2Block.Txs = append(l2Block.Txs, &sdkTypes.Tx{
+			Hash: txHash,
+			Type: int64(txInfo.GetTxType()),
+			Info: string(txInfoBytes),
+		})
+	}
+	return nil
+}
+
+// verify checks the block applied by the fullnode against the block committed to L1.
+func (b *l1Block) verify(pubData []byte, newBlock *block.Block) error {
+	committedPubData := b.commitInfo.PublicData
+	if len(pubData) > len(committedPubData) ||
+		!bytes.Equal(pubData, committedPubData[:len(pubData)]) {
+		return errors.New("pub data not matched")
+	}
+	for _, padding := range committedPubData[len(pubData):] {
+		if padding != 0 {
+			return errors.New("pub data not matched")
+		}
+	}
+	if newBlock.CreatedAt.UnixMilli() != b.commitInfo.Timestamp.Int64() {
+		return errors.New("timestamp not matched")
+	}
+	if b.verifiedInfo != nil {
+		stored := chain.ConstructStoredBlockInfo(newBlock)
+		if !storedBlockInfoEqual(stored, *b.verifiedInfo) {
+			return fmt.Errorf("verified block not matched, local commitment: %s, remote commitment: %s",
+				newBlock.BlockCommitment, common.Bytes2Hex(b.verifiedInfo.Commitment[:]))
+		}
+	}
+	return nil
+}
+
+func storedBlockInfoEqual(a, b zkbnb.StorageStoredBlockInfo) bool {
+	return a.BlockSize == b.BlockSize &&
+		a.BlockNumber == b.BlockNumber &&
+		a.PriorityOperations == b.PriorityOperations &&
+		a.PendingOnchainOperationsHash == b.PendingOnchainOperationsHash &&
+		a.Timestamp.Cmp(b.Timestamp) == 0 &&
+		a.StateRoot == b.StateRoot &&
+		a.Commitment == b.Commitment
+}
+
+// computePubDataTxHash derives the hash of a priority operation from its position in the
+// block, the hash differs from the one given by the operator.
+func computePubDataTxHash(height int64, index int, committedTxHash string) string {
+	hFunc := mimc.NewMiMC()
+	hFunc.Write([]byte(strconv.FormatInt(height, 10)))
+	hFunc.Write([]byte(strconv.Itoa(index)))
+	hFunc.Write(common.FromHex(committedTxHash))
+	return hex.EncodeToString(hFunc.Sum(nil))
+}
+
+func setNonce(txInfo txtypes.TxInfo, nonce int64) {
+	switch txInfo := txInfo.(type) {
+	case *txtypes.TransferTxInfo:
+		txInfo.Nonce = nonce
+	case *txtypes.WithdrawTxInfo:
+		txInfo.Nonce = nonce
+	case *txtypes.CreateCollectionTxInfo:
+		txInfo.Nonce = nonce
+	case *txtypes.MintNftTxInfo:
+		txInfo.Nonce = nonce
+	case *txtypes.TransferNftTxInfo:
+		txInfo.Nonce = nonce
+	case *txtypes.AtomicMatchTxInfo:
+		txInfo.Nonce = nonce
+	case *txtypes.CancelOfferTxInfo:
+		txInfo.Nonce = nonce
+	case *txtypes.WithdrawNftTxInfo:
+		txInfo.Nonce = nonce
+	}
+}
@@ -0,0 +1,180 @@
+package fullnode
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb-go-sdk/client"
+	sdkTypes "github.com/bnb-chain/zkbnb-go-sdk/types"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	defaultPrefetchWorkers        = 8
+	defaultPrefetchMaxAheadBlocks = 64
+
+	// headRefreshInterval is the minimum interval between two queries of the L2 height.
+	headRefreshInterval = time.Second
+	maxFetchBackoff     = 10 * time.Second
+)
+
+type PrefetchConfig struct {
+	// Number of the blocks downloaded concurrently.
+	//nolint:staticcheck
+	Workers int `json:",optional"`
+	// Number of the blocks downloaded ahead of the block executed.
+	//nolint:staticcheck
+	MaxAheadBlocks int `json:",optional"`
+}
+
+type prefetchTask struct {
+	height int64
+	done   chan struct{}
+	block  *sdkTypes.Block
+}
+
+// blockPrefetcher downloads the blocks following the block executed concurrently, the
+// blocks are still returned in order, one at a time. The failed downloads are retried with
+// an exponential backoff until the blocks reach the status synced.
+type blockPrefetcher struct {
+	client          client.ZkBNBClient
+	syncBlockStatus int64
+	maxAheadBlocks  int
+	workerCh        chan struct{}
+
+	mu         sync.Mutex
+	tasks      map[int64]*prefetchTask
+	nextHeight int64
+	// set once a block is taken, so that the next block is scheduled
+	takenCh chan struct{}
+
+	headHeight    int64 // atomic
+	headRefreshed time.Time
+	quitCh        chan struct{}
+}
+
+func newBlockPrefetcher(client client.ZkBNBClient, config PrefetchConfig, syncBlockStatus int64,
+	fromHeight int64, quitCh chan struct{}) *blockPrefetcher {
+	if config.Workers <= 0 {
+		config.Workers = defaultPrefetchWorkers
+	}
+	if config.MaxAheadBlocks <= 0 {
+		config.MaxAheadBlocks = defaultPrefetchMaxAheadBlocks
+	}
+	return &blockPrefetcher{
+		client:          client,
+		syncBlockStatus: syncBlockStatus,
+		maxAheadBlocks:  config.MaxAheadBlocks,
+		workerCh:        make(chan struct{}, config.Workers),
+		tasks:           make(map[int64]*prefetchTask),
+		nextHeight:      fromHeight,
+		takenCh:         make(chan struct{}, 1),
+		quitCh:          quitCh,
+	}
+}
+
+func (p *blockPrefetcher) Start() {
+	go func() {
+		ticker := time.NewTicker(SyncInterval)
+		defer ticker.Stop()
+		for {
+			p.schedule()
+			select {
+			case <-ticker.C:
+			case <-p.takenCh:
+			case <-p.quitCh:
+				return
+			}
+		}
+	}()
+}
+
+// GetBlock waits for the block at the height, types.DbErrNotFound is returned if the block
+// isn't created on L2 yet.
+func (p *blockPrefetcher) GetBlock(height int64) (*sdkTypes.Block, error) {
+	p.mu.Lock()
+	task, exist := p.tasks[height]
+	p.mu.Unlock()
+	if !exist {
+		return nil, types.DbErrNotFound
+	}
+
+	select {
+	case <-task.done:
+	case <-p.quitCh:
+		return nil, types.DbErrNotFound
+	}
+
+	p.mu.Lock()
+	delete(p.tasks, height)
+	p.mu.Unlock()
+	select {
+	case p.takenCh <- struct{}{}:
+	default:
+	}
+	return task.block, nil
+}
+
+// HeadHeight returns the last known height of L2.
+func (p *blockPrefetcher) HeadHeight() int64 {
+	return atomic.LoadInt64(&p.headHeight)
+}
+
+func (p *blockPrefetcher) schedule() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	headHeight := p.HeadHeight()
+	if time.Since(p.headRefreshed) >= headRefreshInterval {
+		p.headRefreshed = time.Now()
+		height, err := p.client.GetCurrentHeight()
+		if err != nil {
+			logx.Errorf("get current height failed: %v", err)
+			return
+		}
+		headHeight = height
+		atomic.StoreInt64(&p.headHeight, height)
+	}
+
+	for p.nextHeight <= headHeight && len(p.tasks) < p.maxAheadBlocks {
+		task := &prefetchTask{
+			height: p.nextHeight,
+			done:   make(chan struct{}),
+		}
+		p.tasks[task.height] = task
+		p.nextHeight++
+		go p.fetch(task)
+	}
+}
+
+func (p *blockPrefetcher) fetch(task *prefetchTask) {
+	backoff := SyncInterval
+	for {
+		p.workerCh <- struct{}{}
+		l2Block, err := p.client.GetBlockByHeight(task.height)
+		<-p.workerCh
+
+		wait := SyncInterval
+		if err != nil {
+			logx.Errorf("get block failed, height: %d, retry in %s, err: %v", task.height, backoff, err)
+			wait = backoff
+			backoff *= 2
+			if backoff > maxFetchBackoff {
+				backoff = maxFetchBackoff
+			}
+		} else if l2Block.Status >= p.syncBlockStatus {
+			task.block = l2Block
+			close(task.done)
+			return
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-p.quitCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package fullnode
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bnb-chain/zkbnb-go-sdk/client"
+	sdkTypes "github.com/bnb-chain/zkbnb-go-sdk/types"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type mockL2Client struct {
+	client.ZkBNBClient
+
+	mu         sync.Mutex
+	headHeight int64
+	failures   map[int64]int
+}
+
+func (c *mockL2Client) GetCurrentHeight() (int64, error) {
+	return c.headHeight, nil
+}
+
+func (c *mockL2Client) GetBlockByHeight(height int64) (*sdkTypes.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures[height] > 0 {
+		c.failures[height]--
+		return nil, errors.New("connection refused")
+	}
+	return &sdkTypes.Block{Height: height, Status: block.StatusVerifiedAndExecuted}, nil
+}
+
+func TestBlockPrefetcher(t *testing.T) {
+	quitCh := make(chan struct{})
+	defer close(quitCh)
+
+	cli := &mockL2Client{headHeight: 20, failures: map[int64]int{3: 2, 7: 1}}
+	p := newBlockPrefetcher(cli, PrefetchConfig{Workers: 4, MaxAheadBlocks: 8}, block.StatusVerifiedAndExecuted, 1, quitCh)
+	p.Start()
+
+	start := time.Now()
+	for height := int64(1); height <= 20; height++ {
+		var l2Block *sdkTypes.Block
+		var err error
+		for l2Block == nil {
+			l2Block, err = p.GetBlock(height)
+			if err == types.DbErrNotFound {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			require.NoError(t, err)
+		}
+		assert.Equal(t, height, l2Block.Height)
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, int64(20), p.HeadHeight())
+
+	_, err := p.GetBlock(21)
+	assert.Equal(t, types.DbErrNotFound, err)
+}
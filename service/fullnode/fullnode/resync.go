@@ -0,0 +1,231 @@
+package fullnode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// Resync rolls back the database, the caches and the trees of the fullnode to the height,
+// the blocks above it are synced again once the fullnode is restarted. The fullnode must
+// be stopped. The trees must keep their versions at the height: without a retention policy
+// under TreeDB the fullnode only keeps the versions of the previous block, the recent policy
+// keeps the versions of the last KeepVersions blocks. The trees are checked before anything
+// is rolled back.
+func Resync(config *Config, height int64) error {
+	db, err := gorm.Open(postgres.Open(config.Postgres.DataSource))
+	if err != nil {
+		return fmt.Errorf("gorm connect db failed: %v", err)
+	}
+	chainDB := statedb.NewChainDB(db)
+
+	curHeight, err := chainDB.BlockModel.GetCurrentBlockHeight()
+	if err != nil {
+		return fmt.Errorf("get current block height failed: %v", err)
+	}
+	if height < 0 || height > curHeight {
+		return fmt.Errorf("invalid height %d, current height: %d", height, curHeight)
+	}
+
+	var treeCtx *tree.Context
+	if config.TreeDB.Driver != tree.MemoryDB {
+		treeCtx, err = tree.NewContext(ServiceName, config.TreeDB.Driver, false, config.TreeDB.RoutinePoolSize,
+			&config.TreeDB.LevelDBOption, &config.TreeDB.PebbleDBOption, &config.TreeDB.RedisDBOption)
+		if err != nil {
+			return fmt.Errorf("open trees failed: %v", err)
+		}
+		err = tree.SetupTreeDB(treeCtx)
+		if err != nil {
+			return fmt.Errorf("open trees failed: %v", err)
+		}
+		defer treeCtx.TreeDB.Close()
+
+		err = checkTreeVersions(treeCtx, height)
+		if err != nil {
+			return err
+		}
+	}
+
+	accountIndexes, err := chainDB.AccountHistoryModel.GetAccountIndexesAboveHeight(height)
+	if err != nil {
+		return fmt.Errorf("get accounts updated above height failed: %v", err)
+	}
+	nftIndexes, err := chainDB.L2NftHistoryModel.GetNftIndexesAboveHeight(height)
+	if err != nil {
+		return fmt.Errorf("get nfts updated above height failed: %v", err)
+	}
+	logx.Infof("rollback to height %d, current height: %d, accounts: %d, nfts: %d",
+		height, curHeight, len(accountIndexes), len(nftIndexes))
+
+	err = rollbackDB(chainDB, height, accountIndexes, nftIndexes)
+	if err != nil {
+		return fmt.Errorf("rollback db failed: %v", err)
+	}
+
+	if treeCtx != nil {
+		// the trees are rebuilt from the database at startup with a memorydb
+		err = rollbackTrees(treeCtx, chainDB, height, config.TreeDB.AssetTreeCacheSize)
+		if err != nil {
+			return fmt.Errorf("rollback trees failed: %v", err)
+		}
+	}
+
+	redisCache := dbcache.NewRedisCache(config.CacheRedis[0].Host, config.CacheRedis[0].Pass, 15*time.Minute)
+	defer redisCache.Close()
+	keys := []string{dbcache.GasAccountKey}
+	for _, accountIndex := range accountIndexes {
+		keys = append(keys, dbcache.AccountKeyByIndex(accountIndex))
+	}
+	for _, nftIndex := range nftIndexes {
+		keys = append(keys, dbcache.NftKeyByIndex(nftIndex))
+	}
+	for _, key := range keys {
+		err = redisCache.Delete(context.Background(), key)
+		if err != nil {
+			return fmt.Errorf("delete cache %s failed: %v", key, err)
+		}
+	}
+
+	err = clearDivergence(config.DivergenceReportDir)
+	if err != nil {
+		return fmt.Errorf("clear divergence failed: %v", err)
+	}
+	logx.Infof("rollback to height %d finished", height)
+	return nil
+}
+
+// rollbackDB restores the accounts and the nfts from their histories at the height and
+// deletes the blocks above it.
+func rollbackDB(chainDB *statedb.ChainDB, height int64, accountIndexes, nftIndexes []int64) error {
+	var (
+		accounts        []*account.Account
+		deletedAccounts []int64
+		nfts            []*nft.L2Nft
+		deletedNfts     []int64
+	)
+	for _, accountIndex := range accountIndexes {
+		history, err := chainDB.AccountHistoryModel.GetLatestAccountHistory(accountIndex, height+1)
+		if err == types.DbErrNotFound {
+			deletedAccounts = append(deletedAccounts, accountIndex)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("get history of account %d failed: %v", accountIndex, err)
+		}
+		oAccount, err := chainDB.AccountModel.GetAccountByIndex(accountIndex)
+		if err != nil {
+			return fmt.Errorf("get account %d failed: %v", accountIndex, err)
+		}
+		oAccount.Nonce = history.Nonce
+		oAccount.CollectionNonce = history.CollectionNonce
+		oAccount.AssetInfo = history.AssetInfo
+		oAccount.AssetRoot = history.AssetRoot
+		accounts = append(accounts, oAccount)
+	}
+	for _, nftIndex := range nftIndexes {
+		history, err := chainDB.L2NftHistoryModel.GetLatestNftHistory(nftIndex, height+1)
+		if err == types.DbErrNotFound {
+			deletedNfts = append(deletedNfts, nftIndex)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("get history of nft %d failed: %v", nftIndex, err)
+		}
+		oNft, err := chainDB.L2NftModel.GetNft(nftIndex)
+		if err != nil {
+			return fmt.Errorf("get nft %d failed: %v", nftIndex, err)
+		}
+		oNft.CreatorAccountIndex = history.CreatorAccountIndex
+		oNft.OwnerAccountIndex = history.OwnerAccountIndex
+		oNft.NftContentHash = history.NftContentHash
+		oNft.NftL1Address = history.NftL1Address
+		oNft.NftL1TokenId = history.NftL1TokenId
+		oNft.CreatorTreasuryRate = history.CreatorTreasuryRate
+		oNft.CollectionId = history.CollectionId
+		nfts = append(nfts, oNft)
+	}
+
+	return chainDB.DB.Transaction(func(tx *gorm.DB) error {
+		err := chainDB.AccountModel.UpdateAccountsInTransact(tx, accounts)
+		if err != nil {
+			return err
+		}
+		err = chainDB.AccountModel.DeleteAccountsInTransact(tx, deletedAccounts)
+		if err != nil {
+			return err
+		}
+		err = chainDB.AccountHistoryModel.DeleteAccountHistoriesAboveHeightInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		err = chainDB.L2NftModel.UpdateNftsInTransact(tx, nfts)
+		if err != nil {
+			return err
+		}
+		err = chainDB.L2NftModel.DeleteNftsInTransact(tx, deletedNfts)
+		if err != nil {
+			return err
+		}
+		err = chainDB.L2NftHistoryModel.DeleteNftHistoriesAboveHeightInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		err = chainDB.TxModel.DeleteTxsAboveHeightInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		err = chainDB.CompressedBlockModel.DeleteCompressedBlocksAboveHeightInTransact(tx, height)
+		if err != nil {
+			return err
+		}
+		return chainDB.BlockModel.DeleteBlocksAboveHeightInTransact(tx, height)
+	})
+}
+
+// checkTreeVersions checks that the account and nft trees keep their versions at the height.
+// The asset trees are versioned by their own commits, they keep their versions at the
+// heights kept by the account tree.
+func checkTreeVersions(treeCtx *tree.Context, height int64) error {
+	accountTree, err := tree.NewAccountTree(treeCtx, height)
+	if err != nil {
+		return fmt.Errorf("open account tree failed: %v", err)
+	}
+	nftTree, err := tree.NewNftTree(treeCtx, height)
+	if err != nil {
+		return fmt.Errorf("open nft tree failed: %v", err)
+	}
+	for _, t := range []struct {
+		name string
+		smt  bsmt.SparseMerkleTree
+	}{{"account", accountTree}, {"nft", nftTree}} {
+		if !t.smt.IsEmpty() && t.smt.RecentVersion() > bsmt.Version(height) {
+			return fmt.Errorf("the %s tree only keeps the versions from %d, it can't be rolled back to %d, "+
+				"more versions are kept with TreeDB.Retention", t.name, t.smt.RecentVersion(), height)
+		}
+	}
+	return nil
+}
+
+// rollbackTrees rolls back the trees above the height, the trees are opened the way the
+// fullnode opens them, which rolls back the versions above the height.
+func rollbackTrees(treeCtx *tree.Context, chainDB *statedb.ChainDB, height int64, assetTreeCacheSize int) error {
+	_, _, err := tree.InitAccountTree(chainDB.AccountModel, chainDB.AccountHistoryModel, height,
+		treeCtx, assetTreeCacheSize)
+	if err != nil {
+		return err
+	}
+	_, err = tree.InitNftTree(chainDB.L2NftHistoryModel, height, treeCtx)
+	return err
+}
@@ -0,0 +1,163 @@
+package fullnode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+type mockAccountHistoryModel struct {
+	account.AccountHistoryModel
+	// the number of accounts at each height
+	counts map[int64]int64
+}
+
+func (m *mockAccountHistoryModel) GetValidAccountCount(height int64) (int64, error) {
+	return m.counts[height], nil
+}
+
+// mockNftHistoryModel is only read if the trees are reloaded from the database.
+type mockNftHistoryModel struct {
+	nft.L2NftHistoryModel
+}
+
+// testTrees are the trees of the fullnode, committed block by block.
+type testTrees struct {
+	treeCtx     *tree.Context
+	chainDB     *statedb.ChainDB
+	accountTree bsmt.SparseMerkleTree
+	assetTrees  *tree.AssetTreeCache
+	nftTree     bsmt.SparseMerkleTree
+}
+
+func newTestTrees(t *testing.T, retention tree.RetentionOption) *testTrees {
+	treeCtx, err := tree.NewContext(ServiceName, tree.LevelDB, false, 0,
+		&tree.LevelDBOption{File: t.TempDir(), Cache: 16, Handles: 16}, nil, nil)
+	require.NoError(t, err)
+	treeCtx.SetRetention(retention)
+	require.NoError(t, tree.SetupTreeDB(treeCtx))
+	t.Cleanup(func() {
+		_ = treeCtx.TreeDB.Close()
+	})
+
+	trees := &testTrees{
+		treeCtx: treeCtx,
+		chainDB: &statedb.ChainDB{
+			AccountHistoryModel: &mockAccountHistoryModel{counts: map[int64]int64{1: 2, 2: 3, 3: 3}},
+			L2NftHistoryModel:   &mockNftHistoryModel{},
+		},
+	}
+	trees.accountTree, trees.assetTrees, err = tree.InitAccountTree(nil, trees.chainDB.AccountHistoryModel, 0, treeCtx, 16)
+	require.NoError(t, err)
+	trees.nftTree, err = tree.InitNftTree(trees.chainDB.L2NftHistoryModel, 0, treeCtx)
+	require.NoError(t, err)
+	return trees
+}
+
+func accountLeaf(t *testing.T, accountIndex int64, assetRoot []byte) []byte {
+	leaf, err := tree.AccountToNode(fmt.Sprintf("%064x", accountIndex+100), fmt.Sprintf("%064x", accountIndex+1), 0, 0, assetRoot)
+	require.NoError(t, err)
+	return leaf
+}
+
+func (trees *testTrees) setBalance(t *testing.T, accountIndex, balance int64) {
+	assetTree := trees.assetTrees.Get(accountIndex)
+	leaf, err := tree.AssetToNode(fmt.Sprint(balance), "0")
+	require.NoError(t, err)
+	require.NoError(t, assetTree.Set(0, leaf))
+	require.NoError(t, trees.accountTree.Set(uint64(accountIndex), accountLeaf(t, accountIndex, assetTree.Root())))
+}
+
+func (trees *testTrees) setOwner(t *testing.T, owner int64) {
+	leaf, err := tree.NftAssetToNode(&nft.L2NftHistory{
+		CreatorAccountIndex: 0,
+		OwnerAccountIndex:   owner,
+		NftContentHash:      fmt.Sprintf("%064x", 1),
+		NftL1Address:        common.Address{}.Hex(),
+		NftL1TokenId:        "0",
+	})
+	require.NoError(t, err)
+	require.NoError(t, trees.nftTree.Set(0, leaf))
+}
+
+func (trees *testTrees) commit(t *testing.T, height int64) string {
+	prunedVersion, err := trees.treeCtx.PrunedVersion(height, nil, height)
+	require.NoError(t, err)
+	require.NoError(t, tree.CommitTrees(trees.treeCtx, prunedVersion, trees.accountTree, trees.assetTrees, trees.nftTree))
+	return common.Bytes2Hex(tree.ComputeStateRootHash(trees.accountTree.Root(), trees.nftTree.Root()))
+}
+
+// commitBlocks commits 3 blocks: block 1 creates the accounts 0 and 1 and the nft 0, block 2
+// updates account 1, creates account 2 and transfers the nft, block 3 updates account 0.
+func (trees *testTrees) commitBlocks(t *testing.T) map[int64]string {
+	roots := make(map[int64]string)
+	trees.setBalance(t, 0, 100)
+	trees.setBalance(t, 1, 50)
+	trees.setOwner(t, 0)
+	roots[1] = trees.commit(t, 1)
+	trees.setBalance(t, 1, 40)
+	trees.setBalance(t, 2, 10)
+	trees.setOwner(t, 1)
+	roots[2] = trees.commit(t, 2)
+	trees.setBalance(t, 0, 90)
+	roots[3] = trees.commit(t, 3)
+	return roots
+}
+
+func TestRollbackTrees(t *testing.T) {
+	trees := newTestTrees(t, tree.RetentionOption{Policy: tree.RetainRecent, KeepVersions: 10})
+	roots := trees.commitBlocks(t)
+
+	require.NoError(t, checkTreeVersions(trees.treeCtx, 1))
+	require.NoError(t, rollbackTrees(trees.treeCtx, trees.chainDB, 1, 16))
+
+	// the trees are opened at height 1 the way the fullnode opens them once restarted
+	accountTree, assetTrees, err := tree.InitAccountTree(nil, trees.chainDB.AccountHistoryModel, 1, trees.treeCtx, 16)
+	require.NoError(t, err)
+	nftTree, err := tree.InitNftTree(trees.chainDB.L2NftHistoryModel, 1, trees.treeCtx)
+	require.NoError(t, err)
+	assert.Equal(t, bsmt.Version(1), accountTree.LatestVersion())
+	assert.Equal(t, bsmt.Version(1), nftTree.LatestVersion())
+	assert.Equal(t, roots[1], common.Bytes2Hex(tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root())))
+
+	balance, err := tree.AssetToNode("50", "0")
+	require.NoError(t, err)
+	leaf, err := assetTrees.Get(1).Get(0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, balance, leaf)
+	leaf, err = accountTree.Get(1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, accountLeaf(t, 1, assetTrees.Get(1).Root()), leaf)
+	assert.True(t, assetTrees.Get(2).IsEmpty())
+
+	// the blocks are synced again on top of the trees rolled back
+	trees.accountTree, trees.assetTrees, trees.nftTree = accountTree, assetTrees, nftTree
+	trees.setBalance(t, 1, 40)
+	trees.setBalance(t, 2, 10)
+	trees.setOwner(t, 1)
+	assert.Equal(t, roots[2], trees.commit(t, 2))
+	trees.setBalance(t, 0, 90)
+	assert.Equal(t, roots[3], trees.commit(t, 3))
+}
+
+func TestCheckTreeVersions(t *testing.T) {
+	// without a retention policy the fullnode only keeps the versions of the previous block
+	trees := newTestTrees(t, tree.RetentionOption{})
+	trees.commitBlocks(t)
+	assert.Error(t, checkTreeVersions(trees.treeCtx, 1))
+	assert.NoError(t, checkTreeVersions(trees.treeCtx, 2))
+	assert.NoError(t, checkTreeVersions(trees.treeCtx, 3))
+
+	trees = newTestTrees(t, tree.RetentionOption{Policy: tree.RetainRecent, KeepVersions: 3})
+	trees.commitBlocks(t)
+	assert.NoError(t, checkTreeVersions(trees.treeCtx, 1))
+}
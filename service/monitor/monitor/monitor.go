@@ -122,6 +122,10 @@ func NewMonitor(c config.Config) *Monitor {
 	monitor.governanceContractAddress = governanceAddressConfig.Value
 	monitor.cli = bscRpcCli
 
+	if err := monitor.backfillAccountNameHash(); err != nil {
+		logx.Errorf("backfill account name hash of priority requests failed: %v", err)
+	}
+
 	if err := prometheus.Register(priorityOperationMetric); err != nil {
 		logx.Severef("fatal error, cannot register prometheus, err: %s", err.Error())
 		panic(err)
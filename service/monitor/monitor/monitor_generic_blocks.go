@@ -305,5 +305,10 @@ func convertLogToNewPriorityRequestEvent(log types.Log) (*priorityrequest.Priori
 		ExpirationBlock: event.ExpirationBlock.Int64(),
 		Status:          priorityrequest.PendingStatus,
 	}
+	accountNameHash, err := getAccountNameHash(request.TxType, event.PubData)
+	if err != nil {
+		return nil, err
+	}
+	request.AccountNameHash = accountNameHash
 	return request, nil
 }
@@ -174,3 +174,31 @@ func (m *Monitor) MonitorPriorityRequests() error {
 
 	return nil
 }
+
+// backfillAccountNameHash fills the AccountNameHash of the requests monitored before it's
+// added, from their pub data.
+func (m *Monitor) backfillAccountNameHash() error {
+	fromId := uint(0)
+	for {
+		requests, err := m.PriorityRequestModel.GetPriorityRequestsWithoutAccountNameHash(fromId, backfillBatchSize)
+		if err == types.DbErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, request := range requests {
+			fromId = request.ID
+			accountNameHash, err := getAccountNameHash(request.TxType, common.FromHex(request.Pubdata))
+			if err != nil {
+				logx.Errorf("get account name hash of request %d failed: %v", request.RequestId, err)
+				continue
+			}
+			err = m.PriorityRequestModel.UpdateAccountNameHash(request.ID, accountNameHash)
+			if err != nil {
+				return err
+			}
+		}
+		logx.Infof("backfilled account name hash of priority requests up to id %d", fromId)
+	}
+}
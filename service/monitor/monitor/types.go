@@ -56,6 +56,9 @@ const (
 	TxTypeDepositNft  = types.TxTypeDepositNft
 	TxTypeFullExit    = types.TxTypeFullExit
 	TxTypeFullExitNft = types.TxTypeFullExitNft
+
+	// number of the priority requests backfilled at a time
+	backfillBatchSize = 1000
 )
 
 var (
@@ -18,11 +18,14 @@ package monitor
 
 import (
 	"encoding/hex"
+	"fmt"
 	"strconv"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/bnb-chain/zkbnb/common/chain"
 )
 
 func ComputeL1TxTxHash(requestId int64, txHash string) string {
@@ -35,3 +38,44 @@ func ComputeL1TxTxHash(requestId int64, txHash string) string {
 func EmptyCallOpts() *bind.CallOpts {
 	return &bind.CallOpts{}
 }
+
+// getAccountNameHash returns the hash of the account name in the pub data of a priority
+// request, formatted as the AccountNameHash of the accounts.
+func getAccountNameHash(txType int64, pubData []byte) (string, error) {
+	var accountNameHash []byte
+	switch txType {
+	case TxTypeRegisterZns:
+		txInfo, err := chain.ParseRegisterZnsPubData(pubData)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse registerZNS pub data, err: %v", err)
+		}
+		accountNameHash = txInfo.AccountNameHash
+	case TxTypeDeposit:
+		txInfo, err := chain.ParseDepositPubData(pubData)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse deposit pub data: %v", err)
+		}
+		accountNameHash = txInfo.AccountNameHash
+	case TxTypeDepositNft:
+		txInfo, err := chain.ParseDepositNftPubData(pubData)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse deposit nft pub data: %v", err)
+		}
+		accountNameHash = txInfo.AccountNameHash
+	case TxTypeFullExit:
+		txInfo, err := chain.ParseFullExitPubData(pubData)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse full exit pub data: %v", err)
+		}
+		accountNameHash = txInfo.AccountNameHash
+	case TxTypeFullExitNft:
+		txInfo, err := chain.ParseFullExitNftPubData(pubData)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse full exit nft pub data: %v", err)
+		}
+		accountNameHash = txInfo.AccountNameHash
+	default:
+		return "", fmt.Errorf("invalid request type")
+	}
+	return common.Bytes2Hex(accountNameHash), nil
+}
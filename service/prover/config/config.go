@@ -18,4 +18,8 @@ type Config struct {
 	BlockConfig struct {
 		OptionalBlockSizes []int
 	}
+	// ProvingSlots defines how many block witnesses are proved concurrently
+	// by one prover process, 1 by default.
+	//nolint:staticcheck
+	ProvingSlots int `json:",optional"`
 }
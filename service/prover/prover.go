@@ -24,16 +24,21 @@ func Run(configFile string) error {
 	cronJob := cron.New(cron.WithChain(
 		cron.SkipIfStillRunning(cron.DiscardLogger),
 	))
-	_, err := cronJob.AddFunc("@every 10s", func() {
-		logx.Info("start prover job......")
-		// cron job for receiving cryptoBlock and handling
-		err := p.ProveBlock()
+	// Every proving slot is a separate job, so a long running proof only
+	// blocks its own slot.
+	for slot := 0; slot < p.ProvingSlots; slot++ {
+		slot := slot
+		_, err := cronJob.AddFunc("@every 10s", func() {
+			logx.Infof("start prover job, slot %d......", slot)
+			// cron job for receiving cryptoBlock and handling
+			err := p.ProveBlock()
+			if err != nil {
+				logx.Errorf("failed to generate proof in slot %d, %v", slot, err)
+			}
+		})
 		if err != nil {
-			logx.Errorf("failed to generate proof, %v", err)
+			panic(err)
 		}
-	})
-	if err != nil {
-		panic(err)
 	}
 	cronJob.Start()
 
@@ -52,3 +57,21 @@ func Run(configFile string) error {
 	<-exit
 	return nil
 }
+
+// RunOffline proves the block witnesses exported into witnessDir and writes
+// the proofs into proofDir, it doesn't need access to the database.
+func RunOffline(configFile string, witnessDir, proofDir string) error {
+	var c config.Config
+	conf.MustLoad(configFile, &c)
+	logx.MustSetup(c.LogConf)
+	logx.DisableStat()
+
+	p := prover.NewOfflineProver(c)
+	logx.Infof("start to prove witness files in %s......", witnessDir)
+	err := p.ProveWitnessFiles(witnessDir, proofDir)
+	if err != nil {
+		logx.Errorf("failed to prove witness files, %v", err)
+	}
+	_ = logx.Close()
+	return err
+}
@@ -3,6 +3,8 @@ package prover
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
@@ -31,10 +33,18 @@ type Prover struct {
 	ProofModel        proof.ProofModel
 	BlockWitnessModel blockwitness.BlockWitnessModel
 
+	// The keys and constraint systems are loaded once and only read while
+	// proving, so they are shared by all proving slots.
 	VerifyingKeys      []groth16.VerifyingKey
 	ProvingKeys        []groth16.ProvingKey
 	OptionalBlockSizes []int
 	R1cs               []frontend.CompiledConstraintSystem
+
+	// ProvingSlots is the number of block witnesses proved concurrently.
+	ProvingSlots int
+	// leaseLock serializes witness leasing between the local proving slots,
+	// the redis lock does the same between prover processes.
+	leaseLock sync.Mutex
 }
 
 func WithRedis(redisType string, redisPass string) redis.Option {
@@ -58,19 +68,30 @@ func NewProver(c config.Config) *Prover {
 	if err != nil {
 		logx.Errorf("gorm connect db error, err = %s", err.Error())
 	}
-	redisConn := redis.New(c.CacheRedis[0].Host, WithRedis(c.CacheRedis[0].Type, c.CacheRedis[0].Pass))
+	prover := NewOfflineProver(c)
+	prover.RedisConn = redis.New(c.CacheRedis[0].Host, WithRedis(c.CacheRedis[0].Type, c.CacheRedis[0].Pass))
+	prover.DB = db
+	prover.BlockWitnessModel = blockwitness.NewBlockWitnessModel(db)
+	prover.ProofModel = proof.NewProofModel(db)
+	return prover
+}
+
+// NewOfflineProver creates a prover which only loads the keys, it proves the
+// block witnesses from files and never accesses the database or redis.
+func NewOfflineProver(c config.Config) *Prover {
+	var err error
 	prover := &Prover{
-		Config:            c,
-		RedisConn:         redisConn,
-		DB:                db,
-		BlockWitnessModel: blockwitness.NewBlockWitnessModel(db),
-		ProofModel:        proof.NewProofModel(db),
+		Config: c,
 	}
 
 	if !IsBlockSizesSorted(c.BlockConfig.OptionalBlockSizes) {
 		panic("invalid OptionalBlockSizes")
 	}
 
+	prover.ProvingSlots = c.ProvingSlots
+	if prover.ProvingSlots <= 0 {
+		prover.ProvingSlots = DefaultProvingSlots
+	}
 	prover.OptionalBlockSizes = c.BlockConfig.OptionalBlockSizes
 	prover.ProvingKeys = make([]groth16.ProvingKey, len(prover.OptionalBlockSizes))
 	prover.VerifyingKeys = make([]groth16.VerifyingKey, len(prover.OptionalBlockSizes))
@@ -107,28 +128,11 @@ func NewProver(c config.Config) *Prover {
 	return prover
 }
 
+// ProveBlock leases the unproved block witness with the lowest height and
+// generates its proof. It is safe to be called concurrently, each call leases
+// a distinct block witness.
 func (p *Prover) ProveBlock() error {
-	blockWitness, err := func() (*blockwitness.BlockWitness, error) {
-		lock := redislock.GetRedisLockByKey(p.RedisConn, RedisLockKey)
-		err := redislock.TryAcquireLock(lock)
-		if err != nil {
-			return nil, err
-		}
-		//nolint:errcheck
-		defer lock.Release()
-
-		// Fetch unproved block witness.
-		blockWitness, err := p.BlockWitnessModel.GetLatestBlockWitness()
-		if err != nil {
-			return nil, err
-		}
-		// Update status of block witness.
-		err = p.BlockWitnessModel.UpdateBlockWitnessStatus(blockWitness, blockwitness.StatusReceived)
-		if err != nil {
-			return nil, err
-		}
-		return blockWitness, nil
-	}()
+	blockWitness, err := p.leaseBlockWitness()
 	if err != nil {
 		if err == types.DbErrNotFound {
 			return nil
@@ -147,27 +151,18 @@ func (p *Prover) ProveBlock() error {
 		}
 	}()
 
+	logx.Infof("start to prove block %d", blockWitness.Height)
+
 	// Parse crypto block.
-	var cryptoBlock *circuit.Block
-	err = json.Unmarshal([]byte(blockWitness.WitnessData), &cryptoBlock)
+	cryptoBlock, err := prove.DecodeBlockWitness(blockWitness)
 	if err != nil {
 		return err
 	}
 
-	var keyIndex int
-	for ; keyIndex < len(p.OptionalBlockSizes); keyIndex++ {
-		if len(cryptoBlock.Txs) == p.OptionalBlockSizes[keyIndex] {
-			break
-		}
-	}
-	if keyIndex == len(p.OptionalBlockSizes) {
-		return fmt.Errorf("can't find correct vk/pk")
-	}
-
 	// Generate proof.
-	blockProof, err := prove.GenerateProof(p.R1cs[keyIndex], p.ProvingKeys[keyIndex], p.VerifyingKeys[keyIndex], cryptoBlock)
+	blockProof, err := p.generateProof(cryptoBlock)
 	if err != nil {
-		return fmt.Errorf("failed to generateProof, err: %v", err)
+		return err
 	}
 
 	formattedProof, err := prove.FormatProof(blockProof, cryptoBlock.OldStateRoot, cryptoBlock.NewStateRoot, cryptoBlock.BlockCommitment)
@@ -197,7 +192,111 @@ func (p *Prover) ProveBlock() error {
 	return err
 }
 
+// ProveWitnessFiles proves the witness files in witnessDir and writes the proofs
+// into proofDir, the witnesses which already have proof files are skipped.
+func (p *Prover) ProveWitnessFiles(witnessDir, proofDir string) error {
+	heights, paths, err := prove.ListWitnessFiles(witnessDir)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(proofDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	tasks := make(chan int, len(paths))
+	for i := range paths {
+		if _, err := os.Stat(prove.ProofFilePath(proofDir, heights[i])); err == nil {
+			logx.Infof("proof of block %d exists, skip it", heights[i])
+			continue
+		}
+		tasks <- i
+	}
+	close(tasks)
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for slot := 0; slot < p.ProvingSlots; slot++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				err := p.proveWitnessFile(paths[i], proofDir)
+				if err != nil {
+					logx.Errorf("failed to prove witness file %s, err: %v", paths[i], err)
+					errOnce.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func (p *Prover) proveWitnessFile(path string, proofDir string) error {
+	cryptoBlock, err := prove.ReadWitnessFile(path)
+	if err != nil {
+		return err
+	}
+	logx.Infof("start to prove block %d", cryptoBlock.BlockNumber)
+
+	blockProof, err := p.generateProof(cryptoBlock)
+	if err != nil {
+		return err
+	}
+	return prove.WriteProofFile(proofDir, cryptoBlock.BlockNumber, len(cryptoBlock.Txs), blockProof)
+}
+
+func (p *Prover) generateProof(cryptoBlock *circuit.Block) (groth16.Proof, error) {
+	var keyIndex int
+	for ; keyIndex < len(p.OptionalBlockSizes); keyIndex++ {
+		if len(cryptoBlock.Txs) == p.OptionalBlockSizes[keyIndex] {
+			break
+		}
+	}
+	if keyIndex == len(p.OptionalBlockSizes) {
+		return nil, fmt.Errorf("can't find correct vk/pk")
+	}
+
+	blockProof, err := prove.GenerateProof(p.R1cs[keyIndex], p.ProvingKeys[keyIndex], p.VerifyingKeys[keyIndex], cryptoBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generateProof, err: %v", err)
+	}
+	return blockProof, nil
+}
+
+func (p *Prover) leaseBlockWitness() (*blockwitness.BlockWitness, error) {
+	p.leaseLock.Lock()
+	defer p.leaseLock.Unlock()
+
+	lock := redislock.GetRedisLockByKey(p.RedisConn, RedisLockKey)
+	err := redislock.TryAcquireLock(lock)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer lock.Release()
+
+	// Fetch unproved block witness.
+	blockWitness, err := p.BlockWitnessModel.GetLatestBlockWitness()
+	if err != nil {
+		return nil, err
+	}
+	// Update status of block witness.
+	err = p.BlockWitnessModel.UpdateBlockWitnessStatus(blockWitness, blockwitness.StatusReceived)
+	if err != nil {
+		return nil, err
+	}
+	return blockWitness, nil
+}
+
 func (p *Prover) Shutdown() {
+	if p.DB == nil {
+		return
+	}
 	sqlDB, err := p.DB.DB()
 	if err == nil && sqlDB != nil {
 		err = sqlDB.Close()
@@ -16,4 +16,8 @@
 
 package prover
 
-const RedisLockKey = "prover_mutex_key"
+const (
+	RedisLockKey = "prover_mutex_key"
+
+	DefaultProvingSlots = 1
+)
@@ -15,8 +15,12 @@ type Config struct {
 		//nolint:staticcheck
 		LevelDBOption tree.LevelDBOption `json:",optional"`
 		//nolint:staticcheck
+		PebbleDBOption tree.PebbleDBOption `json:",optional"`
+		//nolint:staticcheck
 		RedisDBOption tree.RedisDBOption `json:",optional"`
 		//nolint:staticcheck
+		Retention tree.RetentionOption `json:",optional"`
+		//nolint:staticcheck
 		RoutinePoolSize    int `json:",optional"`
 		AssetTreeCacheSize int
 	}
@@ -1,7 +1,6 @@
 package witness
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -29,6 +28,8 @@ const (
 	UnprovedBlockWitnessTimeout = 10 * time.Minute
 
 	BlockProcessDelta = 10
+
+	MaxRescheduleBlockWitnessCount = 100
 )
 
 type Witness struct {
@@ -84,12 +85,13 @@ func (w *Witness) initState() error {
 	}
 
 	// dbinitializer tree database
-	treeCtx, err := tree.NewContext("witness", w.config.TreeDB.Driver, false, w.config.TreeDB.RoutinePoolSize, &w.config.TreeDB.LevelDBOption, &w.config.TreeDB.RedisDBOption)
+	treeCtx, err := tree.NewContext("witness", w.config.TreeDB.Driver, false, w.config.TreeDB.RoutinePoolSize, &w.config.TreeDB.LevelDBOption, &w.config.TreeDB.PebbleDBOption, &w.config.TreeDB.RedisDBOption)
 	if err != nil {
 		return err
 	}
 
 	treeCtx.SetOptions(bsmt.BatchSizeLimit(3 * 1024 * 1024))
+	treeCtx.SetRetention(w.config.TreeDB.Retention)
 	err = tree.SetupTreeDB(treeCtx)
 	if err != nil {
 		return fmt.Errorf("init tree database failed %v", err)
@@ -115,6 +117,7 @@ func (w *Witness) initState() error {
 	if err != nil {
 		return fmt.Errorf("initNftTree error: %v", err)
 	}
+	treeCtx.StartPruner(w.assetTrees.GetNextAccountIndex)
 	w.helper = utils.NewWitnessHelper(w.treeCtx, w.accountTree, w.nftTree, w.assetTrees, w.accountModel, w.accountHistoryModel)
 	return nil
 }
@@ -139,63 +142,100 @@ func (w *Witness) GenerateBlockWitness() (err error) {
 		return err
 	}
 
+	// The witness of a block is inserted while the next block is being
+	// constructed, the insertion is waited for before the trees are committed.
+	var (
+		pendingHeight  int64
+		pendingErrChan chan error
+	)
+	waitPendingWitness := func() error {
+		if pendingErrChan == nil {
+			return nil
+		}
+		err := <-pendingErrChan
+		pendingErrChan = nil
+		if err != nil {
+			// rollback trees, the uncommitted changes of the next block are discarded as well
+			rollBackErr := tree.RollBackTrees(w.treeCtx, uint64(pendingHeight)-1, w.accountTree, w.assetTrees, w.nftTree)
+			if rollBackErr != nil {
+				logx.Errorf("unable to rollback trees %v", rollBackErr)
+			}
+			return fmt.Errorf("create unproved crypto block error, block:%d, err: %v", pendingHeight, err)
+		}
+		return nil
+	}
+
 	// scan each block
 	for _, block := range blocks {
 		logx.Infof("construct witness for block %d", block.BlockHeight)
 		// Step1: construct witness
 		blockWitness, err := w.constructBlockWitness(block, latestVerifiedBlockNr)
 		if err != nil {
+			if pendingErr := waitPendingWitness(); pendingErr != nil {
+				return pendingErr
+			}
 			return fmt.Errorf("failed to construct block witness, block:%d, err: %v", block.BlockHeight, err)
 		}
-		// Step2: commit trees for witness
-		err = tree.CommitTrees(uint64(latestVerifiedBlockNr), w.accountTree, w.assetTrees, w.nftTree)
+		// Step2: wait for the witness of the previous block
+		err = waitPendingWitness()
 		if err != nil {
-			return fmt.Errorf("unable to commit trees after txs is executed, block:%d, error: %v", block.BlockHeight, err)
+			return err
 		}
-		// Step3: insert witness into database
-		err = w.blockWitnessModel.CreateBlockWitness(blockWitness)
+		// Step3: commit trees for witness
+		prunedVersion, err := w.treeCtx.PrunedVersion(block.BlockHeight, func() (int64, error) {
+			return latestVerifiedBlockNr, nil
+		}, latestVerifiedBlockNr)
 		if err != nil {
-			// rollback trees
-			rollBackErr := tree.RollBackTrees(uint64(block.BlockHeight)-1, w.accountTree, w.assetTrees, w.nftTree)
-			if rollBackErr != nil {
-				logx.Errorf("unable to rollback trees %v", rollBackErr)
-			}
-			return fmt.Errorf("create unproved crypto block error, block:%d, err: %v", block.BlockHeight, err)
+			return err
 		}
-	}
-	return nil
+		err = tree.CommitTrees(w.treeCtx, prunedVersion, w.accountTree, w.assetTrees, w.nftTree)
+		if err != nil {
+			return fmt.Errorf("unable to commit trees after txs is executed, block:%d, error: %v", block.BlockHeight, err)
+		}
+		// Step4: insert witness into database
+		pendingHeight = block.BlockHeight
+		pendingErrChan = make(chan error, 1)
+		go func(blockWitness *blockwitness.BlockWitness, errChan chan<- error) {
+			errChan <- w.blockWitnessModel.CreateBlockWitness(blockWitness)
+		}(blockWitness, pendingErrChan)
+	}
+	return waitPendingWitness()
 }
 
+// RescheduleBlockWitness publishes the leased block witnesses again if their
+// proofs are not generated in time. Provers may lease several witnesses at
+// once, so all the leased witnesses from the first unproved height are checked.
 func (w *Witness) RescheduleBlockWitness() {
 	nextBlockNumber, err := w.getNextWitnessToCheck()
 	if err != nil {
 		logx.Errorf("failed to get next witness to check, err: %s", err.Error())
-	}
-	nextBlockWitness, err := w.blockWitnessModel.GetBlockWitnessByHeight(nextBlockNumber)
-	if err != nil {
-		logx.Errorf("failed to get latest block witness, err: %s", err.Error())
 		return
 	}
-
-	// skip if next block is not processed
-	if nextBlockWitness.Status == blockwitness.StatusPublished {
+	leasedWitnesses, err := w.blockWitnessModel.GetBlockWitnessesByStatus(blockwitness.StatusReceived,
+		nextBlockNumber, MaxRescheduleBlockWitnessCount)
+	if err != nil {
+		if err != types.DbErrNotFound {
+			logx.Errorf("failed to get leased block witnesses, err: %s", err.Error())
+		}
 		return
 	}
 
-	// skip if the next block proof exists
-	// if the proof is not submitted and verified in L1, there should be another alerts
-	_, err = w.proofModel.GetProofByBlockHeight(nextBlockNumber)
-	if err == nil {
-		return
-	}
+	for _, leasedWitness := range leasedWitnesses {
+		// skip if the block proof exists
+		// if the proof is not submitted and verified in L1, there should be another alerts
+		_, err = w.proofModel.GetProofByBlockHeight(leasedWitness.Height)
+		if err == nil {
+			continue
+		}
 
-	// update block status to Published if it's timeout
-	if time.Now().After(nextBlockWitness.UpdatedAt.Add(UnprovedBlockWitnessTimeout)) {
-		logx.Infof("reschedule block %d", nextBlockWitness.Height)
-		err := w.blockWitnessModel.UpdateBlockWitnessStatus(nextBlockWitness, blockwitness.StatusPublished)
-		if err != nil {
-			logx.Errorf("update unproved block status error, err: %s", err.Error())
-			return
+		// update block status to Published if it's timeout
+		if time.Now().After(leasedWitness.UpdatedAt.Add(UnprovedBlockWitnessTimeout)) {
+			logx.Infof("reschedule block %d", leasedWitness.Height)
+			err := w.blockWitnessModel.UpdateBlockWitnessStatus(leasedWitness, blockwitness.StatusPublished)
+			if err != nil {
+				// the other witnesses are still rescheduled
+				logx.Errorf("update unproved block status error, block: %d, err: %s", leasedWitness.Height, err.Error())
+			}
 		}
 	}
 }
@@ -279,15 +319,14 @@ func (w *Witness) constructBlockWitness(block *block.Block, latestVerifiedBlockN
 		Txs:             txsWitness,
 		Gas:             gasWitness,
 	}
-	bz, err := json.Marshal(b)
+	blockWitness := blockwitness.BlockWitness{
+		Height: block.BlockHeight,
+		Status: blockwitness.StatusPublished,
+	}
+	err = utils.EncodeBlockWitness(b, &blockWitness, blockwitness.FormatBinaryV1)
 	if err != nil {
 		return nil, err
 	}
-	blockWitness := blockwitness.BlockWitness{
-		Height:      block.BlockHeight,
-		WitnessData: string(bz),
-		Status:      blockwitness.StatusPublished,
-	}
 	return &blockWitness, nil
 }
 
@@ -300,6 +339,7 @@ func (w *Witness) Shutdown() {
 		logx.Errorf("close db error: %s", err.Error())
 	}
 
+	w.treeCtx.StopPruner()
 	err = w.treeCtx.TreeDB.Close()
 	if err != nil {
 		logx.Errorf("close treedb error: %s", err.Error())
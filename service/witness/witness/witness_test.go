@@ -0,0 +1,76 @@
+package witness
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type mockBlockWitnessModel struct {
+	blockwitness.BlockWitnessModel
+	witnesses []*blockwitness.BlockWitness
+	failed    map[int64]bool
+	published []int64
+}
+
+func (m *mockBlockWitnessModel) GetBlockWitnessesByStatus(status int64, fromHeight int64, limit int) ([]*blockwitness.BlockWitness, error) {
+	return m.witnesses, nil
+}
+
+func (m *mockBlockWitnessModel) UpdateBlockWitnessStatus(witness *blockwitness.BlockWitness, status int64) error {
+	if m.failed[witness.Height] {
+		return errors.New("update failed")
+	}
+	m.published = append(m.published, witness.Height)
+	return nil
+}
+
+type mockProofModel struct {
+	proof.ProofModel
+	proved map[int64]bool
+}
+
+func (m *mockProofModel) GetLatestProof() (*proof.Proof, error) {
+	return nil, types.DbErrNotFound
+}
+
+func (m *mockProofModel) GetProofByBlockHeight(height int64) (*proof.Proof, error) {
+	if m.proved[height] {
+		return &proof.Proof{BlockNumber: height}, nil
+	}
+	return nil, types.DbErrNotFound
+}
+
+func TestRescheduleBlockWitness(t *testing.T) {
+	leasedAt := time.Now().Add(-2 * UnprovedBlockWitnessTimeout)
+	witnesses := make([]*blockwitness.BlockWitness, 0)
+	for height := int64(1); height <= 5; height++ {
+		witnesses = append(witnesses, &blockwitness.BlockWitness{
+			Model:  gorm.Model{UpdatedAt: leasedAt},
+			Height: height,
+			Status: blockwitness.StatusReceived,
+		})
+	}
+	// the lease of block 5 is not timed out yet
+	witnesses[4].UpdatedAt = time.Now()
+
+	blockWitnessModel := &mockBlockWitnessModel{
+		witnesses: witnesses,
+		failed:    map[int64]bool{2: true},
+	}
+	w := &Witness{
+		blockWitnessModel: blockWitnessModel,
+		proofModel:        &mockProofModel{proved: map[int64]bool{3: true}},
+	}
+	w.RescheduleBlockWitness()
+
+	// block 2 fails to be updated, the following witnesses are rescheduled anyway
+	assert.Equal(t, []int64{1, 4}, blockWitnessModel.published)
+}
@@ -0,0 +1,371 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package audit
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/core"
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+const (
+	// the account or tx is not known for block level divergences
+	unknownIndex = -1
+)
+
+// Divergence is the first place where the replayed chain departs from the stored one.
+type Divergence struct {
+	BlockHeight  int64
+	TxIndex      int64
+	TxHash       string
+	AccountIndex int64
+	Field        string
+	Expected     string
+	Actual       string
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("block %d, tx index %d, tx hash %s, account %d: %s mismatch, stored %s, replayed %s",
+		d.BlockHeight, d.TxIndex, d.TxHash, d.AccountIndex, d.Field, d.Expected, d.Actual)
+}
+
+type auditor struct {
+	chainDb  *sdb.ChainDB
+	bc       *core.BlockChain
+	accounts *historyAccountModel
+	nfts     *historyNftModel
+}
+
+// AuditState replays the blocks between fromHeight and toHeight from the tx table
+// through a fresh blockchain on a memory tree, and compares the state root, the
+// commitment and the pubdata of every block with the stored ones.
+func AuditState(dsn string, fromHeight, toHeight int64) error {
+	if fromHeight <= 0 || toHeight < fromHeight {
+		return fmt.Errorf("invalid height range [%d, %d]", fromHeight, toHeight)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	chainDb := sdb.NewChainDB(db)
+	defer chainDb.Close()
+
+	divergence, err := auditBlocks(chainDb, fromHeight, toHeight)
+	if err != nil {
+		return err
+	}
+	if divergence != nil {
+		return fmt.Errorf("state diverged, %s", divergence)
+	}
+	logx.Infof("blocks [%d, %d] are consistent", fromHeight, toHeight)
+	return nil
+}
+
+// auditBlocks replays the blocks of the chain db and returns the first divergence.
+func auditBlocks(chainDb *sdb.ChainDB, fromHeight, toHeight int64) (*Divergence, error) {
+	a, err := newAuditor(chainDb, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+	for height := fromHeight; height <= toHeight; height++ {
+		blocks, err := chainDb.BlockModel.GetBlocksBetween(height, height)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get block %d: %v", height, err)
+		}
+		if len(blocks) == 0 {
+			return nil, fmt.Errorf("block %d is not committed yet", height)
+		}
+		compressedBlocks, err := chainDb.CompressedBlockModel.GetCompressedBlocksBetween(height, height)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get compressed block %d: %v", height, err)
+		}
+		divergence, err := a.replayBlock(blocks[0], compressedBlocks[0])
+		if err != nil {
+			return nil, fmt.Errorf("unable to replay block %d: %v", height, err)
+		}
+		if divergence != nil {
+			return divergence, nil
+		}
+		logx.Infof("block %d is consistent, state root: %s", height, blocks[0].StateRoot)
+	}
+	return nil, nil
+}
+
+func newAuditor(chainDb *sdb.ChainDB, fromHeight, toHeight int64) (*auditor, error) {
+	baseBlock, err := chainDb.BlockModel.GetBlockByHeightWithoutTx(fromHeight - 1)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get block %d: %v", fromHeight-1, err)
+	}
+	accountCount, err := chainDb.AccountHistoryModel.GetValidAccountCount(toHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	// The flat tables keep the latest state only, serve the state before the range from histories.
+	accounts := newHistoryAccountModel(chainDb.AccountModel, chainDb.AccountHistoryModel, fromHeight)
+	nfts, err := newHistoryNftModel(chainDb.L2NftModel, chainDb.L2NftHistoryModel, fromHeight)
+	if err != nil {
+		return nil, err
+	}
+	replayDb := *chainDb
+	replayDb.AccountModel = accounts
+	replayDb.L2NftModel = nfts
+
+	treeCtx, err := tree.NewContext("audit", tree.MemoryDB, true, 0, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Memory asset trees are lost once evicted, keep all of them.
+	bc, err := core.NewBlockChainForReplay(&replayDb, treeCtx, dbcache.NewMemoryCache(), int(accountCount)+1, baseBlock)
+	if err != nil {
+		return nil, err
+	}
+	stateRoot := common.Bytes2Hex(tree.ComputeStateRootHash(bc.Statedb.AccountTree.Root(), bc.Statedb.NftTree.Root()))
+	if stateRoot != baseBlock.StateRoot {
+		return nil, fmt.Errorf("state root of block %d is not rebuilt from histories, stored %s, rebuilt %s",
+			baseBlock.BlockHeight, baseBlock.StateRoot, stateRoot)
+	}
+	return &auditor{
+		chainDb:  chainDb,
+		bc:       bc,
+		accounts: accounts,
+		nfts:     nfts,
+	}, nil
+}
+
+func (a *auditor) replayBlock(storedBlock *block.Block, compressedBlock *compressedblock.CompressedBlock) (*Divergence, error) {
+	bc := a.bc
+	curBlock, err := bc.InitNewBlock()
+	if err != nil {
+		return nil, err
+	}
+	if curBlock.BlockHeight != storedBlock.BlockHeight {
+		return nil, fmt.Errorf("unexpected block height %d", curBlock.BlockHeight)
+	}
+	// create time needs to be set, otherwise tx will fail if expire time is set
+	curBlock.CreatedAt = storedBlock.CreatedAt
+	storedPubData := common.FromHex(compressedBlock.PublicData)
+
+	for _, storedTx := range storedBlock.Txs {
+		newTx := &tx.Tx{
+			TxHash:        storedTx.TxHash,
+			TxType:        storedTx.TxType,
+			TxInfo:        storedTx.TxInfo,
+			NativeAddress: storedTx.NativeAddress,
+		}
+		offset := len(bc.Statedb.PubData)
+		err = applyTransaction(bc, newTx)
+		if err != nil {
+			return &Divergence{
+				BlockHeight:  storedBlock.BlockHeight,
+				TxIndex:      storedTx.TxIndex,
+				TxHash:       storedTx.TxHash,
+				AccountIndex: storedTx.AccountIndex,
+				Field:        "execution",
+				Expected:     "success",
+				Actual:       err.Error(),
+			}, nil
+		}
+		if divergence := compareTxDetails(storedBlock.BlockHeight, storedTx, newTx); divergence != nil {
+			return divergence, nil
+		}
+		replayedPubData := bc.Statedb.PubData[offset:]
+		end := offset + len(replayedPubData)
+		if end > len(storedPubData) || !bytes.Equal(replayedPubData, storedPubData[offset:end]) {
+			expected := "missing"
+			if end <= len(storedPubData) {
+				expected = common.Bytes2Hex(storedPubData[offset:end])
+			}
+			return &Divergence{
+				BlockHeight:  storedBlock.BlockHeight,
+				TxIndex:      storedTx.TxIndex,
+				TxHash:       storedTx.TxHash,
+				AccountIndex: storedTx.AccountIndex,
+				Field:        "pubdata",
+				Expected:     expected,
+				Actual:       common.Bytes2Hex(replayedPubData),
+			}, nil
+		}
+	}
+
+	blockStates, err := bc.CommitNewBlock(int(storedBlock.BlockSize), storedBlock.CreatedAt.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	if blockStates.Block.StateRoot != storedBlock.StateRoot {
+		divergence, err := a.findDivergedAccount(storedBlock, blockStates.PendingAccountHistory)
+		if err != nil || divergence != nil {
+			return divergence, err
+		}
+		return blockDivergence(storedBlock.BlockHeight, "state root", storedBlock.StateRoot, blockStates.Block.StateRoot), nil
+	}
+	if blockStates.Block.BlockCommitment != storedBlock.BlockCommitment {
+		return blockDivergence(storedBlock.BlockHeight, "block commitment",
+			storedBlock.BlockCommitment, blockStates.Block.BlockCommitment), nil
+	}
+	if blockStates.CompressedBlock.PublicData != compressedBlock.PublicData {
+		return blockDivergence(storedBlock.BlockHeight, "block pubdata",
+			compressedBlock.PublicData, blockStates.CompressedBlock.PublicData), nil
+	}
+
+	// sync pending value to caches
+	err = bc.Statedb.SyncGasAccountToRedis()
+	if err != nil {
+		return nil, err
+	}
+	err = bc.Statedb.SyncStateCacheToRedis()
+	if err != nil {
+		return nil, err
+	}
+	a.accounts.update(blockStates.PendingAccount)
+	a.nfts.update(blockStates.PendingNft)
+	return nil, nil
+}
+
+// applyTransaction applies the tx, the commit processor panics once the inputs
+// are verified, which is reported as a divergence here.
+func applyTransaction(bc *core.BlockChain, newTx *tx.Tx) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return bc.ApplyTransaction(newTx)
+}
+
+func compareTxDetails(height int64, storedTx, replayedTx *tx.Tx) *Divergence {
+	newDivergence := func(accountIndex int64, field, expected, actual string) *Divergence {
+		return &Divergence{
+			BlockHeight:  height,
+			TxIndex:      storedTx.TxIndex,
+			TxHash:       storedTx.TxHash,
+			AccountIndex: accountIndex,
+			Field:        field,
+			Expected:     expected,
+			Actual:       actual,
+		}
+	}
+	for i, stored := range storedTx.TxDetails {
+		if i >= len(replayedTx.TxDetails) {
+			return newDivergence(stored.AccountIndex, fmt.Sprintf("tx detail %d", i), stored.Balance, "missing")
+		}
+		replayed := replayedTx.TxDetails[i]
+		fields := []struct {
+			name             string
+			expected, actual interface{}
+		}{
+			{"account index", stored.AccountIndex, replayed.AccountIndex},
+			{"asset id", stored.AssetId, replayed.AssetId},
+			{"asset type", stored.AssetType, replayed.AssetType},
+			{"balance", stored.Balance, replayed.Balance},
+			{"balance delta", stored.BalanceDelta, replayed.BalanceDelta},
+			{"nonce", stored.Nonce, replayed.Nonce},
+			{"collection nonce", stored.CollectionNonce, replayed.CollectionNonce},
+			{"is gas", stored.IsGas, replayed.IsGas},
+		}
+		for _, field := range fields {
+			if field.expected != field.actual {
+				return newDivergence(stored.AccountIndex, fmt.Sprintf("tx detail %d %s", i, field.name),
+					fmt.Sprint(field.expected), fmt.Sprint(field.actual))
+			}
+		}
+	}
+	if len(replayedTx.TxDetails) > len(storedTx.TxDetails) {
+		extra := replayedTx.TxDetails[len(storedTx.TxDetails)]
+		return newDivergence(extra.AccountIndex, fmt.Sprintf("tx detail %d", len(storedTx.TxDetails)), "missing", extra.Balance)
+	}
+	return nil
+}
+
+// findDivergedAccount compares the replayed accounts with the stored histories of the block
+// and blames the first tx of the block touching the diverged account.
+func (a *auditor) findDivergedAccount(storedBlock *block.Block, replayed []*account.AccountHistory) (*Divergence, error) {
+	for _, replayedHistory := range replayed {
+		storedHistory, err := a.chainDb.AccountHistoryModel.GetLatestAccountHistory(replayedHistory.AccountIndex, storedBlock.BlockHeight+1)
+		if err != nil && err != types.DbErrNotFound {
+			return nil, err
+		}
+		field, expected, actual := "", "", ""
+		switch {
+		case err == types.DbErrNotFound:
+			field, expected, actual = "account", "missing", replayedHistory.AssetRoot
+		case storedHistory.Nonce != replayedHistory.Nonce:
+			field, expected, actual = "nonce", fmt.Sprint(storedHistory.Nonce), fmt.Sprint(replayedHistory.Nonce)
+		case storedHistory.CollectionNonce != replayedHistory.CollectionNonce:
+			field, expected, actual = "collection nonce", fmt.Sprint(storedHistory.CollectionNonce), fmt.Sprint(replayedHistory.CollectionNonce)
+		case storedHistory.AssetRoot != replayedHistory.AssetRoot:
+			field, expected, actual = "asset root", storedHistory.AssetRoot, replayedHistory.AssetRoot
+		default:
+			continue
+		}
+		divergence := &Divergence{
+			BlockHeight:  storedBlock.BlockHeight,
+			TxIndex:      unknownIndex,
+			AccountIndex: replayedHistory.AccountIndex,
+			Field:        field,
+			Expected:     expected,
+			Actual:       actual,
+		}
+		for _, storedTx := range storedBlock.Txs {
+			if touchesAccount(storedTx, replayedHistory.AccountIndex) {
+				divergence.TxIndex = storedTx.TxIndex
+				divergence.TxHash = storedTx.TxHash
+				break
+			}
+		}
+		return divergence, nil
+	}
+	return nil, nil
+}
+
+func touchesAccount(storedTx *tx.Tx, accountIndex int64) bool {
+	if storedTx.AccountIndex == accountIndex {
+		return true
+	}
+	for _, txDetail := range storedTx.TxDetails {
+		if txDetail.AccountIndex == accountIndex {
+			return true
+		}
+	}
+	return false
+}
+
+func blockDivergence(height int64, field, expected, actual string) *Divergence {
+	return &Divergence{
+		BlockHeight:  height,
+		TxIndex:      unknownIndex,
+		AccountIndex: unknownIndex,
+		Field:        field,
+		Expected:     expected,
+		Actual:       actual,
+	}
+}
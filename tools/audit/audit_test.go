@@ -0,0 +1,362 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bnb-chain/zkbnb-crypto/wasm/txtypes"
+	"github.com/bnb-chain/zkbnb/core"
+	sdb "github.com/bnb-chain/zkbnb/core/statedb"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/compressedblock"
+	"github.com/bnb-chain/zkbnb/dao/dbcache"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/tx"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// fixtureChain keeps the tables of a small chain in memory, the blocks are
+// produced by the blockchain the same way the committer does.
+type fixtureChain struct {
+	blocks           []*block.Block
+	compressedBlocks []*compressedblock.CompressedBlock
+	accounts         map[int64]*account.Account
+	accountHistories []*account.AccountHistory
+}
+
+type fixtureBlockModel struct {
+	block.BlockModel
+	c *fixtureChain
+}
+
+func (m *fixtureBlockModel) GetBlocksBetween(start int64, end int64) ([]*block.Block, error) {
+	var blocks []*block.Block
+	for height := start; height <= end && height < int64(len(m.c.blocks)); height++ {
+		blocks = append(blocks, m.c.blocks[height])
+	}
+	return blocks, nil
+}
+
+func (m *fixtureBlockModel) GetBlockByHeightWithoutTx(blockHeight int64) (*block.Block, error) {
+	if blockHeight >= int64(len(m.c.blocks)) {
+		return nil, types.DbErrNotFound
+	}
+	return m.c.blocks[blockHeight], nil
+}
+
+func (m *fixtureBlockModel) GetLatestVerifiedHeight() (int64, error) {
+	return 0, nil
+}
+
+type fixtureCompressedBlockModel struct {
+	compressedblock.CompressedBlockModel
+	c *fixtureChain
+}
+
+func (m *fixtureCompressedBlockModel) GetCompressedBlocksBetween(start, end int64) ([]*compressedblock.CompressedBlock, error) {
+	var blocks []*compressedblock.CompressedBlock
+	for height := start; height <= end && height < int64(len(m.c.compressedBlocks)); height++ {
+		blocks = append(blocks, m.c.compressedBlocks[height])
+	}
+	return blocks, nil
+}
+
+type fixtureAccountModel struct {
+	account.AccountModel
+	c *fixtureChain
+}
+
+func (m *fixtureAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	accountInfo, ok := m.c.accounts[accountIndex]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	copied := *accountInfo
+	return &copied, nil
+}
+
+func (m *fixtureAccountModel) GetAccountByName(name string) (*account.Account, error) {
+	for _, accountInfo := range m.c.accounts {
+		if accountInfo.AccountName == name {
+			return m.GetAccountByIndex(accountInfo.AccountIndex)
+		}
+	}
+	return nil, types.DbErrNotFound
+}
+
+func (m *fixtureAccountModel) GetAccountByNameHash(nameHash string) (*account.Account, error) {
+	for _, accountInfo := range m.c.accounts {
+		if accountInfo.AccountNameHash == nameHash {
+			return m.GetAccountByIndex(accountInfo.AccountIndex)
+		}
+	}
+	return nil, types.DbErrNotFound
+}
+
+type fixtureAccountHistoryModel struct {
+	account.AccountHistoryModel
+	c *fixtureChain
+}
+
+// validAccounts returns the latest history of every account at the height.
+func (m *fixtureAccountHistoryModel) validAccounts(height int64) []*account.AccountHistory {
+	latest := make(map[int64]*account.AccountHistory)
+	for _, history := range m.c.accountHistories {
+		if history.L2BlockHeight <= height {
+			latest[history.AccountIndex] = history
+		}
+	}
+	var histories []*account.AccountHistory
+	for accountIndex := int64(0); len(histories) < len(latest); accountIndex++ {
+		if history, ok := latest[accountIndex]; ok {
+			histories = append(histories, history)
+		}
+	}
+	return histories
+}
+
+func (m *fixtureAccountHistoryModel) GetValidAccountCount(height int64) (int64, error) {
+	return int64(len(m.validAccounts(height))), nil
+}
+
+func (m *fixtureAccountHistoryModel) GetValidAccounts(height int64, limit int, offset int) (int64, []*account.AccountHistory, error) {
+	histories := m.validAccounts(height)
+	if offset >= len(histories) {
+		return 0, nil, nil
+	}
+	histories = histories[offset:]
+	if limit < len(histories) {
+		histories = histories[:limit]
+	}
+	return int64(len(histories)), histories, nil
+}
+
+func (m *fixtureAccountHistoryModel) GetLatestAccountHistory(accountIndex, height int64) (*account.AccountHistory, error) {
+	var latest *account.AccountHistory
+	for _, history := range m.c.accountHistories {
+		if history.AccountIndex == accountIndex && history.L2BlockHeight < height {
+			latest = history
+		}
+	}
+	if latest == nil {
+		return nil, types.DbErrNotFound
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+type fixtureNftModel struct {
+	nft.L2NftModel
+}
+
+func (m *fixtureNftModel) GetLatestNftIndex() (int64, error) {
+	return -1, nil
+}
+
+type fixtureNftHistoryModel struct {
+	nft.L2NftHistoryModel
+}
+
+func (m *fixtureNftHistoryModel) GetLatestNftsCountByBlockHeight(height int64) (int64, error) {
+	return 0, nil
+}
+
+func (c *fixtureChain) chainDb() *sdb.ChainDB {
+	return &sdb.ChainDB{
+		BlockModel:           &fixtureBlockModel{c: c},
+		CompressedBlockModel: &fixtureCompressedBlockModel{c: c},
+		AccountModel:         &fixtureAccountModel{c: c},
+		AccountHistoryModel:  &fixtureAccountHistoryModel{c: c},
+		L2NftModel:           &fixtureNftModel{},
+		L2NftHistoryModel:    &fixtureNftHistoryModel{},
+	}
+}
+
+func fixtureNameHash(name string) []byte {
+	hash := sha256.Sum256([]byte(name))
+	// keep the hash in the scalar field
+	hash[0] = 0
+	return hash[:]
+}
+
+func registerZnsTx(t *testing.T, accountIndex int64) *tx.Tx {
+	key, err := eddsa.GenerateKey(bytes.NewReader(bytes.Repeat([]byte{byte(accountIndex + 1)}, 32)))
+	assert.NoError(t, err)
+	name := fmt.Sprintf("fixture%d.legend", accountIndex)
+	txInfo, err := json.Marshal(&txtypes.RegisterZnsTxInfo{
+		TxType:          types.TxTypeRegisterZns,
+		AccountIndex:    accountIndex,
+		AccountName:     name,
+		AccountNameHash: fixtureNameHash(name),
+		PubKey:          common.Bytes2Hex(key.PublicKey.Bytes()),
+	})
+	assert.NoError(t, err)
+	return &tx.Tx{TxType: types.TxTypeRegisterZns, TxInfo: string(txInfo)}
+}
+
+func depositTx(t *testing.T, accountIndex, assetId, amount int64) *tx.Tx {
+	txInfo, err := json.Marshal(&txtypes.DepositTxInfo{
+		TxType:          types.TxTypeDeposit,
+		AccountNameHash: fixtureNameHash(fmt.Sprintf("fixture%d.legend", accountIndex)),
+		AssetId:         assetId,
+		AssetAmount:     big.NewInt(amount),
+	})
+	assert.NoError(t, err)
+	return &tx.Tx{TxType: types.TxTypeDeposit, TxInfo: string(txInfo)}
+}
+
+// newFixtureChain commits the blocks of txs on top of the genesis block.
+func newFixtureChain(t *testing.T, blockTxs [][]*tx.Tx) *fixtureChain {
+	c := &fixtureChain{
+		blocks: []*block.Block{{
+			BlockHeight: 0,
+			StateRoot:   common.Bytes2Hex(tree.NilStateRoot),
+			BlockStatus: block.StatusVerifiedAndExecuted,
+		}},
+		compressedBlocks: []*compressedblock.CompressedBlock{{}},
+		accounts:         make(map[int64]*account.Account),
+	}
+	treeCtx, err := tree.NewContext("fixture", tree.MemoryDB, true, 0, nil, nil, nil)
+	assert.NoError(t, err)
+	bc, err := core.NewBlockChainForReplay(c.chainDb(), treeCtx, dbcache.NewMemoryCache(), 16, c.blocks[0])
+	assert.NoError(t, err)
+
+	createdAt := time.UnixMilli(1665000000000)
+	for i, txs := range blockTxs {
+		curBlock, err := bc.InitNewBlock()
+		assert.NoError(t, err)
+		curBlock.CreatedAt = createdAt.Add(time.Duration(i) * time.Minute)
+		for j, newTx := range txs {
+			newTx.TxHash = fmt.Sprintf("fixture-%d-%d", curBlock.BlockHeight, j)
+			assert.NoError(t, bc.ApplyTransaction(newTx))
+		}
+		blockStates, err := bc.CommitNewBlock(8, curBlock.CreatedAt.UnixMilli())
+		assert.NoError(t, err)
+		assert.NoError(t, bc.Statedb.SyncGasAccountToRedis())
+		assert.NoError(t, bc.Statedb.SyncStateCacheToRedis())
+
+		c.blocks = append(c.blocks, blockStates.Block)
+		c.compressedBlocks = append(c.compressedBlocks, blockStates.CompressedBlock)
+		for _, accountInfo := range blockStates.PendingAccount {
+			c.accounts[accountInfo.AccountIndex] = accountInfo
+		}
+		c.accountHistories = append(c.accountHistories, blockStates.PendingAccountHistory...)
+	}
+	return c
+}
+
+func fixtureBlockTxs(t *testing.T) [][]*tx.Tx {
+	return [][]*tx.Tx{
+		{registerZnsTx(t, 0), registerZnsTx(t, 1), registerZnsTx(t, 2)},
+		{registerZnsTx(t, 3), depositTx(t, 2, 0, 100), depositTx(t, 3, 1, 50)},
+		{depositTx(t, 2, 0, 25), depositTx(t, 3, 0, 10), depositTx(t, 2, 1, 5)},
+	}
+}
+
+func TestAuditBlocks(t *testing.T) {
+	c := newFixtureChain(t, fixtureBlockTxs(t))
+
+	divergence, err := auditBlocks(c.chainDb(), 1, 3)
+	assert.NoError(t, err)
+	assert.Nil(t, divergence)
+
+	// the state before the range is rebuilt from the histories
+	divergence, err = auditBlocks(c.chainDb(), 2, 3)
+	assert.NoError(t, err)
+	assert.Nil(t, divergence)
+
+	_, err = auditBlocks(c.chainDb(), 1, 4)
+	assert.Error(t, err)
+}
+
+func TestAuditBlocksDivergence(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tamper   func(c *fixtureChain)
+		expected *Divergence
+	}{
+		{
+			name: "tx detail",
+			tamper: func(c *fixtureChain) {
+				c.blocks[3].Txs[1].TxDetails[0].Balance = types.ConstructAccountAsset(0, big.NewInt(1), types.ZeroBigInt).String()
+			},
+			expected: &Divergence{BlockHeight: 3, TxIndex: 1, TxHash: "fixture-3-1", AccountIndex: 3,
+				Field: "tx detail 0 balance"},
+		},
+		{
+			name: "pubdata",
+			tamper: func(c *fixtureChain) {
+				pubData := common.FromHex(c.compressedBlocks[2].PublicData)
+				pubData[0] ^= 0xff
+				c.compressedBlocks[2].PublicData = common.Bytes2Hex(pubData)
+			},
+			expected: &Divergence{BlockHeight: 2, TxIndex: 0, TxHash: "fixture-2-0", AccountIndex: 3,
+				Field: "pubdata"},
+		},
+		{
+			name: "state root",
+			tamper: func(c *fixtureChain) {
+				c.blocks[3].StateRoot = common.Bytes2Hex(tree.NilStateRoot)
+			},
+			expected: &Divergence{BlockHeight: 3, TxIndex: unknownIndex, AccountIndex: unknownIndex,
+				Field: "state root"},
+		},
+		{
+			name: "account history",
+			tamper: func(c *fixtureChain) {
+				c.blocks[3].StateRoot = common.Bytes2Hex(tree.NilStateRoot)
+				for _, history := range c.accountHistories {
+					if history.AccountIndex == 2 && history.L2BlockHeight == 3 {
+						history.AssetRoot = common.Bytes2Hex(tree.NilAccountAssetRoot)
+					}
+				}
+			},
+			expected: &Divergence{BlockHeight: 3, TxIndex: 0, TxHash: "fixture-3-0", AccountIndex: 2,
+				Field: "asset root"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := newFixtureChain(t, fixtureBlockTxs(t))
+			testCase.tamper(c)
+			divergence, err := auditBlocks(c.chainDb(), 1, 3)
+			assert.NoError(t, err)
+			if assert.NotNil(t, divergence) {
+				assert.Equal(t, testCase.expected.BlockHeight, divergence.BlockHeight)
+				assert.Equal(t, testCase.expected.TxIndex, divergence.TxIndex)
+				assert.Equal(t, testCase.expected.TxHash, divergence.TxHash)
+				assert.Equal(t, testCase.expected.AccountIndex, divergence.AccountIndex)
+				assert.Equal(t, testCase.expected.Field, divergence.Field)
+			}
+		})
+	}
+}
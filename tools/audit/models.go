@@ -0,0 +1,155 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package audit
+
+import (
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// historyAccountModel serves the accounts as they were before the replayed range,
+// the flat account table only keeps the latest state. The accounts updated by
+// the replay are kept in memory.
+type historyAccountModel struct {
+	account.AccountModel
+	accountHistoryModel account.AccountHistoryModel
+
+	// histories below height are visible
+	height   int64
+	accounts map[int64]*account.Account
+}
+
+func newHistoryAccountModel(accountModel account.AccountModel, accountHistoryModel account.AccountHistoryModel,
+	height int64) *historyAccountModel {
+	return &historyAccountModel{
+		AccountModel:        accountModel,
+		accountHistoryModel: accountHistoryModel,
+		height:              height,
+		accounts:            make(map[int64]*account.Account),
+	}
+}
+
+func (m *historyAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	if replayed, ok := m.accounts[accountIndex]; ok {
+		accountInfo := *replayed
+		return &accountInfo, nil
+	}
+	accountInfo, err := m.AccountModel.GetAccountByIndex(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+	accountHistory, err := m.accountHistoryModel.GetLatestAccountHistory(accountIndex, m.height)
+	if err != nil {
+		return nil, err
+	}
+	accountInfo.Nonce = accountHistory.Nonce
+	accountInfo.CollectionNonce = accountHistory.CollectionNonce
+	accountInfo.AssetInfo = accountHistory.AssetInfo
+	accountInfo.AssetRoot = accountHistory.AssetRoot
+	return accountInfo, nil
+}
+
+func (m *historyAccountModel) GetConfirmedAccountByIndex(accountIndex int64) (*account.Account, error) {
+	return m.GetAccountByIndex(accountIndex)
+}
+
+func (m *historyAccountModel) GetAccountByName(name string) (*account.Account, error) {
+	accountInfo, err := m.AccountModel.GetAccountByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetAccountByIndex(accountInfo.AccountIndex)
+}
+
+func (m *historyAccountModel) GetAccountByNameHash(nameHash string) (*account.Account, error) {
+	accountInfo, err := m.AccountModel.GetAccountByNameHash(nameHash)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetAccountByIndex(accountInfo.AccountIndex)
+}
+
+func (m *historyAccountModel) update(accounts []*account.Account) {
+	for _, accountInfo := range accounts {
+		m.accounts[accountInfo.AccountIndex] = accountInfo
+	}
+}
+
+// historyNftModel serves the nfts as they were before the replayed range.
+type historyNftModel struct {
+	nft.L2NftModel
+	nftHistoryModel nft.L2NftHistoryModel
+
+	// histories below height are visible
+	height      int64
+	latestIndex int64
+	nfts        map[int64]*nft.L2Nft
+}
+
+func newHistoryNftModel(nftModel nft.L2NftModel, nftHistoryModel nft.L2NftHistoryModel,
+	height int64) (*historyNftModel, error) {
+	nftCount, err := nftHistoryModel.GetLatestNftsCountByBlockHeight(height - 1)
+	if err != nil {
+		return nil, err
+	}
+	return &historyNftModel{
+		L2NftModel:      nftModel,
+		nftHistoryModel: nftHistoryModel,
+		height:          height,
+		latestIndex:     nftCount - 1,
+		nfts:            make(map[int64]*nft.L2Nft),
+	}, nil
+}
+
+func (m *historyNftModel) GetNft(nftIndex int64) (*nft.L2Nft, error) {
+	if replayed, ok := m.nfts[nftIndex]; ok {
+		nftInfo := *replayed
+		return &nftInfo, nil
+	}
+	if nftIndex > m.latestIndex {
+		return nil, types.DbErrNotFound
+	}
+	nftHistory, err := m.nftHistoryModel.GetLatestNftHistory(nftIndex, m.height)
+	if err != nil {
+		return nil, err
+	}
+	return &nft.L2Nft{
+		NftIndex:            nftHistory.NftIndex,
+		CreatorAccountIndex: nftHistory.CreatorAccountIndex,
+		OwnerAccountIndex:   nftHistory.OwnerAccountIndex,
+		NftContentHash:      nftHistory.NftContentHash,
+		NftL1Address:        nftHistory.NftL1Address,
+		NftL1TokenId:        nftHistory.NftL1TokenId,
+		CreatorTreasuryRate: nftHistory.CreatorTreasuryRate,
+		CollectionId:        nftHistory.CollectionId,
+	}, nil
+}
+
+func (m *historyNftModel) GetLatestNftIndex() (int64, error) {
+	return m.latestIndex, nil
+}
+
+func (m *historyNftModel) update(nfts []*nft.L2Nft) {
+	for _, nftInfo := range nfts {
+		m.nfts[nftInfo.NftIndex] = nftInfo
+		if nftInfo.NftIndex > m.latestIndex {
+			m.latestIndex = nftInfo.NftIndex
+		}
+	}
+}
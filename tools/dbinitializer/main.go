@@ -35,6 +35,7 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/l1rolluptx"
 	"github.com/bnb-chain/zkbnb/dao/l1syncedblock"
 	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/dao/offer"
 	"github.com/bnb-chain/zkbnb/dao/priorityrequest"
 	"github.com/bnb-chain/zkbnb/dao/proof"
 	"github.com/bnb-chain/zkbnb/dao/sysconfig"
@@ -75,6 +76,7 @@ type dao struct {
 	l1RollupTModel       l1rolluptx.L1RollupTxModel
 	nftModel             nft.L2NftModel
 	nftHistoryModel      nft.L2NftHistoryModel
+	offerModel           offer.OfferModel
 }
 
 func Initialize(
@@ -109,6 +111,7 @@ func Initialize(
 		l1RollupTModel:       l1rolluptx.NewL1RollupTxModel(db),
 		nftModel:             nft.NewL2NftModel(db),
 		nftHistoryModel:      nft.NewL2NftHistoryModel(db),
+		offerModel:           offer.NewOfferModel(db),
 	}
 
 	dropTables(dao)
@@ -243,6 +246,7 @@ func dropTables(dao *dao) {
 	assert.Nil(nil, dao.l1RollupTModel.DropL1RollupTxTable())
 	assert.Nil(nil, dao.nftModel.DropL2NftTable())
 	assert.Nil(nil, dao.nftHistoryModel.DropL2NftHistoryTable())
+	assert.Nil(nil, dao.offerModel.DropOfferTable())
 }
 
 func initTable(dao *dao, svrConf *contractAddr, bscTestNetworkRPC, localTestNetworkRPC string) {
@@ -262,6 +266,7 @@ func initTable(dao *dao, svrConf *contractAddr, bscTestNetworkRPC, localTestNetw
 	assert.Nil(nil, dao.l1RollupTModel.CreateL1RollupTxTable())
 	assert.Nil(nil, dao.nftModel.CreateL2NftTable())
 	assert.Nil(nil, dao.nftHistoryModel.CreateL2NftHistoryTable())
+	assert.Nil(nil, dao.offerModel.CreateOfferTable())
 	rowsAffected, err := dao.assetModel.CreateAssets(initAssetsInfo(svrConf.BUSDToken))
 	if err != nil {
 		panic(err)
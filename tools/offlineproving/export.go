@@ -0,0 +1,64 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package offlineproving
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/common/prove"
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+)
+
+// ExportWitness writes the block witnesses between fromHeight and toHeight
+// into outDir, one file per block, so that they can be proved without DB access.
+func ExportWitness(dsn string, fromHeight, toHeight int64, outDir string) error {
+	if fromHeight <= 0 || toHeight < fromHeight {
+		return fmt.Errorf("invalid height range [%d, %d]", fromHeight, toHeight)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	blockWitnessModel := blockwitness.NewBlockWitnessModel(db)
+
+	err = os.MkdirAll(outDir, 0700)
+	if err != nil {
+		return err
+	}
+	for height := fromHeight; height <= toHeight; height++ {
+		witness, err := blockWitnessModel.GetBlockWitnessByHeight(height)
+		if err != nil {
+			return fmt.Errorf("unable to get block witness %d: %v", height, err)
+		}
+		cryptoBlock, err := prove.DecodeBlockWitness(witness)
+		if err != nil {
+			return fmt.Errorf("unable to decode block witness %d: %v", height, err)
+		}
+		err = prove.WriteWitnessFile(outDir, cryptoBlock)
+		if err != nil {
+			return fmt.Errorf("unable to write block witness %d: %v", height, err)
+		}
+		logx.Infof("exported block witness %d", height)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package offlineproving
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/common/prove"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+	"github.com/bnb-chain/zkbnb/dao/proof"
+	"github.com/bnb-chain/zkbnb/service/prover/config"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// ImportProof verifies the proof files in proofDir against the blocks stored in
+// the database and saves them into the proof table, the witnesses of the blocks
+// are marked received along with the proofs. The database and verifying keys are
+// read from the prover config.
+func ImportProof(configFile string, proofDir string) error {
+	var c config.Config
+	conf.MustLoad(configFile, &c)
+
+	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	blockModel := block.NewBlockModel(db)
+	proofModel := proof.NewProofModel(db)
+	witnessModel := blockwitness.NewBlockWitnessModel(db)
+
+	verifyingKeys := make(map[int]groth16.VerifyingKey, len(c.BlockConfig.OptionalBlockSizes))
+	for i, blockSize := range c.BlockConfig.OptionalBlockSizes {
+		verifyingKeys[blockSize], err = prove.LoadVerifyingKey(c.KeyPath.VerifyingKeyPath[i])
+		if err != nil {
+			return fmt.Errorf("unable to load verifying key of block size %d: %v", blockSize, err)
+		}
+	}
+
+	heights, paths, err := prove.ListProofFiles(proofDir)
+	if err != nil {
+		return err
+	}
+	for i, height := range heights {
+		_, err = proofModel.GetProofByBlockHeight(height)
+		if err == nil {
+			// the witness may be left published by the imports before it's marked
+			err = witnessModel.UpdateBlockWitnessStatusByHeightInTransact(db, height, blockwitness.StatusReceived)
+			if err != nil {
+				return fmt.Errorf("unable to update witness of block %d: %v", height, err)
+			}
+			logx.Infof("proof of block %d exists, skip it", height)
+			continue
+		}
+		if err != types.DbErrNotFound {
+			return err
+		}
+
+		proofFile, blockProof, err := prove.ReadProofFile(paths[i])
+		if err != nil {
+			return err
+		}
+		if proofFile.BlockHeight != height {
+			return fmt.Errorf("proof file %s is for block %d", paths[i], proofFile.BlockHeight)
+		}
+		currentBlock, err := blockModel.GetBlockByHeightWithoutTx(height)
+		if err != nil {
+			return fmt.Errorf("unable to get block %d: %v", height, err)
+		}
+		previousBlock, err := blockModel.GetBlockByHeightWithoutTx(height - 1)
+		if err != nil {
+			return fmt.Errorf("unable to get block %d: %v", height-1, err)
+		}
+		if proofFile.BlockSize != int(currentBlock.BlockSize) {
+			return fmt.Errorf("block size of proof %d doesn't match block %d", proofFile.BlockSize, height)
+		}
+		verifyingKey, ok := verifyingKeys[int(currentBlock.BlockSize)]
+		if !ok {
+			return fmt.Errorf("can't find correct vk for block %d, size: %d", height, currentBlock.BlockSize)
+		}
+
+		// The public inputs are taken from the database instead of the proof file.
+		oldStateRoot := common.FromHex(previousBlock.StateRoot)
+		newStateRoot := common.FromHex(currentBlock.StateRoot)
+		commitment := common.FromHex(currentBlock.BlockCommitment)
+		err = prove.VerifyProof(blockProof, verifyingKey, oldStateRoot, newStateRoot, commitment)
+		if err != nil {
+			return fmt.Errorf("invalid proof of block %d: %v", height, err)
+		}
+
+		formattedProof, err := prove.FormatProof(blockProof, oldStateRoot, newStateRoot, commitment)
+		if err != nil {
+			return fmt.Errorf("unable to format proof of block %d: %v", height, err)
+		}
+		proofBytes, err := json.Marshal(formattedProof)
+		if err != nil {
+			return err
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			err := proofModel.CreateProofInTransact(tx, &proof.Proof{
+				ProofInfo:   string(proofBytes),
+				BlockNumber: height,
+				Status:      proof.NotSent,
+			})
+			if err != nil {
+				return err
+			}
+			// the provers don't pick up the witness once its proof is received
+			return witnessModel.UpdateBlockWitnessStatusByHeightInTransact(tx, height, blockwitness.StatusReceived)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to create proof of block %d: %v", height, err)
+		}
+		logx.Infof("imported proof of block %d", height)
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ type Config struct {
 		//nolint:staticcheck
 		LevelDBOption tree.LevelDBOption `json:",optional"`
 		//nolint:staticcheck
+		PebbleDBOption tree.PebbleDBOption `json:",optional"`
+		//nolint:staticcheck
 		RedisDBOption tree.RedisDBOption `json:",optional"`
 		//nolint:staticcheck
 		RoutinePoolSize    int `json:",optional"`
@@ -27,7 +27,7 @@ func RecoveryTreeDB(
 	})
 
 	// dbinitializer tree database
-	treeCtx, err := tree.NewContext(serviceName, c.TreeDB.Driver, true, c.TreeDB.RoutinePoolSize, &c.TreeDB.LevelDBOption, &c.TreeDB.RedisDBOption)
+	treeCtx, err := tree.NewContext(serviceName, c.TreeDB.Driver, true, c.TreeDB.RoutinePoolSize, &c.TreeDB.LevelDBOption, &c.TreeDB.PebbleDBOption, &c.TreeDB.RedisDBOption)
 	if err != nil {
 		logx.Errorf("Init tree database failed: %s", err)
 		return
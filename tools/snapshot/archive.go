@@ -0,0 +1,261 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package snapshot
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+// The archive is a gzip compressed stream of json documents: the header first,
+// then one record per account and nft, and the roots record at last.
+const (
+	ArchiveFormat  = "zkbnb-snapshot"
+	ArchiveVersion = 1
+
+	AccountRecordType = "account"
+	NftRecordType     = "nft"
+	RootsRecordType   = "roots"
+)
+
+type Header struct {
+	Format      string
+	Version     int
+	BlockHeight int64
+	StateRoot   string
+	CreatedAt   int64
+}
+
+type Record struct {
+	Type    string
+	Account *AccountRecord `json:",omitempty"`
+	Nft     *NftRecord     `json:",omitempty"`
+	Roots   *RootsRecord   `json:",omitempty"`
+}
+
+// AccountRecord is the flat account at the snapshot height with its tree leaves.
+type AccountRecord struct {
+	AccountIndex    int64
+	AccountName     string
+	PublicKey       string
+	AccountNameHash string
+	L1Address       string
+	Nonce           int64
+	CollectionNonce int64
+	AssetInfo       string
+	AssetRoot       string
+	Leaf            string
+	AssetLeaves     []AssetLeaf
+}
+
+type AssetLeaf struct {
+	AssetId int64
+	Leaf    string
+}
+
+// NftRecord is the flat nft at the snapshot height with its tree leaf.
+type NftRecord struct {
+	NftIndex            int64
+	CreatorAccountIndex int64
+	OwnerAccountIndex   int64
+	NftContentHash      string
+	NftL1Address        string
+	NftL1TokenId        string
+	CreatorTreasuryRate int64
+	CollectionId        int64
+	Leaf                string
+}
+
+type RootsRecord struct {
+	AccountRoot  string
+	NftRoot      string
+	StateRoot    string
+	AccountCount int64
+	NftCount     int64
+}
+
+func newAccountRecord(accountInfo *account.Account, accountHistory *account.AccountHistory) *AccountRecord {
+	return &AccountRecord{
+		AccountIndex:    accountInfo.AccountIndex,
+		AccountName:     accountInfo.AccountName,
+		PublicKey:       accountInfo.PublicKey,
+		AccountNameHash: accountInfo.AccountNameHash,
+		L1Address:       accountInfo.L1Address,
+		Nonce:           accountHistory.Nonce,
+		CollectionNonce: accountHistory.CollectionNonce,
+		AssetInfo:       accountHistory.AssetInfo,
+		AssetRoot:       accountHistory.AssetRoot,
+	}
+}
+
+func newNftRecord(nftHistory *nft.L2NftHistory) *NftRecord {
+	return &NftRecord{
+		NftIndex:            nftHistory.NftIndex,
+		CreatorAccountIndex: nftHistory.CreatorAccountIndex,
+		OwnerAccountIndex:   nftHistory.OwnerAccountIndex,
+		NftContentHash:      nftHistory.NftContentHash,
+		NftL1Address:        nftHistory.NftL1Address,
+		NftL1TokenId:        nftHistory.NftL1TokenId,
+		CreatorTreasuryRate: nftHistory.CreatorTreasuryRate,
+		CollectionId:        nftHistory.CollectionId,
+	}
+}
+
+// computeAssetLeaves computes the asset leaves of the flat account ordered by asset id.
+func computeAssetLeaves(record *AccountRecord) ([]AssetLeaf, error) {
+	formatAccount, err := chain.ToFormatAccountInfo(&account.Account{
+		AccountIndex: record.AccountIndex,
+		AssetInfo:    record.AssetInfo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([]AssetLeaf, 0, len(formatAccount.AssetInfo))
+	for assetId, asset := range formatAccount.AssetInfo {
+		leaf, err := tree.AssetToNode(asset.Balance.String(), asset.OfferCanceledOrFinalized.String())
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, AssetLeaf{AssetId: assetId, Leaf: common.Bytes2Hex(leaf)})
+	}
+	sort.Slice(leaves, func(i, j int) bool { return leaves[i].AssetId < leaves[j].AssetId })
+	return leaves, nil
+}
+
+func computeAccountLeaf(record *AccountRecord, assetRoot []byte) ([]byte, error) {
+	return tree.AccountToNode(record.AccountNameHash, record.PublicKey,
+		record.Nonce, record.CollectionNonce, assetRoot)
+}
+
+func computeNftLeaf(record *NftRecord) ([]byte, error) {
+	return tree.ComputeNftAssetLeafHash(
+		record.CreatorAccountIndex,
+		record.OwnerAccountIndex,
+		record.NftContentHash,
+		record.NftL1Address,
+		record.NftL1TokenId,
+		record.CreatorTreasuryRate,
+		record.CollectionId,
+	)
+}
+
+type archiveWriter struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+// createArchive writes into a temporary file, which is renamed once the archive is closed.
+func createArchive(path string) (*archiveWriter, error) {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	return &archiveWriter{
+		path: path,
+		file: file,
+		gz:   gz,
+		enc:  json.NewEncoder(gz),
+	}, nil
+}
+
+func (w *archiveWriter) write(v interface{}) error {
+	return w.enc.Encode(v)
+}
+
+func (w *archiveWriter) close() error {
+	err := w.gz.Close()
+	if err != nil {
+		return err
+	}
+	err = w.file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(w.file.Name(), w.path)
+}
+
+func (w *archiveWriter) abort() {
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+}
+
+type archiveReader struct {
+	file *os.File
+	gz   *gzip.Reader
+	dec  *json.Decoder
+}
+
+func openArchive(path string) (*archiveReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &archiveReader{
+		file: file,
+		gz:   gz,
+		dec:  json.NewDecoder(gz),
+	}, nil
+}
+
+func (r *archiveReader) readHeader() (*Header, error) {
+	var header Header
+	err := r.dec.Decode(&header)
+	if err != nil {
+		return nil, err
+	}
+	if header.Format != ArchiveFormat {
+		return nil, fmt.Errorf("unknown snapshot format %q", header.Format)
+	}
+	if header.Version != ArchiveVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+	return &header, nil
+}
+
+// read returns io.EOF once all the records are read.
+func (r *archiveReader) read() (*Record, error) {
+	var record Record
+	err := r.dec.Decode(&record)
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *archiveReader) close() {
+	_ = r.gz.Close()
+	_ = r.file.Close()
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package snapshot
+
+import (
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+type Config struct {
+	Postgres struct {
+		DataSource string
+	}
+	TreeDB struct {
+		Driver tree.Driver
+		//nolint:staticcheck
+		LevelDBOption tree.LevelDBOption `json:",optional"`
+		//nolint:staticcheck
+		PebbleDBOption tree.PebbleDBOption `json:",optional"`
+		//nolint:staticcheck
+		RedisDBOption tree.RedisDBOption `json:",optional"`
+		//nolint:staticcheck
+		RoutinePoolSize int `json:",optional"`
+	}
+	LogConf logx.LogConf
+}
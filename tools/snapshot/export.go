@@ -0,0 +1,244 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package snapshot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+const (
+	defaultBatchSize = 1000
+)
+
+type exporter struct {
+	accountModel        account.AccountModel
+	accountHistoryModel account.AccountHistoryModel
+	nftHistoryModel     nft.L2NftHistoryModel
+
+	height      int64
+	batchSize   int
+	accountTree bsmt.SparseMerkleTree
+	nftTree     bsmt.SparseMerkleTree
+	archive     *archiveWriter
+}
+
+// ExportSnapshot writes the state at the given height into the output archive.
+// The leaves are recomputed from the histories on memory trees, and the archive
+// is only kept when the rebuilt state root matches the stored block.
+func ExportSnapshot(dsn string, height int64, output string, batchSize int) error {
+	if height <= 0 {
+		return fmt.Errorf("invalid height %d", height)
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	e := &exporter{
+		accountModel:        account.NewAccountModel(db),
+		accountHistoryModel: account.NewAccountHistoryModel(db),
+		nftHistoryModel:     nft.NewL2NftHistoryModel(db),
+		height:              height,
+		batchSize:           batchSize,
+	}
+	return e.exportSnapshot(block.NewBlockModel(db), output)
+}
+
+func (e *exporter) exportSnapshot(blockModel block.BlockModel, output string) error {
+	blockInfo, err := blockModel.GetBlockByHeightWithoutTx(e.height)
+	if err != nil {
+		return fmt.Errorf("unable to get block %d: %v", e.height, err)
+	}
+
+	treeCtx, err := tree.NewContext("snapshot", tree.MemoryDB, true, 0, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	e.accountTree, err = tree.NewAccountTree(treeCtx, e.height)
+	if err != nil {
+		return err
+	}
+	e.nftTree, err = tree.NewNftTree(treeCtx, e.height)
+	if err != nil {
+		return err
+	}
+
+	archive, err := createArchive(output)
+	if err != nil {
+		return err
+	}
+	e.archive = archive
+	err = e.export(blockInfo)
+	if err != nil {
+		archive.abort()
+		return err
+	}
+	err = archive.close()
+	if err != nil {
+		archive.abort()
+		return err
+	}
+	logx.Infof("exported snapshot of block %d to %s, state root: %s", e.height, output, blockInfo.StateRoot)
+	return nil
+}
+
+func (e *exporter) export(blockInfo *block.Block) error {
+	err := e.archive.write(&Header{
+		Format:      ArchiveFormat,
+		Version:     ArchiveVersion,
+		BlockHeight: blockInfo.BlockHeight,
+		StateRoot:   blockInfo.StateRoot,
+		CreatedAt:   time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+	accountCount, err := e.exportAccounts()
+	if err != nil {
+		return err
+	}
+	nftCount, err := e.exportNfts()
+	if err != nil {
+		return err
+	}
+
+	stateRoot := common.Bytes2Hex(tree.ComputeStateRootHash(e.accountTree.Root(), e.nftTree.Root()))
+	if stateRoot != blockInfo.StateRoot {
+		return fmt.Errorf("state root of block %d is not rebuilt from histories, stored %s, rebuilt %s",
+			blockInfo.BlockHeight, blockInfo.StateRoot, stateRoot)
+	}
+	return e.archive.write(&Record{
+		Type: RootsRecordType,
+		Roots: &RootsRecord{
+			AccountRoot:  common.Bytes2Hex(e.accountTree.Root()),
+			NftRoot:      common.Bytes2Hex(e.nftTree.Root()),
+			StateRoot:    stateRoot,
+			AccountCount: accountCount,
+			NftCount:     nftCount,
+		},
+	})
+}
+
+func (e *exporter) exportAccounts() (int64, error) {
+	count := int64(0)
+	for offset := 0; ; offset += e.batchSize {
+		_, accountHistories, err := e.accountHistoryModel.GetValidAccounts(e.height, e.batchSize, offset)
+		if err != nil {
+			return 0, fmt.Errorf("unable to get accounts: %v", err)
+		}
+		for _, accountHistory := range accountHistories {
+			accountInfo, err := e.accountModel.GetAccountByIndex(accountHistory.AccountIndex)
+			if err != nil {
+				return 0, fmt.Errorf("unable to get account %d: %v", accountHistory.AccountIndex, err)
+			}
+			record := newAccountRecord(accountInfo, accountHistory)
+			err = e.fillAccountLeaves(record)
+			if err != nil {
+				return 0, fmt.Errorf("unable to compute leaves of account %d: %v", record.AccountIndex, err)
+			}
+			err = e.archive.write(&Record{Type: AccountRecordType, Account: record})
+			if err != nil {
+				return 0, err
+			}
+			count++
+		}
+		if len(accountHistories) < e.batchSize {
+			break
+		}
+		logx.Infof("exported %d accounts", count)
+	}
+	return count, nil
+}
+
+func (e *exporter) fillAccountLeaves(record *AccountRecord) error {
+	assetLeaves, err := computeAssetLeaves(record)
+	if err != nil {
+		return err
+	}
+	assetTree, err := tree.NewMemAccountAssetTree()
+	if err != nil {
+		return err
+	}
+	for _, assetLeaf := range assetLeaves {
+		err = assetTree.Set(uint64(assetLeaf.AssetId), common.FromHex(assetLeaf.Leaf))
+		if err != nil {
+			return err
+		}
+	}
+	assetRoot := common.Bytes2Hex(assetTree.Root())
+	if assetRoot != record.AssetRoot {
+		return fmt.Errorf("asset root mismatch, stored %s, rebuilt %s", record.AssetRoot, assetRoot)
+	}
+	leaf, err := computeAccountLeaf(record, assetTree.Root())
+	if err != nil {
+		return err
+	}
+	err = e.accountTree.Set(uint64(record.AccountIndex), leaf)
+	if err != nil {
+		return err
+	}
+	record.AssetLeaves = assetLeaves
+	record.Leaf = common.Bytes2Hex(leaf)
+	return nil
+}
+
+func (e *exporter) exportNfts() (int64, error) {
+	count := int64(0)
+	for offset := 0; ; offset += e.batchSize {
+		_, nftHistories, err := e.nftHistoryModel.GetLatestNftsByBlockHeight(e.height, e.batchSize, offset)
+		if err != nil {
+			return 0, fmt.Errorf("unable to get nfts: %v", err)
+		}
+		for _, nftHistory := range nftHistories {
+			record := newNftRecord(nftHistory)
+			leaf, err := computeNftLeaf(record)
+			if err != nil {
+				return 0, fmt.Errorf("unable to compute leaf of nft %d: %v", record.NftIndex, err)
+			}
+			err = e.nftTree.Set(uint64(record.NftIndex), leaf)
+			if err != nil {
+				return 0, err
+			}
+			record.Leaf = common.Bytes2Hex(leaf)
+			err = e.archive.write(&Record{Type: NftRecordType, Nft: record})
+			if err != nil {
+				return 0, err
+			}
+			count++
+		}
+		if len(nftHistories) < e.batchSize {
+			break
+		}
+		logx.Infof("exported %d nfts", count)
+	}
+	return count, nil
+}
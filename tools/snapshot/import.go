@@ -0,0 +1,468 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/proc"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type importer struct {
+	treeCtx     *tree.Context // nil when the archive is only verified on memory trees
+	height      int64
+	accountTree bsmt.SparseMerkleTree
+	nftTree     bsmt.SparseMerkleTree
+
+	accountCount int64
+	nftCount     int64
+	// asset trees committed so far, they are rolled back if the import fails
+	assetTreeIndexes []int64
+
+	// the flat tables are only restored when tables is set
+	tables   *chainTables
+	tx       *gorm.DB
+	accounts []*account.Account
+	nfts     []*nft.L2Nft
+}
+
+type chainTables struct {
+	blockModel   block.BlockModel
+	accountModel account.AccountModel
+	nftModel     nft.L2NftModel
+	transaction  func(fc func(tx *gorm.DB) error) error
+}
+
+func newChainTables(db *gorm.DB) *chainTables {
+	return &chainTables{
+		blockModel:   block.NewBlockModel(db),
+		accountModel: account.NewAccountModel(db),
+		nftModel:     nft.NewL2NftModel(db),
+		transaction: func(fc func(tx *gorm.DB) error) error {
+			return db.Transaction(fc)
+		},
+	}
+}
+
+// ImportSnapshot rebuilds the trees of the service from the archive. Every leaf is
+// recomputed from the flat state in the archive and the whole archive is verified
+// against the stored block on memory trees first, so nothing is written for a
+// corrupted archive. When the snapshot block is the head of the chain, the flat
+// account and nft tables are restored from the archive as well.
+func ImportSnapshot(configFile string, serviceName string, input string) error {
+	var c Config
+	conf.MustLoad(configFile, &c)
+	logx.MustSetup(c.LogConf)
+	logx.DisableStat()
+	proc.AddShutdownListener(func() {
+		logx.Close()
+	})
+
+	if c.TreeDB.Driver == tree.MemoryDB {
+		return errors.New("snapshot can not be imported into memory tree database")
+	}
+	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	treeCtx, err := tree.NewContext(serviceName, c.TreeDB.Driver, true, c.TreeDB.RoutinePoolSize, &c.TreeDB.LevelDBOption, &c.TreeDB.PebbleDBOption, &c.TreeDB.RedisDBOption)
+	if err != nil {
+		return err
+	}
+	err = tree.SetupTreeDB(treeCtx)
+	if err != nil {
+		return err
+	}
+	defer treeCtx.TreeDB.Close()
+	return importSnapshot(newChainTables(db), treeCtx, input)
+}
+
+// importSnapshot writes the archive into the opened tree database of the context.
+func importSnapshot(tables *chainTables, treeCtx *tree.Context, input string) error {
+	header, blockInfo, err := verifySnapshot(tables.blockModel, input)
+	if err != nil {
+		return err
+	}
+	currentHeight, err := tables.blockModel.GetCurrentBlockHeight()
+	if err != nil {
+		return fmt.Errorf("unable to get current block height: %v", err)
+	}
+	restoreTables := currentHeight == header.BlockHeight
+	if !restoreTables {
+		logx.Infof("chain is at block %d, the flat tables are newer than the snapshot and kept", currentHeight)
+	}
+
+	treeCtx.SetOptions(bsmt.InitializeVersion(bsmt.Version(header.BlockHeight) - 1))
+	accountTree, err := tree.NewAccountTree(treeCtx, header.BlockHeight)
+	if err != nil {
+		return err
+	}
+	nftTree, err := tree.NewNftTree(treeCtx, header.BlockHeight)
+	if err != nil {
+		return err
+	}
+	if !accountTree.IsEmpty() || !nftTree.IsEmpty() {
+		return fmt.Errorf("tree database of %s is not empty", treeCtx.Name)
+	}
+
+	archive, err := openArchive(input)
+	if err != nil {
+		return err
+	}
+	defer archive.close()
+	_, err = archive.readHeader()
+	if err != nil {
+		return fmt.Errorf("unable to read snapshot header: %v", err)
+	}
+	i := &importer{
+		treeCtx:     treeCtx,
+		height:      header.BlockHeight,
+		accountTree: accountTree,
+		nftTree:     nftTree,
+	}
+	if restoreTables {
+		i.tables = tables
+	}
+	err = tables.transaction(func(tx *gorm.DB) error {
+		i.tx = tx
+		roots, err := i.importRecords(archive)
+		if err != nil {
+			return err
+		}
+		err = i.flush()
+		if err != nil {
+			return err
+		}
+		return i.verify(roots, blockInfo)
+	})
+	if err != nil {
+		i.rollback()
+		return err
+	}
+
+	_, err = accountTree.Commit(nil)
+	if err != nil {
+		i.rollback()
+		return fmt.Errorf("unable to commit account tree: %v", err)
+	}
+	_, err = nftTree.Commit(nil)
+	if err != nil {
+		i.rollback()
+		return fmt.Errorf("unable to commit nft tree: %v", err)
+	}
+	logx.Infof("imported snapshot of block %d into %s, state root: %s", header.BlockHeight, treeCtx.Name, blockInfo.StateRoot)
+	return nil
+}
+
+// verifySnapshot rebuilds the archive on memory trees and checks it against the stored block.
+func verifySnapshot(blockModel block.BlockModel, input string) (*Header, *block.Block, error) {
+	archive, err := openArchive(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer archive.close()
+	header, err := archive.readHeader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read snapshot header: %v", err)
+	}
+	blockInfo, err := blockModel.GetBlockByHeightWithoutTx(header.BlockHeight)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get block %d: %v", header.BlockHeight, err)
+	}
+	if blockInfo.StateRoot != header.StateRoot {
+		return nil, nil, fmt.Errorf("snapshot is not taken from this chain, state root of block %d is %s, snapshot %s",
+			header.BlockHeight, blockInfo.StateRoot, header.StateRoot)
+	}
+
+	memCtx, err := tree.NewContext("snapshot", tree.MemoryDB, true, 0, nil, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	accountTree, err := tree.NewAccountTree(memCtx, header.BlockHeight)
+	if err != nil {
+		return nil, nil, err
+	}
+	nftTree, err := tree.NewNftTree(memCtx, header.BlockHeight)
+	if err != nil {
+		return nil, nil, err
+	}
+	i := &importer{
+		height:      header.BlockHeight,
+		accountTree: accountTree,
+		nftTree:     nftTree,
+	}
+	roots, err := i.importRecords(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = i.verify(roots, blockInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, blockInfo, nil
+}
+
+// rollback removes the versions written by the import, so that it can be retried.
+func (i *importer) rollback() {
+	version := bsmt.Version(i.height) - 1
+	for _, accountIndex := range i.assetTreeIndexes {
+		assetTree, err := tree.NewAccountAssetTree(i.treeCtx, accountIndex, i.height)
+		if err == nil {
+			err = assetTree.Rollback(version)
+		}
+		if err != nil && err != bsmt.ErrEmptyRoot {
+			logx.Errorf("unable to rollback asset tree of account %d: %v", accountIndex, err)
+		}
+	}
+	for name, smt := range map[string]bsmt.SparseMerkleTree{"account": i.accountTree, "nft": i.nftTree} {
+		if smt.LatestVersion() <= version {
+			continue
+		}
+		err := smt.Rollback(version)
+		if err != nil && err != bsmt.ErrEmptyRoot {
+			logx.Errorf("unable to rollback %s tree: %v", name, err)
+		}
+	}
+}
+
+func (i *importer) importRecords(archive *archiveReader) (*RootsRecord, error) {
+	var roots *RootsRecord
+	for {
+		record, err := archive.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read snapshot record: %v", err)
+		}
+		if roots != nil {
+			return nil, errors.New("unexpected record after snapshot roots")
+		}
+		switch record.Type {
+		case AccountRecordType:
+			err = i.importAccount(record.Account)
+			if err != nil {
+				return nil, fmt.Errorf("unable to import account %d: %v", record.Account.AccountIndex, err)
+			}
+			i.accountCount++
+			if i.accountCount%defaultBatchSize == 0 {
+				logx.Infof("imported %d accounts", i.accountCount)
+			}
+		case NftRecordType:
+			err = i.importNft(record.Nft)
+			if err != nil {
+				return nil, fmt.Errorf("unable to import nft %d: %v", record.Nft.NftIndex, err)
+			}
+			i.nftCount++
+		case RootsRecordType:
+			roots = record.Roots
+		default:
+			return nil, fmt.Errorf("unknown snapshot record type %q", record.Type)
+		}
+	}
+	if roots == nil {
+		return nil, errors.New("snapshot is truncated, roots are missing")
+	}
+	return roots, nil
+}
+
+func (i *importer) importAccount(record *AccountRecord) error {
+	if record == nil {
+		return errors.New("empty account record")
+	}
+	assetLeaves, err := computeAssetLeaves(record)
+	if err != nil {
+		return err
+	}
+	if len(assetLeaves) != len(record.AssetLeaves) {
+		return fmt.Errorf("asset leaves mismatch, archived %d, computed %d", len(record.AssetLeaves), len(assetLeaves))
+	}
+	assetTree, err := i.newAssetTree(record.AccountIndex)
+	if err != nil {
+		return err
+	}
+	for j, assetLeaf := range assetLeaves {
+		if assetLeaf != record.AssetLeaves[j] {
+			return fmt.Errorf("leaf of asset %d mismatch, archived %s, computed %s",
+				assetLeaf.AssetId, record.AssetLeaves[j].Leaf, assetLeaf.Leaf)
+		}
+		err = assetTree.Set(uint64(assetLeaf.AssetId), common.FromHex(assetLeaf.Leaf))
+		if err != nil {
+			return err
+		}
+	}
+	assetRoot := common.Bytes2Hex(assetTree.Root())
+	if assetRoot != record.AssetRoot {
+		return fmt.Errorf("asset root mismatch, archived %s, rebuilt %s", record.AssetRoot, assetRoot)
+	}
+	leaf, err := computeAccountLeaf(record, assetTree.Root())
+	if err != nil {
+		return err
+	}
+	if common.Bytes2Hex(leaf) != record.Leaf {
+		return fmt.Errorf("account leaf mismatch, archived %s, computed %s", record.Leaf, common.Bytes2Hex(leaf))
+	}
+	err = i.accountTree.Set(uint64(record.AccountIndex), leaf)
+	if err != nil {
+		return err
+	}
+	if i.treeCtx == nil {
+		return nil
+	}
+
+	_, err = assetTree.Commit(nil)
+	if err != nil {
+		return err
+	}
+	i.assetTreeIndexes = append(i.assetTreeIndexes, record.AccountIndex)
+	if i.tables == nil {
+		return nil
+	}
+	accountInfo, err := i.tables.accountModel.GetAccountByIndex(record.AccountIndex)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	if err == types.DbErrNotFound {
+		accountInfo = &account.Account{AccountIndex: record.AccountIndex}
+	}
+	accountInfo.AccountName = record.AccountName
+	accountInfo.PublicKey = record.PublicKey
+	accountInfo.AccountNameHash = record.AccountNameHash
+	accountInfo.L1Address = record.L1Address
+	accountInfo.Nonce = record.Nonce
+	accountInfo.CollectionNonce = record.CollectionNonce
+	accountInfo.AssetInfo = record.AssetInfo
+	accountInfo.AssetRoot = record.AssetRoot
+	accountInfo.Status = account.AccountStatusConfirmed
+	i.accounts = append(i.accounts, accountInfo)
+	if len(i.accounts) >= defaultBatchSize {
+		return i.flush()
+	}
+	return nil
+}
+
+func (i *importer) newAssetTree(accountIndex int64) (bsmt.SparseMerkleTree, error) {
+	if i.treeCtx == nil {
+		return tree.NewMemAccountAssetTree()
+	}
+	assetTree, err := tree.NewAccountAssetTree(i.treeCtx, accountIndex, i.height)
+	if err != nil {
+		return nil, err
+	}
+	if !assetTree.IsEmpty() {
+		return nil, errors.New("asset tree is not empty")
+	}
+	return assetTree, nil
+}
+
+func (i *importer) importNft(record *NftRecord) error {
+	if record == nil {
+		return errors.New("empty nft record")
+	}
+	leaf, err := computeNftLeaf(record)
+	if err != nil {
+		return err
+	}
+	if common.Bytes2Hex(leaf) != record.Leaf {
+		return fmt.Errorf("nft leaf mismatch, archived %s, computed %s", record.Leaf, common.Bytes2Hex(leaf))
+	}
+	err = i.nftTree.Set(uint64(record.NftIndex), leaf)
+	if err != nil {
+		return err
+	}
+	if i.tables == nil {
+		return nil
+	}
+
+	nftInfo, err := i.tables.nftModel.GetNft(record.NftIndex)
+	if err != nil && err != types.DbErrNotFound {
+		return err
+	}
+	if err == types.DbErrNotFound {
+		nftInfo = &nft.L2Nft{NftIndex: record.NftIndex}
+	}
+	nftInfo.CreatorAccountIndex = record.CreatorAccountIndex
+	nftInfo.OwnerAccountIndex = record.OwnerAccountIndex
+	nftInfo.NftContentHash = record.NftContentHash
+	nftInfo.NftL1Address = record.NftL1Address
+	nftInfo.NftL1TokenId = record.NftL1TokenId
+	nftInfo.CreatorTreasuryRate = record.CreatorTreasuryRate
+	nftInfo.CollectionId = record.CollectionId
+	i.nfts = append(i.nfts, nftInfo)
+	if len(i.nfts) >= defaultBatchSize {
+		return i.flush()
+	}
+	return nil
+}
+
+// flush writes the pending flat rows in the import transaction.
+func (i *importer) flush() error {
+	if i.tables == nil {
+		return nil
+	}
+	if len(i.accounts) > 0 {
+		err := i.tables.accountModel.UpdateAccountsInTransact(i.tx, i.accounts)
+		if err != nil {
+			return fmt.Errorf("unable to restore accounts: %v", err)
+		}
+		i.accounts = i.accounts[:0]
+	}
+	if len(i.nfts) > 0 {
+		err := i.tables.nftModel.UpdateNftsInTransact(i.tx, i.nfts)
+		if err != nil {
+			return fmt.Errorf("unable to restore nfts: %v", err)
+		}
+		i.nfts = i.nfts[:0]
+	}
+	return nil
+}
+
+func (i *importer) verify(roots *RootsRecord, blockInfo *block.Block) error {
+	if roots.AccountCount != i.accountCount || roots.NftCount != i.nftCount {
+		return fmt.Errorf("snapshot is truncated, archived %d accounts and %d nfts, imported %d accounts and %d nfts",
+			roots.AccountCount, roots.NftCount, i.accountCount, i.nftCount)
+	}
+	accountRoot := common.Bytes2Hex(i.accountTree.Root())
+	if accountRoot != roots.AccountRoot {
+		return fmt.Errorf("account root mismatch, archived %s, rebuilt %s", roots.AccountRoot, accountRoot)
+	}
+	nftRoot := common.Bytes2Hex(i.nftTree.Root())
+	if nftRoot != roots.NftRoot {
+		return fmt.Errorf("nft root mismatch, archived %s, rebuilt %s", roots.NftRoot, nftRoot)
+	}
+	stateRoot := common.Bytes2Hex(tree.ComputeStateRootHash(i.accountTree.Root(), i.nftTree.Root()))
+	if stateRoot != blockInfo.StateRoot {
+		return fmt.Errorf("state root of block %d mismatch, stored %s, rebuilt %s",
+			blockInfo.BlockHeight, blockInfo.StateRoot, stateRoot)
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// fixtureChain keeps the histories of a small chain in memory, the state roots
+// of the blocks are computed from the histories with the tree helpers.
+type fixtureChain struct {
+	height           int64
+	blocks           map[int64]*block.Block
+	accounts         map[int64]*account.Account
+	accountHistories []*account.AccountHistory
+	nfts             map[int64]*nft.L2Nft
+	nftHistories     []*nft.L2NftHistory
+
+	updateNftsErr error
+}
+
+type fixtureBlockModel struct {
+	block.BlockModel
+	c *fixtureChain
+}
+
+func (m *fixtureBlockModel) GetBlockByHeightWithoutTx(blockHeight int64) (*block.Block, error) {
+	blockInfo, ok := m.c.blocks[blockHeight]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	return blockInfo, nil
+}
+
+func (m *fixtureBlockModel) GetCurrentBlockHeight() (int64, error) {
+	return m.c.height, nil
+}
+
+type fixtureAccountModel struct {
+	account.AccountModel
+	c *fixtureChain
+}
+
+func (m *fixtureAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	accountInfo, ok := m.c.accounts[accountIndex]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	copied := *accountInfo
+	return &copied, nil
+}
+
+func (m *fixtureAccountModel) UpdateAccountsInTransact(_ *gorm.DB, accounts []*account.Account) error {
+	for _, accountInfo := range accounts {
+		copied := *accountInfo
+		m.c.accounts[accountInfo.AccountIndex] = &copied
+	}
+	return nil
+}
+
+type fixtureAccountHistoryModel struct {
+	account.AccountHistoryModel
+	c *fixtureChain
+}
+
+func (m *fixtureAccountHistoryModel) GetValidAccounts(height int64, limit int, offset int) (int64, []*account.AccountHistory, error) {
+	latest := m.c.latestAccounts(height)
+	if offset >= len(latest) {
+		return 0, nil, nil
+	}
+	end := offset + limit
+	if end > len(latest) {
+		end = len(latest)
+	}
+	return int64(end - offset), latest[offset:end], nil
+}
+
+type fixtureNftModel struct {
+	nft.L2NftModel
+	c *fixtureChain
+}
+
+func (m *fixtureNftModel) GetNft(nftIndex int64) (*nft.L2Nft, error) {
+	nftInfo, ok := m.c.nfts[nftIndex]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	copied := *nftInfo
+	return &copied, nil
+}
+
+func (m *fixtureNftModel) UpdateNftsInTransact(_ *gorm.DB, nfts []*nft.L2Nft) error {
+	if m.c.updateNftsErr != nil {
+		return m.c.updateNftsErr
+	}
+	for _, nftInfo := range nfts {
+		copied := *nftInfo
+		m.c.nfts[nftInfo.NftIndex] = &copied
+	}
+	return nil
+}
+
+type fixtureNftHistoryModel struct {
+	nft.L2NftHistoryModel
+	c *fixtureChain
+}
+
+func (m *fixtureNftHistoryModel) GetLatestNftsByBlockHeight(height int64, limit int, offset int) (int64, []*nft.L2NftHistory, error) {
+	latest := m.c.latestNfts(height)
+	if offset >= len(latest) {
+		return 0, nil, nil
+	}
+	end := offset + limit
+	if end > len(latest) {
+		end = len(latest)
+	}
+	return int64(end - offset), latest[offset:end], nil
+}
+
+func (c *fixtureChain) latestAccounts(height int64) []*account.AccountHistory {
+	latest := make(map[int64]*account.AccountHistory)
+	for _, history := range c.accountHistories {
+		if history.L2BlockHeight <= height {
+			latest[history.AccountIndex] = history
+		}
+	}
+	var histories []*account.AccountHistory
+	for accountIndex := int64(0); len(histories) < len(latest); accountIndex++ {
+		if history, ok := latest[accountIndex]; ok {
+			histories = append(histories, history)
+		}
+	}
+	return histories
+}
+
+func (c *fixtureChain) latestNfts(height int64) []*nft.L2NftHistory {
+	latest := make(map[int64]*nft.L2NftHistory)
+	for _, history := range c.nftHistories {
+		if history.L2BlockHeight <= height {
+			latest[history.NftIndex] = history
+		}
+	}
+	var histories []*nft.L2NftHistory
+	for nftIndex := int64(0); len(histories) < len(latest); nftIndex++ {
+		if history, ok := latest[nftIndex]; ok {
+			histories = append(histories, history)
+		}
+	}
+	return histories
+}
+
+func (c *fixtureChain) addAccount(t *testing.T, height, accountIndex, nonce int64, balances ...int64) {
+	assetInfo := "{"
+	assetTree, err := tree.NewMemAccountAssetTree()
+	assert.NoError(t, err)
+	for assetId, balance := range balances {
+		if assetId > 0 {
+			assetInfo += ","
+		}
+		assetInfo += fmt.Sprintf(`"%d":{"AssetId":%d,"Balance":%d,"OfferCanceledOrFinalized":0}`, assetId, assetId, balance)
+		leaf, err := tree.AssetToNode(fmt.Sprint(balance), "0")
+		assert.NoError(t, err)
+		assert.NoError(t, assetTree.Set(uint64(assetId), leaf))
+	}
+	assetInfo += "}"
+
+	c.accountHistories = append(c.accountHistories, &account.AccountHistory{
+		AccountIndex:  accountIndex,
+		Nonce:         nonce,
+		AssetInfo:     assetInfo,
+		AssetRoot:     common.Bytes2Hex(assetTree.Root()),
+		L2BlockHeight: height,
+	})
+	c.accounts[accountIndex] = &account.Account{
+		Model:           gorm.Model{ID: uint(accountIndex + 1)},
+		AccountIndex:    accountIndex,
+		AccountName:     fmt.Sprintf("account%d.legend", accountIndex),
+		PublicKey:       fmt.Sprintf("%064x", accountIndex+1),
+		AccountNameHash: fmt.Sprintf("%064x", accountIndex+100),
+		L1Address:       fmt.Sprintf("0x%040x", accountIndex+1),
+		Nonce:           nonce,
+		AssetInfo:       assetInfo,
+		AssetRoot:       common.Bytes2Hex(assetTree.Root()),
+		Status:          account.AccountStatusConfirmed,
+	}
+}
+
+func (c *fixtureChain) addNft(height, nftIndex, ownerAccountIndex int64) {
+	history := &nft.L2NftHistory{
+		NftIndex:            nftIndex,
+		CreatorAccountIndex: 1,
+		OwnerAccountIndex:   ownerAccountIndex,
+		NftContentHash:      fmt.Sprintf("%064x", nftIndex+1),
+		NftL1Address:        common.Address{}.Hex(),
+		NftL1TokenId:        "0",
+		CreatorTreasuryRate: 10,
+		L2BlockHeight:       height,
+	}
+	c.nftHistories = append(c.nftHistories, history)
+	c.nfts[nftIndex] = &nft.L2Nft{
+		Model:               gorm.Model{ID: uint(nftIndex + 1)},
+		NftIndex:            nftIndex,
+		CreatorAccountIndex: history.CreatorAccountIndex,
+		OwnerAccountIndex:   history.OwnerAccountIndex,
+		NftContentHash:      history.NftContentHash,
+		NftL1Address:        history.NftL1Address,
+		NftL1TokenId:        history.NftL1TokenId,
+		CreatorTreasuryRate: history.CreatorTreasuryRate,
+	}
+}
+
+// sealBlock stores the block at the height with the state root of the histories.
+func (c *fixtureChain) sealBlock(t *testing.T, height int64) {
+	treeCtx, err := tree.NewContext("fixture", tree.MemoryDB, true, 0, nil, nil, nil)
+	assert.NoError(t, err)
+	accountTree, err := tree.NewAccountTree(treeCtx, height)
+	assert.NoError(t, err)
+	for _, history := range c.latestAccounts(height) {
+		accountInfo := c.accounts[history.AccountIndex]
+		leaf, err := tree.AccountToNode(accountInfo.AccountNameHash, accountInfo.PublicKey,
+			history.Nonce, history.CollectionNonce, common.FromHex(history.AssetRoot))
+		assert.NoError(t, err)
+		assert.NoError(t, accountTree.Set(uint64(history.AccountIndex), leaf))
+	}
+	nftTree, err := tree.NewNftTree(treeCtx, height)
+	assert.NoError(t, err)
+	for _, history := range c.latestNfts(height) {
+		leaf, err := tree.ComputeNftAssetLeafHash(history.CreatorAccountIndex, history.OwnerAccountIndex,
+			history.NftContentHash, history.NftL1Address, history.NftL1TokenId, history.CreatorTreasuryRate, history.CollectionId)
+		assert.NoError(t, err)
+		assert.NoError(t, nftTree.Set(uint64(history.NftIndex), leaf))
+	}
+	c.blocks[height] = &block.Block{
+		BlockHeight: height,
+		StateRoot:   common.Bytes2Hex(tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root())),
+	}
+	c.height = height
+}
+
+// newFixtureChain builds a chain of 3 blocks, the snapshots are taken at block 2,
+// where account 1 and nft 1 are changed and account 3 is registered afterwards.
+func newFixtureChain(t *testing.T) *fixtureChain {
+	c := &fixtureChain{
+		blocks:   make(map[int64]*block.Block),
+		accounts: make(map[int64]*account.Account),
+		nfts:     make(map[int64]*nft.L2Nft),
+	}
+	c.addAccount(t, 1, 0, 0, 0, 0)
+	c.addAccount(t, 1, 1, 0, 100)
+	c.addAccount(t, 1, 2, 0, 500, 20)
+	c.sealBlock(t, 1)
+	c.addAccount(t, 2, 1, 1, 80, 5)
+	c.addNft(2, 0, 2)
+	c.addNft(2, 1, 1)
+	c.sealBlock(t, 2)
+	c.addAccount(t, 3, 1, 2, 60, 5)
+	c.addAccount(t, 3, 3, 0, 10)
+	c.addNft(3, 1, 2)
+	c.sealBlock(t, 3)
+	return c
+}
+
+func (c *fixtureChain) exportSnapshot(height int64, output string) error {
+	e := &exporter{
+		accountModel:        &fixtureAccountModel{c: c},
+		accountHistoryModel: &fixtureAccountHistoryModel{c: c},
+		nftHistoryModel:     &fixtureNftHistoryModel{c: c},
+		height:              height,
+		batchSize:           2,
+	}
+	return e.exportSnapshot(&fixtureBlockModel{c: c}, output)
+}
+
+// newNode returns a node bootstrapped from the blocks of the chain up to the height,
+// with empty flat tables.
+func (c *fixtureChain) newNode(height int64) *fixtureChain {
+	node := &fixtureChain{
+		height:   height,
+		blocks:   make(map[int64]*block.Block),
+		accounts: make(map[int64]*account.Account),
+		nfts:     make(map[int64]*nft.L2Nft),
+	}
+	for blockHeight := int64(1); blockHeight <= height; blockHeight++ {
+		node.blocks[blockHeight] = c.blocks[blockHeight]
+	}
+	return node
+}
+
+func (c *fixtureChain) tables() *chainTables {
+	return &chainTables{
+		blockModel:   &fixtureBlockModel{c: c},
+		accountModel: &fixtureAccountModel{c: c},
+		nftModel:     &fixtureNftModel{c: c},
+		transaction: func(fc func(tx *gorm.DB) error) error {
+			return fc(nil)
+		},
+	}
+}
+
+// newTreeContext opens a leveldb tree database in a temporary directory, the memory
+// driver can not be used as it gives every tree a new database.
+func newTreeContext(t *testing.T) *tree.Context {
+	treeCtx, err := tree.NewContext("committer", tree.LevelDB, true, 0,
+		&tree.LevelDBOption{File: t.TempDir(), Cache: 16, Handles: 16}, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tree.SetupTreeDB(treeCtx))
+	t.Cleanup(func() {
+		_ = treeCtx.TreeDB.Close()
+	})
+	return treeCtx
+}
+
+func assertTreesEmpty(t *testing.T, treeCtx *tree.Context, height int64) {
+	accountTree, err := tree.NewAccountTree(treeCtx, height)
+	assert.NoError(t, err)
+	assert.True(t, accountTree.IsEmpty())
+	for accountIndex := int64(0); accountIndex < 3; accountIndex++ {
+		assetTree, err := tree.NewAccountAssetTree(treeCtx, accountIndex, height)
+		assert.NoError(t, err)
+		assert.True(t, assetTree.IsEmpty(), "asset tree of account %d", accountIndex)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	c := newFixtureChain(t)
+	output := filepath.Join(t.TempDir(), "snapshot.gz")
+	assert.NoError(t, c.exportSnapshot(2, output))
+
+	node := c.newNode(2)
+	treeCtx := newTreeContext(t)
+	assert.NoError(t, importSnapshot(node.tables(), treeCtx, output))
+
+	accountTree, err := tree.NewAccountTree(treeCtx, 2)
+	assert.NoError(t, err)
+	nftTree, err := tree.NewNftTree(treeCtx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, c.blocks[2].StateRoot, common.Bytes2Hex(tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root())))
+	for _, history := range c.latestAccounts(2) {
+		assetTree, err := tree.NewAccountAssetTree(treeCtx, history.AccountIndex, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, history.AssetRoot, common.Bytes2Hex(assetTree.Root()))
+	}
+
+	assert.Len(t, node.accounts, 3)
+	for _, history := range c.latestAccounts(2) {
+		accountInfo := node.accounts[history.AccountIndex]
+		assert.Equal(t, c.accounts[history.AccountIndex].AccountName, accountInfo.AccountName)
+		assert.Equal(t, history.Nonce, accountInfo.Nonce)
+		assert.Equal(t, history.AssetInfo, accountInfo.AssetInfo)
+		assert.Equal(t, history.AssetRoot, accountInfo.AssetRoot)
+		assert.Equal(t, account.AccountStatusConfirmed, accountInfo.Status)
+	}
+	assert.Len(t, node.nfts, 2)
+	assert.Equal(t, int64(1), node.nfts[1].OwnerAccountIndex)
+
+	// importing again is rejected as the trees are not empty any more
+	err = importSnapshot(node.tables(), treeCtx, output)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not empty")
+}
+
+func TestSnapshotImportBehindChain(t *testing.T) {
+	c := newFixtureChain(t)
+	output := filepath.Join(t.TempDir(), "snapshot.gz")
+	assert.NoError(t, c.exportSnapshot(2, output))
+
+	// the flat tables of the chain are at block 3 and newer than the snapshot
+	treeCtx := newTreeContext(t)
+	assert.NoError(t, importSnapshot(c.tables(), treeCtx, output))
+	assert.Equal(t, int64(2), c.accounts[1].Nonce)
+	assert.Len(t, c.accounts, 4)
+	assert.Equal(t, int64(2), c.nfts[1].OwnerAccountIndex)
+}
+
+func TestSnapshotImportCorrupted(t *testing.T) {
+	c := newFixtureChain(t)
+	dir := t.TempDir()
+	output := filepath.Join(dir, "snapshot.gz")
+	assert.NoError(t, c.exportSnapshot(2, output))
+
+	// the nonce of the last account is changed, which is only noticed at its leaf
+	archive, err := openArchive(output)
+	assert.NoError(t, err)
+	header, err := archive.readHeader()
+	assert.NoError(t, err)
+	corrupted := filepath.Join(dir, "corrupted.gz")
+	writer, err := createArchive(corrupted)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.write(header))
+	for {
+		record, err := archive.read()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		if record.Type == AccountRecordType && record.Account.AccountIndex == 2 {
+			record.Account.Nonce++
+		}
+		assert.NoError(t, writer.write(record))
+	}
+	archive.close()
+	assert.NoError(t, writer.close())
+
+	node := c.newNode(2)
+	treeCtx := newTreeContext(t)
+	err = importSnapshot(node.tables(), treeCtx, corrupted)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "account leaf mismatch")
+	assertTreesEmpty(t, treeCtx, 2)
+	assert.Empty(t, node.accounts)
+	assert.Empty(t, node.nfts)
+}
+
+func TestSnapshotImportRollback(t *testing.T) {
+	c := newFixtureChain(t)
+	output := filepath.Join(t.TempDir(), "snapshot.gz")
+	assert.NoError(t, c.exportSnapshot(2, output))
+
+	// the asset trees are committed when restoring the tables fails
+	node := c.newNode(2)
+	node.updateNftsErr = errors.New("connection reset")
+	treeCtx := newTreeContext(t)
+	err := importSnapshot(node.tables(), treeCtx, output)
+	assert.Error(t, err)
+	assertTreesEmpty(t, treeCtx, 2)
+
+	node.updateNftsErr = nil
+	assert.NoError(t, importSnapshot(node.tables(), treeCtx, output))
+	accountTree, err := tree.NewAccountTree(treeCtx, 2)
+	assert.NoError(t, err)
+	assert.False(t, accountTree.IsEmpty())
+}
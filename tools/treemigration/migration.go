@@ -0,0 +1,87 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package treemigration
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+const (
+	// flush the pebble batch once it holds this many bytes
+	batchValueSize = 64 * 1024 * 1024
+)
+
+// MigrateLevelDBToPebble copies every key of the leveldb tree database into a new
+// pebble database. Both drivers use the same key layout, so the trees of every
+// service and version are migrated as they are. The services must be stopped.
+func MigrateLevelDBToPebble(levelDBFile, pebbleFile string) error {
+	entries, err := os.ReadDir(pebbleFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("pebble directory %s is not empty", pebbleFile)
+	}
+
+	src, err := leveldb.OpenFile(levelDBFile, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+	if err != nil {
+		return fmt.Errorf("unable to open leveldb %s: %v", levelDBFile, err)
+	}
+	defer src.Close()
+	dst, err := tree.NewPebbleDB(&tree.PebbleDBOption{File: pebbleFile}, false)
+	if err != nil {
+		return fmt.Errorf("unable to open pebble %s: %v", pebbleFile, err)
+	}
+	defer dst.Close()
+
+	iter := src.NewIterator(nil, nil)
+	defer iter.Release()
+	batch := dst.NewBatch()
+	var total int
+	for iter.Next() {
+		err = batch.Set(iter.Key(), iter.Value())
+		if err != nil {
+			return err
+		}
+		total++
+		if batch.ValueSize() >= batchValueSize {
+			err = batch.Write()
+			if err != nil {
+				return err
+			}
+			batch.Reset()
+			logx.Infof("migrated %d keys", total)
+		}
+	}
+	if err = iter.Error(); err != nil {
+		return fmt.Errorf("unable to iterate leveldb: %v", err)
+	}
+	err = batch.Write()
+	if err != nil {
+		return err
+	}
+	logx.Infof("tree database migration finished, total: %d keys", total)
+	return nil
+}
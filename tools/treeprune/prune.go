@@ -0,0 +1,97 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package treeprune
+
+import (
+	"errors"
+
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/proc"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+type Config struct {
+	Postgres struct {
+		DataSource string
+	}
+	TreeDB struct {
+		Driver tree.Driver
+		//nolint:staticcheck
+		LevelDBOption tree.LevelDBOption `json:",optional"`
+		//nolint:staticcheck
+		PebbleDBOption tree.PebbleDBOption `json:",optional"`
+		//nolint:staticcheck
+		RedisDBOption tree.RedisDBOption `json:",optional"`
+		//nolint:staticcheck
+		RoutinePoolSize int `json:",optional"`
+	}
+	LogConf logx.LogConf
+}
+
+// PruneTreeDB prunes the trees of the service down to the versions kept by their
+// last commit. The service must be stopped, in dry run mode the reclaimable space
+// is reported only.
+func PruneTreeDB(configFile string, serviceName string, dryRun bool) error {
+	var c Config
+	conf.MustLoad(configFile, &c)
+	logx.MustSetup(c.LogConf)
+	logx.DisableStat()
+	proc.AddShutdownListener(func() {
+		logx.Close()
+	})
+
+	if c.TreeDB.Driver == tree.MemoryDB {
+		return errors.New("memory tree database can not be pruned")
+	}
+	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	accountNums, err := account.NewAccountModel(db).GetAccountsTotalCount()
+	if err != nil {
+		return err
+	}
+
+	treeCtx, err := tree.NewContext(serviceName, c.TreeDB.Driver, false, c.TreeDB.RoutinePoolSize, &c.TreeDB.LevelDBOption, &c.TreeDB.PebbleDBOption, &c.TreeDB.RedisDBOption)
+	if err != nil {
+		return err
+	}
+	err = tree.SetupTreeDB(treeCtx)
+	if err != nil {
+		return err
+	}
+	defer treeCtx.TreeDB.Close()
+
+	stats, err := tree.NewPruner(treeCtx, func() int64 { return accountNums }).Prune(dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		logx.Infof("%d versions in %d of %d nodes of %d trees can be pruned, reclaimable: %d bytes",
+			stats.PrunedVersions, stats.PrunedNodes, stats.Nodes, stats.Trees, stats.ReclaimableBytes)
+		return nil
+	}
+	logx.Infof("pruned %d versions in %d of %d nodes of %d trees, reclaimed: %d bytes",
+		stats.PrunedVersions, stats.PrunedNodes, stats.Nodes, stats.Trees, stats.ReclaimableBytes)
+	return nil
+}
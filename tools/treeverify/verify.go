@@ -0,0 +1,455 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package treeverify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/proc"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/common/chain"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+)
+
+const (
+	defaultBatchSize = 1000
+)
+
+type Config struct {
+	Postgres struct {
+		DataSource string
+	}
+	TreeDB struct {
+		Driver tree.Driver
+		//nolint:staticcheck
+		LevelDBOption tree.LevelDBOption `json:",optional"`
+		//nolint:staticcheck
+		PebbleDBOption tree.PebbleDBOption `json:",optional"`
+		//nolint:staticcheck
+		RedisDBOption tree.RedisDBOption `json:",optional"`
+		//nolint:staticcheck
+		RoutinePoolSize int `json:",optional"`
+	}
+	LogConf logx.LogConf
+}
+
+// Report lists the indexes of the leaves that don't match the database.
+type Report struct {
+	Accounts []int64
+	// asset ids by account index, the asset trees are only checked at the latest version
+	Assets map[int64][]int64
+	Nfts   []int64
+	// the leaves match but the roots don't, the trees hold leaves unknown to the database
+	UnknownLeaves bool
+}
+
+func (r *Report) Consistent() bool {
+	return len(r.Accounts) == 0 && len(r.Assets) == 0 && len(r.Nfts) == 0 && !r.UnknownLeaves
+}
+
+type verifier struct {
+	treeCtx             *tree.Context
+	accountModel        account.AccountModel
+	accountHistoryModel account.AccountHistoryModel
+	nftHistoryModel     nft.L2NftHistoryModel
+
+	height      int64
+	batchSize   int
+	repair      bool
+	checkAssets bool
+	accountTree bsmt.SparseMerkleTree
+	nftTree     bsmt.SparseMerkleTree
+	report      *Report
+
+	// leaves to set once the trees are rolled back to the previous height
+	accountItems []bsmt.Item
+	nftItems     []bsmt.Item
+	// leaves of the trees changed at the height, the rollback walks their paths
+	accountChanged []bsmt.Item
+	nftChanged     []bsmt.Item
+}
+
+// VerifyTree recomputes the account, asset and nft leaves at the height from the
+// histories and compares them with the trees of the service. The asset trees are
+// only checked if the height is the latest version of the trees. In repair mode
+// the account and nft trees are rolled back to the previous height and committed
+// again with the recomputed leaves, the repaired asset trees are committed as a new
+// version at the height, which bumps their versions by one. The service must be stopped.
+func VerifyTree(configFile string, serviceName string, height int64, batchSize int, repair bool) (*Report, error) {
+	var c Config
+	conf.MustLoad(configFile, &c)
+	logx.MustSetup(c.LogConf)
+	logx.DisableStat()
+	proc.AddShutdownListener(func() {
+		logx.Close()
+	})
+
+	if height <= 0 {
+		return nil, fmt.Errorf("invalid height %d", height)
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if c.TreeDB.Driver == tree.MemoryDB {
+		return nil, errors.New("memory tree database can not be verified")
+	}
+	db, err := gorm.Open(postgres.Open(c.Postgres.DataSource), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	blockInfo, err := block.NewBlockModel(db).GetBlockByHeightWithoutTx(height)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get block %d: %v", height, err)
+	}
+
+	treeCtx, err := tree.NewContext(serviceName, c.TreeDB.Driver, false, c.TreeDB.RoutinePoolSize, &c.TreeDB.LevelDBOption, &c.TreeDB.PebbleDBOption, &c.TreeDB.RedisDBOption)
+	if err != nil {
+		return nil, err
+	}
+	err = tree.SetupTreeDB(treeCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer treeCtx.TreeDB.Close()
+
+	v := &verifier{
+		treeCtx:             treeCtx,
+		accountModel:        account.NewAccountModel(db),
+		accountHistoryModel: account.NewAccountHistoryModel(db),
+		nftHistoryModel:     nft.NewL2NftHistoryModel(db),
+		height:              height,
+		batchSize:           batchSize,
+		repair:              repair,
+		report:              &Report{Assets: make(map[int64][]int64)},
+	}
+	return v.verify(blockInfo)
+}
+
+func (v *verifier) verify(blockInfo *block.Block) (*Report, error) {
+	height, repair := v.height, v.repair
+	accountTree, err := tree.NewAccountTree(v.treeCtx, height)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open account tree: %v", err)
+	}
+	nftTree, err := tree.NewNftTree(v.treeCtx, height)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open nft tree: %v", err)
+	}
+
+	latest := bsmt.Version(height)
+	for name, smt := range map[string]bsmt.SparseMerkleTree{"account": accountTree, "nft": nftTree} {
+		if smt.LatestVersion() < latest {
+			return nil, fmt.Errorf("%s tree is at version %d, lower than height %d", name, smt.LatestVersion(), height)
+		}
+		if smt.RecentVersion() > latest {
+			return nil, fmt.Errorf("%s tree is pruned to version %d, higher than height %d", name, smt.RecentVersion(), height)
+		}
+		if repair && smt.LatestVersion() != latest {
+			return nil, fmt.Errorf("%s tree is at version %d, only the latest version can be repaired", name, smt.LatestVersion())
+		}
+		if repair && smt.RecentVersion() > latest-1 {
+			return nil, fmt.Errorf("%s tree is pruned to version %d, can not be rolled back to repair", name, smt.RecentVersion())
+		}
+	}
+
+	v.checkAssets = accountTree.LatestVersion() == latest
+	v.accountTree = accountTree
+	v.nftTree = nftTree
+	if !v.checkAssets {
+		logx.Infof("account tree is at version %d, asset trees are checked through the account leaves only", accountTree.LatestVersion())
+	}
+	err = v.verifyAccounts()
+	if err != nil {
+		return nil, err
+	}
+	err = v.verifyNfts()
+	if err != nil {
+		return nil, err
+	}
+
+	if repair && len(v.report.Accounts) > 0 {
+		err = v.repairTree("account", accountTree, v.accountItems, v.accountChanged)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if repair && len(v.report.Nfts) > 0 {
+		err = v.repairTree("nft", nftTree, v.nftItems, v.nftChanged)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if accountTree.LatestVersion() == latest && nftTree.LatestVersion() == latest {
+		stateRoot := common.Bytes2Hex(tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root()))
+		if stateRoot != blockInfo.StateRoot {
+			logx.Errorf("state root mismatch at height %d, tree %s, block %s", height, stateRoot, blockInfo.StateRoot)
+			// the root is expected to differ if the mismatched leaves are not repaired
+			if repair || v.report.Consistent() {
+				v.report.UnknownLeaves = true
+			}
+		}
+	}
+
+	logx.Infof("tree verification of height %d finished, mismatched accounts: %v, assets: %v, nfts: %v, unknown leaves: %v",
+		height, v.report.Accounts, v.report.Assets, v.report.Nfts, v.report.UnknownLeaves)
+	return v.report, nil
+}
+
+func (v *verifier) verifyAccounts() error {
+	count := 0
+	for offset := 0; ; offset += v.batchSize {
+		_, accountHistories, err := v.accountHistoryModel.GetValidAccounts(v.height, v.batchSize, offset)
+		if err != nil {
+			return fmt.Errorf("unable to get accounts: %v", err)
+		}
+		for _, accountHistory := range accountHistories {
+			err = v.verifyAccount(accountHistory)
+			if err != nil {
+				return fmt.Errorf("unable to verify account %d: %v", accountHistory.AccountIndex, err)
+			}
+			count++
+		}
+		if len(accountHistories) < v.batchSize {
+			break
+		}
+		logx.Infof("verified %d accounts", count)
+	}
+	logx.Infof("verified %d accounts, mismatched: %d", count, len(v.report.Accounts))
+	return nil
+}
+
+func (v *verifier) verifyAccount(accountHistory *account.AccountHistory) error {
+	accountIndex := accountHistory.AccountIndex
+	accountInfo, err := v.accountModel.GetAccountByIndex(accountIndex)
+	if err != nil {
+		return err
+	}
+	formatAccount, err := chain.ToFormatAccountInfo(&account.Account{
+		AccountIndex: accountIndex,
+		AssetInfo:    accountHistory.AssetInfo,
+	})
+	if err != nil {
+		return err
+	}
+
+	expectedAssetTree, err := tree.NewMemAccountAssetTree()
+	if err != nil {
+		return err
+	}
+	assetLeaves := make(map[int64][]byte, len(formatAccount.AssetInfo))
+	for assetId, asset := range formatAccount.AssetInfo {
+		leaf, err := tree.ComputeAccountAssetLeafHash(asset.Balance.String(), asset.OfferCanceledOrFinalized.String())
+		if err != nil {
+			return err
+		}
+		err = expectedAssetTree.Set(uint64(assetId), leaf)
+		if err != nil {
+			return err
+		}
+		assetLeaves[assetId] = leaf
+	}
+	assetRoot := expectedAssetTree.Root()
+	if common.Bytes2Hex(assetRoot) != accountHistory.AssetRoot {
+		logx.Errorf("asset root of account %d in history is %s, recomputed %x", accountIndex, accountHistory.AssetRoot, assetRoot)
+	}
+	if v.checkAssets {
+		err = v.verifyAssets(accountIndex, assetLeaves, assetRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	leaf, err := tree.ComputeAccountLeafHash(accountInfo.AccountNameHash, accountInfo.PublicKey,
+		accountHistory.Nonce, accountHistory.CollectionNonce, assetRoot)
+	if err != nil {
+		return err
+	}
+	mismatched, err := v.verifyLeaf(v.accountTree, accountIndex, leaf, tree.NilAccountNodeHash, &v.accountItems, &v.accountChanged)
+	if err != nil {
+		return err
+	}
+	if mismatched {
+		v.report.Accounts = append(v.report.Accounts, accountIndex)
+	}
+	return nil
+}
+
+func (v *verifier) verifyAssets(accountIndex int64, assetLeaves map[int64][]byte, assetRoot []byte) error {
+	assetTree, err := tree.NewAccountAssetTree(v.treeCtx, accountIndex, v.height)
+	if err != nil {
+		return err
+	}
+	var mismatched []int64
+	for assetId, leaf := range assetLeaves {
+		treeLeaf, err := getLeaf(assetTree, assetId, nil, tree.NilAccountAssetNodeHash)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(treeLeaf, leaf) {
+			continue
+		}
+		logx.Errorf("asset %d of account %d mismatch, tree %x, expected %x", assetId, accountIndex, treeLeaf, leaf)
+		mismatched = append(mismatched, assetId)
+		if v.repair {
+			err = assetTree.Set(uint64(assetId), leaf)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(mismatched) == 0 {
+		if !bytes.Equal(assetTree.Root(), assetRoot) {
+			logx.Errorf("asset tree of account %d holds unknown leaves, root %x, expected %x", accountIndex, assetTree.Root(), assetRoot)
+			v.report.UnknownLeaves = true
+		}
+		return nil
+	}
+	v.report.Assets[accountIndex] = mismatched
+	if !v.repair {
+		return nil
+	}
+	// the repaired asset tree is committed as a new version at the height, the asset
+	// trees are versioned by their own commits, so no other version is rewritten
+	err = tree.CommitAssetTree(v.treeCtx, accountIndex, assetTree, v.height)
+	if err != nil {
+		return fmt.Errorf("unable to commit asset tree: %v", err)
+	}
+	if !bytes.Equal(assetTree.Root(), assetRoot) {
+		logx.Errorf("asset tree of account %d holds unknown leaves after repair, root %x, expected %x", accountIndex, assetTree.Root(), assetRoot)
+		v.report.UnknownLeaves = true
+	}
+	logx.Infof("repaired %d assets of account %d", len(mismatched), accountIndex)
+	return nil
+}
+
+func (v *verifier) verifyNfts() error {
+	count := 0
+	for offset := 0; ; offset += v.batchSize {
+		_, nftHistories, err := v.nftHistoryModel.GetLatestNftsByBlockHeight(v.height, v.batchSize, offset)
+		if err != nil {
+			return fmt.Errorf("unable to get nfts: %v", err)
+		}
+		for _, nftHistory := range nftHistories {
+			leaf, err := tree.ComputeNftAssetLeafHash(
+				nftHistory.CreatorAccountIndex,
+				nftHistory.OwnerAccountIndex,
+				nftHistory.NftContentHash,
+				nftHistory.NftL1Address,
+				nftHistory.NftL1TokenId,
+				nftHistory.CreatorTreasuryRate,
+				nftHistory.CollectionId,
+			)
+			if err != nil {
+				return fmt.Errorf("unable to compute leaf of nft %d: %v", nftHistory.NftIndex, err)
+			}
+			mismatched, err := v.verifyLeaf(v.nftTree, nftHistory.NftIndex, leaf, tree.NilNftNodeHash, &v.nftItems, &v.nftChanged)
+			if err != nil {
+				return fmt.Errorf("unable to verify nft %d: %v", nftHistory.NftIndex, err)
+			}
+			if mismatched {
+				v.report.Nfts = append(v.report.Nfts, nftHistory.NftIndex)
+			}
+			count++
+		}
+		if len(nftHistories) < v.batchSize {
+			break
+		}
+		logx.Infof("verified %d nfts", count)
+	}
+	logx.Infof("verified %d nfts, mismatched: %d", count, len(v.report.Nfts))
+	return nil
+}
+
+// verifyLeaf compares the leaf of the tree at the height, in repair mode the leaf is
+// collected if it differs from the leaf at the previous height, and the leaf of the
+// tree is collected in changed if the tree changed it at the height.
+func (v *verifier) verifyLeaf(smt bsmt.SparseMerkleTree, index int64, leaf, nilHash []byte, items, changed *[]bsmt.Item) (bool, error) {
+	version := bsmt.Version(v.height)
+	treeLeaf, err := getLeaf(smt, index, &version, nilHash)
+	if err != nil {
+		return false, err
+	}
+	mismatched := !bytes.Equal(treeLeaf, leaf)
+	if mismatched {
+		logx.Errorf("leaf %d mismatch at height %d, tree %x, expected %x", index, v.height, treeLeaf, leaf)
+	}
+	if !v.repair {
+		return mismatched, nil
+	}
+	version--
+	prevLeaf, err := getLeaf(smt, index, &version, nilHash)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(prevLeaf, leaf) {
+		*items = append(*items, bsmt.Item{Key: uint64(index), Val: leaf})
+	}
+	if !bytes.Equal(prevLeaf, treeLeaf) {
+		*changed = append(*changed, bsmt.Item{Key: uint64(index), Val: treeLeaf})
+	}
+	return mismatched, nil
+}
+
+// repairTree rolls the tree back to the previous height and commits the leaves at the height again.
+func (v *verifier) repairTree(name string, smt bsmt.SparseMerkleTree, items, changed []bsmt.Item) error {
+	// the rollback reads the children of the nodes it walks at the wrong path unless they
+	// are loaded, setting the changed leaves loads their paths, the reset drops the sets
+	for _, item := range changed {
+		err := smt.Set(item.Key, item.Val)
+		if err != nil {
+			return fmt.Errorf("unable to load %s leaf %d: %v", name, item.Key, err)
+		}
+	}
+	smt.Reset()
+	err := smt.Rollback(bsmt.Version(v.height - 1))
+	if err != nil {
+		return fmt.Errorf("unable to rollback %s tree: %v", name, err)
+	}
+	for _, item := range items {
+		err := smt.Set(item.Key, item.Val)
+		if err != nil {
+			return fmt.Errorf("unable to set %s leaf %d: %v", name, item.Key, err)
+		}
+	}
+	recentVersion := smt.RecentVersion()
+	_, err = smt.Commit(&recentVersion)
+	if err != nil {
+		return fmt.Errorf("unable to commit %s tree: %v", name, err)
+	}
+	logx.Infof("repaired %s tree at height %d, %d leaves set", name, v.height, len(items))
+	return nil
+}
+
+func getLeaf(smt bsmt.SparseMerkleTree, index int64, version *bsmt.Version, nilHash []byte) ([]byte, error) {
+	leaf, err := smt.Get(uint64(index), version)
+	if errors.Is(err, bsmt.ErrEmptyRoot) || errors.Is(err, bsmt.ErrNodeNotFound) {
+		return nilHash, nil
+	}
+	return leaf, err
+}
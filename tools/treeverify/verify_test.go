@@ -0,0 +1,291 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package treeverify
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb/dao/account"
+	"github.com/bnb-chain/zkbnb/dao/block"
+	"github.com/bnb-chain/zkbnb/dao/nft"
+	"github.com/bnb-chain/zkbnb/tree"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+type mockAccountModel struct {
+	account.AccountModel
+	accounts map[int64]*account.Account
+}
+
+func (m *mockAccountModel) GetAccountByIndex(accountIndex int64) (*account.Account, error) {
+	accountInfo, ok := m.accounts[accountIndex]
+	if !ok {
+		return nil, types.DbErrNotFound
+	}
+	return accountInfo, nil
+}
+
+type mockAccountHistoryModel struct {
+	account.AccountHistoryModel
+	histories []*account.AccountHistory
+}
+
+func (m *mockAccountHistoryModel) GetValidAccounts(_ int64, limit int, offset int) (int64, []*account.AccountHistory, error) {
+	if offset >= len(m.histories) {
+		return 0, nil, nil
+	}
+	end := offset + limit
+	if end > len(m.histories) {
+		end = len(m.histories)
+	}
+	return int64(end - offset), m.histories[offset:end], nil
+}
+
+type mockNftHistoryModel struct {
+	nft.L2NftHistoryModel
+	histories []*nft.L2NftHistory
+}
+
+func (m *mockNftHistoryModel) GetLatestNftsByBlockHeight(_ int64, limit int, offset int) (int64, []*nft.L2NftHistory, error) {
+	if offset >= len(m.histories) {
+		return 0, nil, nil
+	}
+	end := offset + limit
+	if end > len(m.histories) {
+		end = len(m.histories)
+	}
+	return int64(end - offset), m.histories[offset:end], nil
+}
+
+// testChain commits the trees of two blocks, the leaves of account 1, its asset 0
+// and nft 0 are corrupted at block 2.
+type testChain struct {
+	treeCtx  *tree.Context
+	accounts *mockAccountModel
+	// the histories at block 2
+	accountHistories *mockAccountHistoryModel
+	nftHistories     *mockNftHistoryModel
+	block            *block.Block
+}
+
+func newTestChain(t *testing.T) *testChain {
+	treeCtx, err := tree.NewContext("committer", tree.LevelDB, true, 0,
+		&tree.LevelDBOption{File: t.TempDir(), Cache: 16, Handles: 16}, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tree.SetupTreeDB(treeCtx))
+	t.Cleanup(func() {
+		_ = treeCtx.TreeDB.Close()
+	})
+
+	c := &testChain{
+		treeCtx:          treeCtx,
+		accounts:         &mockAccountModel{accounts: make(map[int64]*account.Account)},
+		accountHistories: &mockAccountHistoryModel{},
+		nftHistories:     &mockNftHistoryModel{},
+	}
+	for accountIndex := int64(0); accountIndex < 2; accountIndex++ {
+		c.accounts.accounts[accountIndex] = &account.Account{
+			AccountIndex:    accountIndex,
+			PublicKey:       fmt.Sprintf("%064x", accountIndex+1),
+			AccountNameHash: fmt.Sprintf("%064x", accountIndex+100),
+		}
+	}
+
+	accountTree, err := tree.NewAccountTree(treeCtx, 0)
+	assert.NoError(t, err)
+	nftTree, err := tree.NewNftTree(treeCtx, 0)
+	assert.NoError(t, err)
+	nftHistory := &nft.L2NftHistory{
+		NftIndex:            0,
+		CreatorAccountIndex: 1,
+		OwnerAccountIndex:   0,
+		NftContentHash:      fmt.Sprintf("%064x", 1),
+		NftL1Address:        common.Address{}.Hex(),
+		NftL1TokenId:        "0",
+		CreatorTreasuryRate: 10,
+	}
+
+	// block 1
+	c.setAccount(t, accountTree, 1, 0, []int64{100}, nil)
+	c.setAccount(t, accountTree, 1, 1, []int64{50, 7}, nil)
+	c.setNft(t, nftTree, nftHistory)
+	commitTree(t, accountTree)
+	commitTree(t, nftTree)
+
+	// block 2, account 1 spends 10 of asset 0 and nft 0 is transferred to account 1,
+	// the trees get a wrong balance and a wrong owner
+	c.accountHistories.histories = c.accountHistories.histories[:1]
+	c.setAccount(t, accountTree, 2, 1, []int64{40, 7}, []int64{41, 7})
+	transferred := *nftHistory
+	transferred.OwnerAccountIndex = 1
+	c.nftHistories.histories = []*nft.L2NftHistory{&transferred}
+	corrupted := transferred
+	corrupted.OwnerAccountIndex = 0
+	corrupted.CreatorTreasuryRate = 20
+	leaf, err := tree.NftAssetToNode(&corrupted)
+	assert.NoError(t, err)
+	assert.NoError(t, nftTree.Set(0, leaf))
+	stateRoot := c.stateRoot(t, &transferred)
+	commitTree(t, accountTree)
+	commitTree(t, nftTree)
+	c.block = &block.Block{BlockHeight: 2, StateRoot: stateRoot}
+	return c
+}
+
+// setAccount appends the account history at the height and commits the asset tree
+// with the tree balances, which default to the balances of the history.
+func (c *testChain) setAccount(t *testing.T, accountTree bsmt.SparseMerkleTree, height, accountIndex int64, balances, treeBalances []int64) {
+	if treeBalances == nil {
+		treeBalances = balances
+	}
+	assetInfo := "{"
+	for assetId, balance := range balances {
+		if assetId > 0 {
+			assetInfo += ","
+		}
+		assetInfo += fmt.Sprintf(`"%d":{"AssetId":%d,"Balance":%d,"OfferCanceledOrFinalized":0}`, assetId, assetId, balance)
+	}
+	assetInfo += "}"
+	c.accountHistories.histories = append(c.accountHistories.histories, &account.AccountHistory{
+		AccountIndex:  accountIndex,
+		AssetInfo:     assetInfo,
+		AssetRoot:     common.Bytes2Hex(assetRoot(t, balances)),
+		L2BlockHeight: height,
+	})
+
+	assetTree, err := tree.NewAccountAssetTree(c.treeCtx, accountIndex, height)
+	assert.NoError(t, err)
+	for assetId, balance := range treeBalances {
+		leaf, err := tree.AssetToNode(fmt.Sprint(balance), "0")
+		assert.NoError(t, err)
+		assert.NoError(t, assetTree.Set(uint64(assetId), leaf))
+	}
+	assert.NoError(t, tree.CommitAssetTree(c.treeCtx, accountIndex, assetTree, height))
+
+	accountInfo := c.accounts.accounts[accountIndex]
+	leaf, err := tree.AccountToNode(accountInfo.AccountNameHash, accountInfo.PublicKey, 0, 0, assetTree.Root())
+	assert.NoError(t, err)
+	assert.NoError(t, accountTree.Set(uint64(accountIndex), leaf))
+}
+
+func (c *testChain) setNft(t *testing.T, nftTree bsmt.SparseMerkleTree, nftHistory *nft.L2NftHistory) {
+	c.nftHistories.histories = []*nft.L2NftHistory{nftHistory}
+	leaf, err := tree.NftAssetToNode(nftHistory)
+	assert.NoError(t, err)
+	assert.NoError(t, nftTree.Set(uint64(nftHistory.NftIndex), leaf))
+}
+
+// stateRoot computes the state root of the histories on memory trees.
+func (c *testChain) stateRoot(t *testing.T, nftHistory *nft.L2NftHistory) string {
+	memCtx, err := tree.NewContext("verify", tree.MemoryDB, true, 0, nil, nil, nil)
+	assert.NoError(t, err)
+	accountTree, err := tree.NewAccountTree(memCtx, 0)
+	assert.NoError(t, err)
+	for _, history := range c.accountHistories.histories {
+		accountInfo := c.accounts.accounts[history.AccountIndex]
+		leaf, err := tree.AccountToNode(accountInfo.AccountNameHash, accountInfo.PublicKey, 0, 0, common.FromHex(history.AssetRoot))
+		assert.NoError(t, err)
+		assert.NoError(t, accountTree.Set(uint64(history.AccountIndex), leaf))
+	}
+	nftTree, err := tree.NewNftTree(memCtx, 0)
+	assert.NoError(t, err)
+	leaf, err := tree.NftAssetToNode(nftHistory)
+	assert.NoError(t, err)
+	assert.NoError(t, nftTree.Set(uint64(nftHistory.NftIndex), leaf))
+	return common.Bytes2Hex(tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root()))
+}
+
+func (c *testChain) verify(repair bool) (*Report, error) {
+	v := &verifier{
+		treeCtx:             c.treeCtx,
+		accountModel:        c.accounts,
+		accountHistoryModel: c.accountHistories,
+		nftHistoryModel:     c.nftHistories,
+		height:              2,
+		batchSize:           1,
+		repair:              repair,
+		report:              &Report{Assets: make(map[int64][]int64)},
+	}
+	return v.verify(c.block)
+}
+
+func assetRoot(t *testing.T, balances []int64) []byte {
+	assetTree, err := tree.NewMemAccountAssetTree()
+	assert.NoError(t, err)
+	for assetId, balance := range balances {
+		leaf, err := tree.AssetToNode(fmt.Sprint(balance), "0")
+		assert.NoError(t, err)
+		assert.NoError(t, assetTree.Set(uint64(assetId), leaf))
+	}
+	return assetTree.Root()
+}
+
+func commitTree(t *testing.T, smt bsmt.SparseMerkleTree) {
+	recentVersion := smt.RecentVersion()
+	_, err := smt.Commit(&recentVersion)
+	assert.NoError(t, err)
+}
+
+func TestVerifyTree(t *testing.T) {
+	c := newTestChain(t)
+
+	report, err := c.verify(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, report.Accounts)
+	assert.Equal(t, map[int64][]int64{1: {0}}, report.Assets)
+	assert.Equal(t, []int64{0}, report.Nfts)
+	assert.False(t, report.UnknownLeaves)
+	assert.False(t, report.Consistent())
+}
+
+func TestVerifyTreeRepair(t *testing.T) {
+	c := newTestChain(t)
+	assetTree, err := tree.NewAccountAssetTree(c.treeCtx, 1, 2)
+	assert.NoError(t, err)
+	assetVersion := assetTree.LatestVersion()
+
+	report, err := c.verify(true)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, report.Accounts)
+	assert.Equal(t, map[int64][]int64{1: {0}}, report.Assets)
+	assert.Equal(t, []int64{0}, report.Nfts)
+	assert.False(t, report.UnknownLeaves)
+
+	accountTree, err := tree.NewAccountTree(c.treeCtx, 2)
+	assert.NoError(t, err)
+	nftTree, err := tree.NewNftTree(c.treeCtx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, bsmt.Version(2), accountTree.LatestVersion())
+	assert.Equal(t, bsmt.Version(2), nftTree.LatestVersion())
+	assert.Equal(t, c.block.StateRoot, common.Bytes2Hex(tree.ComputeStateRootHash(accountTree.Root(), nftTree.Root())))
+
+	// the repaired asset tree is committed as a new version
+	assetTree, err = tree.NewAccountAssetTree(c.treeCtx, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, assetVersion+1, assetTree.LatestVersion())
+	assert.Equal(t, c.accountHistories.histories[1].AssetRoot, common.Bytes2Hex(assetTree.Root()))
+
+	report, err = c.verify(false)
+	assert.NoError(t, err)
+	assert.True(t, report.Consistent())
+}
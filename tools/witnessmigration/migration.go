@@ -0,0 +1,75 @@
+/*
+ * Copyright © 2021 ZkBNB Protocol
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package witnessmigration
+
+import (
+	"fmt"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/bnb-chain/zkbnb/common/prove"
+	"github.com/bnb-chain/zkbnb/dao/blockwitness"
+	"github.com/bnb-chain/zkbnb/types"
+)
+
+// MigrateBlockWitness converts the block witnesses stored in the legacy json
+// format into the binary format, batchSize rows at a time.
+func MigrateBlockWitness(dsn string, batchSize int) error {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	blockWitnessModel := blockwitness.NewBlockWitnessModel(db)
+
+	// Add the format columns if the table is created by an old version.
+	err = blockWitnessModel.CreateBlockWitnessTable()
+	if err != nil {
+		return fmt.Errorf("unable to migrate block witness table: %v", err)
+	}
+
+	var total int
+	for {
+		witnesses, err := blockWitnessModel.GetBlockWitnessesByFormat(blockwitness.FormatJSON, batchSize)
+		if err != nil {
+			if err == types.DbErrNotFound {
+				break
+			}
+			return err
+		}
+		for _, witness := range witnesses {
+			cryptoBlock, err := prove.DecodeBlockWitness(witness)
+			if err != nil {
+				return fmt.Errorf("unable to decode block witness %d: %v", witness.Height, err)
+			}
+			err = prove.EncodeBlockWitness(cryptoBlock, witness, blockwitness.FormatBinaryV1)
+			if err != nil {
+				return fmt.Errorf("unable to encode block witness %d: %v", witness.Height, err)
+			}
+			err = blockWitnessModel.UpdateBlockWitnessData(witness)
+			if err != nil {
+				return fmt.Errorf("unable to update block witness %d: %v", witness.Height, err)
+			}
+		}
+		total += len(witnesses)
+		logx.Infof("migrated %d block witnesses, latest height: %d", total, witnesses[len(witnesses)-1].Height)
+	}
+	logx.Infof("block witness migration finished, total: %d", total)
+	return nil
+}
@@ -18,7 +18,7 @@
 package tree
 
 import (
-	"errors"
+	"fmt"
 	"hash"
 	"strconv"
 
@@ -36,6 +36,20 @@ func accountAssetNamespace(index int64) string {
 	return AccountAssetPrefix + strconv.Itoa(int(index)) + ":"
 }
 
+// NewAccountTree opens the account tree of the context without loading any leaves.
+func NewAccountTree(ctx *Context, blockHeight int64) (bsmt.SparseMerkleTree, error) {
+	return bsmt.NewBASSparseMerkleTree(ctx.Hasher(),
+		SetNamespace(ctx, AccountPrefix), AccountTreeHeight, NilAccountNodeHash,
+		ctx.Options(blockHeight)...)
+}
+
+// NewAccountAssetTree opens the asset tree of the account without loading any leaves.
+func NewAccountAssetTree(ctx *Context, accountIndex, blockHeight int64) (bsmt.SparseMerkleTree, error) {
+	return bsmt.NewBASSparseMerkleTree(ctx.Hasher(),
+		SetNamespace(ctx, accountAssetNamespace(accountIndex)), AssetTreeHeight, NilAccountAssetNodeHash,
+		ctx.Options(blockHeight)...)
+}
+
 func InitAccountTree(
 	accountModel account.AccountModel,
 	accountHistoryModel account.AccountHistoryModel,
@@ -51,22 +65,16 @@ func InitAccountTree(
 		return nil, nil, err
 	}
 
-	opts := ctx.Options(blockHeight)
-
 	// init account state trees
 	accountAssetTrees = NewLazyTreeCache(assetCacheSize, accountNums-1, blockHeight, func(index, block int64) bsmt.SparseMerkleTree {
-		tree, err := bsmt.NewBASSparseMerkleTree(ctx.Hasher(),
-			SetNamespace(ctx, accountAssetNamespace(index)), AssetTreeHeight, NilAccountAssetNodeHash,
-			ctx.Options(block)...)
+		tree, err := NewAccountAssetTree(ctx, index, block)
 		if err != nil {
 			logx.Errorf("unable to create new tree by assets: %s", err.Error())
 			panic(err.Error())
 		}
 		return tree
 	})
-	accountTree, err = bsmt.NewBASSparseMerkleTree(ctx.Hasher(),
-		SetNamespace(ctx, AccountPrefix), AccountTreeHeight, NilAccountNodeHash,
-		opts...)
+	accountTree, err = NewAccountTree(ctx, blockHeight)
 	if err != nil {
 		logx.Errorf("unable to create new account tree: %s", err.Error())
 		return nil, nil, err
@@ -80,19 +88,12 @@ func InitAccountTree(
 		for i := 0; i < int(accountNums); i += ctx.BatchReloadSize() {
 			err := reloadAccountTreeFromRDB(
 				accountModel, accountHistoryModel, blockHeight,
-				i, i+ctx.BatchReloadSize(),
-				accountTree, accountAssetTrees)
-			if err != nil {
-				return nil, nil, err
-			}
-		}
-
-		for i := int64(0); i < accountNums; i++ {
-			_, err := accountAssetTrees.Get(i).Commit(nil)
+				i, ctx.BatchReloadSize(),
+				ctx, accountTree, accountAssetTrees)
 			if err != nil {
-				logx.Errorf("unable to set asset to tree: %s", err.Error())
 				return nil, nil, err
 			}
+			logx.Infof("reloaded %d/%d accounts", minInt64(int64(i+ctx.BatchReloadSize()), accountNums), accountNums)
 		}
 
 		_, err = accountTree.Commit(nil)
@@ -113,26 +114,96 @@ func InitAccountTree(
 		}
 	}
 
-	for i := int64(0); i < accountNums; i++ {
-		asset := accountAssetTrees.Get(i)
-		if asset.LatestVersion() > bsmt.Version(blockHeight) && !asset.IsEmpty() {
-			logx.Infof("asset tree %d version [%d] is higher than block, rollback to %d", i, asset.LatestVersion(), blockHeight)
-			err := asset.Rollback(bsmt.Version(blockHeight))
+	err = rollbackAccountAssetTrees(ctx, blockHeight, accountNums, accountAssetTrees)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accountTree, accountAssetTrees, nil
+}
+
+// rollbackAccountAssetTrees rolls back the asset trees committed above the block to their version at the block.
+// Only the asset trees committed above the block are checked if they are marked as dirty.
+func rollbackAccountAssetTrees(ctx *Context, blockHeight int64, accountNums int64, accountAssetTrees *AssetTreeCache) error {
+	marker := newAssetDirtyMarker(ctx)
+	versions := newAssetVersionIndex(ctx)
+	var (
+		indexes []int64
+		marked  bool
+		err     error
+	)
+	if marker != nil {
+		indexes, marked, err = marker.dirtyAbove(blockHeight)
+		if err != nil {
+			logx.Errorf("unable to read asset dirty marker: %s", err.Error())
+			return err
+		}
+	}
+	if !marked {
+		indexes = make([]int64, accountNums)
+		for i := range indexes {
+			indexes[i] = int64(i)
+		}
+	}
+	logx.Infof("check %d asset trees for rollback, total accounts: %d", len(indexes), accountNums)
+
+	for i := 0; i < len(indexes); i += ctx.BatchReloadSize() {
+		batch := indexes[i:minInt(i+ctx.BatchReloadSize(), len(indexes))]
+		errChan := make(chan error, len(batch))
+		for _, index := range batch {
+			err := func(i int64) error {
+				return ctx.RoutinePool().Submit(func() {
+					asset := accountAssetTrees.Get(i)
+					version := bsmt.Version(blockHeight)
+					if versions != nil {
+						var err error
+						version, err = versions.rollbackVersion(i, asset, blockHeight)
+						if err != nil {
+							errChan <- fmt.Errorf("unable to find asset [%d] tree version: %v, block: %d", i, err, blockHeight)
+							return
+						}
+					}
+					if asset.LatestVersion() > version && !asset.IsEmpty() {
+						logx.Infof("asset tree %d version [%d] is higher than block, rollback to %d", i, asset.LatestVersion(), version)
+						err := asset.Rollback(version)
+						if err != nil {
+							errChan <- fmt.Errorf("unable to rollback asset [%d] tree: %v, version: %d", i, err, version)
+							return
+						}
+					}
+					errChan <- nil
+				})
+			}(index)
 			if err != nil {
-				logx.Errorf("unable to rollback asset [%d] tree: %s, version: %d", i, err.Error(), blockHeight)
-				return nil, nil, err
+				return err
 			}
 		}
+		for range batch {
+			if err := <-errChan; err != nil {
+				logx.Error(err.Error())
+				return err
+			}
+		}
+		logx.Infof("checked %d/%d asset trees", i+len(batch), len(indexes))
 	}
 
-	return accountTree, accountAssetTrees, nil
+	if marker != nil {
+		err = marker.reset(blockHeight)
+		if err != nil {
+			logx.Errorf("unable to reset asset dirty marker: %s", err.Error())
+			return err
+		}
+	}
+	return nil
 }
 
+// reloadAccountTreeFromRDB rebuilds the asset trees of a batch of accounts on the tree routine
+// pool and commits them, then sets the account leaves into the account tree.
 func reloadAccountTreeFromRDB(
 	accountModel account.AccountModel,
 	accountHistoryModel account.AccountHistoryModel,
 	blockHeight int64,
 	offset, limit int,
+	ctx *Context,
 	accountTree bsmt.SparseMerkleTree,
 	accountAssetTrees *AssetTreeCache,
 ) error {
@@ -145,25 +216,18 @@ func reloadAccountTreeFromRDB(
 
 	var (
 		accountInfoMap = make(map[int64]*account.Account)
+		accountIndexes = make([]int64, 0, len(accountHistories))
 	)
 
 	for _, accountHistory := range accountHistories {
 		if accountInfoMap[accountHistory.AccountIndex] == nil {
-			accountInfo, err := accountModel.GetAccountByIndex(accountHistory.AccountIndex)
-			if err != nil {
-				logx.Errorf("unable to get account by account index: %s", err.Error())
-				return err
-			}
 			accountInfoMap[accountHistory.AccountIndex] = &account.Account{
-				AccountIndex:    accountInfo.AccountIndex,
-				AccountName:     accountInfo.AccountName,
-				PublicKey:       accountInfo.PublicKey,
-				AccountNameHash: accountInfo.AccountNameHash,
-				L1Address:       accountInfo.L1Address,
+				AccountIndex:    accountHistory.AccountIndex,
 				Nonce:           types.EmptyNonce,
 				CollectionNonce: types.EmptyCollectionNonce,
 				Status:          account.AccountStatusConfirmed,
 			}
+			accountIndexes = append(accountIndexes, accountHistory.AccountIndex)
 		}
 		if accountHistory.Nonce != types.EmptyNonce {
 			accountInfoMap[accountHistory.AccountIndex].Nonce = accountHistory.Nonce
@@ -175,56 +239,113 @@ func reloadAccountTreeFromRDB(
 		accountInfoMap[accountHistory.AccountIndex].AssetRoot = accountHistory.AssetRoot
 	}
 
-	// get related account info
-	for i := int64(0); i < int64(len(accountHistories)); i++ {
-		accountIndex := accountHistories[i].AccountIndex
-		if accountInfoMap[accountIndex] == nil {
-			logx.Errorf("invalid account index")
-			return errors.New("invalid account index")
-		}
-		oAccountInfo := accountInfoMap[accountIndex]
-		accountInfo, err := chain.ToFormatAccountInfo(oAccountInfo)
+	items := make([]bsmt.Item, len(accountIndexes))
+	errChan := make(chan error, len(accountIndexes))
+	for i, accountIndex := range accountIndexes {
+		err := func(i int, accountInfo *account.Account) error {
+			return ctx.RoutinePool().Submit(func() {
+				hashVal, err := reloadAccount(accountModel, accountInfo, accountAssetTrees)
+				if err != nil {
+					errChan <- err
+					return
+				}
+				items[i] = bsmt.Item{Key: uint64(accountInfo.AccountIndex), Val: hashVal}
+				errChan <- nil
+			})
+		}(i, accountInfoMap[accountIndex])
 		if err != nil {
-			logx.Errorf("unable to convert to format account info: %s", err.Error())
 			return err
 		}
-		// create account assets node
-		for assetId, assetInfo := range accountInfo.AssetInfo {
-			hashVal, err := AssetToNode(
-				assetInfo.Balance.String(),
-				assetInfo.OfferCanceledOrFinalized.String(),
-			)
-			if err != nil {
-				logx.Errorf("unable to convert asset to node: %s", err.Error())
-				return err
-			}
-			err = accountAssetTrees.Get(accountIndex).Set(uint64(assetId), hashVal)
-			if err != nil {
-				logx.Errorf("unable to set asset to tree: %s", err.Error())
-				return err
-			}
-		}
-		accountHashVal, err := AccountToNode(
-			accountInfoMap[accountIndex].AccountNameHash,
-			accountInfoMap[accountIndex].PublicKey,
-			accountInfoMap[accountIndex].Nonce,
-			accountInfoMap[accountIndex].CollectionNonce,
-			accountAssetTrees.Get(accountIndex).Root(),
-		)
-		if err != nil {
-			logx.Errorf("unable to convert account to node: %s", err.Error())
+	}
+	for range accountIndexes {
+		if err := <-errChan; err != nil {
 			return err
 		}
-		err = accountTree.Set(uint64(accountIndex), accountHashVal)
+	}
+
+	for _, item := range items {
+		err = accountTree.Set(item.Key, item.Val)
 		if err != nil {
 			logx.Errorf("unable to set account to tree: %s", err.Error())
 			return err
 		}
 	}
-
 	return nil
 }
 
+// reloadAccount writes the assets of the account into its asset tree, commits the asset tree
+// and returns the account leaf.
+func reloadAccount(
+	accountModel account.AccountModel,
+	accountInfo *account.Account,
+	accountAssetTrees *AssetTreeCache,
+) ([]byte, error) {
+	staticInfo, err := accountModel.GetAccountByIndex(accountInfo.AccountIndex)
+	if err != nil {
+		logx.Errorf("unable to get account by account index: %s", err.Error())
+		return nil, err
+	}
+	accountInfo.AccountName = staticInfo.AccountName
+	accountInfo.PublicKey = staticInfo.PublicKey
+	accountInfo.AccountNameHash = staticInfo.AccountNameHash
+	accountInfo.L1Address = staticInfo.L1Address
+
+	formatAccountInfo, err := chain.ToFormatAccountInfo(accountInfo)
+	if err != nil {
+		logx.Errorf("unable to convert to format account info: %s", err.Error())
+		return nil, err
+	}
+	// The tree is held until it's committed, even if it's evicted from the cache meanwhile.
+	assetTree := accountAssetTrees.Get(accountInfo.AccountIndex)
+	// create account assets node
+	for assetId, assetInfo := range formatAccountInfo.AssetInfo {
+		hashVal, err := AssetToNode(
+			assetInfo.Balance.String(),
+			assetInfo.OfferCanceledOrFinalized.String(),
+		)
+		if err != nil {
+			logx.Errorf("unable to convert asset to node: %s", err.Error())
+			return nil, err
+		}
+		err = assetTree.Set(uint64(assetId), hashVal)
+		if err != nil {
+			logx.Errorf("unable to set asset to tree: %s", err.Error())
+			return nil, err
+		}
+	}
+	_, err = assetTree.Commit(nil)
+	if err != nil {
+		logx.Errorf("unable to commit asset tree: %s", err.Error())
+		return nil, err
+	}
+	accountHashVal, err := AccountToNode(
+		accountInfo.AccountNameHash,
+		accountInfo.PublicKey,
+		accountInfo.Nonce,
+		accountInfo.CollectionNonce,
+		assetTree.Root(),
+	)
+	if err != nil {
+		logx.Errorf("unable to convert account to node: %s", err.Error())
+		return nil, err
+	}
+	return accountHashVal, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 func AssetToNode(balance string, offerCanceledOrFinalized string) (hashVal []byte, err error) {
 	hashVal, err = ComputeAccountAssetLeafHash(balance, offerCanceledOrFinalized)
 	if err != nil {
@@ -17,13 +17,14 @@ type AssetTreeCache struct {
 	changes           map[int64]bool
 	changesLock       sync.RWMutex
 	treeCache         *lru.Cache
+	maxSize           int
 }
 
 // Creates new AssetTreeCache
 // maxSize defines the maximum size of currently initialized trees
 // accountNumber defines the number of accounts to create/or next index for new account
 func NewLazyTreeCache(maxSize int, accountNumber int64, blockNumber int64, f func(index, block int64) bsmt.SparseMerkleTree) *AssetTreeCache {
-	cache := AssetTreeCache{initFunction: f, nextAccountNumber: accountNumber, blockNumber: blockNumber, changes: make(map[int64]bool, maxSize*10)}
+	cache := AssetTreeCache{initFunction: f, nextAccountNumber: accountNumber, blockNumber: blockNumber, changes: make(map[int64]bool, maxSize*10), maxSize: maxSize}
 	cache.treeCache, _ = lru.NewWithEvict(maxSize, cache.onDelete)
 	return &cache
 }
@@ -49,8 +50,13 @@ func (c *AssetTreeCache) GetNextAccountIndex() int64 {
 
 // Returns asset tree based on account index
 func (c *AssetTreeCache) Get(i int64) (tree bsmt.SparseMerkleTree) {
+	if tmpTree, ok := c.treeCache.Get(i); ok {
+		assetTreeCacheHitMetrics.Inc()
+		return tmpTree.(bsmt.SparseMerkleTree)
+	}
+	assetTreeCacheMissMetrics.Inc()
 	c.mainLock.RLock()
-	c.treeCache.ContainsOrAdd(i, c.initFunction(i, c.blockNumber))
+	c.treeCache.ContainsOrAdd(i, c.newTree(i))
 	c.mainLock.RUnlock()
 	if tmpTree, ok := c.treeCache.Get(i); ok {
 		tree = tmpTree.(bsmt.SparseMerkleTree)
@@ -58,18 +64,34 @@ func (c *AssetTreeCache) Get(i int64) (tree bsmt.SparseMerkleTree) {
 	return
 }
 
+// Prefetch initializes the asset trees of the accounts which are not cached yet, in the
+// given order. Trees are only added while the cache has room, so that no tree is evicted
+// for them. Returns the number of trees initialized.
+func (c *AssetTreeCache) Prefetch(indexes []int64) int {
+	c.mainLock.RLock()
+	defer c.mainLock.RUnlock()
+
+	prefetched := 0
+	for _, i := range indexes {
+		if c.treeCache.Len() >= c.maxSize {
+			break
+		}
+		if i < 0 || i > c.nextAccountNumber || c.treeCache.Contains(i) {
+			continue
+		}
+		if ok, _ := c.treeCache.ContainsOrAdd(i, c.newTree(i)); !ok {
+			prefetched++
+		}
+	}
+	return prefetched
+}
+
 // Returns slice of indexes of asset trees that were changned
 func (c *AssetTreeCache) GetChanges() []int64 {
 	c.mainLock.Lock()
 	c.changesLock.Lock()
 	defer c.mainLock.Unlock()
 	defer c.changesLock.Unlock()
-	for _, key := range c.treeCache.Keys() {
-		tree, _ := c.treeCache.Peek(key)
-		if tree.(bsmt.SparseMerkleTree).LatestVersion()-tree.(bsmt.SparseMerkleTree).RecentVersion() > 1 {
-			c.changes[key.(int64)] = true
-		}
-	}
 	ret := make([]int64, 0, len(c.changes))
 	for key := range c.changes {
 		ret = append(ret, key)
@@ -84,11 +106,35 @@ func (c *AssetTreeCache) CleanChanges() {
 	c.changesLock.Unlock()
 }
 
-// Internal method to that marks if changes happend to tree eviced from LRU
+// Internal method to count the trees evicted from LRU
 func (c *AssetTreeCache) onDelete(k, v interface{}) {
+	assetTreeCacheEvictionMetrics.Inc()
+}
+
+func (c *AssetTreeCache) markChanged(i int64) {
 	c.changesLock.Lock()
-	if v.(bsmt.SparseMerkleTree).LatestVersion()-v.(bsmt.SparseMerkleTree).RecentVersion() > 1 {
-		c.changes[k.(int64)] = true
-	}
+	c.changes[i] = true
 	c.changesLock.Unlock()
 }
+
+func (c *AssetTreeCache) newTree(i int64) bsmt.SparseMerkleTree {
+	return &trackedAssetTree{SparseMerkleTree: c.initFunction(i, c.blockNumber), index: i, cache: c}
+}
+
+// trackedAssetTree marks the asset tree as changed once its leaves are set. The versions
+// can't tell the changed trees once older versions are retained by the retention policy.
+type trackedAssetTree struct {
+	bsmt.SparseMerkleTree
+	index int64
+	cache *AssetTreeCache
+}
+
+func (t *trackedAssetTree) Set(key uint64, val []byte) error {
+	t.cache.markChanged(t.index)
+	return t.SparseMerkleTree.Set(key, val)
+}
+
+func (t *trackedAssetTree) MultiSet(items []bsmt.Item) error {
+	t.cache.markChanged(t.index)
+	return t.SparseMerkleTree.MultiSet(items)
+}
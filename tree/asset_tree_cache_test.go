@@ -0,0 +1,37 @@
+package tree
+
+import (
+	"testing"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+)
+
+func TestAssetTreeCachePrefetch(t *testing.T) {
+	initialized := 0
+	cache := NewLazyTreeCache(3, 9, 0, func(index, block int64) bsmt.SparseMerkleTree {
+		initialized++
+		tree, err := NewMemAccountAssetTree()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tree
+	})
+
+	// out of range indexes are skipped, and the cache is not filled over its size
+	if n := cache.Prefetch([]int64{-1, 1, 1, 10, 2, 3, 4}); n != 3 {
+		t.Fatalf("expected 3 prefetched trees, got %d", n)
+	}
+	if initialized != 3 {
+		t.Fatalf("expected 3 initialized trees, got %d", initialized)
+	}
+	// cached trees are not initialized again
+	for _, i := range []int64{1, 2, 3} {
+		cache.Get(i)
+	}
+	if initialized != 3 {
+		t.Fatalf("expected cached trees, got %d initialized trees", initialized)
+	}
+	if n := cache.Prefetch([]int64{5}); n != 0 {
+		t.Fatalf("expected no prefetched tree in a full cache, got %d", n)
+	}
+}
@@ -0,0 +1,120 @@
+package tree
+
+import (
+	"encoding/binary"
+	"errors"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database"
+)
+
+const (
+	AssetVersionPrefix = "asset_version:"
+)
+
+// assetVersionIndex persists the height each version of the asset trees is committed at.
+// The asset trees are versioned by their own commits, the index maps the heights kept
+// by the retention policy and the rollback heights to the versions of each asset tree.
+// The versions committed before the index existed are taken as committed at the lowest
+// height, the entries below the pruned version of a tree are removed.
+type assetVersionIndex struct {
+	db database.TreeDB
+}
+
+func newAssetVersionIndex(ctx *Context) *assetVersionIndex {
+	if ctx.Driver == MemoryDB {
+		// memory trees are always reloaded
+		return nil
+	}
+	return &assetVersionIndex{db: SetNamespace(ctx, AssetVersionPrefix)}
+}
+
+// commit records the next version of the asset tree at the height, it must be called
+// before the asset tree is committed. The returned pruned version is the version of
+// the asset tree at the pruned height, or the latest version if retain is false.
+func (x *assetVersionIndex) commit(index int64, asset bsmt.SparseMerkleTree, height, prunedHeight int64, retain bool) (bsmt.Version, error) {
+	recent, latest := asset.RecentVersion(), asset.LatestVersion()
+	prunedVersion := latest
+	if retain {
+		var err error
+		prunedVersion, err = x.versionAt(index, recent, latest, prunedHeight)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	batch := x.db.NewBatch()
+	err := batch.Set(assetVersionKey(index, latest+1), encodeHeight(height))
+	if err != nil {
+		return 0, err
+	}
+	for version := recent + 1; version <= prunedVersion; version++ {
+		err = batch.Delete(assetVersionKey(index, version))
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = batch.Write()
+	if err != nil {
+		return 0, err
+	}
+	return prunedVersion, nil
+}
+
+// record records the version of the asset tree at the height.
+func (x *assetVersionIndex) record(index int64, version bsmt.Version, height int64) error {
+	return x.db.Set(assetVersionKey(index, version), encodeHeight(height))
+}
+
+// rollbackVersion returns the version of the asset tree at the height, the recent
+// version is returned if the versions above the height are pruned.
+func (x *assetVersionIndex) rollbackVersion(index int64, asset bsmt.SparseMerkleTree, height int64) (bsmt.Version, error) {
+	version := asset.LatestVersion()
+	for ; version > asset.RecentVersion(); version-- {
+		committedAt, ok, err := x.height(index, version)
+		if err != nil {
+			return 0, err
+		}
+		if !ok || committedAt <= height {
+			break
+		}
+	}
+	return version, nil
+}
+
+// versionAt returns the latest version between from and latest committed at or below the height.
+func (x *assetVersionIndex) versionAt(index int64, from, latest bsmt.Version, height int64) (bsmt.Version, error) {
+	version := from
+	for next := from + 1; next <= latest; next++ {
+		committedAt, ok, err := x.height(index, next)
+		if err != nil {
+			return 0, err
+		}
+		if ok && committedAt > height {
+			break
+		}
+		version = next
+	}
+	return version, nil
+}
+
+func (x *assetVersionIndex) height(index int64, version bsmt.Version) (int64, bool, error) {
+	buf, err := x.db.Get(assetVersionKey(index, version))
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if len(buf) != 8 {
+		return 0, false, errors.New("invalid asset version index")
+	}
+	return int64(binary.BigEndian.Uint64(buf)), true, nil
+}
+
+func assetVersionKey(index int64, version bsmt.Version) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf, uint64(index))
+	binary.BigEndian.PutUint64(buf[8:], uint64(version))
+	return buf
+}
@@ -0,0 +1,169 @@
+package tree
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/bnb-chain/zkbnb-smt/database"
+)
+
+const (
+	AssetDirtyPrefix = "asset_dirty:"
+)
+
+var (
+	dirtyLatestKey = []byte("latest")
+	dirtyOldestKey = []byte("oldest")
+	dirtyHeightKey = []byte("h:")
+)
+
+// assetDirtyMarker persists the asset trees committed at each height, so that only
+// the asset trees committed above the height a service restarts from have to be
+// checked for rollback. The markers of the heights that can not be rolled back to
+// any more are removed.
+type assetDirtyMarker struct {
+	db database.TreeDB
+}
+
+func newAssetDirtyMarker(ctx *Context) *assetDirtyMarker {
+	if ctx.Driver == MemoryDB {
+		// memory trees are always reloaded
+		return nil
+	}
+	return &assetDirtyMarker{db: SetNamespace(ctx, AssetDirtyPrefix)}
+}
+
+// mark records the asset trees committed at the height, it must be called before
+// the asset trees are committed.
+func (m *assetDirtyMarker) mark(height int64, indexes []int64) error {
+	buf := make([]byte, 8*len(indexes))
+	for i, index := range indexes {
+		binary.BigEndian.PutUint64(buf[8*i:], uint64(index))
+	}
+	batch := m.db.NewBatch()
+	err := batch.Set(dirtyKey(height), buf)
+	if err != nil {
+		return err
+	}
+	err = batch.Set(dirtyLatestKey, encodeHeight(height))
+	if err != nil {
+		return err
+	}
+	if _, err = m.getHeight(dirtyOldestKey); errors.Is(err, database.ErrDatabaseNotFound) {
+		err = batch.Set(dirtyOldestKey, encodeHeight(height))
+	}
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// prune removes the markers up to the height.
+func (m *assetDirtyMarker) prune(height int64) error {
+	oldest, err := m.getHeight(dirtyOldestKey)
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		return nil
+	}
+	if err != nil || oldest > height {
+		return err
+	}
+	batch := m.db.NewBatch()
+	for h := oldest; h <= height; h++ {
+		err = batch.Delete(dirtyKey(h))
+		if err != nil {
+			return err
+		}
+	}
+	err = batch.Set(dirtyOldestKey, encodeHeight(height+1))
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// dirtyAbove returns the asset trees committed above the height, ok is false if
+// there are no markers, e.g. the trees are committed by an old version.
+func (m *assetDirtyMarker) dirtyAbove(height int64) (indexes []int64, ok bool, err error) {
+	latest, err := m.getHeight(dirtyLatestKey)
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	oldest, err := m.getHeight(dirtyOldestKey)
+	if err != nil {
+		return nil, false, err
+	}
+	if oldest > height+1 {
+		// the markers right above the height are pruned
+		return nil, false, nil
+	}
+	seen := make(map[int64]bool)
+	for h := height + 1; h <= latest; h++ {
+		buf, err := m.db.Get(dirtyKey(h))
+		if errors.Is(err, database.ErrDatabaseNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		for i := 0; i+8 <= len(buf); i += 8 {
+			index := int64(binary.BigEndian.Uint64(buf[i:]))
+			if !seen[index] {
+				seen[index] = true
+				indexes = append(indexes, index)
+			}
+		}
+	}
+	return indexes, true, nil
+}
+
+// reset removes the markers above the height once the asset trees are rolled back to it.
+func (m *assetDirtyMarker) reset(height int64) error {
+	latest, err := m.getHeight(dirtyLatestKey)
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		latest = height
+	} else if err != nil {
+		return err
+	}
+	batch := m.db.NewBatch()
+	for h := height + 1; h <= latest; h++ {
+		err = batch.Delete(dirtyKey(h))
+		if err != nil {
+			return err
+		}
+	}
+	err = batch.Set(dirtyLatestKey, encodeHeight(height))
+	if err != nil {
+		return err
+	}
+	if _, err = m.getHeight(dirtyOldestKey); errors.Is(err, database.ErrDatabaseNotFound) {
+		err = batch.Set(dirtyOldestKey, encodeHeight(height+1))
+	}
+	if err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (m *assetDirtyMarker) getHeight(key []byte) (int64, error) {
+	buf, err := m.db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(buf) != 8 {
+		return 0, errors.New("invalid asset dirty marker")
+	}
+	return int64(binary.BigEndian.Uint64(buf)), nil
+}
+
+func dirtyKey(height int64) []byte {
+	return append(append([]byte{}, dirtyHeightKey...), encodeHeight(height)...)
+}
+
+func encodeHeight(height int64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(height))
+	return buf
+}
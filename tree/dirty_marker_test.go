@@ -0,0 +1,35 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetDirtyMarker(t *testing.T) {
+	marker := newAssetDirtyMarker(newTestContext(t, PebbleDB))
+	_, ok, err := marker.dirtyAbove(0)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	for i, indexes := range [][]int64{{0, 1}, {1, 2}, {3}} {
+		require.NoError(t, marker.mark(int64(i+1), indexes))
+	}
+	check := func(height int64, expected []int64, expectedOk bool) {
+		t.Helper()
+		indexes, ok, err := marker.dirtyAbove(height)
+		require.NoError(t, err)
+		assert.Equal(t, expectedOk, ok, "dirty asset trees above %d", height)
+		assert.Equal(t, expected, indexes, "dirty asset trees above %d", height)
+	}
+	check(1, []int64{1, 2, 3}, true)
+	check(3, nil, true)
+
+	require.NoError(t, marker.prune(1))
+	check(0, nil, false)
+	check(1, []int64{1, 2, 3}, true)
+
+	require.NoError(t, marker.reset(2))
+	check(1, []int64{1, 2}, true)
+	check(2, nil, true)
+}
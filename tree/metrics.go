@@ -0,0 +1,43 @@
+package tree
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var commitLatencyMetrics = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "zkbnb",
+	Name:      "tree_commit_latency",
+	Help:      "Tree commit latency in milliseconds.",
+	Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+}, []string{"tree"})
+
+var (
+	assetTreeCacheHitMetrics = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "zkbnb",
+		Name:      "asset_tree_cache_hit",
+		Help:      "Number of asset trees found in the cache.",
+	})
+	assetTreeCacheMissMetrics = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "zkbnb",
+		Name:      "asset_tree_cache_miss",
+		Help:      "Number of asset trees initialized on access.",
+	})
+	assetTreeCacheEvictionMetrics = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "zkbnb",
+		Name:      "asset_tree_cache_eviction",
+		Help:      "Number of asset trees evicted from the cache.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(commitLatencyMetrics)
+	prometheus.MustRegister(assetTreeCacheHitMetrics)
+	prometheus.MustRegister(assetTreeCacheMissMetrics)
+	prometheus.MustRegister(assetTreeCacheEvictionMetrics)
+}
+
+func observeCommitLatency(tree string, start time.Time) {
+	commitLatencyMetrics.WithLabelValues(tree).Observe(float64(time.Since(start).Milliseconds()))
+}
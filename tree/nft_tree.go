@@ -24,6 +24,13 @@ import (
 	"github.com/bnb-chain/zkbnb/dao/nft"
 )
 
+// NewNftTree opens the nft tree of the context without loading any leaves.
+func NewNftTree(ctx *Context, blockHeight int64) (bsmt.SparseMerkleTree, error) {
+	return bsmt.NewBASSparseMerkleTree(ctx.Hasher(),
+		SetNamespace(ctx, NFTPrefix), NftTreeHeight, NilNftNodeHash,
+		ctx.Options(blockHeight)...)
+}
+
 func InitNftTree(
 	nftHistoryModel nft.L2NftHistoryModel,
 	blockHeight int64,
@@ -31,9 +38,7 @@ func InitNftTree(
 ) (
 	nftTree bsmt.SparseMerkleTree, err error,
 ) {
-	nftTree, err = bsmt.NewBASSparseMerkleTree(ctx.Hasher(),
-		SetNamespace(ctx, NFTPrefix), NftTreeHeight, NilNftNodeHash,
-		ctx.Options(blockHeight)...)
+	nftTree, err = NewNftTree(ctx, blockHeight)
 	if err != nil {
 		logx.Errorf("unable to create tree from db: %s", err.Error())
 		return nil, err
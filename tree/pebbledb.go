@@ -0,0 +1,199 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+
+	"github.com/bnb-chain/zkbnb-smt/database"
+)
+
+var (
+	_ database.TreeDB  = (*PebbleDatabase)(nil)
+	_ database.Batcher = (*pebbleBatch)(nil)
+)
+
+const (
+	// minPebbleCache is the minimum amount of memory in megabytes to allocate to pebble
+	// block caching.
+	minPebbleCache = 16
+
+	// minPebbleHandles is the minimum number of files handles to allocate to the open
+	// database files.
+	minPebbleHandles = 16
+
+	// minPebbleMemTableSize is the minimum size in megabytes of a single memtable.
+	minPebbleMemTableSize = 4
+
+	pebbleLevels = 7
+)
+
+// PebbleDatabase is a TreeDB backed by pebble. Keys are laid out in the same way as
+// the leveldb driver, so that a leveldb database can be migrated by copying keys.
+type PebbleDatabase struct {
+	namespace []byte
+	db        *pebble.DB
+}
+
+// NewPebbleDB opens the pebble database configured by the option.
+func NewPebbleDB(option *PebbleDBOption, readonly bool) (*PebbleDatabase, error) {
+	cache := option.Cache
+	if cache < minPebbleCache {
+		cache = minPebbleCache
+	}
+	handles := option.Handles
+	if handles < minPebbleHandles {
+		handles = minPebbleHandles
+	}
+	// The memtable takes a quarter of the cache by default, as the leveldb write buffer does.
+	memTableSize := option.MemTableSize
+	if memTableSize <= 0 {
+		memTableSize = cache / 4
+	}
+	if memTableSize < minPebbleMemTableSize {
+		memTableSize = minPebbleMemTableSize
+	}
+	compactions := option.MaxConcurrentCompactions
+	if compactions <= 0 {
+		compactions = runtime.NumCPU()
+	}
+
+	blockCache := pebble.NewCache(int64(cache) * 1024 * 1024)
+	defer blockCache.Unref()
+	opts := &pebble.Options{
+		Cache:                       blockCache,
+		MaxOpenFiles:                handles,
+		MemTableSize:                memTableSize * 1024 * 1024,
+		MemTableStopWritesThreshold: 2,
+		MaxConcurrentCompactions:    func() int { return compactions },
+		ReadOnly:                    readonly,
+	}
+	// Bloom filters on every level, as leveldb is configured by the smt drivers.
+	opts.Levels = make([]pebble.LevelOptions, pebbleLevels)
+	for i := range opts.Levels {
+		opts.Levels[i].FilterPolicy = bloom.FilterPolicy(10)
+	}
+	opts.EnsureDefaults()
+	db, err := pebble.Open(option.File, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &PebbleDatabase{db: db}, nil
+}
+
+// WrapPebbleWithNamespace returns a view of the pebble database with all the keys
+// prefixed by the namespace.
+func WrapPebbleWithNamespace(db *PebbleDatabase, namespace string) *PebbleDatabase {
+	return &PebbleDatabase{
+		namespace: []byte(namespace),
+		db:        db.db,
+	}
+}
+
+// wrapPebbleKey returns a wrapper key with namespace.
+func wrapPebbleKey(namespace, key []byte) []byte {
+	if len(namespace) > 0 {
+		return bytes.Join([][]byte{namespace, key}, []byte(":"))
+	}
+	return key
+}
+
+// Close flushes any pending data to disk and closes
+// all io accesses to the underlying key-value store.
+func (db *PebbleDatabase) Close() error {
+	return db.db.Close()
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *PebbleDatabase) Has(key []byte) (bool, error) {
+	_, closer, err := db.db.Get(wrapPebbleKey(db.namespace, key))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, closer.Close()
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *PebbleDatabase) Get(key []byte) ([]byte, error) {
+	dat, closer, err := db.db.Get(wrapPebbleKey(db.namespace, key))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, database.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]byte, len(dat))
+	copy(ret, dat)
+	return ret, closer.Close()
+}
+
+// Set inserts the given value into the key-value store.
+func (db *PebbleDatabase) Set(key []byte, value []byte) error {
+	return db.db.Set(wrapPebbleKey(db.namespace, key), value, pebble.NoSync)
+}
+
+// Delete removes the key from the key-value store.
+func (db *PebbleDatabase) Delete(key []byte) error {
+	return db.db.Delete(wrapPebbleKey(db.namespace, key), pebble.NoSync)
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to its host
+// database until a final write is called.
+func (db *PebbleDatabase) NewBatch() database.Batcher {
+	return &pebbleBatch{
+		db:        db.db,
+		namespace: db.namespace,
+		b:         db.db.NewBatch(),
+	}
+}
+
+// pebbleBatch is a write-only pebble batch that commits changes to its host database
+// when Write is called. A batch cannot be used concurrently.
+type pebbleBatch struct {
+	namespace []byte
+	db        *pebble.DB
+	b         *pebble.Batch
+	size      int
+}
+
+// Set inserts the given value into the batch for later committing.
+func (b *pebbleBatch) Set(key, value []byte) error {
+	err := b.b.Set(wrapPebbleKey(b.namespace, key), value, nil)
+	if err != nil {
+		return err
+	}
+	b.size += len(value)
+	return nil
+}
+
+// Delete inserts a key removal into the batch for later committing.
+func (b *pebbleBatch) Delete(key []byte) error {
+	err := b.b.Delete(wrapPebbleKey(b.namespace, key), nil)
+	if err != nil {
+		return err
+	}
+	b.size += len(key)
+	return nil
+}
+
+// Write flushes any accumulated data to disk.
+func (b *pebbleBatch) Write() error {
+	return b.b.Commit(pebble.NoSync)
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *pebbleBatch) ValueSize() int {
+	return b.size
+}
+
+// Reset resets the batch for reuse.
+func (b *pebbleBatch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
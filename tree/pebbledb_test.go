@@ -0,0 +1,109 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database"
+	"github.com/bnb-chain/zkbnb-smt/database/dbtest"
+)
+
+func TestPebbleDatabaseSuite(t *testing.T) {
+	dbtest.TestDatabaseSuite(t, func() database.TreeDB {
+		db, err := NewPebbleDB(&PebbleDBOption{File: t.TempDir()}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
+
+func TestPebbleTreeRoot(t *testing.T) {
+	memCtx := newTestContext(t, MemoryDB)
+	pebbleCtx := newTestContext(t, PebbleDB)
+
+	memTree, err := NewAccountAssetTree(memCtx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pebbleTree, err := NewAccountAssetTree(pebbleCtx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for version := 1; version <= 3; version++ {
+		for i := 0; i < 16; i++ {
+			leaf, err := ComputeAccountAssetLeafHash(fmt.Sprint(version*100+i), "0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = memTree.Set(uint64(i*version), leaf); err != nil {
+				t.Fatal(err)
+			}
+			if err = pebbleTree.Set(uint64(i*version), leaf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err = memTree.Commit(nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = pebbleTree.Commit(nil); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(memTree.Root(), pebbleTree.Root()) {
+			t.Fatalf("root mismatch at version %d", version)
+		}
+	}
+
+	// reopen from disk
+	reopened, err := NewAccountAssetTree(pebbleCtx, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.LatestVersion() != bsmt.Version(3) || !bytes.Equal(reopened.Root(), memTree.Root()) {
+		t.Fatal("reopened tree mismatch")
+	}
+}
+
+// BenchmarkCommitTree replays the committer pattern of setting the leaves touched
+// by a block and committing the tree, on each persistent driver.
+func BenchmarkCommitTree(b *testing.B) {
+	for _, driver := range []Driver{LevelDB, PebbleDB} {
+		b.Run(string(driver), func(b *testing.B) {
+			ctx := newTestContext(b, driver)
+			accountTree, err := NewAccountTree(ctx, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			leaf := EmptyAccountNodeHash()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < 64; j++ {
+					if err = accountTree.Set(uint64(i*64+j), leaf); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if _, err = accountTree.Commit(nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func newTestContext(tb testing.TB, driver Driver) *Context {
+	dir := tb.TempDir()
+	ctx, err := NewContext("test", driver, false, 0,
+		&LevelDBOption{File: dir}, &PebbleDBOption{File: dir}, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err = SetupTreeDB(ctx); err != nil {
+		tb.Fatal(err)
+	}
+	if ctx.TreeDB != nil && driver != MemoryDB {
+		tb.Cleanup(func() { _ = ctx.TreeDB.Close() })
+	}
+	return ctx
+}
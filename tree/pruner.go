@@ -0,0 +1,213 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database"
+)
+
+var (
+	errPrunerStopped = errors.New("pruner is stopped")
+)
+
+// PruneStats reports the tree nodes holding versions older than the pruned
+// version of their tree, and the storage taken by those versions.
+type PruneStats struct {
+	Trees            int64
+	Nodes            int64
+	PrunedNodes      int64
+	PrunedVersions   int64
+	ReclaimableBytes int64
+}
+
+func (s *PruneStats) add(o *PruneStats) {
+	s.Trees += o.Trees
+	s.Nodes += o.Nodes
+	s.PrunedNodes += o.PrunedNodes
+	s.PrunedVersions += o.PrunedVersions
+	s.ReclaimableBytes += o.ReclaimableBytes
+}
+
+// Pruner drops the versions older than the pruned version of each tree from the
+// stored nodes. Commit only prunes the nodes written by the commit, so the nodes
+// that are not updated any more keep their old versions until they are pruned here.
+// The pruned version of a tree is the one recorded by its last commit, the versions
+// below it can not be read or rolled back to any more.
+type Pruner struct {
+	ctx         *Context
+	accountNums func() int64
+	quit        chan struct{}
+	done        chan struct{}
+}
+
+func NewPruner(ctx *Context, accountNums func() int64) *Pruner {
+	return &Pruner{
+		ctx:         ctx,
+		accountNums: accountNums,
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (p *Pruner) Start(interval time.Duration) {
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.quit:
+				return
+			case <-ticker.C:
+				start := time.Now()
+				stats, err := p.Prune(false)
+				if err == errPrunerStopped {
+					return
+				}
+				if err != nil {
+					logx.Errorf("unable to prune trees: %s", err.Error())
+					continue
+				}
+				logx.Infof("pruned %d versions from %d nodes of %d trees, reclaimed %d bytes, cost: %v",
+					stats.PrunedVersions, stats.PrunedNodes, stats.Trees, stats.ReclaimableBytes, time.Since(start))
+			}
+		}
+	}()
+}
+
+func (p *Pruner) Stop() {
+	close(p.quit)
+	<-p.done
+}
+
+// Prune prunes the account, asset and nft trees, nothing is written in dry run mode.
+func (p *Pruner) Prune(dryRun bool) (*PruneStats, error) {
+	stats := &PruneStats{}
+	treeStats, err := p.pruneTree(AccountPrefix, AccountTreeHeight, NilAccountNodeHash, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	stats.add(treeStats)
+	for i := int64(0); i < p.accountNums(); i++ {
+		treeStats, err = p.pruneTree(accountAssetNamespace(i), AssetTreeHeight, NilAccountAssetNodeHash, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		stats.add(treeStats)
+	}
+	treeStats, err = p.pruneTree(NFTPrefix, NftTreeHeight, NilNftNodeHash, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	stats.add(treeStats)
+	return stats, nil
+}
+
+func (p *Pruner) pruneTree(namespace string, maxDepth uint8, nilHash []byte, dryRun bool) (*PruneStats, error) {
+	db := SetNamespace(p.ctx, namespace)
+	smt, err := bsmt.NewBASSparseMerkleTree(p.ctx.Hasher(), db, maxDepth, nilHash, p.ctx.Options(0)...)
+	if err != nil {
+		return nil, err
+	}
+	stats := &PruneStats{}
+	if smt.IsEmpty() || smt.RecentVersion() == 0 {
+		return stats, nil
+	}
+	stats.Trees = 1
+	err = p.pruneNode(db, 0, 0, maxDepth, smt.RecentVersion(), dryRun, stats)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (p *Pruner) pruneNode(db database.TreeDB, depth uint8, path uint64, maxDepth uint8,
+	prunedVersion bsmt.Version, dryRun bool, stats *PruneStats) error {
+	select {
+	case <-p.quit:
+		return errPrunerStopped
+	default:
+	}
+
+	// The node is read and written back without any commit in between.
+	p.ctx.commitLock.Lock()
+	key := storageNodeKey(depth, path)
+	buf, err := db.Get(key)
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		p.ctx.commitLock.Unlock()
+		return nil
+	}
+	if err != nil {
+		p.ctx.commitLock.Unlock()
+		return err
+	}
+	node := &bsmt.StorageTreeNode{}
+	err = rlp.DecodeBytes(buf, node)
+	if err != nil {
+		p.ctx.commitLock.Unlock()
+		return err
+	}
+	stats.Nodes++
+	pruned := pruneVersions(&node.Versions, prunedVersion)
+	for _, child := range node.Children {
+		if child != nil {
+			pruned += pruneVersions(&child.Versions, prunedVersion)
+		}
+	}
+	if pruned > 0 {
+		newBuf, err := rlp.EncodeToBytes(node)
+		if err != nil {
+			p.ctx.commitLock.Unlock()
+			return err
+		}
+		if !dryRun {
+			err = db.Set(key, newBuf)
+			if err != nil {
+				p.ctx.commitLock.Unlock()
+				return err
+			}
+		}
+		stats.PrunedNodes++
+		stats.PrunedVersions += int64(pruned)
+		stats.ReclaimableBytes += int64(len(buf) - len(newBuf))
+	}
+	p.ctx.commitLock.Unlock()
+
+	if depth >= maxDepth {
+		return nil
+	}
+	for i, child := range node.Children {
+		if child == nil || len(child.Versions) == 0 {
+			continue
+		}
+		err = p.pruneNode(db, depth+4, path<<4|uint64(i), maxDepth, prunedVersion, dryRun, stats)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneVersions prunes the versions in the same way as Commit does, and returns
+// the number of the dropped versions.
+func pruneVersions(versions *[]*bsmt.VersionInfo, prunedVersion bsmt.Version) int {
+	node := &bsmt.TreeNode{Versions: *versions}
+	node.Prune(prunedVersion)
+	pruned := len(*versions) - len(node.Versions)
+	*versions = node.Versions
+	return pruned
+}
+
+// storageNodeKey is the key of the tree node in the smt storage.
+func storageNodeKey(depth uint8, path uint64) []byte {
+	pathBuf := make([]byte, 8)
+	binary.BigEndian.PutUint64(pathBuf, path)
+	return bytes.Join([][]byte{[]byte(`t`), {depth}, pathBuf}, []byte(`:`))
+}
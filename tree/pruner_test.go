@@ -0,0 +1,272 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+)
+
+func TestPrunerPrunesColdNodes(t *testing.T) {
+	ctx := newTestContext(t, PebbleDB)
+	assetTree, err := NewAccountAssetTree(ctx, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := func(i int) []byte {
+		hashVal, err := ComputeAccountAssetLeafHash(fmt.Sprint(i), "0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hashVal
+	}
+	// asset 0 is updated by the first versions only
+	for version := 1; version <= 4; version++ {
+		if err = assetTree.Set(0, leaf(version)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = assetTree.Commit(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prunedVersion := bsmt.Version(4)
+	if err = assetTree.Set(1<<12, leaf(5)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = assetTree.Commit(&prunedVersion); err != nil {
+		t.Fatal(err)
+	}
+	root := assetTree.Root()
+
+	pruner := NewPruner(ctx, func() int64 { return 1 })
+	stats, err := pruner.Prune(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.PrunedVersions == 0 || stats.ReclaimableBytes <= 0 {
+		t.Fatalf("nothing to prune: %+v", stats)
+	}
+	stats, err = pruner.Prune(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.PrunedVersions == 0 {
+		t.Fatalf("nothing pruned: %+v", stats)
+	}
+	stats, err = pruner.Prune(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.PrunedVersions != 0 {
+		t.Fatalf("versions left after prune: %+v", stats)
+	}
+
+	reopened, err := NewAccountAssetTree(ctx, 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reopened.Root(), root) {
+		t.Fatal("root mismatch after prune")
+	}
+	for _, version := range []bsmt.Version{4, 5} {
+		val, err := reopened.Get(0, &version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(val, leaf(4)) {
+			t.Fatalf("leaf mismatch at version %d", version)
+		}
+	}
+}
+
+func TestPrunedVersion(t *testing.T) {
+	ctx := &Context{}
+	verified := func() (int64, error) { return 7, nil }
+	check := func(expected uint64) {
+		t.Helper()
+		version, err := ctx.PrunedVersion(10, verified, 9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != expected {
+			t.Fatalf("unexpected pruned version %d, expected %d", version, expected)
+		}
+	}
+	check(9)
+	ctx.SetRetention(RetentionOption{Policy: RetainRecent, KeepVersions: 3})
+	check(8)
+	ctx.SetRetention(RetentionOption{Policy: RetainVerified})
+	check(7)
+}
+
+// commitTestBlocks commits the blocks from the height on, asset tree 0 is updated by
+// every block and asset tree 1 by the first block only. The blocks are verified two
+// blocks behind.
+func commitTestBlocks(t *testing.T, ctx *Context, from, to int64, accountTree bsmt.SparseMerkleTree,
+	assetTrees *AssetTreeCache, nftTree bsmt.SparseMerkleTree) map[int64][]byte {
+	roots := make(map[int64][]byte)
+	for height := from; height <= to; height++ {
+		leaf, err := ComputeAccountAssetLeafHash(fmt.Sprint(height), "0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = assetTrees.Get(0).Set(0, leaf); err != nil {
+			t.Fatal(err)
+		}
+		if height == 1 {
+			if err = assetTrees.Get(1).Set(0, leaf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = accountTree.Set(0, assetTrees.Get(0).Root()); err != nil {
+			t.Fatal(err)
+		}
+		roots[height] = assetTrees.Get(0).Root()
+		verifiedHeight := func() (int64, error) { return height - 2, nil }
+		prunedVersion, err := ctx.PrunedVersion(height, verifiedHeight, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = CommitTrees(ctx, prunedVersion, accountTree, assetTrees, nftTree); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return roots
+}
+
+func newTestTrees(t *testing.T, ctx *Context) (bsmt.SparseMerkleTree, *AssetTreeCache, bsmt.SparseMerkleTree) {
+	accountTree, err := NewAccountTree(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nftTree, err := NewNftTree(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetTrees := NewLazyTreeCache(10, 1, 0, func(index, block int64) bsmt.SparseMerkleTree {
+		assetTree, err := NewAccountAssetTree(ctx, index, block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return assetTree
+	})
+	return accountTree, assetTrees, nftTree
+}
+
+func TestCommitTreesAssetRetention(t *testing.T) {
+	ctx := newTestContext(t, PebbleDB)
+	ctx.SetRetention(RetentionOption{Policy: RetainRecent, KeepVersions: 3})
+	accountTree, assetTrees, nftTree := newTestTrees(t, ctx)
+	roots := commitTestBlocks(t, ctx, 1, 6, accountTree, assetTrees, nftTree)
+
+	// the heights from 4 on are kept, asset tree 0 is committed once per block
+	if accountTree.RecentVersion() != 4 || assetTrees.Get(0).RecentVersion() != 4 {
+		t.Fatalf("unexpected pruned versions, account tree: %d, asset tree: %d",
+			accountTree.RecentVersion(), assetTrees.Get(0).RecentVersion())
+	}
+	versioned := NewVersionedAccountAssetTree(ctx, 0)
+	for height := int64(4); height <= 6; height++ {
+		proof, err := versioned.GetProof(0, bsmt.Version(height))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(proof.Root, roots[height]) {
+			t.Fatalf("asset root mismatch at height %d", height)
+		}
+	}
+	// asset tree 1 is not committed after block 1, its only version stays readable
+	if assetTrees.Get(1).LatestVersion() != 1 || assetTrees.Get(1).RecentVersion() != 0 {
+		t.Fatalf("unexpected versions of asset tree 1: %d, %d",
+			assetTrees.Get(1).LatestVersion(), assetTrees.Get(1).RecentVersion())
+	}
+
+	// without a retention policy the asset trees only keep the previous version
+	ctx = newTestContext(t, PebbleDB)
+	accountTree, assetTrees, nftTree = newTestTrees(t, ctx)
+	commitTestBlocks(t, ctx, 1, 6, accountTree, assetTrees, nftTree)
+	if assetTrees.Get(0).RecentVersion() != 5 {
+		t.Fatalf("unexpected pruned version of asset tree: %d", assetTrees.Get(0).RecentVersion())
+	}
+}
+
+func TestRollBackTreesAssetVersion(t *testing.T) {
+	ctx := newTestContext(t, PebbleDB)
+	ctx.SetRetention(RetentionOption{Policy: RetainRecent, KeepVersions: 10})
+	accountTree, assetTrees, nftTree := newTestTrees(t, ctx)
+	commitTestBlocks(t, ctx, 1, 3, accountTree, assetTrees, nftTree)
+	root := assetTrees.Get(1).Root()
+
+	// the asset trees are rolled back to their versions at the height, not to the pruned version
+	leaf, err := ComputeAccountAssetLeafHash("100", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = assetTrees.Get(1).Set(1, leaf); err != nil {
+		t.Fatal(err)
+	}
+	if err = RollBackTrees(ctx, 3, accountTree, assetTrees, nftTree); err != nil {
+		t.Fatal(err)
+	}
+	if assetTrees.Get(1).LatestVersion() != 1 || !bytes.Equal(assetTrees.Get(1).Root(), root) {
+		t.Fatalf("unexpected asset tree 1 after rollback, version: %d", assetTrees.Get(1).LatestVersion())
+	}
+	if len(assetTrees.GetChanges()) != 0 {
+		t.Fatal("changes left after rollback")
+	}
+}
+
+// TestPrunerStorageLayout pins the storage layout of the smt library the pruner and the
+// versioned trees read and rewrite: the node keys and the rlp encoded storage nodes.
+func TestPrunerStorageLayout(t *testing.T) {
+	ctx := newTestContext(t, PebbleDB)
+	accountTree, err := NewAccountTree(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := uint64(0x12345678)
+	for version := 1; version <= 2; version++ {
+		leaf, err := ComputeAccountAssetLeafHash(fmt.Sprint(version), "0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = accountTree.Set(key, leaf); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = accountTree.Commit(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db := SetNamespace(ctx, AccountPrefix)
+	for depth := uint8(0); depth < AccountTreeHeight; depth += 4 {
+		path := key >> (AccountTreeHeight - depth)
+		buf, err := db.Get(storageNodeKey(depth, path))
+		if err != nil {
+			t.Fatalf("node at depth %d not found: %v", depth, err)
+		}
+		node := &bsmt.StorageTreeNode{}
+		if err = rlp.DecodeBytes(buf, node); err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := rlp.EncodeToBytes(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, buf) {
+			t.Fatalf("node at depth %d is not encoded back to the same bytes", depth)
+		}
+		if node.Path != path {
+			t.Fatalf("unexpected path %x of node at depth %d", node.Path, depth)
+		}
+		child := node.Children[(key>>(AccountTreeHeight-depth-4))&0xf]
+		if child == nil || len(child.Versions) != 2 || child.Versions[1].Ver != 2 {
+			t.Fatalf("unexpected child versions of node at depth %d", depth)
+		}
+		if depth == 0 && (len(node.Versions) != 2 || !bytes.Equal(node.Versions[1].Hash, accountTree.Root())) {
+			t.Fatal("root node does not hold the root of the latest version")
+		}
+	}
+}
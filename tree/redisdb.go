@@ -0,0 +1,290 @@
+package tree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"hash/crc32"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/bnb-chain/zkbnb-smt/database"
+)
+
+var (
+	_ database.TreeDB  = (*RedisDatabase)(nil)
+	_ database.Batcher = (*redisBatch)(nil)
+)
+
+// defaultRedisPipelineSize is the maximum number of commands sent in one pipeline.
+const defaultRedisPipelineSize = 1000
+
+// RedisDatabase is a TreeDB backed by a redis cluster, a single redis instance, or
+// several standalone redis instances with the keys sharded by their hash. Keys are laid
+// out in the same way as the redis driver of the smt, so a single instance keeps its data.
+//
+// The shard of a key depends on the number of shards, so the instances can not be
+// added or removed without moving the keys.
+type RedisDatabase struct {
+	namespace    []byte
+	shards       []redis.UniversalClient
+	pipelineSize int
+}
+
+// NewRedisDB connects to the redis instances configured by the option.
+func NewRedisDB(option *RedisDBOption) (*RedisDatabase, error) {
+	var shards []redis.UniversalClient
+	switch {
+	case len(option.ClusterAddr) > 0:
+		shards = append(shards, redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:              option.ClusterAddr,
+			Username:           option.Username,
+			Password:           option.Password,
+			MaxRedirects:       option.MaxRedirects,
+			ReadOnly:           option.ReadOnly,
+			RouteByLatency:     option.RouteByLatency,
+			RouteRandomly:      option.RouteRandomly,
+			MaxRetries:         option.MaxRetries,
+			MinRetryBackoff:    option.MinRetryBackoff,
+			MaxRetryBackoff:    option.MaxRetryBackoff,
+			DialTimeout:        option.DialTimeout,
+			ReadTimeout:        option.ReadTimeout,
+			WriteTimeout:       option.WriteTimeout,
+			PoolFIFO:           option.PoolFIFO,
+			PoolSize:           option.PoolSize,
+			MinIdleConns:       option.MinIdleConns,
+			MaxConnAge:         option.MaxConnAge,
+			PoolTimeout:        option.PoolTimeout,
+			IdleTimeout:        option.IdleTimeout,
+			IdleCheckFrequency: option.IdleCheckFrequency,
+		}))
+	case len(option.ShardAddr) > 0:
+		for _, addr := range option.ShardAddr {
+			shards = append(shards, newRedisClient(addr, option))
+		}
+	default:
+		shards = append(shards, newRedisClient(option.Addr, option))
+	}
+
+	db := &RedisDatabase{
+		shards:       shards,
+		pipelineSize: option.PipelineSize,
+	}
+	if db.pipelineSize <= 0 {
+		db.pipelineSize = defaultRedisPipelineSize
+	}
+	for _, shard := range shards {
+		ctx := context.Background()
+		if option.DialTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, option.DialTimeout)
+			defer cancel()
+		}
+		err := shard.Ping(ctx).Err()
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+func newRedisClient(addr string, option *RedisDBOption) redis.UniversalClient {
+	return redis.NewClient(&redis.Options{
+		Addr:               addr,
+		Username:           option.Username,
+		Password:           option.Password,
+		MaxRetries:         option.MaxRetries,
+		MinRetryBackoff:    option.MinRetryBackoff,
+		MaxRetryBackoff:    option.MaxRetryBackoff,
+		DialTimeout:        option.DialTimeout,
+		ReadTimeout:        option.ReadTimeout,
+		WriteTimeout:       option.WriteTimeout,
+		PoolFIFO:           option.PoolFIFO,
+		PoolSize:           option.PoolSize,
+		MinIdleConns:       option.MinIdleConns,
+		MaxConnAge:         option.MaxConnAge,
+		PoolTimeout:        option.PoolTimeout,
+		IdleTimeout:        option.IdleTimeout,
+		IdleCheckFrequency: option.IdleCheckFrequency,
+	})
+}
+
+// WrapRedisWithNamespace returns a view of the redis database with all the keys
+// prefixed by the namespace.
+func WrapRedisWithNamespace(db *RedisDatabase, namespace string) *RedisDatabase {
+	return &RedisDatabase{
+		namespace:    []byte(namespace),
+		shards:       db.shards,
+		pipelineSize: db.pipelineSize,
+	}
+}
+
+// wrapRedisKey returns a wrapper key with namespace.
+func wrapRedisKey(namespace, key []byte) string {
+	if len(namespace) > 0 {
+		return string(bytes.Join([][]byte{namespace, key}, []byte(":")))
+	}
+	return string(key)
+}
+
+// shard returns the index of the instance holding the key.
+func (db *RedisDatabase) shard(key string) int {
+	if len(db.shards) == 1 {
+		return 0
+	}
+	return int(crc32.ChecksumIEEE([]byte(key)) % uint32(len(db.shards)))
+}
+
+// Close closes the connections to all the instances.
+func (db *RedisDatabase) Close() error {
+	var closeErr error
+	for _, shard := range db.shards {
+		err := shard.Close()
+		if err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *RedisDatabase) Has(key []byte) (bool, error) {
+	k := wrapRedisKey(db.namespace, key)
+	n, err := db.shards[db.shard(k)].Exists(context.Background(), k).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *RedisDatabase) Get(key []byte) ([]byte, error) {
+	k := wrapRedisKey(db.namespace, key)
+	value, err := db.shards[db.shard(k)].Get(context.Background(), k).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, database.ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Set inserts the given value into the key-value store.
+func (db *RedisDatabase) Set(key []byte, value []byte) error {
+	k := wrapRedisKey(db.namespace, key)
+	return db.shards[db.shard(k)].Set(context.Background(), k, value, 0).Err()
+}
+
+// Delete removes the key from the key-value store.
+func (db *RedisDatabase) Delete(key []byte) error {
+	k := wrapRedisKey(db.namespace, key)
+	return db.shards[db.shard(k)].Del(context.Background(), k).Err()
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to its host
+// database until a final write is called.
+func (db *RedisDatabase) NewBatch() database.Batcher {
+	return &redisBatch{
+		db:     db,
+		writes: make([][]redisWrite, len(db.shards)),
+	}
+}
+
+type redisWrite struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+// redisBatch buffers the writes of every shard, they are sent in pipelines when Write
+// is called, the shards are written concurrently. A batch cannot be used concurrently.
+type redisBatch struct {
+	db     *RedisDatabase
+	writes [][]redisWrite
+	size   int
+}
+
+// Set inserts the given value into the batch for later committing.
+func (b *redisBatch) Set(key, value []byte) error {
+	k := wrapRedisKey(b.db.namespace, key)
+	shard := b.db.shard(k)
+	b.writes[shard] = append(b.writes[shard], redisWrite{key: k, value: append([]byte{}, value...)})
+	b.size += len(value)
+	return nil
+}
+
+// Delete inserts the key removal into the batch for later committing.
+func (b *redisBatch) Delete(key []byte) error {
+	k := wrapRedisKey(b.db.namespace, key)
+	shard := b.db.shard(k)
+	b.writes[shard] = append(b.writes[shard], redisWrite{key: k, delete: true})
+	b.size += len(key)
+	return nil
+}
+
+// Write flushes the accumulated writes to the instances.
+func (b *redisBatch) Write() error {
+	var (
+		wg       sync.WaitGroup
+		errLock  sync.Mutex
+		writeErr error
+	)
+	for i, writes := range b.writes {
+		if len(writes) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(shard redis.UniversalClient, writes []redisWrite) {
+			defer wg.Done()
+			err := b.writeShard(shard, writes)
+			if err != nil {
+				errLock.Lock()
+				if writeErr == nil {
+					writeErr = err
+				}
+				errLock.Unlock()
+			}
+		}(b.db.shards[i], writes)
+	}
+	wg.Wait()
+	return writeErr
+}
+
+func (b *redisBatch) writeShard(shard redis.UniversalClient, writes []redisWrite) error {
+	for start := 0; start < len(writes); start += b.db.pipelineSize {
+		end := start + b.db.pipelineSize
+		if end > len(writes) {
+			end = len(writes)
+		}
+		_, err := shard.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+			for _, write := range writes[start:end] {
+				if write.delete {
+					pipe.Del(context.Background(), write.key)
+					continue
+				}
+				pipe.Set(context.Background(), write.key, write.value, 0)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *redisBatch) ValueSize() int {
+	return b.size
+}
+
+// Reset resets the batch for reuse.
+func (b *redisBatch) Reset() {
+	for i := range b.writes {
+		b.writes[i] = b.writes[i][:0]
+	}
+	b.size = 0
+}
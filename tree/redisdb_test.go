@@ -0,0 +1,80 @@
+package tree
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/bnb-chain/zkbnb-smt/database"
+	"github.com/bnb-chain/zkbnb-smt/database/dbtest"
+)
+
+func newTestRedisShards(t *testing.T, n int) ([]*miniredis.Miniredis, *RedisDBOption) {
+	servers := make([]*miniredis.Miniredis, 0, n)
+	option := &RedisDBOption{PipelineSize: 8}
+	for i := 0; i < n; i++ {
+		server := miniredis.RunT(t)
+		servers = append(servers, server)
+		option.ShardAddr = append(option.ShardAddr, server.Addr())
+	}
+	return servers, option
+}
+
+func TestRedisDatabaseSuite(t *testing.T) {
+	dbtest.TestDatabaseSuite(t, func() database.TreeDB {
+		_, option := newTestRedisShards(t, 3)
+		db, err := NewRedisDB(option)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
+
+func TestRedisShardedBatch(t *testing.T) {
+	servers, option := newTestRedisShards(t, 3)
+	db, err := NewRedisDB(option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	view := WrapRedisWithNamespace(db, "test:account")
+
+	batch := view.NewBatch()
+	for i := 0; i < 100; i++ {
+		if err = batch.Set([]byte(fmt.Sprint(i)), []byte(fmt.Sprint(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = batch.Delete([]byte("0")); err != nil {
+		t.Fatal(err)
+	}
+	if err = batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for i, server := range servers {
+		keys := server.Keys()
+		if len(keys) == 0 {
+			t.Fatalf("no keys written to shard %d", i)
+		}
+		total += len(keys)
+	}
+	if total != 99 {
+		t.Fatalf("expected 99 keys, got %d", total)
+	}
+	if _, err = view.Get([]byte("0")); err != database.ErrDatabaseNotFound {
+		t.Fatalf("expected deleted key, got %v", err)
+	}
+	for i := 1; i < 100; i++ {
+		value, err := view.Get([]byte(fmt.Sprint(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(value) != fmt.Sprint(i) {
+			t.Fatalf("unexpected value of key %d: %s", i, value)
+		}
+	}
+}
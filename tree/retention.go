@@ -0,0 +1,116 @@
+package tree
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bnb-chain/zkbnb-smt/database"
+)
+
+const (
+	RetentionPrefix = "retention:"
+)
+
+var retentionPolicyKey = []byte(`policy`)
+
+type RetentionPolicy string
+
+const (
+	// RetainDefault keeps the versions chosen by the service.
+	RetainDefault RetentionPolicy = ""
+	// RetainRecent keeps the trees of the last KeepVersions heights.
+	RetainRecent RetentionPolicy = "recent"
+	// RetainVerified keeps the trees of every height from the last verified height on.
+	RetainVerified RetentionPolicy = "verified"
+)
+
+// The retention policy applies to the asset trees as well. They are versioned by their
+// own commits, so each asset tree keeps its versions committed at the kept heights and
+// the one it holds at the oldest kept height. Without a policy the asset trees only
+// keep their previous version.
+
+type RetentionOption struct {
+	//nolint:staticcheck
+	Policy RetentionPolicy `json:",optional"`
+	//nolint:staticcheck
+	KeepVersions int64 `json:",optional"`
+	// Interval of the background pruner, the pruner is disabled if it's not set.
+	//nolint:staticcheck
+	PruneInterval time.Duration `json:",optional"`
+}
+
+func (ctx *Context) SetRetention(retention RetentionOption) {
+	ctx.retention = retention
+}
+
+func (ctx *Context) Retention() RetentionOption {
+	return ctx.retention
+}
+
+// CommittedRetention returns the retention policy the trees of the context are committed
+// with, the readers of the trees rely on it to know the versions kept by the service.
+func CommittedRetention(ctx *Context) (RetentionPolicy, error) {
+	if ctx.Driver == MemoryDB {
+		return RetainDefault, nil
+	}
+	buf, err := SetNamespace(ctx, RetentionPrefix).Get(retentionPolicyKey)
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		return RetainDefault, nil
+	}
+	if err != nil {
+		return RetainDefault, err
+	}
+	return RetentionPolicy(buf), nil
+}
+
+// storeRetention stores the retention policy once the trees are committed with it.
+func (ctx *Context) storeRetention() error {
+	if ctx.retentionStored || ctx.Driver == MemoryDB {
+		return nil
+	}
+	err := SetNamespace(ctx, RetentionPrefix).Set(retentionPolicyKey, []byte(ctx.retention.Policy))
+	if err != nil {
+		return err
+	}
+	ctx.retentionStored = true
+	return nil
+}
+
+// PrunedVersion returns the oldest version of the account and nft trees to keep
+// once the trees are committed at the block height, the oldest height the asset
+// trees are kept at as well. The service default is used
+// if no retention policy is configured.
+func (ctx *Context) PrunedVersion(height int64, verifiedHeight func() (int64, error), serviceDefault int64) (uint64, error) {
+	switch ctx.retention.Policy {
+	case RetainRecent:
+		if ctx.retention.KeepVersions <= 0 || height < ctx.retention.KeepVersions {
+			return 0, nil
+		}
+		return uint64(height - ctx.retention.KeepVersions + 1), nil
+	case RetainVerified:
+		verified, err := verifiedHeight()
+		if err != nil {
+			return 0, err
+		}
+		return uint64(verified), nil
+	}
+	return uint64(serviceDefault), nil
+}
+
+// StartPruner starts the background pruner of the trees if a prune interval is configured,
+// accountNums returns the number of the asset trees.
+func (ctx *Context) StartPruner(accountNums func() int64) {
+	if ctx.retention.PruneInterval <= 0 || ctx.Driver == MemoryDB {
+		return
+	}
+	ctx.pruner = NewPruner(ctx, accountNums)
+	ctx.pruner.Start(ctx.retention.PruneInterval)
+}
+
+// StopPruner stops the background pruner, it must be called before the tree database is closed.
+func (ctx *Context) StopPruner() {
+	if ctx.pruner != nil {
+		ctx.pruner.Stop()
+		ctx.pruner = nil
+	}
+}
@@ -1,17 +1,16 @@
 package tree
 
 import (
-	"encoding/json"
 	"errors"
 	"hash"
 	"strings"
+	"sync"
 	"time"
 
 	bsmt "github.com/bnb-chain/zkbnb-smt"
 	"github.com/bnb-chain/zkbnb-smt/database"
 	"github.com/bnb-chain/zkbnb-smt/database/leveldb"
 	"github.com/bnb-chain/zkbnb-smt/database/memory"
-	"github.com/bnb-chain/zkbnb-smt/database/redis"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
 	"github.com/panjf2000/ants/v2"
 )
@@ -32,11 +31,34 @@ type LevelDBOption struct {
 	Handles int `json:",optional"`
 }
 
+type PebbleDBOption struct {
+	File string
+	// Block cache size in megabytes.
+	//nolint:staticcheck
+	Cache int `json:",optional"`
+	//nolint:staticcheck
+	Handles int `json:",optional"`
+	// Size of a single memtable in megabytes, a quarter of the cache by default.
+	//nolint:staticcheck
+	MemTableSize int `json:",optional"`
+	// Default is the number of CPUs.
+	//nolint:staticcheck
+	MaxConcurrentCompactions int `json:",optional"`
+}
+
 type RedisDBOption struct {
 	//nolint:staticcheck
 	ClusterAddr []string `json:",optional"`
 	//nolint:staticcheck
 	Addr string `json:",optional"`
+	// Standalone instances the keys are sharded to by their hash, when a cluster is not
+	// available. The instances can't be changed once the tree is written.
+	//nolint:staticcheck
+	ShardAddr []string `json:",optional"`
+	// Maximum number of commands sent in one pipeline when a batch is written.
+	// Default is 1000 commands.
+	//nolint:staticcheck
+	PipelineSize int `json:",optional"`
 	// Use the specified Username to authenticate the current connection
 	// with one of the connections defined in the ACL list when connecting
 	// to a Redis 6.0 instance, or greater, that is using the Redis ACL system.
@@ -133,34 +155,52 @@ type RedisDBOption struct {
 const (
 	MemoryDB Driver = "memorydb"
 	LevelDB  Driver = "leveldb"
+	PebbleDB Driver = "pebble"
 	RedisDB  Driver = "redis"
 )
 
 func SetupTreeDB(
 	context *Context,
+) error {
+	return setupTreeDB(context, false)
+}
+
+// SetupReadOnlyTreeDB opens the tree database without writing to it. The leveldb and
+// pebble databases are locked by the service writing them, so a copy of the database
+// has to be opened, the redis database can be shared.
+func SetupReadOnlyTreeDB(
+	context *Context,
+) error {
+	if context.Driver == MemoryDB {
+		return ErrUnsupportedDriver
+	}
+	return setupTreeDB(context, true)
+}
+
+func setupTreeDB(
+	context *Context,
+	readonly bool,
 ) error {
 	switch context.Driver {
 	case MemoryDB:
 		context.TreeDB = memory.NewMemoryDB()
 		return nil
 	case LevelDB:
-		db, err := leveldb.New(context.LevelDBOption.File, context.LevelDBOption.Cache, context.LevelDBOption.Handles, false)
+		db, err := leveldb.New(context.LevelDBOption.File, context.LevelDBOption.Cache, context.LevelDBOption.Handles, readonly)
 		if err != nil {
 			return err
 		}
 		context.TreeDB = db
 		return nil
-	case RedisDB:
-		bytes, err := json.Marshal(context.RedisDBOption)
+	case PebbleDB:
+		db, err := NewPebbleDB(context.PebbleDBOption, readonly)
 		if err != nil {
 			return err
 		}
-		redisOption := &redis.RedisConfig{}
-		err = json.Unmarshal(bytes, redisOption)
-		if err != nil {
-			return err
-		}
-		db, err := redis.New(redisOption)
+		context.TreeDB = db
+		return nil
+	case RedisDB:
+		db, err := NewRedisDB(context.RedisDBOption)
 		if err != nil {
 			return err
 		}
@@ -179,8 +219,10 @@ func SetNamespace(
 		return memory.NewMemoryDB()
 	case LevelDB:
 		return leveldb.WrapWithNamespace(context.TreeDB.(*leveldb.Database), strings.Join([]string{context.Name, namespace}, ":"))
+	case PebbleDB:
+		return WrapPebbleWithNamespace(context.TreeDB.(*PebbleDatabase), strings.Join([]string{context.Name, namespace}, ":"))
 	case RedisDB:
-		return redis.WrapWithNamespace(context.TreeDB.(*redis.Database), strings.Join([]string{context.Name, namespace}, ":"))
+		return WrapRedisWithNamespace(context.TreeDB.(*RedisDatabase), strings.Join([]string{context.Name, namespace}, ":"))
 	}
 	return context.TreeDB
 }
@@ -193,6 +235,7 @@ func NewContext(
 	name string, driver Driver,
 	reload bool, routineSize int,
 	levelDBOption *LevelDBOption,
+	pebbleDBOption *PebbleDBOption,
 	redisDBOption *RedisDBOption) (*Context, error) {
 
 	if routineSize <= 0 {
@@ -206,6 +249,7 @@ func NewContext(
 		Name:           name,
 		Driver:         driver,
 		LevelDBOption:  levelDBOption,
+		PebbleDBOption: pebbleDBOption,
 		RedisDBOption:  redisDBOption,
 		reload:         reload,
 		routinePool:    pool,
@@ -215,10 +259,11 @@ func NewContext(
 }
 
 type Context struct {
-	Name          string
-	Driver        Driver
-	LevelDBOption *LevelDBOption
-	RedisDBOption *RedisDBOption
+	Name           string
+	Driver         Driver
+	LevelDBOption  *LevelDBOption
+	PebbleDBOption *PebbleDBOption
+	RedisDBOption  *RedisDBOption
 
 	TreeDB          database.TreeDB
 	defaultOptions  []bsmt.Option
@@ -226,6 +271,13 @@ type Context struct {
 	batchReloadSize int
 	routinePool     *ants.Pool
 	hasher          *bsmt.Hasher
+
+	retention RetentionOption
+	// the retention policy is stored by the first commit
+	retentionStored bool
+	pruner          *Pruner
+	// commits and rollbacks exclude the pruner from rewriting tree nodes
+	commitLock sync.Mutex
 }
 
 func (ctx *Context) IsLoad() bool {
@@ -20,6 +20,7 @@ package tree
 import (
 	"bytes"
 	"math/big"
+	"time"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
 	"github.com/ethereum/go-ethereum/common"
@@ -88,11 +89,18 @@ func EmptyNftNodeHash() []byte {
 }
 
 func CommitTrees(
+	ctx *Context,
 	version uint64,
 	accountTree bsmt.SparseMerkleTree,
 	assetTrees *AssetTreeCache,
 	nftTree bsmt.SparseMerkleTree) error {
+	ctx.commitLock.Lock()
+	defer ctx.commitLock.Unlock()
 
+	err := ctx.storeRetention()
+	if err != nil {
+		return errors.Wrap(err, "unable to store retention policy")
+	}
 	assetTreeChanges := assetTrees.GetChanges()
 	defer assetTrees.CleanChanges()
 	totalTask := len(assetTreeChanges) + 2
@@ -100,11 +108,25 @@ func CommitTrees(
 	errChan := make(chan error, totalTask)
 	defer close(errChan)
 
-	err := gopool.Submit(func() {
-		accPrunedVersion := bsmt.Version(version)
-		if accountTree.LatestVersion() < accPrunedVersion {
-			accPrunedVersion = accountTree.LatestVersion()
+	accPrunedVersion := bsmt.Version(version)
+	if accountTree.LatestVersion() < accPrunedVersion {
+		accPrunedVersion = accountTree.LatestVersion()
+	}
+	height := int64(accountTree.LatestVersion()) + 1
+	retain := ctx.retention.Policy != RetainDefault
+	versions := newAssetVersionIndex(ctx)
+	marker := newAssetDirtyMarker(ctx)
+	if marker != nil {
+		// mark the asset trees before they are committed, so that they are checked
+		// for rollback if the service is stopped before the block is committed
+		err := marker.mark(height, assetTreeChanges)
+		if err != nil {
+			return errors.Wrap(err, "unable to mark dirty asset trees")
 		}
+	}
+
+	err = gopool.Submit(func() {
+		defer observeCommitLatency("account", time.Now())
 		ver, err := accountTree.Commit(&accPrunedVersion)
 		if err != nil {
 			errChan <- errors.Wrapf(err, "unable to commit account tree, tree ver: %d, prune ver: %d", ver, accPrunedVersion)
@@ -119,8 +141,18 @@ func CommitTrees(
 	for _, idx := range assetTreeChanges {
 		err := func(i int64) error {
 			return gopool.Submit(func() {
+				defer observeCommitLatency("asset", time.Now())
 				asset := assetTrees.Get(i)
+				// the asset trees keep the versions of the heights kept by the account tree
 				version := asset.LatestVersion()
+				if versions != nil {
+					var err error
+					version, err = versions.commit(i, asset, height, int64(accPrunedVersion), retain)
+					if err != nil {
+						errChan <- errors.Wrapf(err, "unable to index asset tree [%d] version", i)
+						return
+					}
+				}
 				ver, err := asset.Commit(&version)
 				if err != nil {
 					errChan <- errors.Wrapf(err, "unable to commit asset tree [%d], tree ver: %d, prune ver: %d", i, ver, version)
@@ -135,6 +167,7 @@ func CommitTrees(
 	}
 
 	err = gopool.Submit(func() {
+		defer observeCommitLatency("nft", time.Now())
 		nftPrunedVersion := bsmt.Version(version)
 		if nftTree.LatestVersion() < nftPrunedVersion {
 			nftPrunedVersion = nftTree.LatestVersion()
@@ -157,22 +190,51 @@ func CommitTrees(
 		}
 	}
 
+	if marker != nil {
+		err = marker.prune(int64(accPrunedVersion))
+		if err != nil {
+			return errors.Wrap(err, "unable to prune dirty asset tree markers")
+		}
+	}
 	return nil
 }
 
+// CommitAssetTree commits the asset tree at the height out of the block commits, e.g.
+// once its leaves are repaired. The asset tree gets a new version recorded at the height,
+// the versions kept by the asset tree are not pruned.
+func CommitAssetTree(ctx *Context, accountIndex int64, asset bsmt.SparseMerkleTree, height int64) error {
+	ctx.commitLock.Lock()
+	defer ctx.commitLock.Unlock()
+
+	versions := newAssetVersionIndex(ctx)
+	if versions != nil {
+		err := versions.record(accountIndex, asset.LatestVersion()+1, height)
+		if err != nil {
+			return errors.Wrapf(err, "unable to index asset tree [%d] version", accountIndex)
+		}
+	}
+	recentVersion := asset.RecentVersion()
+	_, err := asset.Commit(&recentVersion)
+	return err
+}
+
 func RollBackTrees(
+	ctx *Context,
 	version uint64,
 	accountTree bsmt.SparseMerkleTree,
 	assetTrees *AssetTreeCache,
 	nftTree bsmt.SparseMerkleTree) error {
+	ctx.commitLock.Lock()
+	defer ctx.commitLock.Unlock()
 
 	assetTreeChanges := assetTrees.GetChanges()
 	defer assetTrees.CleanChanges()
-	totalTask := len(assetTreeChanges) + 3
+	totalTask := len(assetTreeChanges) + 2
 	errChan := make(chan error, totalTask)
 	defer close(errChan)
 
 	ver := bsmt.Version(version)
+	versions := newAssetVersionIndex(ctx)
 	err := gopool.Submit(func() {
 		if accountTree.LatestVersion() > ver && !accountTree.IsEmpty() {
 			err := accountTree.Rollback(ver)
@@ -192,6 +254,14 @@ func RollBackTrees(
 			return gopool.Submit(func() {
 				asset := assetTrees.Get(i)
 				version := asset.RecentVersion()
+				if versions != nil {
+					var err error
+					version, err = versions.rollbackVersion(i, asset, int64(ver))
+					if err != nil {
+						errChan <- errors.Wrapf(err, "unable to find asset tree [%d] version", i)
+						return
+					}
+				}
 				err := asset.Rollback(version)
 				if err != nil {
 					errChan <- errors.Wrapf(err, "unable to rollback asset tree [%d], ver: %d", i, version)
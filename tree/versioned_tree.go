@@ -0,0 +1,237 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database"
+)
+
+var (
+	latestVersionKey = []byte(`latestVersion`)
+	recentVersionKey = []byte(`recentVersionNumber`)
+
+	ErrVersionNotFound = errors.New("tree version not found")
+)
+
+// VersionedTree reads the leaves and merkle proofs of a tree at any version kept in
+// the storage. The stored nodes are read directly instead of loading the tree. With redis
+// the versions committed by the service writing the tree are visible once they are stored,
+// a leveldb or pebble reader opens a static copy and only sees the versions of the copy.
+type VersionedTree struct {
+	db        database.TreeDB
+	maxDepth  uint8
+	nilHashes [][]byte
+	hasher    *bsmt.Hasher
+}
+
+// VersionedProof is the leaf of the key at a version, and the sibling hashes from the leaf
+// up to the root in the same order as the proofs of the witness.
+type VersionedProof struct {
+	Leaf  []byte
+	Proof [][]byte
+	Root  []byte
+}
+
+func NewVersionedAccountTree(ctx *Context) *VersionedTree {
+	return newVersionedTree(ctx, AccountPrefix, AccountTreeHeight, NilAccountNodeHash)
+}
+
+func NewVersionedAccountAssetTree(ctx *Context, accountIndex int64) *VersionedTree {
+	return newVersionedTree(ctx, accountAssetNamespace(accountIndex), AssetTreeHeight, NilAccountAssetNodeHash)
+}
+
+func NewVersionedNftTree(ctx *Context) *VersionedTree {
+	return newVersionedTree(ctx, NFTPrefix, NftTreeHeight, NilNftNodeHash)
+}
+
+func newVersionedTree(ctx *Context, namespace string, maxDepth uint8, nilHash []byte) *VersionedTree {
+	nilHashes := make([][]byte, maxDepth+1)
+	nilHashes[maxDepth] = nilHash
+	for depth := int(maxDepth) - 1; depth >= 0; depth-- {
+		nilHashes[depth] = ctx.Hasher().Hash(nilHashes[depth+1], nilHashes[depth+1])
+	}
+	return &VersionedTree{
+		db:        SetNamespace(ctx, namespace),
+		maxDepth:  maxDepth,
+		nilHashes: nilHashes,
+		hasher:    ctx.Hasher(),
+	}
+}
+
+// Versions returns the latest version and the oldest version kept by the tree.
+func (t *VersionedTree) Versions() (latest, recent bsmt.Version, err error) {
+	latest, err = t.getVersion(latestVersionKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	recent, err = t.getVersion(recentVersionKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	return latest, recent, nil
+}
+
+// GetProof returns the leaf of the key and its merkle proof at the version.
+func (t *VersionedTree) GetProof(key uint64, version bsmt.Version) (*VersionedProof, error) {
+	if key >= 1<<t.maxDepth {
+		return nil, bsmt.ErrInvalidKey
+	}
+	err := t.checkVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := make([][]byte, 0, t.maxDepth)
+	var (
+		root []byte
+		leaf []byte
+	)
+	for depth := uint8(0); depth < t.maxDepth; depth += 4 {
+		path := key >> (t.maxDepth - depth)
+		nibble := key >> (t.maxDepth - depth - 4) & 0xf
+		children, err := t.getChildren(depth, path, version)
+		if err != nil {
+			return nil, err
+		}
+		levels := t.hashChildren(children)
+		if depth == 0 {
+			root = levels[0][0]
+		}
+		for i := 1; i <= 4; i++ {
+			index := nibble >> (4 - i)
+			proof = append(proof, levels[i][index^1])
+		}
+		leaf = children[nibble]
+	}
+	// the siblings are collected from the root down to the leaf
+	for i, j := 0, len(proof)-1; i < j; i, j = i+1, j-1 {
+		proof[i], proof[j] = proof[j], proof[i]
+	}
+	return &VersionedProof{Leaf: leaf, Proof: proof, Root: root}, nil
+}
+
+// Root returns the root of the tree at the version.
+func (t *VersionedTree) Root(version bsmt.Version) ([]byte, error) {
+	err := t.checkVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	children, err := t.getChildren(0, 0, version)
+	if err != nil {
+		return nil, err
+	}
+	return t.hashChildren(children)[0][0], nil
+}
+
+// FindVersion returns the latest version kept by the tree whose root is the given root.
+func (t *VersionedTree) FindVersion(root []byte) (bsmt.Version, error) {
+	latest, recent, err := t.Versions()
+	if err != nil {
+		return 0, err
+	}
+	node, err := t.getNode(0, 0)
+	if err != nil {
+		return 0, err
+	}
+	if node == nil || len(node.Versions) == 0 {
+		if bytes.Equal(root, t.nilHashes[0]) {
+			return latest, nil
+		}
+		return 0, ErrVersionNotFound
+	}
+	for i := len(node.Versions) - 1; i >= 0; i-- {
+		if node.Versions[i].Ver < recent {
+			break
+		}
+		if bytes.Equal(node.Versions[i].Hash, root) {
+			return node.Versions[i].Ver, nil
+		}
+	}
+	return 0, ErrVersionNotFound
+}
+
+func (t *VersionedTree) checkVersion(version bsmt.Version) error {
+	latest, recent, err := t.Versions()
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		return bsmt.ErrVersionTooHigh
+	}
+	if version < recent {
+		return bsmt.ErrVersionTooOld
+	}
+	return nil
+}
+
+// getChildren returns the hashes of the 16 children of the node at the version.
+func (t *VersionedTree) getChildren(depth uint8, path uint64, version bsmt.Version) ([][]byte, error) {
+	children := make([][]byte, 16)
+	for i := range children {
+		children[i] = t.nilHashes[depth+4]
+	}
+	node, err := t.getNode(depth, path)
+	if err != nil || node == nil {
+		return children, err
+	}
+	for i, child := range node.Children {
+		if child == nil {
+			continue
+		}
+		for j := len(child.Versions) - 1; j >= 0; j-- {
+			if child.Versions[j].Ver <= version {
+				children[i] = child.Versions[j].Hash
+				break
+			}
+		}
+	}
+	return children, nil
+}
+
+// hashChildren hashes the 16 children up to the node, level i holds 1<<i hashes.
+func (t *VersionedTree) hashChildren(children [][]byte) [5][][]byte {
+	var levels [5][][]byte
+	levels[4] = children
+	for i := 3; i >= 0; i-- {
+		levels[i] = make([][]byte, 1<<i)
+		for j := range levels[i] {
+			levels[i][j] = t.hasher.Hash(levels[i+1][2*j], levels[i+1][2*j+1])
+		}
+	}
+	return levels
+}
+
+func (t *VersionedTree) getNode(depth uint8, path uint64) (*bsmt.StorageTreeNode, error) {
+	buf, err := t.db.Get(storageNodeKey(depth, path))
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	node := &bsmt.StorageTreeNode{}
+	err = rlp.DecodeBytes(buf, node)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (t *VersionedTree) getVersion(key []byte) (bsmt.Version, error) {
+	buf, err := t.db.Get(key)
+	if errors.Is(err, database.ErrDatabaseNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(buf) != 8 {
+		return 0, errors.New("invalid tree version")
+	}
+	return bsmt.Version(binary.BigEndian.Uint64(buf)), nil
+}
@@ -0,0 +1,127 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	bsmt "github.com/bnb-chain/zkbnb-smt"
+	"github.com/bnb-chain/zkbnb-smt/database/memory"
+)
+
+func TestVersionedTreeProof(t *testing.T) {
+	ctx := newTestContext(t, PebbleDB)
+	nftTree, err := NewNftTree(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	memTree, err := bsmt.NewBASSparseMerkleTree(ctx.Hasher(), memory.NewMemoryDB(), NftTreeHeight, NilNftNodeHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versioned := NewVersionedNftTree(ctx)
+	keys := []uint64{0, 1, 17, 1 << 20, 1<<40 - 1}
+
+	roots := make(map[bsmt.Version][]byte)
+	proofs := make(map[bsmt.Version]map[uint64][][]byte)
+	for version := bsmt.Version(1); version <= 4; version++ {
+		for i, key := range keys[:version+1] {
+			leaf, err := ComputeNftAssetLeafHash(int64(i), int64(version), "0x01", "0x0000000000000000000000000000000000000000", "0", 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, smt := range []bsmt.SparseMerkleTree{nftTree, memTree} {
+				if err = smt.Set(key, leaf); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		for _, smt := range []bsmt.SparseMerkleTree{nftTree, memTree} {
+			if _, err = smt.Commit(nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		roots[version] = memTree.Root()
+		proofs[version] = make(map[uint64][][]byte)
+		for _, key := range keys {
+			proof, err := memTree.GetProof(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proofs[version][key] = proof
+		}
+	}
+
+	for version, root := range roots {
+		found, err := versioned.FindVersion(root)
+		if err != nil || found != version {
+			t.Fatalf("unexpected version of root %x: %d, %v", root, found, err)
+		}
+		for _, key := range keys {
+			proof, err := versioned.GetProof(key, version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if treeRoot, err := versioned.Root(version); err != nil || !bytes.Equal(proof.Root, root) || !bytes.Equal(treeRoot, root) {
+				t.Fatalf("root mismatch at version %d", version)
+			}
+			if fmt.Sprint(proof.Proof) != fmt.Sprint(proofs[version][key]) {
+				t.Fatalf("proof of %d mismatch at version %d", key, version)
+			}
+		}
+	}
+	if _, err = versioned.GetProof(0, 5); err != bsmt.ErrVersionTooHigh {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVersionedTreeVerifiedRetention(t *testing.T) {
+	ctx := newTestContext(t, PebbleDB)
+	ctx.SetRetention(RetentionOption{Policy: RetainVerified})
+	accountTree, assetTrees, nftTree := newTestTrees(t, ctx)
+	roots := commitTestBlocks(t, ctx, 1, 6, accountTree, assetTrees, nftTree)
+	policy, err := CommittedRetention(ctx)
+	if err != nil || policy != RetainVerified {
+		t.Fatalf("unexpected retention policy: %q, %v", policy, err)
+	}
+
+	// height 4 is the latest verified height, the lower versions are pruned
+	versionedAccounts := NewVersionedAccountTree(ctx)
+	if _, err = versionedAccounts.GetProof(0, 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = versionedAccounts.GetProof(0, 3); err != bsmt.ErrVersionTooOld {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	versionedAssets := NewVersionedAccountAssetTree(ctx, 0)
+	version, err := versionedAssets.FindVersion(roots[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := versionedAssets.GetProof(0, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(proof.Root, roots[4]) {
+		t.Fatal("asset root mismatch at the verified height")
+	}
+	if _, err = versionedAssets.FindVersion(roots[3]); err != ErrVersionNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// asset tree 1 is only committed by block 1, its version is kept
+	if _, err = NewVersionedAccountAssetTree(ctx, 1).FindVersion(assetTrees.Get(1).Root()); err != nil {
+		t.Fatal(err)
+	}
+
+	// without a retention policy the asset tree only keeps its latest versions
+	ctx = newTestContext(t, PebbleDB)
+	accountTree, assetTrees, nftTree = newTestTrees(t, ctx)
+	roots = commitTestBlocks(t, ctx, 1, 6, accountTree, assetTrees, nftTree)
+	policy, err = CommittedRetention(ctx)
+	if err != nil || policy != RetainDefault {
+		t.Fatalf("unexpected retention policy: %q, %v", policy, err)
+	}
+	if _, err = NewVersionedAccountAssetTree(ctx, 0).FindVersion(roots[4]); err != ErrVersionNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -36,6 +36,8 @@ var (
 	DbErrFailToUpdateNft             = errors.New("fail to update nft")
 	DbErrFailToCreateNftHistory      = errors.New("fail to create nft history")
 	DbErrFailToCreatePriorityRequest = errors.New("fail to create priority request")
+	DbErrFailToCreateOffer           = errors.New("fail to create offer")
+	DbErrDuplicateOffer              = errors.New("duplicate offer")
 	DbErrFailToUpdatePriorityRequest = errors.New("fail to update priority request")
 
 	JsonErrUnmarshal = errors.New("json.Unmarshal err")
@@ -78,8 +80,13 @@ var (
 	AppErrInvalidBlockHeight = New(21301, "invalid block height")
 
 	// Tx
-	AppErrPoolTxNotFound = New(21400, "pool tx not found")
-	AppErrInvalidTxInfo  = New(21401, "invalid tx info")
+	AppErrPoolTxNotFound             = New(21400, "pool tx not found")
+	AppErrInvalidTxInfo              = New(21401, "invalid tx info")
+	AppErrPendingBalanceNotAvailable = New(21404, "pending balance on L1 is not available")
+
+	// Priority request
+	AppErrPriorityRequestNotFound = New(21402, "priority request not found")
+	AppErrInvalidL1TxHash         = New(21403, "invalid l1 tx hash")
 
 	// Offer
 	AppErrInvalidOfferType           = New(21500, "invalid offer type")
@@ -98,6 +105,10 @@ var (
 	AppErrInvalidSellOfferState      = New(21511, "invalid sell offer state, already canceled or finalized")
 	AppErrInvalidBuyOfferState       = New(21512, "invalid buy offer state, already canceled or finalized")
 	AppErrInvalidAssetOfOffer        = New(21513, "invalid asset of offer")
+	AppErrOfferNotFound              = New(21514, "offer not found")
+	AppErrOfferAlreadyListed         = New(21515, "offer id is already listed")
+	AppErrInvalidOfferSig            = New(21516, "invalid offer signature")
+	AppErrOfferBookDisabled          = New(21517, "offers are not accepted by the api server")
 
 	// Nft
 	AppErrNftAlreadyExist       = New(21600, "invalid nft index, already exist")
@@ -111,9 +122,28 @@ var (
 	AppErrInvalidCollectionName = New(21701, "invalid collection name")
 	AppErrInvalidIntroduction   = New(21702, "invalid introduction")
 
+	// Proof
+	AppErrProofNotSupported = New(21800, "merkle proof is not supported")
+	AppErrProofNotAvailable = New(21801, "merkle proof is not available at the height")
+	AppErrProofNotRetained  = New(21802, "tree versions of the verified heights are not retained")
+	AppErrProofPruned       = New(21803, "tree version at the height is pruned")
+
+	// Subscription
+	AppErrInvalidTopic              = New(21900, "invalid topic")
+	AppErrInvalidSubscriptionKey    = New(21901, "invalid subscription key")
+	AppErrInvalidSubscriptionAction = New(21902, "invalid subscription action")
+	AppErrTooManySubscriptions      = New(21903, "too many subscriptions")
+
+	// JSON-RPC, the codes are defined by the JSON-RPC 2.0 specification
+	RpcErrParse          = New(-32700, "parse error")
+	RpcErrInvalidRequest = New(-32600, "invalid request")
+	RpcErrMethodNotFound = New(-32601, "method not found")
+	RpcErrTooManyCalls   = New(-32005, "too many calls in the batch")
+
 	AppErrInvalidGasAsset = New(25003, "invalid gas asset")
 	AppErrInvalidTxType   = New(25004, "invalid tx type")
 	AppErrTooManyTxs      = New(25005, "too many pending txs")
+	AppErrReadOnly        = New(25006, "txs are not accepted by the read only api server")
 	AppErrNotFound        = New(29404, "not found")
 	AppErrInternal        = New(29500, "internal server error")
 )
@@ -41,6 +41,16 @@ const (
 	TxTypeOffer
 )
 
+// NftTxTypes are the txs executed on a single nft, the nft index of the other txs is meaningless.
+var NftTxTypes = []int64{
+	TxTypeDepositNft,
+	TxTypeMintNft,
+	TxTypeTransferNft,
+	TxTypeAtomicMatch,
+	TxTypeWithdrawNft,
+	TxTypeFullExitNft,
+}
+
 func IsL2Tx(txType int64) bool {
 	if txType == TxTypeTransfer ||
 		txType == TxTypeWithdraw ||
@@ -206,6 +216,14 @@ func ParseCancelOfferTxInfo(txInfoStr string) (txInfo *txtypes.CancelOfferTxInfo
 	return txInfo, nil
 }
 
+func ParseOfferTxInfo(txInfoStr string) (txInfo *txtypes.OfferTxInfo, err error) {
+	err = json.Unmarshal([]byte(txInfoStr), &txInfo)
+	if err != nil {
+		return nil, err
+	}
+	return txInfo, nil
+}
+
 func ParseWithdrawTxInfo(txInfoStr string) (txInfo *txtypes.WithdrawTxInfo, err error) {
 	err = json.Unmarshal([]byte(txInfoStr), &txInfo)
 	if err != nil {